@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cli"
+)
+
+var (
+	configFile        string
+	checkConnectivity bool
+	outputFile        string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the cloud config of the vSphere cloud provider",
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a cloud config file",
+	Long: `Loads every section of the cloud config the cloud controller manager loads,
+including the overrides by environment variables, and reports all problems at
+once with their YAML line: unknown keys, invalid CIDRs in the nodes section,
+conflicting IP families, incomplete secret references and undefined load
+balancer classes.
+`,
+	Example: `# Validate the config
+	vcpctl config validate --config vsphere.conf
+
+	# Also log in to the configured vCenters and NSX-T
+	vcpctl config validate --config vsphere.conf --check-connectivity
+`,
+	Run: RunValidate,
+}
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert a deprecated INI cloud config file to YAML",
+	Long: `Converts the Global, VirtualCenter, Labels, Nodes, LoadBalancer,
+LoadBalancerClass, Route, IPAM and NSXT sections of an INI cloud config to
+YAML. The YAML config is only written if the cloud controller manager reads it
+to the same configuration as the INI config.
+`,
+	Example: `# Print the YAML config
+	vcpctl config convert --config vsphere.conf
+
+	# Write the YAML config to a file
+	vcpctl config convert --config vsphere.conf --output vsphere.yaml
+`,
+	Run: RunConvert,
+}
+
+// AddConfig initializes the "config" command.
+func AddConfig(cmd *cobra.Command) {
+	validateCmd.Flags().StringVar(&configFile, "config", "", "VSphere cloud provider config file path")
+	validateCmd.Flags().BoolVar(&checkConnectivity, "check-connectivity", false, "Log in to the configured vCenters and NSX-T")
+
+	convertCmd.Flags().StringVar(&configFile, "config", "", "INI cloud config file path")
+	convertCmd.Flags().StringVarP(&outputFile, "output", "o", "", "YAML cloud config file path (prints to stdout if empty)")
+
+	configCmd.AddCommand(validateCmd)
+	configCmd.AddCommand(convertCmd)
+	cmd.AddCommand(configCmd)
+}
+
+// RunValidate executes the "config validate" command.
+func RunValidate(cmd *cobra.Command, args []string) {
+	if configFile == "" {
+		fmt.Fprintf(os.Stderr, "error: --config is required\n")
+		os.Exit(1)
+	}
+	byConfig, err := os.ReadFile(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := cli.ValidateConfig(context.Background(), byConfig, checkConnectivity)
+	for _, problem := range problems {
+		fmt.Printf("%s: %s\n", configFile, problem)
+	}
+	if count := cli.ErrorCount(problems); count > 0 {
+		fmt.Fprintf(os.Stderr, "error: %d problem(s) found in %s\n", count, configFile)
+		os.Exit(1)
+	}
+	fmt.Printf("%s is valid\n", configFile)
+}
+
+// RunConvert executes the "config convert" command.
+func RunConvert(cmd *cobra.Command, args []string) {
+	if configFile == "" {
+		fmt.Fprintf(os.Stderr, "error: --config is required\n")
+		os.Exit(1)
+	}
+	byConfig, err := os.ReadFile(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	byYAML, err := cli.ConvertConfig(byConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: converting %s failed: %v\n", configFile, err)
+		os.Exit(1)
+	}
+	if outputFile == "" {
+		fmt.Print(string(byYAML))
+		return
+	}
+	if err := os.WriteFile(outputFile, byYAML, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s converted to %s\n", configFile, outputFile)
+}
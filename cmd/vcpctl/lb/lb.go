@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lb
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer"
+	lcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+	"k8s.io/cloud-provider-vsphere/pkg/nsxt"
+	ncfg "k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
+)
+
+var (
+	configFile  string
+	kubeconfig  string
+	clusterName string
+	owner       string
+	dryRun      bool
+	confirm     bool
+	maxDeletes  int
+)
+
+var lbCmd = &cobra.Command{
+	Use:   "lb",
+	Short: "Manage NSX-T load balancer objects of the vSphere cloud provider",
+}
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Show or delete orphaned NSX-T load balancer objects",
+	Long: `Lists the NSX-T virtual servers, pools, monitors and IP allocations tagged with
+the owner and cluster name which do not belong to an existing Kubernetes service
+of type LoadBalancer. By default the objects are only printed (dry-run).
+`,
+	Example: `# Show the orphaned objects of cluster mycluster
+	vcpctl lb cleanup --config vsphere.conf --cluster-name mycluster
+
+	# Delete them, even if there are more than allowed by cleanupMaxDeletions
+	vcpctl lb cleanup --config vsphere.conf --cluster-name mycluster --dry-run=false --confirm
+`,
+	Run: RunCleanup,
+}
+
+// AddLB initializes the "lb" command.
+func AddLB(cmd *cobra.Command) {
+	cleanupCmd.Flags().StringVar(&configFile, "config", "", "VSphere cloud provider config file path")
+	cleanupCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file of the cluster (uses in-cluster config if empty)")
+	cleanupCmd.Flags().StringVar(&clusterName, "cluster-name", "", "Cluster name as passed to the cloud controller manager")
+	cleanupCmd.Flags().StringVar(&owner, "owner", "vsphere-cloud-controller-manager", "Owner tag of the NSX-T objects (app name of the cloud controller manager)")
+	cleanupCmd.Flags().BoolVar(&dryRun, "dry-run", true, "Only print the objects which would be deleted")
+	cleanupCmd.Flags().BoolVar(&confirm, "confirm", false, "Confirm deletion of more objects than allowed by the deletion limit")
+	cleanupCmd.Flags().IntVar(&maxDeletes, "max-deletions", -1, "Maximum number of objects to delete (defaults to cleanupMaxDeletions from the config)")
+
+	lbCmd.AddCommand(cleanupCmd)
+	cmd.AddCommand(lbCmd)
+}
+
+// RunCleanup executes the "lb cleanup" command.
+func RunCleanup(cmd *cobra.Command, args []string) {
+	if err := runCleanup(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCleanup() error {
+	if configFile == "" || clusterName == "" {
+		return fmt.Errorf("--config and --cluster-name are required")
+	}
+	byConfig, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	nsxtcfg, err := ncfg.ReadNsxtConfig(byConfig)
+	if err != nil {
+		return fmt.Errorf("reading NSX-T config failed: %v", err)
+	}
+	lbcfg, err := lcfg.ReadLBConfig(byConfig)
+	if err != nil {
+		return fmt.Errorf("reading load balancer config failed: %v", err)
+	}
+
+	loadbalancer.AppName = owner
+	ncm, err := nsxt.NewConnectorManager(nsxtcfg)
+	if err != nil {
+		return err
+	}
+	provider, err := loadbalancer.NewLBProvider(lbcfg, ncm.GetConnector())
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("load balancer support is not enabled in %s", configFile)
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	list, err := client.CoreV1().Services("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	services := map[types.NamespacedName]corev1.Service{}
+	for _, item := range list.Items {
+		if item.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			services[types.NamespacedName{Namespace: item.Namespace, Name: item.Name}] = item
+		}
+	}
+
+	plan, err := provider.PlanCleanup(clusterName, services)
+	if err != nil {
+		return err
+	}
+	plan.Print(os.Stdout)
+	if dryRun || plan.Count() == 0 {
+		return nil
+	}
+
+	opts := loadbalancer.CleanupOptions{
+		MaxDeletions: lbcfg.LoadBalancer.CleanupMaxDeletions,
+		Confirmed:    confirm,
+	}
+	if maxDeletes >= 0 {
+		opts.MaxDeletions = maxDeletes
+	}
+	fmt.Printf("Deleting %d NSX-T objects...\n", plan.Count())
+	return provider.ExecuteCleanup(plan, false, opts)
+}
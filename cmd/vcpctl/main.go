@@ -23,17 +23,22 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/cloud-provider-vsphere/cmd/vcpctl/config"
+	"k8s.io/cloud-provider-vsphere/cmd/vcpctl/lb"
 	"k8s.io/cloud-provider-vsphere/cmd/vcpctl/provision"
 )
 
 func main() {
 
 	provision.AddProvision(cmd)
+	lb.AddLB(cmd)
+	config.AddConfig(cmd)
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("\nCompleted!\n")
+	// status on stderr, so that the output of commands can be redirected
+	fmt.Fprintf(os.Stderr, "\nCompleted!\n")
 }
 
 var cmd = &cobra.Command{
@@ -44,6 +49,8 @@ var cmd = &cobra.Command{
 * Create vSphere role with a minimal set of permissioins.
 * Create vSphere solution user, to be used with CCM
 * Convert old in-tree vsphere.conf configuration files to new configMap
+* Inspect and clean up orphaned NSX-T load balancer objects
+* Validate cloud config files and convert INI cloud config files to YAML
 
 `,
 
@@ -111,4 +111,6 @@ func RunProvision(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	fmt.Println("Log in to vCenter as the solution user with the following cloud config:")
+	fmt.Print(cli.SolutionUserConfig(vcCert))
 }
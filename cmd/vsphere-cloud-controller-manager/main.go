@@ -30,6 +30,7 @@ import (
 
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual"
 	"k8s.io/cloud-provider/app"
@@ -204,6 +205,7 @@ func main() {
 		}
 		if clusterNameFlag != nil {
 			loadbalancer.ClusterName = (*clusterNameFlag).String()
+			ipam.ClusterName = (*clusterNameFlag).String()
 			vsphereparavirtual.ClusterName = (*clusterNameFlag).String()
 		}
 		// if route controller is enabled in vsphereparavirtual cloud provider, set routeEnabled to true
@@ -28,6 +28,10 @@ ROOT_DIR="k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual"
 CUSTOM_RESOURCE_PACKAGE="nsxnetworking"
 # CUSTOM_RESOURCE_VERSION: the version of the resource
 CUSTOM_RESOURCE_VERSION="v1alpha1"
+# VSPHERE_ROOT_DIR: the root directory in which the apis of the vsphere cloud provider are defined
+VSPHERE_ROOT_DIR="k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere"
+# VSPHERE_CUSTOM_RESOURCE_PACKAGE: the name of the custom resource package of the vsphere cloud provider
+VSPHERE_CUSTOM_RESOURCE_PACKAGE="vsphereconfig"
 
 # emojis to make nice output
 printf "\xF0\x9F\x94\x8D\n"
@@ -42,3 +46,10 @@ bash "${CODEGEN_PKG}"/generate-groups.sh all \
   "$CUSTOM_RESOURCE_PACKAGE:$CUSTOM_RESOURCE_VERSION" \
   --output-base "$(dirname "${BASH_SOURCE[0]}")/../../.." \
   --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
+
+# The same for the configuration resources of the vsphere cloud provider
+bash "${CODEGEN_PKG}"/generate-groups.sh all \
+  "${VSPHERE_ROOT_DIR}"/client "${VSPHERE_ROOT_DIR}"/apis \
+  "$VSPHERE_CUSTOM_RESOURCE_PACKAGE:$CUSTOM_RESOURCE_VERSION" \
+  --output-base "$(dirname "${BASH_SOURCE[0]}")/../../.." \
+  --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
@@ -48,7 +48,7 @@ cp -a "${TMP_DIFFROOT}"/* "${DIFFROOT}"
 if [[ $ret -eq 0 ]]
 then
   printf "\xE2\x9C\x85"
-  echo "$CUSTOM_RESOURCE_PACKAGE:$CUSTOM_RESOURCE_VERSION up to date."
+  echo "$CUSTOM_RESOURCE_PACKAGE:$CUSTOM_RESOURCE_VERSION and vsphereconfig:$CUSTOM_RESOURCE_VERSION up to date."
 else
   printf "\xE2\x9D\x8C"
   echo "${DIFFROOT} is out of date. Please run hack/update-codegen.sh"
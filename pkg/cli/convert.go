@@ -0,0 +1,389 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/gcfg.v1"
+	yamlv2 "gopkg.in/yaml.v2"
+
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	icfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam/config"
+	lcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+	rcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route/config"
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	ncfg "k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
+)
+
+// cloudConfigINI holds the sections of an INI cloud config as written, before
+// the readers complete them with defaults
+type cloudConfigINI struct {
+	common ccfg.CPIConfigINI
+	lb     lcfg.LBConfigINI
+	route  rcfg.RouteConfigINI
+	ipam   icfg.IPAMConfigINI
+	nsxt   ncfg.NsxtConfigINI
+}
+
+// ConvertConfig converts a deprecated INI cloud config to YAML. The YAML
+// config only contains the keys set in the INI config. Both configs are read
+// with the readers of the cloud controller manager to verify they are
+// equivalent.
+func ConvertConfig(byConfig []byte) ([]byte, error) {
+	if len(byConfig) == 0 {
+		return nil, errors.New("config is empty")
+	}
+
+	var ini cloudConfigINI
+	for _, section := range []interface{}{&ini.common, &ini.lb, &ini.route, &ini.ipam, &ini.nsxt} {
+		if err := gcfg.FatalOnly(gcfg.ReadStringInto(section, string(byConfig))); err != nil {
+			return nil, errors.Wrap(err, "reading INI config failed")
+		}
+	}
+
+	cfg, err := ini.toYAML()
+	if err != nil {
+		return nil, err
+	}
+	byYAML, err := yamlv2.Marshal(marshalYAML(reflect.ValueOf(cfg)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ini.verify(byConfig, byYAML); err != nil {
+		return nil, err
+	}
+	return byYAML, nil
+}
+
+// toYAML returns the YAML representation of the INI config
+func (ini *cloudConfigINI) toYAML() (*cloudConfigYAML, error) {
+	cfg := &cloudConfigYAML{}
+
+	global := ini.common.Global
+	port, err := parsePort(global.VCenterPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "Global")
+	}
+	cfg.Global = vcfg.GlobalYAML{
+		User:              global.User,
+		Password:          global.Password,
+		VCenterIP:         global.VCenterIP,
+		VCenterPort:       port,
+		InsecureFlag:      global.InsecureFlag,
+		Datacenters:       splitList(global.Datacenters),
+		RoundTripperCount: global.RoundTripperCount,
+		CAFile:            global.CAFile,
+		Thumbprint:        global.Thumbprint,
+		SecretName:        global.SecretName,
+		SecretNamespace:   global.SecretNamespace,
+		SecretsDirectory:  global.SecretsDirectory,
+		APIDisable:        global.APIDisable,
+		APIBinding:        global.APIBinding,
+		IPFamilyPriority:  splitList(global.IPFamily),
+	}
+
+	if len(ini.common.VirtualCenter) > 0 {
+		cfg.Vcenter = make(map[string]*vcfg.VirtualCenterConfigYAML)
+	}
+	for tenant, vc := range ini.common.VirtualCenter {
+		port, err := parsePort(vc.VCenterPort)
+		if err != nil {
+			return nil, errors.Wrapf(err, "VirtualCenter %q", tenant)
+		}
+		// the name of an INI section is the server unless set explicitly,
+		// the YAML config always requires it
+		server := vc.VCenterIP
+		if server == "" {
+			server = tenant
+		}
+		cfg.Vcenter[tenant] = &vcfg.VirtualCenterConfigYAML{
+			User:              vc.User,
+			Password:          vc.Password,
+			VCenterIP:         server,
+			VCenterPort:       port,
+			InsecureFlag:      vc.InsecureFlag,
+			Datacenters:       splitList(vc.Datacenters),
+			RoundTripperCount: vc.RoundTripperCount,
+			CAFile:            vc.CAFile,
+			Thumbprint:        vc.Thumbprint,
+			SecretName:        vc.SecretName,
+			SecretNamespace:   vc.SecretNamespace,
+			IPFamilyPriority:  splitList(vc.IPFamily),
+		}
+	}
+
+	cfg.Labels = vcfg.LabelsYAML{
+		Zone:   ini.common.Labels.Zone,
+		Region: ini.common.Labels.Region,
+	}
+
+	nodes := ini.common.Nodes
+	cfg.Nodes = ccfg.NodesYAML{
+		InternalNetworkSubnetCIDR:        nodes.InternalNetworkSubnetCIDR,
+		ExternalNetworkSubnetCIDR:        nodes.ExternalNetworkSubnetCIDR,
+		InternalVMNetworkName:            nodes.InternalVMNetworkName,
+		ExternalVMNetworkName:            nodes.ExternalVMNetworkName,
+		ExcludeInternalNetworkSubnetCIDR: nodes.ExcludeInternalNetworkSubnetCIDR,
+		ExcludeExternalNetworkSubnetCIDR: nodes.ExcludeExternalNetworkSubnetCIDR,
+	}
+
+	nsxt := ini.nsxt.NSXT
+	cfg.NSXT = ncfg.NsxtYAML{
+		User:               nsxt.User,
+		Password:           nsxt.Password,
+		Host:               nsxt.Host,
+		InsecureFlag:       nsxt.InsecureFlag,
+		RemoteAuth:         nsxt.RemoteAuth,
+		SecretName:         nsxt.SecretName,
+		SecretNamespace:    nsxt.SecretNamespace,
+		VMCAccessToken:     nsxt.VMCAccessToken,
+		VMCAuthHost:        nsxt.VMCAuthHost,
+		ClientAuthCertFile: nsxt.ClientAuthCertFile,
+		ClientAuthKeyFile:  nsxt.ClientAuthKeyFile,
+		CAFile:             nsxt.CAFile,
+	}
+
+	lb := ini.lb.LoadBalancer
+	cfg.LoadBalancer = lcfg.LoadBalancerConfigYAML{
+		Size:                lb.Size,
+		LBServiceID:         lb.LBServiceID,
+		Tier1GatewayPath:    lb.Tier1GatewayPath,
+		SnatDisabled:        lb.SnatDisabled,
+		CleanupDryRun:       lb.CleanupDryRun,
+		CleanupMaxDeletions: lb.CleanupMaxDeletions,
+		HierarchicalAPI:     lb.HierarchicalAPI,
+		IPPoolName:          lb.IPPoolName,
+		IPPoolID:            lb.IPPoolID,
+		TCPAppProfileName:   lb.TCPAppProfileName,
+		TCPAppProfilePath:   lb.TCPAppProfilePath,
+		UDPAppProfileName:   lb.UDPAppProfileName,
+		UDPAppProfilePath:   lb.UDPAppProfilePath,
+	}
+	if err := unmarshalJSONMap(lb.RawTags, &cfg.LoadBalancer.AdditionalTags); err != nil {
+		return nil, errors.Wrap(err, "LoadBalancer tags")
+	}
+	if len(ini.lb.LoadBalancerClass) > 0 {
+		cfg.LoadBalancerClass = make(map[string]*lcfg.LoadBalancerClassConfigYAML)
+	}
+	for name, class := range ini.lb.LoadBalancerClass {
+		cfg.LoadBalancerClass[name] = &lcfg.LoadBalancerClassConfigYAML{
+			IPPoolName:        class.IPPoolName,
+			IPPoolID:          class.IPPoolID,
+			TCPAppProfileName: class.TCPAppProfileName,
+			TCPAppProfilePath: class.TCPAppProfilePath,
+			UDPAppProfileName: class.UDPAppProfileName,
+			UDPAppProfilePath: class.UDPAppProfilePath,
+		}
+	}
+
+	route := ini.route.Route
+	cfg.Route = rcfg.RouteYAML{
+		RouterPath:         route.RouterPath,
+		AdminDistance:      route.AdminDistance,
+		ECMP:               route.ECMP,
+		NextHopSubnets:     splitList(route.NextHopSubnets),
+		NextHopNetworkName: route.NextHopNetworkName,
+		BFD:                route.BFD,
+	}
+	if err := unmarshalJSONMap(route.RawZoneRouterPaths, &cfg.Route.ZoneRouterPaths); err != nil {
+		return nil, errors.Wrap(err, "Route zone-router-paths")
+	}
+	if err := unmarshalJSONMap(route.RawTags, &cfg.Route.AdditionalTags); err != nil {
+		return nil, errors.Wrap(err, "Route tags")
+	}
+
+	ipam := ini.ipam.IPAM
+	cfg.IPAM = icfg.IPAMYAML{
+		IPPoolID:         ipam.IPPoolID,
+		IPBlockPath:      ipam.IPBlockPath,
+		PrefixLength:     ipam.PrefixLength,
+		IPv6IPBlockPath:  ipam.IPv6IPBlockPath,
+		IPv6PrefixLength: ipam.IPv6PrefixLength,
+	}
+
+	return cfg, nil
+}
+
+// verify reads the INI and the YAML config with the readers of the cloud
+// controller manager and returns an error if the results differ
+func (ini *cloudConfigINI) verify(byINI, byYAML []byte) error {
+	compare := func(section string, fromINI, fromYAML interface{}, errINI, errYAML error) error {
+		if errINI != nil {
+			return errors.Wrapf(errINI, "reading %s of the INI config failed", section)
+		}
+		if errYAML != nil {
+			return errors.Wrapf(errYAML, "reading %s of the converted YAML config failed", section)
+		}
+		if !reflect.DeepEqual(fromINI, fromYAML) {
+			return fmt.Errorf("%s of the converted YAML config differs from the INI config", section)
+		}
+		return nil
+	}
+
+	cpiINI, errINI := ccfg.ReadCPIConfigINI(byINI)
+	cpiYAML, errYAML := ccfg.ReadCPIConfigYAML(byYAML)
+	if errINI == nil && errYAML == nil {
+		normalizeCommonConfig(&cpiINI.Config)
+		normalizeCommonConfig(&cpiYAML.Config)
+	}
+	if err := compare("Global, VirtualCenter, Labels and Nodes", cpiINI, cpiYAML, errINI, errYAML); err != nil {
+		return err
+	}
+
+	lbINI, errINI := lcfg.ReadConfigINI(byINI)
+	lbYAML, errYAML := lcfg.ReadConfigYAML(byYAML)
+	if err := compare("LoadBalancer and LoadBalancerClass", lbINI, lbYAML, errINI, errYAML); err != nil {
+		return err
+	}
+
+	if !reflect.ValueOf(ini.route).IsZero() {
+		routeINI, errINI := rcfg.ReadConfigINI(byINI)
+		routeYAML, errYAML := rcfg.ReadConfigYAML(byYAML)
+		if err := compare("Route", routeINI, routeYAML, errINI, errYAML); err != nil {
+			return err
+		}
+	}
+
+	if ini.ipam != (icfg.IPAMConfigINI{}) {
+		ipamINI, errINI := icfg.ReadConfigINI(byINI)
+		ipamYAML, errYAML := icfg.ReadConfigYAML(byYAML)
+		if err := compare("IPAM", ipamINI, ipamYAML, errINI, errYAML); err != nil {
+			return err
+		}
+	}
+
+	if ini.nsxt != (ncfg.NsxtConfigINI{}) {
+		nsxtINI, errINI := ncfg.ReadConfigINI(byINI)
+		nsxtYAML, errYAML := ncfg.ReadConfigYAML(byYAML)
+		if err := compare("NSXT", nsxtINI, nsxtYAML, errINI, errYAML); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeCommonConfig trims the elements of the comma separated lists of the
+// config, which the INI reader keeps as written
+func normalizeCommonConfig(cfg *vcfg.Config) {
+	cfg.Global.Datacenters = strings.Join(splitList(cfg.Global.Datacenters), ",")
+	for _, vc := range cfg.VirtualCenter {
+		vc.Datacenters = strings.Join(splitList(vc.Datacenters), ",")
+		vc.IPFamilyPriority = splitList(strings.Join(vc.IPFamilyPriority, ","))
+	}
+}
+
+// splitList splits a comma separated list and drops empty elements
+func splitList(list string) []string {
+	var elems []string
+	for _, elem := range strings.Split(list, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// parsePort parses a vCenter port of the INI config
+func parsePort(port string) (uint, error) {
+	if port == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", port)
+	}
+	return uint(value), nil
+}
+
+// unmarshalJSONMap parses a JSON object of the INI config
+func unmarshalJSONMap(raw string, value *map[string]string) error {
+	if raw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), value)
+}
+
+// marshalYAML returns the value to marshal for v, following the naming rules
+// of gopkg.in/yaml.v2, without the keys of zero values
+func marshalYAML(v reflect.Value) interface{} {
+	if !v.IsValid() || v.IsZero() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return marshalYAML(v.Elem())
+	case reflect.Struct:
+		var fields yamlv2.MapSlice
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("yaml"), ",")
+			name := tag[0]
+			if name == "-" {
+				continue
+			}
+			value := marshalYAML(v.Field(i))
+			if value == nil {
+				continue
+			}
+			if len(tag) > 1 && tag[1] == "inline" {
+				fields = append(fields, value.(yamlv2.MapSlice)...)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields = append(fields, yamlv2.MapItem{Key: name, Value: value})
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+		return fields
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		items := make(yamlv2.MapSlice, 0, len(keys))
+		for _, key := range keys {
+			value := marshalYAML(v.MapIndex(key))
+			switch {
+			case value != nil:
+			case v.Type().Elem().Kind() == reflect.Ptr:
+				// keep the entry, e.g. a load balancer class without settings
+				value = yamlv2.MapSlice{}
+			default:
+				value = v.MapIndex(key).Interface()
+			}
+			items = append(items, yamlv2.MapItem{Key: key.Interface(), Value: value})
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
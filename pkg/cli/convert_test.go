@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const legacyConfigINI = `
+[Global]
+port = 443
+insecure-flag = true
+secret-name = vsphere-credentials
+secret-namespace = kube-system
+datacenters = dc0, dc1
+ip-family = ipv4, ipv6
+
+[VirtualCenter "10.0.0.1"]
+
+[VirtualCenter "tenant2"]
+server = 10.0.0.2
+datacenters = dc2
+
+[Labels]
+region = k8s-region
+zone = k8s-zone
+
+[Nodes]
+internal-network-subnet-cidr = 10.0.0.0/24
+external-vm-network-name = VM Network
+
+[LoadBalancer]
+ip-pool-name = pool1
+size = MEDIUM
+tier1-gateway-path = /infra/tier-1s/gw1
+tcp-app-profile-name = default-tcp-lb-app-profile
+udp-app-profile-name = default-udp-lb-app-profile
+tags = {\"owner\": \"team1\"}
+
+[LoadBalancerClass "public"]
+ip-pool-name = public
+
+[Route]
+router-path = /infra/tier-1s/gw1
+next-hop-subnets = 10.1.0.0/24, 10.2.0.0/24
+
+[NSXT]
+host = nsxt.example.com
+user = admin
+password = secret
+insecure-flag = true
+`
+
+const legacyConfigYAML = `global:
+  port: 443
+  insecureFlag: true
+  datacenters:
+  - dc0
+  - dc1
+  secretName: vsphere-credentials
+  secretNamespace: kube-system
+  ipFamily:
+  - ipv4
+  - ipv6
+vcenter:
+  10.0.0.1:
+    server: 10.0.0.1
+  tenant2:
+    server: 10.0.0.2
+    datacenters:
+    - dc2
+labels:
+  zone: k8s-zone
+  region: k8s-region
+nodes:
+  internalNetworkSubnetCidr: 10.0.0.0/24
+  externalVmNetworkName: VM Network
+nsxt:
+  user: admin
+  password: secret
+  host: nsxt.example.com
+  insecureFlag: true
+loadBalancer:
+  size: MEDIUM
+  tier1GatewayPath: /infra/tier-1s/gw1
+  tags:
+    owner: team1
+  ipPoolName: pool1
+  tcpAppProfileName: default-tcp-lb-app-profile
+  udpAppProfileName: default-udp-lb-app-profile
+loadBalancerClass:
+  public:
+    ipPoolName: public
+route:
+  routerPath: /infra/tier-1s/gw1
+  nextHopSubnets:
+  - 10.1.0.0/24
+  - 10.2.0.0/24
+`
+
+func TestConvertConfig(t *testing.T) {
+	byYAML, err := ConvertConfig([]byte(legacyConfigINI))
+	if err != nil {
+		t.Fatalf("Should succeed when a valid INI config is provided: %s", err)
+	}
+	if string(byYAML) != legacyConfigYAML {
+		t.Errorf("unexpected YAML config:\n%s", byYAML)
+	}
+	if problems := ValidateConfig(context.Background(), byYAML, false); len(problems) != 0 {
+		t.Errorf("converted config should have no problems but got: %v", problems)
+	}
+}
+
+func TestConvertConfigInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "empty",
+			config: "",
+			err:    "config is empty",
+		},
+		{
+			name:   "invalid port",
+			config: "[Global]\nserver = 10.0.0.1\nport = https\n",
+			err:    `invalid port "https"`,
+		},
+		{
+			name:   "invalid tags",
+			config: "[LoadBalancer]\ntags = owner=team1\n",
+			err:    "LoadBalancer tags",
+		},
+		{
+			name:   "no credentials",
+			config: "[Global]\nserver = 10.0.0.1\n",
+			err:    "reading Global, VirtualCenter, Labels and Nodes of the INI config failed",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ConvertConfig([]byte(testCase.config))
+		if err == nil || !strings.Contains(err.Error(), testCase.err) {
+			t.Errorf("%s: expected error %q but got: %v", testCase.name, testCase.err, err)
+		}
+	}
+}
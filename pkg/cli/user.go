@@ -24,6 +24,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"os"
 	"strings"
@@ -121,17 +122,35 @@ type Role struct {
 	Privileges []string
 }
 
+// SolutionUserConfig returns the YAML cloud config snippet that logs in to
+// vCenter as the solution user created for the certificate cert.
+func SolutionUserConfig(cert string) string {
+	certPath, keyPath := certificateFiles(cert)
+	return fmt.Sprintf(`global:
+  authMode: certificate
+  certFile: %s
+  keyFile: %s
+`, certPath, keyPath)
+}
+
+// certificateFiles returns the paths of the certificate and private key
+// created for the solution user certificate cert.
+func certificateFiles(cert string) (string, string) {
+	id := strings.TrimSuffix(cert, ".crt")
+	return id + ".crt", id + ".key"
+}
+
 // createCert creates a key pair for login purpose
 func (u *User) createCert() error {
-	id := strings.TrimSuffix(u.cert, ".crt")
+	certPath, keyPath := certificateFiles(u.cert)
 	var encodedCert bytes.Buffer
-	certFile, err := os.Create(id + ".crt")
+	certFile, err := os.Create(certPath)
 	if err != nil {
 		return err
 	}
 	defer certFile.Close()
 
-	keyFile, err := os.Create(id + ".key")
+	keyFile, err := os.Create(keyPath)
 	if err != nil {
 		return err
 	}
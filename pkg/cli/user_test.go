@@ -76,6 +76,19 @@ func TestRunUserFunc(t *testing.T) {
 	}
 }
 
+func TestSolutionUserConfig(t *testing.T) {
+	expected := `global:
+  authMode: certificate
+  certFile: /etc/cloud/k8s-vcp.crt
+  keyFile: /etc/cloud/k8s-vcp.key
+`
+	for _, cert := range []string{"/etc/cloud/k8s-vcp.crt", "/etc/cloud/k8s-vcp"} {
+		if actual := SolutionUserConfig(cert); actual != expected {
+			t.Errorf("SolutionUserConfig(%s) expected:\n%s\nbut got:\n%s", cert, expected, actual)
+		}
+	}
+}
+
 func buildClient(ctx context.Context) (func(), error) {
 	m, s, err := test.NewServiceInstance()
 	if err != nil {
@@ -0,0 +1,674 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/aaa"
+	yamlv2 "gopkg.in/yaml.v2"
+	yaml "gopkg.in/yaml.v3"
+
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	icfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam/config"
+	lcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+	rcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route/config"
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	cm "k8s.io/cloud-provider-vsphere/pkg/common/connectionmanager"
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
+	"k8s.io/cloud-provider-vsphere/pkg/nsxt"
+	ncfg "k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
+)
+
+// connectivityTimeout bounds the connectivity check of a single endpoint
+const connectivityTimeout = 30 * time.Second
+
+// cloudConfigYAML lists the sections of the YAML cloud config read by the
+// cloud controller manager
+type cloudConfigYAML struct {
+	vcfg.CommonConfigYAML `yaml:",inline"`
+	Nodes                 ccfg.NodesYAML `yaml:"nodes"`
+	NSXT                  ncfg.NsxtYAML  `yaml:"nsxt"`
+	lcfg.LBConfigYAML     `yaml:",inline"`
+	Route                 rcfg.RouteYAML `yaml:"route"`
+	IPAM                  icfg.IPAMYAML  `yaml:"ipam"`
+}
+
+// ConfigProblem is a problem found in a cloud config
+type ConfigProblem struct {
+	// Line is the line of the YAML cloud config the problem was found at,
+	// 0 if unknown
+	Line int
+	// Path is the path of the config key, e.g. "nodes.addressPolicy[0].cidrs"
+	Path    string
+	Message string
+	// Warning is set for problems the cloud controller manager accepts, like
+	// deprecated settings
+	Warning bool
+}
+
+func (p ConfigProblem) String() string {
+	var prefix string
+	if p.Warning {
+		prefix = "warning: "
+	}
+	if p.Line > 0 {
+		prefix += fmt.Sprintf("line %d: ", p.Line)
+	}
+	if p.Path != "" {
+		prefix += p.Path + ": "
+	}
+	return prefix + p.Message
+}
+
+// ErrorCount returns the number of problems that are not warnings
+func ErrorCount(problems []ConfigProblem) int {
+	count := 0
+	for _, problem := range problems {
+		if !problem.Warning {
+			count++
+		}
+	}
+	return count
+}
+
+// configValidator collects the problems of a cloud config
+type configValidator struct {
+	// root is the document of a YAML cloud config, nil for an INI cloud config
+	root     *yaml.Node
+	problems []ConfigProblem
+}
+
+// ValidateConfig loads every section of the cloud config that the cloud
+// controller manager loads, including the overrides by environment variables,
+// and returns all problems found, ordered by line. If checkConnectivity is
+// set, it also logs in to the configured vCenters and NSX-T.
+func ValidateConfig(ctx context.Context, byConfig []byte, checkConnectivity bool) []ConfigProblem {
+	v := &configValidator{}
+	if len(byConfig) == 0 {
+		v.add(nil, "config is empty")
+		return v.problems
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(byConfig, &doc); err == nil && len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		v.root = doc.Content[0]
+		v.validateYAML(byConfig)
+	} else {
+		v.validateINI(byConfig)
+	}
+
+	if checkConnectivity && ErrorCount(v.problems) == 0 {
+		v.checkConnectivity(ctx, byConfig)
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Line < v.problems[j].Line
+	})
+	return v.problems
+}
+
+// add records a problem of the key at path
+func (v *configValidator) add(path []string, format string, args ...interface{}) {
+	v.problems = append(v.problems, ConfigProblem{
+		Line:    v.line(path),
+		Path:    formatPath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// warn records a warning for the key at path
+func (v *configValidator) warn(path []string, format string, args ...interface{}) {
+	v.add(path, format, args...)
+	v.problems[len(v.problems)-1].Warning = true
+}
+
+// hasProblems returns true if a problem was recorded for the key at path or
+// one of its children
+func (v *configValidator) hasProblems(path []string) bool {
+	prefix := formatPath(path)
+	for _, problem := range v.problems {
+		if problem.Path == prefix || strings.HasPrefix(problem.Path, prefix+".") || strings.HasPrefix(problem.Path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// line returns the line of the key at path in the YAML cloud config, or of
+// its closest parent if the key is not set
+func (v *configValidator) line(path []string) int {
+	if v.root == nil {
+		return 0
+	}
+	line := 0
+	node := v.root
+	for _, elem := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == elem {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(strings.Trim(elem, "[]")); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
+
+// isSet returns true if the key at path is set in the YAML cloud config
+func (v *configValidator) isSet(path ...string) bool {
+	if v.root == nil {
+		return false
+	}
+	node := v.root
+	for _, elem := range path {
+		var next *yaml.Node
+		for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == elem {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return false
+		}
+		node = next
+	}
+	return true
+}
+
+// formatPath formats a path like "nodes.addressPolicy[0].cidrs"
+func formatPath(path []string) string {
+	var b strings.Builder
+	for i, elem := range path {
+		if i > 0 && !strings.HasPrefix(elem, "[") {
+			b.WriteString(".")
+		}
+		b.WriteString(elem)
+	}
+	return b.String()
+}
+
+// child returns a copy of path extended by elems
+func child(path []string, elems ...string) []string {
+	return append(append([]string{}, path...), elems...)
+}
+
+// validateYAML validates a YAML cloud config
+func (v *configValidator) validateYAML(byConfig []byte) {
+	v.checkKeys(v.root, reflect.TypeOf(cloudConfigYAML{}), nil)
+
+	var cfg cloudConfigYAML
+	if err := yamlv2.Unmarshal(byConfig, &cfg); err != nil {
+		v.add(nil, "%v", err)
+		return
+	}
+
+	v.checkVCenters(&cfg.CommonConfigYAML)
+	families := v.checkIPFamilies(&cfg.CommonConfigYAML)
+	v.checkNodes(nodesFromEnv(cfg.Nodes), families)
+	if v.isSet("nsxt") {
+		v.checkSecretRef([]string{"nsxt"}, cfg.NSXT.SecretName, cfg.NSXT.SecretNamespace)
+	}
+	if v.isSet("loadBalancer") || v.isSet("loadBalancerClass") {
+		v.checkLoadBalancerClasses(&cfg.LBConfigYAML)
+	}
+	if v.isSet("ipam") {
+		if cfg.IPAM.IPBlockPath != "" && !families[vcfg.IPv4Family] {
+			v.add([]string{"ipam", "ipBlockPath"}, "conflicting IP families: IPv4 IP block configured but ipFamily does not include %s", vcfg.IPv4Family)
+		}
+		if cfg.IPAM.IPv6IPBlockPath != "" && !families[vcfg.IPv6Family] {
+			v.add([]string{"ipam", "ipv6IPBlockPath"}, "conflicting IP families: IPv6 IP block configured but ipFamily does not include %s", vcfg.IPv6Family)
+		}
+	}
+
+	// the readers of the cloud controller manager, for the problems not
+	// covered by the checks above
+	v.readSection([]string{"global"}, func() error {
+		cfg, err := ccfg.ReadCPIConfigYAML(byConfig)
+		if err != nil {
+			return err
+		}
+		return cfg.FromCPIEnv()
+	})
+	if v.isSet("nsxt") {
+		v.readSection([]string{"nsxt"}, func() error {
+			cfg, err := ncfg.ReadConfigYAML(byConfig)
+			if err != nil {
+				return err
+			}
+			return cfg.FromEnv()
+		})
+	}
+	if v.isSet("loadBalancer") || v.isSet("loadBalancerClass") {
+		v.readSection([]string{"loadBalancer"}, func() error {
+			_, err := lcfg.ReadConfigYAML(byConfig)
+			return err
+		})
+	}
+	if v.isSet("route") {
+		v.readSection([]string{"route"}, func() error {
+			_, err := rcfg.ReadConfigYAML(byConfig)
+			return err
+		})
+	}
+	if v.isSet("ipam") {
+		v.readSection([]string{"ipam"}, func() error {
+			_, err := icfg.ReadConfigYAML(byConfig)
+			return err
+		})
+	}
+}
+
+// readSection records the error of the reader of the section at path, unless
+// a problem was already found in the section
+func (v *configValidator) readSection(path []string, read func() error) {
+	if err := read(); err != nil && !v.hasProblems(path) {
+		v.add(path, "%v", err)
+	}
+}
+
+// checkKeys records the keys of node that are unknown to the YAML type t
+func (v *configValidator) checkKeys(node *yaml.Node, t reflect.Type, path []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			fieldType, ok := fields[key]
+			if ok {
+				v.checkKeys(node.Content[i+1], fieldType, child(path, key))
+				continue
+			}
+			message := fmt.Sprintf("unknown key %q", key)
+			for name := range fields {
+				if strings.EqualFold(name, key) {
+					message += fmt.Sprintf(", did you mean %q?", name)
+				}
+			}
+			v.problems = append(v.problems, ConfigProblem{
+				Line:    node.Content[i].Line,
+				Path:    formatPath(path),
+				Message: message,
+			})
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkKeys(node.Content[i+1], t.Elem(), child(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			v.checkKeys(item, t.Elem(), child(path, fmt.Sprintf("[%d]", i)))
+		}
+	}
+}
+
+// yamlFields returns the types of the fields of the struct type t by their
+// YAML key, following the naming rules of gopkg.in/yaml.v2
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "inline" {
+			for key, fieldType := range yamlFields(field.Type) {
+				fields[key] = fieldType
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// checkVCenters records vCenters without credentials and incomplete secret
+// references
+func (v *configValidator) checkVCenters(cfg *vcfg.CommonConfigYAML) {
+	global := []string{"global"}
+	v.checkSecretRef(global, cfg.Global.SecretName, cfg.Global.SecretNamespace)
+	globalSecret := (cfg.Global.SecretName != "" && cfg.Global.SecretNamespace != "") || cfg.Global.CredentialSource != nil
+
+	if len(cfg.Vcenter) == 0 && cfg.Global.VCenterIP == "" {
+		v.add([]string{"vcenter"}, "no vCenter configured, set global.server or add a vcenter")
+		return
+	}
+
+	tenants := make([]string, 0, len(cfg.Vcenter))
+	for tenant := range cfg.Vcenter {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		vc := cfg.Vcenter[tenant]
+		path := []string{"vcenter", tenant}
+		if vc == nil {
+			v.add(path, "vCenter is empty")
+			continue
+		}
+		if vc.VCenterIP == "" {
+			v.add(path, "server is required")
+		}
+		v.checkSecretRef(path, vc.SecretName, vc.SecretNamespace)
+
+		authMode := vc.AuthMode
+		if authMode == "" {
+			authMode = cfg.Global.AuthMode
+		}
+		secret := globalSecret || (vc.SecretName != "" && vc.SecretNamespace != "")
+		switch {
+		case secret || authMode == "certificate" || authMode == "token":
+		case (vc.User == "" && cfg.Global.User == "") || (vc.Password == "" && cfg.Global.Password == ""):
+			v.add(path, "no credentials: set user and password, secretName and secretNamespace, or global.credentialSource")
+		}
+	}
+}
+
+// checkSecretRef records a secret reference missing its name or namespace
+func (v *configValidator) checkSecretRef(path []string, name, namespace string) {
+	if name != "" && namespace == "" {
+		v.add(child(path, "secretName"), "secretNamespace is required if secretName is set")
+	}
+	if name == "" && namespace != "" {
+		v.add(child(path, "secretNamespace"), "secretName is required if secretNamespace is set")
+	}
+}
+
+// checkIPFamilies records invalid and duplicate IP families and returns the
+// IP families of all vCenters
+func (v *configValidator) checkIPFamilies(cfg *vcfg.CommonConfigYAML) map[string]bool {
+	families := map[string]bool{}
+	check := func(path []string, priority []string) {
+		if len(priority) == 0 {
+			return
+		}
+		seen := map[string]bool{}
+		for i, family := range priority {
+			family = strings.ToLower(family)
+			switch {
+			case family != vcfg.IPv4Family && family != vcfg.IPv6Family:
+				v.add(child(path, "ipFamily", fmt.Sprintf("[%d]", i)), "invalid IP family %q, expected %s or %s", priority[i], vcfg.IPv4Family, vcfg.IPv6Family)
+			case seen[family]:
+				v.add(child(path, "ipFamily", fmt.Sprintf("[%d]", i)), "duplicate IP family %q", priority[i])
+			}
+			seen[family] = true
+			families[family] = true
+		}
+	}
+
+	check([]string{"global"}, cfg.Global.IPFamilyPriority)
+	inherited := len(cfg.Vcenter) == 0
+	for tenant, vc := range cfg.Vcenter {
+		if vc == nil || len(vc.IPFamilyPriority) == 0 {
+			inherited = true
+			continue
+		}
+		check([]string{"vcenter", tenant}, vc.IPFamilyPriority)
+	}
+	if inherited && len(cfg.Global.IPFamilyPriority) == 0 {
+		families[vcfg.DefaultIPFamily] = true
+	}
+	return families
+}
+
+// nodesFromEnv returns the Nodes section with the subnets overridden by
+// environment variables
+func nodesFromEnv(nodes ccfg.NodesYAML) *ccfg.NodesYAML {
+	cfg := &ccfg.CPIConfig{}
+	cfg.Nodes.InternalNetworkSubnetCIDR = nodes.InternalNetworkSubnetCIDR
+	cfg.Nodes.ExternalNetworkSubnetCIDR = nodes.ExternalNetworkSubnetCIDR
+	if err := cfg.FromCPIEnv(); err == nil {
+		nodes.InternalNetworkSubnetCIDR = cfg.Nodes.InternalNetworkSubnetCIDR
+		nodes.ExternalNetworkSubnetCIDR = cfg.Nodes.ExternalNetworkSubnetCIDR
+	}
+	return &nodes
+}
+
+// checkNodes records invalid CIDRs in the Nodes section and CIDRs of an IP
+// family no vCenter is configured for
+func (v *configValidator) checkNodes(nodes *ccfg.NodesYAML, families map[string]bool) {
+	checkCIDR := func(path []string, cidr string) {
+		ip, _, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			v.add(path, "invalid CIDR %q", cidr)
+			return
+		}
+		family := vcfg.IPv6Family
+		if ip.To4() != nil {
+			family = vcfg.IPv4Family
+		}
+		if !families[family] {
+			v.add(path, "conflicting IP families: CIDR %q is %s but ipFamily does not include it", cidr, family)
+		}
+	}
+
+	for key, cidrs := range map[string]string{
+		"internalNetworkSubnetCidr":        nodes.InternalNetworkSubnetCIDR,
+		"externalNetworkSubnetCidr":        nodes.ExternalNetworkSubnetCIDR,
+		"excludeInternalNetworkSubnetCidr": nodes.ExcludeInternalNetworkSubnetCIDR,
+		"excludeExternalNetworkSubnetCidr": nodes.ExcludeExternalNetworkSubnetCIDR,
+	} {
+		if cidrs == "" {
+			continue
+		}
+		// a comma separated list, as parsed by the node manager
+		for _, cidr := range strings.Split(cidrs, ",") {
+			checkCIDR([]string{"nodes", key}, cidr)
+		}
+	}
+	for i, rule := range nodes.AddressPolicy {
+		for j, cidr := range rule.CIDRs {
+			checkCIDR([]string{"nodes", "addressPolicy", fmt.Sprintf("[%d]", i), "cidrs", fmt.Sprintf("[%d]", j)}, cidr)
+		}
+	}
+}
+
+// checkLoadBalancerClasses records load balancer classes without IP pool and
+// an undefined default class
+func (v *configValidator) checkLoadBalancerClasses(cfg *lcfg.LBConfigYAML) {
+	defaultPool := cfg.LoadBalancer.IPPoolName != "" || cfg.LoadBalancer.IPPoolID != ""
+	if !defaultPool {
+		if _, ok := cfg.LoadBalancerClass[lcfg.DefaultLoadBalancerClass]; !ok {
+			v.add([]string{"loadBalancer"}, "undefined load balancer class %q: set ipPoolName or ipPoolId, or define loadBalancerClass.%s",
+				lcfg.DefaultLoadBalancerClass, lcfg.DefaultLoadBalancerClass)
+		}
+	}
+
+	names := make([]string, 0, len(cfg.LoadBalancerClass))
+	for name := range cfg.LoadBalancerClass {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		class := cfg.LoadBalancerClass[name]
+		path := []string{"loadBalancerClass", name}
+		if class == nil {
+			v.add(path, "load balancer class is empty")
+			continue
+		}
+		if class.IPPoolName != "" && class.IPPoolID != "" {
+			v.add(path, "either ipPoolName or ipPoolId can be set")
+		}
+		if class.IPPoolName == "" && class.IPPoolID == "" && !defaultPool {
+			v.add(path, "load balancer class has no IP pool: set ipPoolName or ipPoolId")
+		}
+	}
+}
+
+// validateINI validates a deprecated INI cloud config with the readers of the
+// cloud controller manager
+func (v *configValidator) validateINI(byConfig []byte) {
+	cfg, err := ccfg.ReadCPIConfigINI(byConfig)
+	if err != nil {
+		v.add(nil, "%v", err)
+		return
+	}
+	if err := cfg.FromCPIEnv(); err != nil {
+		v.add([]string{"Global"}, "%v", err)
+	}
+	if nsxtcfg, err := ncfg.ReadConfigINI(byConfig); err == nil {
+		if err := nsxtcfg.FromEnv(); err != nil {
+			v.add([]string{"NSXT"}, "%v", err)
+		}
+	}
+	if _, err := lcfg.ReadConfigINI(byConfig); err != nil {
+		v.add([]string{"LoadBalancer"}, "%v", err)
+	}
+	// the route controller and node IPAM are only enabled with their section
+	sections := iniSections(byConfig)
+	if sections["route"] {
+		if _, err := rcfg.ReadConfigINI(byConfig); err != nil {
+			v.add([]string{"Route"}, "%v", err)
+		}
+	}
+	if sections["ipam"] {
+		if _, err := icfg.ReadConfigINI(byConfig); err != nil {
+			v.add([]string{"IPAM"}, "%v", err)
+		}
+	}
+	v.warn(nil, "the INI cloud config is deprecated, convert it to YAML")
+}
+
+// iniSections returns the lower case names of the sections of an INI cloud
+// config, which are case insensitive
+func iniSections(byConfig []byte) map[string]bool {
+	sections := map[string]bool{}
+	for _, line := range strings.Split(string(byConfig), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+		name := strings.TrimLeft(line, "[ \t")
+		if end := strings.IndexAny(name, " \t\"]"); end >= 0 {
+			name = name[:end]
+		}
+		sections[strings.ToLower(name)] = true
+	}
+	return sections
+}
+
+// checkConnectivity logs in to the vCenters and NSX-T of the cloud config
+func (v *configValidator) checkConnectivity(ctx context.Context, byConfig []byte) {
+	cfg, err := ccfg.ReadCPIConfig(byConfig)
+	if err != nil {
+		v.add(nil, "%v", err)
+		return
+	}
+	connMgr := cm.NewConnectionManager(&cfg.Config, nil, nil)
+	defer connMgr.Logout()
+	for tenant, vsi := range connMgr.Instances() {
+		path := []string{"vcenter", tenant}
+		if !v.isSet("vcenter", tenant) {
+			path = []string{"global", "server"}
+		}
+		if secret := kubernetesSecret(cfg, vsi.Cfg); secret != "" {
+			v.warn(path, "skipped connecting to vCenter %s: its credentials are read from the Kubernetes secret %s", vsi.Cfg.VCenterIP, secret)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, connectivityTimeout)
+		err := connMgr.Connect(ctx, vsi)
+		cancel()
+		if err != nil {
+			v.add(path, "connecting to vCenter %s failed: %v", vsi.Cfg.VCenterIP, err)
+		}
+	}
+
+	if v.root != nil && !v.isSet("nsxt") {
+		return
+	}
+	nsxtcfg, err := ncfg.ReadNsxtConfig(byConfig)
+	if err != nil {
+		return
+	}
+	if nsxtcfg.SecretName != "" && nsxtcfg.User == "" && nsxtcfg.VMCAccessToken == "" &&
+		nsxtcfg.ClientAuthCertFile == "" && nsxtcfg.CredentialSource == nil {
+		v.warn([]string{"nsxt"}, "skipped connecting to NSX-T %s: its credentials are read from the Kubernetes secret %s/%s",
+			nsxtcfg.Host, nsxtcfg.SecretNamespace, nsxtcfg.SecretName)
+		return
+	}
+	ncm, err := nsxt.NewConnectorManager(nsxtcfg)
+	if err != nil {
+		v.add([]string{"nsxt"}, "connecting to NSX-T %s failed: %v", nsxtcfg.Host, err)
+		return
+	}
+	if _, err := aaa.NewUserInfoClient(ncm.GetConnector()).Get(nil, nil); err != nil {
+		v.add([]string{"nsxt"}, "connecting to NSX-T %s failed: %v", nsxtcfg.Host, err)
+	}
+}
+
+// kubernetesSecret returns the Kubernetes secret the credentials of a vCenter
+// are read from, or an empty string if they can be read without a Kubernetes
+// client
+func kubernetesSecret(cfg *ccfg.CPIConfig, vc *vcfg.VirtualCenterConfig) string {
+	authMode := vc.AuthMode
+	if authMode == "" {
+		authMode = cfg.Global.AuthMode
+	}
+	if authMode == vclib.AuthModeCertificate || authMode == vclib.AuthModeToken {
+		return ""
+	}
+	if vc.User != "" && vc.Password != "" {
+		return ""
+	}
+	if vc.SecretRef != "" && vc.SecretRef != vcfg.DefaultCredentialManager {
+		return vc.SecretRef
+	}
+	if cfg.Global.CredentialSource != nil || cfg.Global.SecretsDirectory != "" || cfg.Global.SecretName == "" {
+		return ""
+	}
+	return cfg.Global.SecretNamespace + "/" + cfg.Global.SecretName
+}
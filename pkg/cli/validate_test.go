@@ -0,0 +1,186 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const validConfigYAML = `
+global:
+  port: 443
+  insecureFlag: true
+  secretName: vsphere-credentials
+  secretNamespace: kube-system
+  ipFamily:
+    - ipv4
+    - ipv6
+
+vcenter:
+  tenant1:
+    server: 10.0.0.1
+    datacenters:
+      - dc0
+
+nodes:
+  internalNetworkSubnetCidr: 10.0.0.0/24,fd00::/64
+
+loadBalancer:
+  ipPoolName: pool1
+  size: SMALL
+  tcpAppProfileName: default-tcp-lb-app-profile
+  udpAppProfileName: default-udp-lb-app-profile
+  tier1GatewayPath: /infra/tier-1s/gw1
+
+nsxt:
+  host: nsxt.example.com
+  secretName: nsxt-credentials
+  secretNamespace: kube-system
+`
+
+const invalidConfigYAML = `
+global:
+  port: 443
+  insecureFlag: true
+  secretName: vsphere-credentials
+  IPFamily:
+    - ipv4
+
+vcenter:
+  tenant1:
+    server: 10.0.0.1
+    datacenters:
+      - dc0
+
+nodes:
+  internalNetworkSubnetCidr: 10.0.0.0/24,10.0.1.0/33
+  addressPolicy:
+    - type: internal
+      cidrs:
+        - fd00::/64
+
+loadBalancer:
+  size: SMALL
+  tcpAppProfileName: default-tcp-lb-app-profile
+  udpAppProfileName: default-udp-lb-app-profile
+  tier1GatewayPath: /infra/tier-1s/gw1
+
+loadBalancerClass:
+  public:
+    ipPoolName: public
+    ipPoolId: 8a2b0c1d
+`
+
+func TestValidateConfig(t *testing.T) {
+	if problems := ValidateConfig(context.Background(), []byte(validConfigYAML), false); len(problems) != 0 {
+		t.Errorf("valid config should have no problems but got: %v", problems)
+	}
+
+	problems := ValidateConfig(context.Background(), []byte(invalidConfigYAML), false)
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{5, "global.secretName: secretNamespace is required"},
+		{6, `global: unknown key "IPFamily", did you mean "ipFamily"?`},
+		{10, "vcenter.tenant1: no credentials"},
+		{16, `nodes.internalNetworkSubnetCidr: invalid CIDR "10.0.1.0/33"`},
+		{20, `nodes.addressPolicy[0].cidrs[0]: conflicting IP families`},
+		{22, `loadBalancer: undefined load balancer class "default"`},
+		{29, "loadBalancerClass.public: either ipPoolName or ipPoolId"},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems but got: %v", len(expected), problems)
+	}
+	for i, problem := range problems {
+		if problem.Line != expected[i].line || !strings.Contains(problem.String(), expected[i].message) {
+			t.Errorf("expected problem %q at line %d but got: %s", expected[i].message, expected[i].line, problem)
+		}
+	}
+}
+
+func TestValidateConfigEnv(t *testing.T) {
+	t.Setenv("VSPHERE_NODES_EXTERNAL_NETWORK_SUBNET_CIDR", "192.168.0.0/16,invalid")
+	t.Setenv("NSXT_ALLOW_UNVERIFIED_SSL", "maybe")
+
+	problems := ValidateConfig(context.Background(), []byte(validConfigYAML), false)
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems but got: %v", problems)
+	}
+	if problems[0].Path != "nodes.externalNetworkSubnetCidr" || problems[0].Line != 17 {
+		t.Errorf("expected an invalid external subnet at line 17 but got: %s", problems[0])
+	}
+	if problems[1].Path != "nsxt" || problems[1].Line != 27 {
+		t.Errorf("expected an NSX-T problem at line 27 but got: %s", problems[1])
+	}
+}
+
+func TestValidateConfigConnectivitySkipsSecrets(t *testing.T) {
+	problems := ValidateConfig(context.Background(), []byte(validConfigYAML), true)
+	if ErrorCount(problems) != 0 {
+		t.Fatalf("vCenters and NSX-T with credentials in secrets should be skipped but got: %v", problems)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 warnings but got: %v", problems)
+	}
+	if problems[0].Path != "vcenter.tenant1" || !strings.Contains(problems[0].Message, "kube-system/vsphere-credentials") {
+		t.Errorf("expected the vCenter to be skipped but got: %s", problems[0])
+	}
+	if problems[1].Path != "nsxt" || !strings.Contains(problems[1].Message, "kube-system/nsxt-credentials") {
+		t.Errorf("expected NSX-T to be skipped but got: %s", problems[1])
+	}
+}
+
+func TestValidateConfigINI(t *testing.T) {
+	problems := ValidateConfig(context.Background(), []byte(`
+[Global]
+server = 10.0.0.1
+user = user
+password = password
+datacenters = dc0
+`), false)
+	if len(problems) != 1 || !problems[0].Warning || !strings.Contains(problems[0].Message, "deprecated") {
+		t.Errorf("expected only a deprecation warning but got: %v", problems)
+	}
+	if ErrorCount(problems) != 0 {
+		t.Errorf("a deprecation warning should not count as error")
+	}
+
+	problems = ValidateConfig(context.Background(), []byte(`
+[Global]
+server = 10.0.0.1
+user = user
+password = password
+datacenters = dc0
+
+[Route]
+router-path = /infra/tier-1s/gw1
+admin-distance = 256
+
+[IPAM]
+ip-block-path = /infra/ip-blocks/pods
+prefix-length = 33
+`), false)
+	if ErrorCount(problems) != 2 {
+		t.Fatalf("expected problems in the Route and IPAM sections but got: %v", problems)
+	}
+	if problems[0].Path != "Route" || problems[1].Path != "IPAM" {
+		t.Errorf("expected problems in the Route and IPAM sections but got: %v", problems)
+	}
+}
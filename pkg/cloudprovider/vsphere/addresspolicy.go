@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"net"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+)
+
+// addressRule is a parsed rule of the node address policy
+type addressRule struct {
+	addressType v1.NodeAddressType
+	subnets     []*net.IPNet
+	portGroups  []string
+	deviceKeys  []int32
+	macPrefixes []string
+	multiple    bool
+	dns         bool
+	hostName    bool
+	dnsDomains  bool
+}
+
+// newAddressRules parses the rules of the node address policy
+func newAddressRules(policy []ccfg.AddressRule) ([]*addressRule, error) {
+	var rules []*addressRule
+	for _, r := range policy {
+		subnets, err := parseCIDRs(strings.Join(r.CIDRs, ","))
+		if err != nil {
+			return nil, err
+		}
+		rule := &addressRule{
+			addressType: v1.NodeAddressType(r.Type),
+			subnets:     subnets,
+			portGroups:  r.PortGroups,
+			deviceKeys:  r.DeviceKeys,
+			multiple:    r.Multiple,
+			dns:         r.IsDNS(),
+			hostName:    r.HostName,
+			dnsDomains:  r.GuestDNSDomains,
+		}
+		for _, prefix := range r.MACPrefixes {
+			rule.macPrefixes = append(rule.macPrefixes, strings.ToLower(prefix))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matches returns true if the address matches all criteria of the rule. Each
+// criterion is satisfied if any of its values matches.
+func (r *addressRule) matches(candidate *ipAddrNetworkName) bool {
+	if len(r.subnets) > 0 && findSubnetMatch([]*ipAddrNetworkName{candidate}, r.subnets) == nil {
+		return false
+	}
+	if len(r.portGroups) > 0 && !ArrayContainsCaseInsensitive(r.portGroups, candidate.networkName) {
+		return false
+	}
+	if len(r.deviceKeys) > 0 {
+		found := false
+		for _, key := range r.deviceKeys {
+			found = found || key == candidate.deviceKey
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.macPrefixes) > 0 {
+		found := false
+		for _, prefix := range r.macPrefixes {
+			found = found || strings.HasPrefix(strings.ToLower(candidate.macAddress), prefix)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// dnsNames returns the DNS names added by a DNS rule
+func (r *addressRule) dnsNames(hostName string, domains []string) []string {
+	var names []string
+	if hostName == "" {
+		return names
+	}
+	if r.hostName {
+		names = append(names, hostName)
+	}
+	if r.dnsDomains {
+		shortName := strings.Split(hostName, ".")[0]
+		for _, domain := range domains {
+			names = append(names, shortName+"."+strings.Trim(domain, "."))
+		}
+	}
+	return names
+}
+
+// applyAddressPolicy returns the node addresses selected by the rules in their order.
+// For IP rules the addresses are added in the order of the IP families, an address
+// is only added by the first matching rule. Unless a rule allows multiple addresses,
+// it adds the first matching address per IP family or the first DNS name.
+func applyAddressPolicy(rules []*addressRule, ipAddrNetworkNames []*ipAddrNetworkName, ipFamilies []string,
+	hostName string, domains []string) []v1.NodeAddress {
+	var addrs []v1.NodeAddress
+	used := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.dns {
+			names := rule.dnsNames(hostName, domains)
+			if !rule.multiple && len(names) > 1 {
+				names = names[:1]
+			}
+			for _, name := range names {
+				klog.V(2).Infof("Adding %s by address policy: %s", rule.addressType, name)
+				addrs = append(addrs, v1.NodeAddress{Type: rule.addressType, Address: name})
+			}
+			continue
+		}
+		for _, ipFamily := range ipFamilies {
+			matches := filter(collectMatchesForIPFamily(ipAddrNetworkNames, ipFamily), func(candidate *ipAddrNetworkName) bool {
+				return !used[candidate.ipAddr] && rule.matches(candidate)
+			})
+			if !rule.multiple && len(matches) > 1 {
+				matches = matches[:1]
+			}
+			for _, match := range matches {
+				klog.V(2).Infof("Adding %s by address policy: %s", rule.addressType, match.ipAddr)
+				used[match.ipAddr] = true
+				addrs = append(addrs, v1.NodeAddress{Type: rule.addressType, Address: match.ipAddr})
+			}
+		}
+	}
+	return addrs
+}
+
+// guestDNSDomains returns the domain names and search domains of the guest IP stacks
+func guestDNSDomains(ipStacks []types.GuestStackInfo) []string {
+	var domains []string
+	seen := make(map[string]bool)
+	for _, stack := range ipStacks {
+		if stack.DnsConfig == nil {
+			continue
+		}
+		for _, domain := range append([]string{stack.DnsConfig.DomainName}, stack.DnsConfig.SearchDomain...) {
+			if domain != "" && !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains
+}
+
+// hasIPAddress returns true if the node addresses contain an IP address
+func hasIPAddress(addrs []v1.NodeAddress) bool {
+	for _, addr := range addrs {
+		if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
+			return true
+		}
+	}
+	return false
+}
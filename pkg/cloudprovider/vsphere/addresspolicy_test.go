@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+	v1 "k8s.io/api/core/v1"
+
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+)
+
+func TestApplyAddressPolicy(t *testing.T) {
+	candidates := []*ipAddrNetworkName{
+		{ipAddr: "10.0.0.10", networkName: "K8s Internal", macAddress: "00:50:56:aa:00:01", deviceKey: 4000},
+		{ipAddr: "10.0.0.11", networkName: "K8s Internal", macAddress: "00:50:56:aa:00:01", deviceKey: 4000},
+		{ipAddr: "fd00::10", networkName: "K8s Internal", macAddress: "00:50:56:aa:00:01", deviceKey: 4000},
+		{ipAddr: "192.0.2.10", networkName: "Public", macAddress: "00:0C:29:bb:00:02", deviceKey: 4001},
+		{ipAddr: "172.16.0.10", networkName: "Storage", macAddress: "00:50:56:cc:00:03", deviceKey: 4002},
+	}
+	domains := []string{"corp.example.com", "example.com"}
+
+	testCases := []struct {
+		name       string
+		policy     []ccfg.AddressRule
+		ipFamilies []string
+		expected   []v1.NodeAddress
+	}{
+		{
+			name:       "first address per family by port group",
+			policy:     []ccfg.AddressRule{{Type: "InternalIP", PortGroups: []string{"k8s internal"}}},
+			ipFamilies: []string{"ipv4", "ipv6"},
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalIP, Address: "fd00::10"},
+			},
+		},
+		{
+			name:       "multiple addresses by cidr",
+			policy:     []ccfg.AddressRule{{Type: "InternalIP", CIDRs: []string{"10.0.0.0/24"}, Multiple: true}},
+			ipFamilies: []string{"ipv4"},
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.11"},
+			},
+		},
+		{
+			name: "ordered rules by device key and mac prefix",
+			policy: []ccfg.AddressRule{
+				{Type: "ExternalIP", DeviceKeys: []int32{4001}},
+				{Type: "InternalIP", MACPrefixes: []string{"00:50:56"}, Multiple: true},
+			},
+			ipFamilies: []string{"ipv4"},
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeExternalIP, Address: "192.0.2.10"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.11"},
+				{Type: v1.NodeInternalIP, Address: "172.16.0.10"},
+			},
+		},
+		{
+			name: "address added by first matching rule only",
+			policy: []ccfg.AddressRule{
+				{Type: "InternalIP", CIDRs: []string{"10.0.0.0/8"}},
+				{Type: "ExternalIP", PortGroups: []string{"K8s Internal"}},
+			},
+			ipFamilies: []string{"ipv4"},
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeExternalIP, Address: "10.0.0.11"},
+			},
+		},
+		{
+			name:       "all criteria must match",
+			policy:     []ccfg.AddressRule{{Type: "InternalIP", PortGroups: []string{"Public"}, MACPrefixes: []string{"00:50:56"}}},
+			ipFamilies: []string{"ipv4"},
+		},
+		{
+			name: "dns names",
+			policy: []ccfg.AddressRule{
+				{Type: "InternalDNS", HostName: true, GuestDNSDomains: true, Multiple: true},
+				{Type: "ExternalDNS", GuestDNSDomains: true},
+			},
+			ipFamilies: []string{"ipv4"},
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalDNS, Address: "node1"},
+				{Type: v1.NodeInternalDNS, Address: "node1.corp.example.com"},
+				{Type: v1.NodeInternalDNS, Address: "node1.example.com"},
+				{Type: v1.NodeExternalDNS, Address: "node1.corp.example.com"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := newAddressRules(tc.policy)
+			if err != nil {
+				t.Fatalf("failed to parse address policy: %v", err)
+			}
+			addrs := applyAddressPolicy(rules, candidates, tc.ipFamilies, "node1", domains)
+			if !reflect.DeepEqual(tc.expected, addrs) {
+				t.Errorf("expected addresses %v, got %v", tc.expected, addrs)
+			}
+		})
+	}
+}
+
+func TestGuestDNSDomains(t *testing.T) {
+	ipStacks := []vimtypes.GuestStackInfo{
+		{},
+		{DnsConfig: &vimtypes.NetDnsConfigInfo{DomainName: "corp.example.com", SearchDomain: []string{"example.com", "corp.example.com"}}},
+	}
+	domains := guestDNSDomains(ipStacks)
+	if !reflect.DeepEqual([]string{"corp.example.com", "example.com"}, domains) {
+		t.Errorf("unexpected domains %v", domains)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeValid means the spec is valid and does not conflict with
+	// the cloud config file.
+	ConditionTypeValid = "Valid"
+	// ConditionTypeConnected means the vCenter of a VSphereVCenter was logged
+	// in to successfully.
+	ConditionTypeConnected = "Connected"
+	// ConditionTypeApplied means the object is used by the cloud controller
+	// manager.
+	ConditionTypeApplied = "Applied"
+)
+
+// DefaultNodeNetworkPolicy is the name of the only VSphereNodeNetworkPolicy
+// which is applied.
+const DefaultNodeNetworkPolicy = "default"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereVCenter adds a vCenter to the vCenters of the cloud config file. Its
+// name is used as tenant ref of the vCenter.
+type VSphereVCenter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   VSphereVCenterSpec `json:"spec"`
+	Status ConfigStatus       `json:"status,omitempty"`
+}
+
+// VSphereVCenterSpec defines the vCenter. Unset fields are inherited from the
+// global section of the cloud config file.
+type VSphereVCenterSpec struct {
+	// Server is the IP or FQDN of the vCenter.
+	Server string `json:"server"`
+	// Port of the vCenter.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Datacenters in which VMs are located.
+	// +optional
+	Datacenters []string `json:"datacenters,omitempty"`
+	// IncludeDatacenters and ExcludeDatacenters select the datacenters in
+	// which VMs are searched by name patterns, instead of listing them in
+	// datacenters.
+	// +optional
+	IncludeDatacenters []string `json:"includeDatacenters,omitempty"`
+	// +optional
+	ExcludeDatacenters []string `json:"excludeDatacenters,omitempty"`
+	// IncludeFolders and ExcludeFolders ignore the VMs found outside of the
+	// VM folders matching the path patterns.
+	// +optional
+	IncludeFolders []string `json:"includeFolders,omitempty"`
+	// +optional
+	ExcludeFolders []string `json:"excludeFolders,omitempty"`
+	// InsecureFlag is true if the vCenter uses a self-signed cert.
+	// +optional
+	InsecureFlag bool `json:"insecureFlag,omitempty"`
+	// Thumbprint of the vCenter's certificate.
+	// +optional
+	Thumbprint string `json:"thumbprint,omitempty"`
+	// SecretRef references the secret holding the vCenter credentials.
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+	// IPFamily lists the IP families of the node addresses by priority,
+	// ipv4 and/or ipv6.
+	// +optional
+	IPFamily []string `json:"ipFamily,omitempty"`
+}
+
+// SecretReference references a secret.
+type SecretReference struct {
+	// Name of the secret.
+	Name string `json:"name"`
+	// Namespace of the secret.
+	Namespace string `json:"namespace"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereVCenterList is a list of VSphereVCenter
+type VSphereVCenterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereVCenter `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereLoadBalancerClass adds a load balancer class to the classes of the
+// cloud config file. Its name is used as class name.
+type VSphereLoadBalancerClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   VSphereLoadBalancerClassSpec `json:"spec"`
+	Status ConfigStatus                 `json:"status,omitempty"`
+}
+
+// VSphereLoadBalancerClassSpec defines the load balancer class. Unset fields
+// are inherited from the default load balancer class.
+type VSphereLoadBalancerClassSpec struct {
+	// IPPoolName is the name of the NSX-T IP pool of the class.
+	// +optional
+	IPPoolName string `json:"ipPoolName,omitempty"`
+	// IPPoolID is the ID of the NSX-T IP pool of the class.
+	// +optional
+	IPPoolID string `json:"ipPoolId,omitempty"`
+	// TCPAppProfileName is the name of the NSX-T TCP application profile.
+	// +optional
+	TCPAppProfileName string `json:"tcpAppProfileName,omitempty"`
+	// TCPAppProfilePath is the path of the NSX-T TCP application profile.
+	// +optional
+	TCPAppProfilePath string `json:"tcpAppProfilePath,omitempty"`
+	// UDPAppProfileName is the name of the NSX-T UDP application profile.
+	// +optional
+	UDPAppProfileName string `json:"udpAppProfileName,omitempty"`
+	// UDPAppProfilePath is the path of the NSX-T UDP application profile.
+	// +optional
+	UDPAppProfilePath string `json:"udpAppProfilePath,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereLoadBalancerClassList is a list of VSphereLoadBalancerClass
+type VSphereLoadBalancerClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereLoadBalancerClass `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereNodeNetworkPolicy overrides the node networking of the cloud config
+// file. Only the policy named "default" is applied.
+type VSphereNodeNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   VSphereNodeNetworkPolicySpec `json:"spec"`
+	Status ConfigStatus                 `json:"status,omitempty"`
+}
+
+// VSphereNodeNetworkPolicySpec selects the node addresses. Set fields replace
+// the ones of the nodes section of the cloud config file.
+type VSphereNodeNetworkPolicySpec struct {
+	// InternalNetworkSubnetCIDRs select the internal node addresses.
+	// +optional
+	InternalNetworkSubnetCIDRs []string `json:"internalNetworkSubnetCidrs,omitempty"`
+	// ExternalNetworkSubnetCIDRs select the external node addresses.
+	// +optional
+	ExternalNetworkSubnetCIDRs []string `json:"externalNetworkSubnetCidrs,omitempty"`
+	// InternalVMNetworkName selects the internal node addresses by VM network.
+	// +optional
+	InternalVMNetworkName string `json:"internalVmNetworkName,omitempty"`
+	// ExternalVMNetworkName selects the external node addresses by VM network.
+	// +optional
+	ExternalVMNetworkName string `json:"externalVmNetworkName,omitempty"`
+	// ExcludeInternalNetworkSubnetCIDRs are never used as internal node addresses.
+	// +optional
+	ExcludeInternalNetworkSubnetCIDRs []string `json:"excludeInternalNetworkSubnetCidrs,omitempty"`
+	// ExcludeExternalNetworkSubnetCIDRs are never used as external node addresses.
+	// +optional
+	ExcludeExternalNetworkSubnetCIDRs []string `json:"excludeExternalNetworkSubnetCidrs,omitempty"`
+	// AddressPolicy is an ordered list of rules selecting the node addresses.
+	// It replaces the address policy of the cloud config file, which takes
+	// precedence over the fields above.
+	// +optional
+	AddressPolicy []AddressRule `json:"addressPolicy,omitempty"`
+}
+
+// AddressRule maps the IP addresses of the VM's network interfaces matching
+// all given criteria, or the DNS names of the guest, to a node address type.
+type AddressRule struct {
+	// Type is the node address type: InternalIP, ExternalIP, InternalDNS or
+	// ExternalDNS.
+	Type string `json:"type"`
+	// CIDRs match the IP addresses of the network interfaces.
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+	// PortGroups match the port group names of the network interfaces.
+	// +optional
+	PortGroups []string `json:"portGroups,omitempty"`
+	// DeviceKeys match the device keys of the virtual network interfaces.
+	// +optional
+	DeviceKeys []int32 `json:"deviceKeys,omitempty"`
+	// MACPrefixes match the MAC addresses of the network interfaces.
+	// +optional
+	MACPrefixes []string `json:"macPrefixes,omitempty"`
+	// Multiple adds all matching addresses instead of the first one per IP
+	// family.
+	// +optional
+	Multiple bool `json:"multiple,omitempty"`
+	// HostName adds the guest host name as DNS address.
+	// +optional
+	HostName bool `json:"hostName,omitempty"`
+	// GuestDNSDomains adds the guest host name qualified with the DNS
+	// domains of the guest as DNS addresses.
+	// +optional
+	GuestDNSDomains bool `json:"guestDNSDomains,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereNodeNetworkPolicyList is a list of VSphereNodeNetworkPolicy
+type VSphereNodeNetworkPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VSphereNodeNetworkPolicy `json:"items"`
+}
+
+// ConfigStatus is the observed state of a configuration object.
+type ConfigStatus struct {
+	// Conditions show the validation and connection results.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
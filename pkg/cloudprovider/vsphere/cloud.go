@@ -30,6 +30,8 @@ import (
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/log"
 
 	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam"
+	icfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam/config"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer"
 	lcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route"
@@ -81,16 +83,23 @@ func init() {
 			klog.Errorf("ReadRouteConfig failed: %s", err)
 			routecfg = nil
 		}
+		ipamcfg, err := icfg.ReadIPAMConfig(byConfig)
+		if err != nil {
+			if err != icfg.ErrIPBlockPathRequired {
+				klog.Errorf("ReadIPAMConfig failed: %s", err)
+			}
+			ipamcfg = nil //Error reading IPAMConfig, node IPAM is disabled
+		}
 
-		return newVSphere(cfg, nsxtcfg, lbcfg, routecfg, true)
+		return newVSphere(cfg, nsxtcfg, lbcfg, routecfg, ipamcfg, true)
 	})
 }
 
 var _ cloudprovider.Interface = &VSphere{}
 
 // Creates new Controller node interface and returns
-func newVSphere(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lcfg.LBConfig, routecfg *rcfg.Config, finalize ...bool) (*VSphere, error) {
-	vs, err := buildVSphereFromConfig(cfg, nsxtcfg, lbcfg, routecfg)
+func newVSphere(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lcfg.LBConfig, routecfg *rcfg.Config, ipamcfg *icfg.Config, finalize ...bool) (*VSphere, error) {
+	vs, err := buildVSphereFromConfig(cfg, nsxtcfg, lbcfg, routecfg, ipamcfg)
 	if err != nil {
 		return nil, err
 	}
@@ -113,12 +122,20 @@ func (vs *VSphere) Initialize(clientBuilder cloudprovider.ControllerClientBuilde
 		vs.connectionManager = connMgr
 		vs.nodeManager.connectionManager = connMgr
 
-		vs.informMgr.AddNodeListener(vs.nodeAdded, vs.nodeDeleted, nil)
+		vs.informMgr.AddNodeListener(vs.nodeAdded, vs.nodeDeleted, vs.nodeUpdated)
 
 		vs.informMgr.Listen()
 
 		// if running secrets, init them
 		connMgr.InitializeSecretLister()
+
+		// pick up credentials rotated in a mounted secrets directory or
+		// an external credential source
+		connMgr.WatchCredentials(stop)
+
+		// vCenters, load balancer classes and node networking added by
+		// configuration objects
+		vs.initializeConfigCRDs(clientBuilder, stop)
 	} else {
 		klog.Errorf("Kubernetes Client Init Failed: %v", err)
 	}
@@ -129,10 +146,21 @@ func (vs *VSphere) Initialize(clientBuilder cloudprovider.ControllerClientBuilde
 		}
 		vs.loadbalancer.Initialize(loadbalancer.ClusterName, client, stop)
 	}
-	err = vs.nsxtConnectorMgr.AddSecretListener(vs.informMgr.GetSecretInformer())
+	if vs.routes != nil {
+		vs.routes.Initialize(client, stop)
+	}
+	if vs.ipam != nil {
+		klog.Info("initializing node IPAM")
+		if ipam.ClusterName == "" {
+			klog.Warning("Missing cluster id, node subnets of different clusters cannot be distinguished")
+		}
+		vs.ipam.Initialize(client, stop)
+	}
+	err = vs.nsxtConnectorMgr.AddSecretListener(vs.informMgr.GetSecretInformer(), client)
 	if err != nil {
 		klog.Warning("Adding NSXT secret listener failed: %v", err)
 	}
+	vs.nsxtConnectorMgr.WatchCredentialSource(stop)
 }
 
 func (vs *VSphere) isLoadBalancerSupportEnabled() bool {
@@ -204,7 +232,7 @@ func (vs *VSphere) HasClusterID() bool {
 }
 
 // Initializes vSphere from vSphere CloudProvider Configuration
-func buildVSphereFromConfig(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lcfg.LBConfig, routecfg *rcfg.Config) (*VSphere, error) {
+func buildVSphereFromConfig(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lcfg.LBConfig, routecfg *rcfg.Config, ipamcfg *icfg.Config) (*VSphere, error) {
 	nm := newNodeManager(cfg, nil)
 
 	ncm, err := nsxt.NewConnectorManager(nsxtcfg)
@@ -221,6 +249,14 @@ func buildVSphereFromConfig(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lc
 	if err != nil {
 		return nil, err
 	}
+	if routes != nil {
+		routes.SetNodeNetworkAddresses(nm.NodeNetworkAddresses)
+	}
+
+	nodeIPAM, err := ipam.NewNodeIPAM(ipamcfg, ncm.GetConnector())
+	if err != nil {
+		return nil, err
+	}
 
 	// redirect vapi logging from the NSX-T GO SDK to klog
 	log.SetLogger(NewKlogBridge())
@@ -237,6 +273,7 @@ func buildVSphereFromConfig(cfg *ccfg.CPIConfig, nsxtcfg *ncfg.Config, lbcfg *lc
 		nsxtConnectorMgr: ncm,
 		loadbalancer:     lb,
 		routes:           routes,
+		ipam:             nodeIPAM,
 		instances:        newInstances(nm),
 		zones:            newZones(nm, cfg.Labels.Zone, cfg.Labels.Region),
 	}
@@ -276,6 +313,27 @@ func (vs *VSphere) nodeAdded(obj interface{}) {
 	if vs.routes != nil {
 		vs.routes.AddNode(node)
 	}
+	if vs.ipam != nil {
+		vs.ipam.AddNode(node)
+	}
+}
+
+// Notification handler when node is updated, keeps the node addresses
+// used for the static routes up to date and picks up nodes still lacking
+// pod CIDRs.
+func (vs *VSphere) nodeUpdated(oldObj, newObj interface{}) {
+	node, ok := newObj.(*v1.Node)
+	if node == nil || !ok {
+		klog.Warningf("nodeUpdated: unrecognized object %+v", newObj)
+		return
+	}
+
+	if vs.routes != nil {
+		vs.routes.AddNode(node)
+	}
+	if vs.ipam != nil {
+		vs.ipam.AddNode(node)
+	}
 }
 
 // Notification handler when node is removed from k8s cluster.
@@ -290,4 +348,7 @@ func (vs *VSphere) nodeDeleted(obj interface{}) {
 	if vs.routes != nil {
 		vs.routes.DeleteNode(node)
 	}
+	if vs.ipam != nil {
+		vs.ipam.DeleteNode(node)
+	}
 }
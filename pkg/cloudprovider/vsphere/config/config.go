@@ -18,6 +18,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 
 	klog "k8s.io/klog/v2"
@@ -49,6 +50,46 @@ func (cfg *CPIConfig) FromCPIEnv() error {
 	return nil
 }
 
+// address types supported by the address policy
+const (
+	addressTypeInternalIP  = "InternalIP"
+	addressTypeExternalIP  = "ExternalIP"
+	addressTypeInternalDNS = "InternalDNS"
+	addressTypeExternalDNS = "ExternalDNS"
+)
+
+// IsDNS returns true if the rule adds DNS names instead of IP addresses
+func (r *AddressRule) IsDNS() bool {
+	return r.Type == addressTypeInternalDNS || r.Type == addressTypeExternalDNS
+}
+
+// ValidateAddressPolicy checks the address types and matching criteria of the address rules
+func (n *Nodes) ValidateAddressPolicy() error {
+	for i, rule := range n.AddressPolicy {
+		switch rule.Type {
+		case addressTypeInternalIP, addressTypeExternalIP:
+			if rule.HostName || rule.GuestDNSDomains {
+				return fmt.Errorf("address rule %d: hostName and guestDNSDomains require a DNS address type", i)
+			}
+		case addressTypeInternalDNS, addressTypeExternalDNS:
+			if !rule.HostName && !rule.GuestDNSDomains {
+				return fmt.Errorf("address rule %d: hostName or guestDNSDomains is required for address type %s", i, rule.Type)
+			}
+			if len(rule.CIDRs) > 0 || len(rule.PortGroups) > 0 || len(rule.DeviceKeys) > 0 || len(rule.MACPrefixes) > 0 {
+				return fmt.Errorf("address rule %d: address type %s does not match network interfaces", i, rule.Type)
+			}
+		default:
+			return fmt.Errorf("address rule %d: invalid address type %q", i, rule.Type)
+		}
+		for _, cidr := range rule.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("address rule %d: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
 /*
 	TODO:
 	When the INI based cloud-config is deprecated, the references to the
@@ -44,6 +44,18 @@ func (ccy *CPIConfigYAML) CreateConfig() *CPIConfig {
 			ExcludeExternalNetworkSubnetCIDR: ccy.Nodes.ExcludeExternalNetworkSubnetCIDR,
 		},
 	}
+	for _, rule := range ccy.Nodes.AddressPolicy {
+		cfg.Nodes.AddressPolicy = append(cfg.Nodes.AddressPolicy, AddressRule{
+			Type:            rule.Type,
+			CIDRs:           rule.CIDRs,
+			PortGroups:      rule.PortGroups,
+			DeviceKeys:      rule.DeviceKeys,
+			MACPrefixes:     rule.MACPrefixes,
+			Multiple:        rule.Multiple,
+			HostName:        rule.HostName,
+			GuestDNSDomains: rule.GuestDNSDomains,
+		})
+	}
 
 	return cfg
 }
@@ -69,5 +81,9 @@ func ReadCPIConfigYAML(byConfig []byte) (*CPIConfig, error) {
 
 	cfg := &CPIConfigYAML{*vCFG, cfgOLD.Nodes}
 
-	return cfg.CreateConfig(), nil
+	cpiCfg := cfg.CreateConfig()
+	if err := cpiCfg.Nodes.ValidateAddressPolicy(); err != nil {
+		return nil, err
+	}
+	return cpiCfg, nil
 }
@@ -73,6 +73,35 @@ nodes:
   excludeExternalNetworkSubnetCidr: "192.1.2.0/24,fe80::2/128"
 `
 
+const addressPolicyYAMLConfig = `
+global:
+  server: 0.0.0.0
+  port: 443
+  user: user
+  password: password
+  insecureFlag: true
+  datacenters:
+    - us-west
+  caFile: /some/path/to/a/ca.pem
+
+nodes:
+  addressPolicy:
+    - type: InternalIP
+      cidrs:
+        - 192.0.2.0/24
+      portGroups:
+        - Internal K8s Traffic
+      multiple: true
+    - type: ExternalIP
+      deviceKeys:
+        - 4001
+      macPrefixes:
+        - "00:50:56"
+    - type: InternalDNS
+      hostName: true
+      guestDNSDomains: true
+`
+
 func TestReadYAMLConfigSubnetCidr(t *testing.T) {
 	_, err := ReadCPIConfigYAML(nil)
 	if err == nil {
@@ -130,3 +159,49 @@ func TestReadYAMLConfigExcludeSubnetCidr(t *testing.T) {
 		t.Errorf("incorrect exclude external network subnet cidrs: %s", cfg.Nodes.ExcludeExternalNetworkSubnetCIDR)
 	}
 }
+
+func TestReadYAMLConfigAddressPolicy(t *testing.T) {
+	cfg, err := ReadCPIConfigYAML([]byte(addressPolicyYAMLConfig))
+	if err != nil {
+		t.Fatalf("Should succeed when a valid config is provided: %s", err)
+	}
+
+	policy := cfg.Nodes.AddressPolicy
+	if len(policy) != 3 {
+		t.Fatalf("incorrect number of address rules: %d", len(policy))
+	}
+	if policy[0].Type != "InternalIP" || policy[0].CIDRs[0] != "192.0.2.0/24" ||
+		policy[0].PortGroups[0] != "Internal K8s Traffic" || !policy[0].Multiple {
+		t.Errorf("incorrect internal IP address rule: %+v", policy[0])
+	}
+	if policy[1].Type != "ExternalIP" || policy[1].DeviceKeys[0] != 4001 || policy[1].MACPrefixes[0] != "00:50:56" {
+		t.Errorf("incorrect external IP address rule: %+v", policy[1])
+	}
+	if !policy[2].IsDNS() || !policy[2].HostName || !policy[2].GuestDNSDomains {
+		t.Errorf("incorrect internal DNS address rule: %+v", policy[2])
+	}
+}
+
+func TestValidateAddressPolicy(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  AddressRule
+		valid bool
+	}{
+		{name: "ip rule", rule: AddressRule{Type: "InternalIP", CIDRs: []string{"fd00::/64"}}, valid: true},
+		{name: "dns rule", rule: AddressRule{Type: "ExternalDNS", HostName: true}, valid: true},
+		{name: "invalid type", rule: AddressRule{Type: "Hostname"}},
+		{name: "invalid cidr", rule: AddressRule{Type: "InternalIP", CIDRs: []string{"192.0.2.0"}}},
+		{name: "dns rule without names", rule: AddressRule{Type: "InternalDNS"}},
+		{name: "dns rule with matchers", rule: AddressRule{Type: "InternalDNS", HostName: true, PortGroups: []string{"VM Network"}}},
+		{name: "ip rule with host name", rule: AddressRule{Type: "ExternalIP", HostName: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := Nodes{AddressPolicy: []AddressRule{tc.rule}}
+			if err := nodes.ValidateAddressPolicy(); (err == nil) != tc.valid {
+				t.Errorf("unexpected validation result: %v", err)
+			}
+		})
+	}
+}
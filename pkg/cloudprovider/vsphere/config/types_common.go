@@ -43,6 +43,31 @@ type Nodes struct {
 	// status.addresses fields.
 	ExcludeInternalNetworkSubnetCIDR string
 	ExcludeExternalNetworkSubnetCIDR string
+	// AddressPolicy is an ordered list of rules selecting the status.addresses
+	// of the nodes. If set, it replaces the selection by the fields above.
+	AddressPolicy []AddressRule
+}
+
+// AddressRule maps the IP addresses of the VM's network interfaces matching all
+// given criteria, or the DNS names of the guest, to a node address type.
+type AddressRule struct {
+	// Type is the node address type: InternalIP, ExternalIP, InternalDNS or ExternalDNS
+	Type string
+	// CIDRs match the IP addresses of the network interfaces
+	CIDRs []string
+	// PortGroups match the network (port group) names of the network interfaces, ignoring case
+	PortGroups []string
+	// DeviceKeys match the device keys of the virtual network interfaces
+	DeviceKeys []int32
+	// MACPrefixes match the MAC addresses of the network interfaces, ignoring case
+	MACPrefixes []string
+	// Multiple adds all matching addresses instead of the first one per IP family
+	Multiple bool
+	// HostName adds the guest host name as DNS address
+	HostName bool
+	// GuestDNSDomains adds the guest host name qualified with the domain name and
+	// search domains of the guest IP stack DNS config as DNS addresses
+	GuestDNSDomains bool
 }
 
 // CPIConfig is used to read and store information (related only to the CPI) from the cloud configuration file
@@ -27,6 +27,7 @@ import (
 
 	ConfigYAML -> Config
 	NodesYAML -> Nodes
+	AddressRuleYAML -> AddressRule
 */
 
 // NodesYAML captures internal/external networks
@@ -46,6 +47,22 @@ type NodesYAML struct {
 	// status.addresses fields.
 	ExcludeInternalNetworkSubnetCIDR string `yaml:"excludeInternalNetworkSubnetCidr"`
 	ExcludeExternalNetworkSubnetCIDR string `yaml:"excludeExternalNetworkSubnetCidr"`
+	// AddressPolicy is an ordered list of rules selecting the status.addresses
+	// of the nodes. If set, it replaces the selection by the fields above.
+	AddressPolicy []AddressRuleYAML `yaml:"addressPolicy"`
+}
+
+// AddressRuleYAML maps the IP addresses of the VM's network interfaces matching all
+// given criteria, or the DNS names of the guest, to a node address type.
+type AddressRuleYAML struct {
+	Type            string   `yaml:"type"`
+	CIDRs           []string `yaml:"cidrs"`
+	PortGroups      []string `yaml:"portGroups"`
+	DeviceKeys      []int32  `yaml:"deviceKeys"`
+	MACPrefixes     []string `yaml:"macPrefixes"`
+	Multiple        bool     `yaml:"multiple"`
+	HostName        bool     `yaml:"hostName"`
+	GuestDNSDomains bool     `yaml:"guestDNSDomains"`
 }
 
 // CPIConfigYAML is the YAML representation
@@ -0,0 +1,518 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/apis/vsphereconfig/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/client/clientset/versioned"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/client/informers/externalversions"
+	listers "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/client/listers/vsphereconfig/v1alpha1"
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	lcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+const (
+	// vcenterConnectTimeout bounds the login to the vCenter of a VSphereVCenter
+	vcenterConnectTimeout = 30 * time.Second
+
+	reasonValid                = "Valid"
+	reasonInvalid              = "Invalid"
+	reasonConflict             = "ConflictsWithCloudConfig"
+	reasonConnected            = "Connected"
+	reasonConnectFailed        = "ConnectFailed"
+	reasonApplied              = "Applied"
+	reasonLoadBalancerDisabled = "LoadBalancerDisabled"
+	reasonNotDefault           = "NotDefault"
+)
+
+type configKind string
+
+const (
+	kindVCenter           configKind = "VSphereVCenter"
+	kindLoadBalancerClass configKind = "VSphereLoadBalancerClass"
+	kindNodeNetwork       configKind = "VSphereNodeNetworkPolicy"
+)
+
+type configKey struct {
+	kind configKind
+	name string
+}
+
+// configController applies the VSphereVCenter, VSphereLoadBalancerClass and
+// VSphereNodeNetworkPolicy objects to the cloud provider at runtime
+type configController struct {
+	vs     *VSphere
+	client versioned.Interface
+
+	factory      externalversions.SharedInformerFactory
+	vcenters     listers.VSphereVCenterLister
+	lbClasses    listers.VSphereLoadBalancerClassLister
+	nodePolicies listers.VSphereNodeNetworkPolicyLister
+	queue        workqueue.RateLimitingInterface
+
+	// configs of the connected vCenters by tenant ref, only used by the
+	// single worker
+	connected map[string]*vcfg.VirtualCenterConfig
+}
+
+// initializeConfigCRDs starts the config controller if the CRDs of the
+// configuration objects are installed
+func (vs *VSphere) initializeConfigCRDs(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
+	restConfig, err := clientBuilder.Config(ClientName)
+	if err != nil {
+		klog.Errorf("Kubernetes Client Config Failed: %v", err)
+		return
+	}
+	client, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		klog.Errorf("Creating config CRD client failed: %v", err)
+		return
+	}
+	if !configCRDsInstalled(client) {
+		klog.V(2).Infof("%s CRDs are not installed, using the cloud config only", v1alpha1.SchemeGroupVersion)
+		return
+	}
+	klog.Info("initializing configuration by CRDs")
+	newConfigController(vs, client).Run(stop)
+}
+
+func configCRDsInstalled(client versioned.Interface) bool {
+	_, err := client.Discovery().ServerResourcesForGroupVersion(v1alpha1.SchemeGroupVersion.String())
+	return err == nil
+}
+
+func newConfigController(vs *VSphere, client versioned.Interface) *configController {
+	factory := externalversions.NewSharedInformerFactory(client, 0)
+	informers := factory.Config().V1alpha1()
+	c := &configController{
+		vs:           vs,
+		client:       client,
+		factory:      factory,
+		vcenters:     informers.VSphereVCenters().Lister(),
+		lbClasses:    informers.VSphereLoadBalancerClasses().Lister(),
+		nodePolicies: informers.VSphereNodeNetworkPolicies().Lister(),
+		queue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "VSphereConfig"),
+		connected:    map[string]*vcfg.VirtualCenterConfig{},
+	}
+	informers.VSphereVCenters().Informer().AddEventHandler(c.eventHandler(kindVCenter))
+	informers.VSphereLoadBalancerClasses().Informer().AddEventHandler(c.eventHandler(kindLoadBalancerClass))
+	informers.VSphereNodeNetworkPolicies().Informer().AddEventHandler(c.eventHandler(kindNodeNetwork))
+	return c
+}
+
+func (c *configController) eventHandler(kind configKind) cache.ResourceEventHandlerFuncs {
+	enqueue := func(obj interface{}) {
+		name, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		c.queue.Add(configKey{kind: kind, name: name})
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+		DeleteFunc: enqueue,
+	}
+}
+
+// Run starts the informers and the worker applying the configuration objects
+func (c *configController) Run(stop <-chan struct{}) {
+	c.factory.Start(stop)
+	go func() {
+		for informerType, synced := range c.factory.WaitForCacheSync(stop) {
+			if !synced {
+				utilruntime.HandleError(fmt.Errorf("failed to sync %v informer", informerType))
+			}
+		}
+		wait.Until(c.runWorker, time.Second, stop)
+	}()
+	go func() {
+		<-stop
+		c.queue.ShutDown()
+	}()
+}
+
+func (c *configController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *configController) processNextWorkItem() bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(key.(configKey)); err != nil {
+		utilruntime.HandleError(fmt.Errorf("error syncing %v: %v, requeuing", key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *configController) sync(key configKey) error {
+	switch key.kind {
+	case kindVCenter:
+		return c.syncVCenter(key.name)
+	case kindLoadBalancerClass:
+		return c.syncLoadBalancerClass(key.name)
+	case kindNodeNetwork:
+		return c.syncNodeNetworkPolicy(key.name)
+	}
+	return fmt.Errorf("unknown kind %s", key.kind)
+}
+
+// syncVCenter adds, replaces or removes the vCenter of a VSphereVCenter
+func (c *configController) syncVCenter(name string) error {
+	vcenter, err := c.vcenters.Get(name)
+	if apierrors.IsNotFound(err) {
+		c.removeVCenter(name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	conditions := append([]metav1.Condition{}, vcenter.Status.Conditions...)
+	if _, ok := c.vs.cfg.VirtualCenter[name]; ok {
+		setCondition(&conditions, vcenter.Generation, v1alpha1.ConditionTypeValid, false, reasonConflict,
+			fmt.Sprintf("vCenter %s is defined in the cloud config", name))
+		meta.RemoveStatusCondition(&conditions, v1alpha1.ConditionTypeConnected)
+		return c.updateVCenterStatus(vcenter, conditions)
+	}
+	vcConfig, err := vcenterConfig(&c.vs.cfg.Global, vcenter)
+	if err != nil {
+		c.removeVCenter(name)
+		setCondition(&conditions, vcenter.Generation, v1alpha1.ConditionTypeValid, false, reasonInvalid, err.Error())
+		meta.RemoveStatusCondition(&conditions, v1alpha1.ConditionTypeConnected)
+		return c.updateVCenterStatus(vcenter, conditions)
+	}
+	setCondition(&conditions, vcenter.Generation, v1alpha1.ConditionTypeValid, true, reasonValid, "")
+
+	if reflect.DeepEqual(c.connected[name], vcConfig) {
+		return c.updateVCenterStatus(vcenter, conditions)
+	}
+	delete(c.connected, name)
+	klog.Infof("Setting vCenter %s of %s %s", vcConfig.VCenterIP, kindVCenter, name)
+	_, replaced := c.vs.connectionManager.Instances()[name]
+	vsi := c.vs.connectionManager.SetVCenter(vcConfig)
+	if replaced {
+		// the nodes refer to the VMs of the replaced connection
+		c.vs.nodeManager.forgetVCenter(name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vcenterConnectTimeout)
+	defer cancel()
+	connectErr := c.vs.connectionManager.Connect(ctx, vsi)
+	if connectErr != nil {
+		setCondition(&conditions, vcenter.Generation, v1alpha1.ConditionTypeConnected, false, reasonConnectFailed, connectErr.Error())
+	} else {
+		c.connected[name] = vcConfig
+		setCondition(&conditions, vcenter.Generation, v1alpha1.ConditionTypeConnected, true, reasonConnected, "")
+	}
+	if err := c.updateVCenterStatus(vcenter, conditions); err != nil {
+		return err
+	}
+	return connectErr
+}
+
+func (c *configController) removeVCenter(name string) {
+	if _, ok := c.vs.cfg.VirtualCenter[name]; ok {
+		return
+	}
+	if _, ok := c.vs.connectionManager.Instances()[name]; ok {
+		klog.Infof("Removing vCenter of %s %s", kindVCenter, name)
+		c.vs.connectionManager.RemoveVCenter(name)
+		c.vs.nodeManager.forgetVCenter(name)
+	}
+	delete(c.connected, name)
+}
+
+// vcenterConfig creates the vCenter config of a VSphereVCenter, inheriting
+// unset fields from the global section of the cloud config
+func vcenterConfig(global *vcfg.Global, vcenter *v1alpha1.VSphereVCenter) (*vcfg.VirtualCenterConfig, error) {
+	spec := &vcenter.Spec
+	if spec.Server == "" {
+		return nil, vcfg.ErrInvalidVCenterIP
+	}
+	vcConfig := &vcfg.VirtualCenterConfig{
+		TenantRef:         vcenter.Name,
+		VCenterIP:         spec.Server,
+		VCenterPort:       global.VCenterPort,
+		InsecureFlag:      spec.InsecureFlag || global.InsecureFlag,
+		Datacenters:       global.Datacenters,
+		RoundTripperCount: global.RoundTripperCount,
+		CAFile:            global.CAFile,
+		Thumbprint:        global.Thumbprint,
+		AuthMode:          global.AuthMode,
+		CertFile:          global.CertFile,
+		KeyFile:           global.KeyFile,
+		TokenFile:         global.TokenFile,
+	}
+	if spec.Port != 0 {
+		vcConfig.VCenterPort = strconv.Itoa(int(spec.Port))
+	}
+	vcConfig.IncludeDatacenters = spec.IncludeDatacenters
+	vcConfig.ExcludeDatacenters = spec.ExcludeDatacenters
+	if len(spec.Datacenters) != 0 {
+		vcConfig.Datacenters = strings.Join(spec.Datacenters, ",")
+	} else if len(spec.IncludeDatacenters) != 0 || len(spec.ExcludeDatacenters) != 0 {
+		vcConfig.Datacenters = ""
+	} else if global.Datacenters == "" {
+		vcConfig.IncludeDatacenters = global.IncludeDatacenters
+		vcConfig.ExcludeDatacenters = global.ExcludeDatacenters
+	}
+	if vcConfig.Datacenters != "" && (len(vcConfig.IncludeDatacenters) != 0 || len(vcConfig.ExcludeDatacenters) != 0) {
+		return nil, vcfg.ErrDatacenterFilterConflict
+	}
+	if _, err := vcfg.NewNameFilter(vcConfig.IncludeDatacenters, vcConfig.ExcludeDatacenters); err != nil {
+		return nil, err
+	}
+	vcConfig.IncludeFolders = spec.IncludeFolders
+	vcConfig.ExcludeFolders = spec.ExcludeFolders
+	if len(spec.IncludeFolders) == 0 && len(spec.ExcludeFolders) == 0 {
+		vcConfig.IncludeFolders = global.IncludeFolders
+		vcConfig.ExcludeFolders = global.ExcludeFolders
+	}
+	if _, err := vcfg.NewNameFilter(vcConfig.IncludeFolders, vcConfig.ExcludeFolders); err != nil {
+		return nil, err
+	}
+	if spec.Thumbprint != "" {
+		vcConfig.Thumbprint = spec.Thumbprint
+	}
+	if len(spec.IPFamily) != 0 {
+		vcConfig.IPFamilyPriority = spec.IPFamily
+	}
+	if len(vcConfig.IPFamilyPriority) == 0 {
+		vcConfig.IPFamilyPriority = []string{vcfg.DefaultIPFamily}
+	}
+	for _, ipFamily := range vcConfig.IPFamilyPriority {
+		if !strings.EqualFold(ipFamily, vcfg.IPv4Family) && !strings.EqualFold(ipFamily, vcfg.IPv6Family) {
+			return nil, vcfg.ErrInvalidIPFamilyType
+		}
+	}
+
+	if spec.SecretRef != nil {
+		if spec.SecretRef.Name == "" || spec.SecretRef.Namespace == "" {
+			return nil, errors.New("secretRef requires a name and a namespace")
+		}
+		vcConfig.SecretName = spec.SecretRef.Name
+		vcConfig.SecretNamespace = spec.SecretRef.Namespace
+		vcConfig.SecretRef = spec.SecretRef.Namespace + "/" + spec.SecretRef.Name
+	} else {
+		vcConfig.User = global.User
+		vcConfig.Password = global.Password
+		vcConfig.SecretRef = vcfg.DefaultCredentialManager
+	}
+	return vcConfig, nil
+}
+
+// syncLoadBalancerClass adds, replaces or removes the class of a
+// VSphereLoadBalancerClass
+func (c *configController) syncLoadBalancerClass(name string) error {
+	class, err := c.lbClasses.Get(name)
+	if apierrors.IsNotFound(err) {
+		if c.vs.loadbalancer != nil {
+			c.vs.loadbalancer.RemoveLoadBalancerClass(name)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	conditions := append([]metav1.Condition{}, class.Status.Conditions...)
+	if c.vs.loadbalancer == nil {
+		setCondition(&conditions, class.Generation, v1alpha1.ConditionTypeApplied, false, reasonLoadBalancerDisabled,
+			"load balancer support is not configured")
+		return c.updateLoadBalancerClassStatus(class, conditions)
+	}
+	classConfig := &lcfg.LoadBalancerClassConfig{
+		IPPoolName:        class.Spec.IPPoolName,
+		IPPoolID:          class.Spec.IPPoolID,
+		TCPAppProfileName: class.Spec.TCPAppProfileName,
+		TCPAppProfilePath: class.Spec.TCPAppProfilePath,
+		UDPAppProfileName: class.Spec.UDPAppProfileName,
+		UDPAppProfilePath: class.Spec.UDPAppProfilePath,
+	}
+	if err := c.vs.loadbalancer.SetLoadBalancerClass(name, classConfig); err != nil {
+		setCondition(&conditions, class.Generation, v1alpha1.ConditionTypeValid, false, reasonInvalid, err.Error())
+		setCondition(&conditions, class.Generation, v1alpha1.ConditionTypeApplied, false, reasonInvalid, "")
+		return c.updateLoadBalancerClassStatus(class, conditions)
+	}
+	setCondition(&conditions, class.Generation, v1alpha1.ConditionTypeValid, true, reasonValid, "")
+	setCondition(&conditions, class.Generation, v1alpha1.ConditionTypeApplied, true, reasonApplied, "")
+	return c.updateLoadBalancerClassStatus(class, conditions)
+}
+
+// syncNodeNetworkPolicy replaces or restores the node networking of the cloud
+// config by the default VSphereNodeNetworkPolicy
+func (c *configController) syncNodeNetworkPolicy(name string) error {
+	policy, err := c.nodePolicies.Get(name)
+	if apierrors.IsNotFound(err) {
+		if name == v1alpha1.DefaultNodeNetworkPolicy {
+			klog.Infof("Restoring node networking of the cloud config")
+			c.vs.nodeManager.SetNodeNetworkPolicy(nil)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	conditions := append([]metav1.Condition{}, policy.Status.Conditions...)
+	nodes, err := nodeNetworking(&c.vs.cfg.Nodes, &policy.Spec)
+	if err != nil {
+		setCondition(&conditions, policy.Generation, v1alpha1.ConditionTypeValid, false, reasonInvalid, err.Error())
+	} else {
+		setCondition(&conditions, policy.Generation, v1alpha1.ConditionTypeValid, true, reasonValid, "")
+	}
+	switch {
+	case name != v1alpha1.DefaultNodeNetworkPolicy:
+		setCondition(&conditions, policy.Generation, v1alpha1.ConditionTypeApplied, false, reasonNotDefault,
+			fmt.Sprintf("only the policy named %q is applied", v1alpha1.DefaultNodeNetworkPolicy))
+	case err != nil:
+		setCondition(&conditions, policy.Generation, v1alpha1.ConditionTypeApplied, false, reasonInvalid, "")
+	default:
+		klog.Infof("Applying node networking of %s %s", kindNodeNetwork, name)
+		c.vs.nodeManager.SetNodeNetworkPolicy(nodes)
+		message := "the addresses of existing nodes are updated with their next node status update"
+		if len(policy.Spec.AddressPolicy) == 0 && len(nodes.AddressPolicy) != 0 {
+			message += ", the address policy of the cloud config takes precedence over the subnets and VM networks"
+		}
+		setCondition(&conditions, policy.Generation, v1alpha1.ConditionTypeApplied, true, reasonApplied, message)
+	}
+	return c.updateNodeNetworkPolicyStatus(policy, conditions)
+}
+
+// nodeNetworking replaces the node networking of the cloud config by the set
+// fields of a policy. The address policy of the cloud config is kept unless
+// the policy has its own.
+func nodeNetworking(cfg *ccfg.Nodes, spec *v1alpha1.VSphereNodeNetworkPolicySpec) (*ccfg.Nodes, error) {
+	nodes := *cfg
+	cidrs := []struct {
+		value []string
+		field *string
+	}{
+		{spec.InternalNetworkSubnetCIDRs, &nodes.InternalNetworkSubnetCIDR},
+		{spec.ExternalNetworkSubnetCIDRs, &nodes.ExternalNetworkSubnetCIDR},
+		{spec.ExcludeInternalNetworkSubnetCIDRs, &nodes.ExcludeInternalNetworkSubnetCIDR},
+		{spec.ExcludeExternalNetworkSubnetCIDRs, &nodes.ExcludeExternalNetworkSubnetCIDR},
+	}
+	for _, cidr := range cidrs {
+		if len(cidr.value) == 0 {
+			continue
+		}
+		*cidr.field = strings.Join(cidr.value, ",")
+		if _, err := parseCIDRs(*cidr.field); err != nil {
+			return nil, err
+		}
+	}
+	if spec.InternalVMNetworkName != "" {
+		nodes.InternalVMNetworkName = spec.InternalVMNetworkName
+	}
+	if spec.ExternalVMNetworkName != "" {
+		nodes.ExternalVMNetworkName = spec.ExternalVMNetworkName
+	}
+	if len(spec.AddressPolicy) != 0 {
+		nodes.AddressPolicy = make([]ccfg.AddressRule, 0, len(spec.AddressPolicy))
+		for _, rule := range spec.AddressPolicy {
+			nodes.AddressPolicy = append(nodes.AddressPolicy, ccfg.AddressRule{
+				Type:            rule.Type,
+				CIDRs:           rule.CIDRs,
+				PortGroups:      rule.PortGroups,
+				DeviceKeys:      rule.DeviceKeys,
+				MACPrefixes:     rule.MACPrefixes,
+				Multiple:        rule.Multiple,
+				HostName:        rule.HostName,
+				GuestDNSDomains: rule.GuestDNSDomains,
+			})
+		}
+		if err := nodes.ValidateAddressPolicy(); err != nil {
+			return nil, err
+		}
+	}
+	return &nodes, nil
+}
+
+func setCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status bool, reason, message string) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	}
+	if status {
+		condition.Status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
+
+func (c *configController) updateVCenterStatus(vcenter *v1alpha1.VSphereVCenter, conditions []metav1.Condition) error {
+	if equality.Semantic.DeepEqual(vcenter.Status.Conditions, conditions) {
+		return nil
+	}
+	vcenter = vcenter.DeepCopy()
+	vcenter.Status.Conditions = conditions
+	_, err := c.client.ConfigV1alpha1().VSphereVCenters().UpdateStatus(context.Background(), vcenter, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *configController) updateLoadBalancerClassStatus(class *v1alpha1.VSphereLoadBalancerClass, conditions []metav1.Condition) error {
+	if equality.Semantic.DeepEqual(class.Status.Conditions, conditions) {
+		return nil
+	}
+	class = class.DeepCopy()
+	class.Status.Conditions = conditions
+	_, err := c.client.ConfigV1alpha1().VSphereLoadBalancerClasses().UpdateStatus(context.Background(), class, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *configController) updateNodeNetworkPolicyStatus(policy *v1alpha1.VSphereNodeNetworkPolicy, conditions []metav1.Condition) error {
+	if equality.Semantic.DeepEqual(policy.Status.Conditions, conditions) {
+		return nil
+	}
+	policy = policy.DeepCopy()
+	policy.Status.Conditions = conditions
+	_, err := c.client.ConfigV1alpha1().VSphereNodeNetworkPolicies().UpdateStatus(context.Background(), policy, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/apis/vsphereconfig/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/client/clientset/versioned/fake"
+	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	cm "k8s.io/cloud-provider-vsphere/pkg/common/connectionmanager"
+)
+
+// startConfigController starts the informers of a config controller without
+// its worker, so that tests can sync the objects one by one.
+func startConfigController(t *testing.T, vs *VSphere, objects ...runtime.Object) (*configController, *fake.Clientset) {
+	t.Helper()
+	client := fake.NewSimpleClientset(objects...)
+	c := newConfigController(vs, client)
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	c.factory.Start(stop)
+	c.factory.WaitForCacheSync(stop)
+	return c, client
+}
+
+func assertCondition(t *testing.T, conditions []metav1.Condition, conditionType string, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	if condition == nil {
+		t.Errorf("Expected a %s condition in %v", conditionType, conditions)
+		return
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Errorf("Expected %s condition %s/%s but got %s/%s: %s", conditionType, status, reason,
+			condition.Status, condition.Reason, condition.Message)
+	}
+}
+
+func TestConfigCRDsInstalled(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	if configCRDsInstalled(client) {
+		t.Error("Expected the CRDs not to be installed")
+	}
+	client.Resources = []*metav1.APIResourceList{{GroupVersion: v1alpha1.SchemeGroupVersion.String()}}
+	if !configCRDsInstalled(client) {
+		t.Error("Expected the CRDs to be installed")
+	}
+}
+
+func TestConfigControllerVCenter(t *testing.T) {
+	cfg, cleanup := configFromSim(false)
+	defer cleanup()
+
+	connMgr := cm.NewConnectionManager(cfg, nil, nil)
+	defer connMgr.Logout()
+	cpiCfg := &ccfg.CPIConfig{Config: *cfg}
+	vs := &VSphere{cfg: cpiCfg, connectionManager: connMgr, nodeManager: newNodeManager(cpiCfg, connMgr)}
+
+	port, _ := strconv.Atoi(cfg.Global.VCenterPort)
+	vcenter := func(name string, spec v1alpha1.VSphereVCenterSpec) *v1alpha1.VSphereVCenter {
+		return &v1alpha1.VSphereVCenter{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+	}
+	c, client := startConfigController(t, vs,
+		vcenter("runtime", v1alpha1.VSphereVCenterSpec{Server: cfg.Global.VCenterIP, Port: int32(port)}),
+		vcenter(cfg.Global.VCenterIP, v1alpha1.VSphereVCenterSpec{Server: cfg.Global.VCenterIP}),
+		vcenter("invalid", v1alpha1.VSphereVCenterSpec{Server: cfg.Global.VCenterIP, IPFamily: []string{"ipv5"}}),
+	)
+
+	testCases := []struct {
+		name       string
+		valid      metav1.ConditionStatus
+		reason     string
+		connected  bool
+		registered bool
+	}{
+		{name: "runtime", valid: metav1.ConditionTrue, reason: reasonValid, connected: true, registered: true},
+		{name: cfg.Global.VCenterIP, valid: metav1.ConditionFalse, reason: reasonConflict, registered: true},
+		{name: "invalid", valid: metav1.ConditionFalse, reason: reasonInvalid},
+	}
+	for _, testCase := range testCases {
+		if err := c.sync(configKey{kind: kindVCenter, name: testCase.name}); err != nil {
+			t.Fatalf("%s: sync failed: %v", testCase.name, err)
+		}
+		vc, err := client.ConfigV1alpha1().VSphereVCenters().Get(context.Background(), testCase.name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertCondition(t, vc.Status.Conditions, v1alpha1.ConditionTypeValid, testCase.valid, testCase.reason)
+		if testCase.connected {
+			assertCondition(t, vc.Status.Conditions, v1alpha1.ConditionTypeConnected, metav1.ConditionTrue, reasonConnected)
+		}
+		if _, ok := connMgr.Instances()[testCase.name]; ok != testCase.registered {
+			t.Errorf("%s: expected registered %t but got %t", testCase.name, testCase.registered, ok)
+		}
+	}
+	if vsi := connMgr.Instances()["runtime"]; vsi.Cfg.User != cfg.Global.User || vsi.Cfg.Datacenters != cfg.Global.Datacenters {
+		t.Errorf("Expected the user and datacenters of the global config but got %s/%s", vsi.Cfg.User, vsi.Cfg.Datacenters)
+	}
+
+	// A deleted vCenter is removed, the ones of the cloud config are kept.
+	node := &NodeInfo{tenantRef: "runtime", vcServer: cfg.Global.VCenterIP, UUID: "uuid-1", NodeName: "node-1"}
+	vs.nodeManager.nodeNameMap[node.NodeName] = node
+	vs.nodeManager.nodeUUIDMap[node.UUID] = node
+	vs.nodeManager.AddNodeInfoToVCList(node.vcServer, "dc0", node)
+	for _, name := range []string{"runtime", cfg.Global.VCenterIP} {
+		if err := client.ConfigV1alpha1().VSphereVCenters().Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		vcenters, err := c.vcenters.List(labels.Everything())
+		return len(vcenters) == 1, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"runtime", cfg.Global.VCenterIP} {
+		if err := c.sync(configKey{kind: kindVCenter, name: name}); err != nil {
+			t.Fatalf("%s: sync failed: %v", name, err)
+		}
+	}
+	if _, ok := connMgr.Instances()["runtime"]; ok {
+		t.Error("Expected the deleted vCenter to be removed")
+	}
+	if _, ok := connMgr.Instances()[cfg.Global.VCenterIP]; !ok {
+		t.Error("Expected the vCenter of the cloud config to be kept")
+	}
+	if vs.nodeManager.nodeNameMap[node.NodeName] != nil || vs.nodeManager.nodeUUIDMap[node.UUID] != nil {
+		t.Error("Expected the nodes of the deleted vCenter to be forgotten")
+	}
+	if _, err := vs.nodeManager.FindDatacenterInfoInVCList(node.vcServer, "dc0"); err != ErrVCenterNotFound {
+		t.Errorf("Expected the deleted vCenter to be removed from the vCenter list, got %v", err)
+	}
+}
+
+func TestVCenterConfigPatterns(t *testing.T) {
+	global := &vcfg.Global{
+		IncludeDatacenters: []string{"dc-*"},
+		ExcludeDatacenters: []string{"dc-test"},
+		IncludeFolders:     []string{"k8s"},
+	}
+	testCases := []struct {
+		name        string
+		global      *vcfg.Global
+		spec        v1alpha1.VSphereVCenterSpec
+		datacenters string
+		include     []string
+		folders     []string
+		err         bool
+	}{
+		{
+			name:    "inherited",
+			global:  global,
+			include: []string{"dc-*"},
+			folders: []string{"k8s"},
+		},
+		{
+			name:    "overridden",
+			global:  global,
+			spec:    v1alpha1.VSphereVCenterSpec{IncludeDatacenters: []string{"prod-*"}, IncludeFolders: []string{"prod"}},
+			include: []string{"prod-*"},
+			folders: []string{"prod"},
+		},
+		{
+			name:        "datacenters override the global patterns",
+			global:      global,
+			spec:        v1alpha1.VSphereVCenterSpec{Datacenters: []string{"dc0"}},
+			datacenters: "dc0",
+			folders:     []string{"k8s"},
+		},
+		{
+			name:    "patterns override the global datacenters",
+			global:  &vcfg.Global{Datacenters: "dc0"},
+			spec:    v1alpha1.VSphereVCenterSpec{IncludeDatacenters: []string{"dc-*"}},
+			include: []string{"dc-*"},
+		},
+		{
+			name:   "conflict",
+			global: global,
+			spec:   v1alpha1.VSphereVCenterSpec{Datacenters: []string{"dc0"}, ExcludeDatacenters: []string{"dc1"}},
+			err:    true,
+		},
+		{
+			name:   "invalid pattern",
+			global: global,
+			spec:   v1alpha1.VSphereVCenterSpec{IncludeFolders: []string{"re:("}},
+			err:    true,
+		},
+	}
+	for _, testCase := range testCases {
+		testCase.spec.Server = "vc.example.com"
+		vcConfig, err := vcenterConfig(testCase.global, &v1alpha1.VSphereVCenter{ObjectMeta: metav1.ObjectMeta{Name: "vc"}, Spec: testCase.spec})
+		if testCase.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", testCase.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.name, err)
+		}
+		if vcConfig.Datacenters != testCase.datacenters || !reflect.DeepEqual(vcConfig.IncludeDatacenters, testCase.include) ||
+			!reflect.DeepEqual(vcConfig.IncludeFolders, testCase.folders) {
+			t.Errorf("%s: unexpected datacenters %q, datacenter patterns %v and folder patterns %v",
+				testCase.name, vcConfig.Datacenters, vcConfig.IncludeDatacenters, vcConfig.IncludeFolders)
+		}
+	}
+}
+
+func TestConfigControllerNodeNetworkPolicy(t *testing.T) {
+	cpiCfg := &ccfg.CPIConfig{Nodes: ccfg.Nodes{
+		ExternalNetworkSubnetCIDR: "192.0.2.0/24",
+		AddressPolicy:             []ccfg.AddressRule{{Type: "InternalIP", CIDRs: []string{"172.16.0.0/12"}}},
+	}}
+	vs := &VSphere{cfg: cpiCfg, nodeManager: newNodeManager(cpiCfg, nil)}
+
+	c, client := startConfigController(t, vs,
+		&v1alpha1.VSphereNodeNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.DefaultNodeNetworkPolicy},
+			Spec: v1alpha1.VSphereNodeNetworkPolicySpec{
+				InternalNetworkSubnetCIDRs: []string{"10.0.0.0/8", "fd00::/8"},
+				InternalVMNetworkName:      "internal",
+			},
+		},
+		&v1alpha1.VSphereNodeNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec:       v1alpha1.VSphereNodeNetworkPolicySpec{ExternalNetworkSubnetCIDRs: []string{"invalid"}},
+		},
+	)
+
+	for _, name := range []string{v1alpha1.DefaultNodeNetworkPolicy, "other"} {
+		if err := c.sync(configKey{kind: kindNodeNetwork, name: name}); err != nil {
+			t.Fatalf("%s: sync failed: %v", name, err)
+		}
+	}
+	policy, _ := client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Get(context.Background(), v1alpha1.DefaultNodeNetworkPolicy, metav1.GetOptions{})
+	assertCondition(t, policy.Status.Conditions, v1alpha1.ConditionTypeApplied, metav1.ConditionTrue, reasonApplied)
+	policy, _ = client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Get(context.Background(), "other", metav1.GetOptions{})
+	assertCondition(t, policy.Status.Conditions, v1alpha1.ConditionTypeValid, metav1.ConditionFalse, reasonInvalid)
+	assertCondition(t, policy.Status.Conditions, v1alpha1.ConditionTypeApplied, metav1.ConditionFalse, reasonNotDefault)
+
+	nodes := vs.nodeManager.nodes()
+	if nodes.InternalNetworkSubnetCIDR != "10.0.0.0/8,fd00::/8" || nodes.InternalVMNetworkName != "internal" {
+		t.Errorf("Expected the internal networking of the policy but got %s/%s", nodes.InternalNetworkSubnetCIDR, nodes.InternalVMNetworkName)
+	}
+	if nodes.ExternalNetworkSubnetCIDR != "192.0.2.0/24" || !reflect.DeepEqual(nodes.AddressPolicy, cpiCfg.Nodes.AddressPolicy) {
+		t.Errorf("Expected the external networking and address policy of the cloud config but got %s/%v", nodes.ExternalNetworkSubnetCIDR, nodes.AddressPolicy)
+	}
+	policy, _ = client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Get(context.Background(), v1alpha1.DefaultNodeNetworkPolicy, metav1.GetOptions{})
+	if applied := meta.FindStatusCondition(policy.Status.Conditions, v1alpha1.ConditionTypeApplied); applied == nil ||
+		!strings.Contains(applied.Message, "address policy of the cloud config takes precedence") {
+		t.Errorf("Expected the Applied condition to report the address policy of the cloud config but got %v", applied)
+	}
+
+	// An address policy of the policy replaces the one of the cloud config.
+	for _, rule := range []struct {
+		rule   v1alpha1.AddressRule
+		valid  metav1.ConditionStatus
+		reason string
+	}{
+		{v1alpha1.AddressRule{Type: "InternalIP", HostName: true}, metav1.ConditionFalse, reasonInvalid},
+		{v1alpha1.AddressRule{Type: "InternalIP", PortGroups: []string{"internal"}}, metav1.ConditionTrue, reasonValid},
+	} {
+		policy.Spec.AddressPolicy = []v1alpha1.AddressRule{rule.rule}
+		policy.Generation++
+		if _, err := client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Update(context.Background(), policy, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			cached, err := c.nodePolicies.Get(v1alpha1.DefaultNodeNetworkPolicy)
+			return err == nil && cached.Generation == policy.Generation, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.sync(configKey{kind: kindNodeNetwork, name: v1alpha1.DefaultNodeNetworkPolicy}); err != nil {
+			t.Fatal(err)
+		}
+		policy, _ = client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Get(context.Background(), v1alpha1.DefaultNodeNetworkPolicy, metav1.GetOptions{})
+		assertCondition(t, policy.Status.Conditions, v1alpha1.ConditionTypeValid, rule.valid, rule.reason)
+	}
+	assertCondition(t, policy.Status.Conditions, v1alpha1.ConditionTypeApplied, metav1.ConditionTrue, reasonApplied)
+	if nodes := vs.nodeManager.nodes(); len(nodes.AddressPolicy) != 1 || nodes.AddressPolicy[0].PortGroups[0] != "internal" {
+		t.Errorf("Expected the address policy of the policy but got %v", nodes.AddressPolicy)
+	}
+
+	// Deleting the default policy restores the cloud config.
+	if err := client.ConfigV1alpha1().VSphereNodeNetworkPolicies().Delete(context.Background(), v1alpha1.DefaultNodeNetworkPolicy, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := c.nodePolicies.Get(v1alpha1.DefaultNodeNetworkPolicy)
+		return err != nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.sync(configKey{kind: kindNodeNetwork, name: v1alpha1.DefaultNodeNetworkPolicy}); err != nil {
+		t.Fatal(err)
+	}
+	if nodes := vs.nodeManager.nodes(); nodes != &cpiCfg.Nodes {
+		t.Errorf("Expected the node networking of the cloud config but got %v", nodes)
+	}
+}
+
+func TestConfigControllerLoadBalancerClassDisabled(t *testing.T) {
+	vs := &VSphere{cfg: &ccfg.CPIConfig{}}
+	c, client := startConfigController(t, vs, &v1alpha1.VSphereLoadBalancerClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "public"},
+		Spec:       v1alpha1.VSphereLoadBalancerClassSpec{IPPoolName: "public-pool"},
+	})
+
+	if err := c.sync(configKey{kind: kindLoadBalancerClass, name: "public"}); err != nil {
+		t.Fatal(err)
+	}
+	class, _ := client.ConfigV1alpha1().VSphereLoadBalancerClasses().Get(context.Background(), "public", metav1.GetOptions{})
+	assertCondition(t, class.Status.Conditions, v1alpha1.ConditionTypeApplied, metav1.ConditionFalse, reasonLoadBalancerDisabled)
+}
@@ -0,0 +1,438 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	klog "k8s.io/klog/v2"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// guestinfo keys of the cloud-init VMware datasource and of Ignition
+const (
+	guestInfoMetadataKey                 = "guestinfo.metadata"
+	guestInfoMetadataEncodingKey         = "guestinfo.metadata.encoding"
+	guestInfoIgnitionConfigKey           = "guestinfo.ignition.config.data"
+	guestInfoIgnitionConfigEncodingKey   = "guestinfo.ignition.config.data.encoding"
+	cloudInitNetworkConfigEncodingSuffix = ".encoding"
+)
+
+// netplanDeviceTypes are the netplan v2 device types holding addresses, in the
+// order they are configured
+var netplanDeviceTypes = []string{"ethernets", "bonds", "vlans", "bridges"}
+
+// staticAddress is an IP address statically configured for a guest interface
+type staticAddress struct {
+	// ip is the normalized IP address without prefix length
+	ip string
+	// iface is the name of the interface in the network config
+	iface string
+	// macAddress is the MAC address the interface is matched by, if known
+	macAddress string
+}
+
+// matches returns true if the candidate has the address, and is on the NIC with
+// the MAC address of the interface if both MAC addresses are known
+func (s *staticAddress) matches(candidate *ipAddrNetworkName) bool {
+	ip := net.ParseIP(candidate.ipAddr)
+	if ip == nil || ip.String() != s.ip {
+		return false
+	}
+	return s.macAddress == "" || candidate.macAddress == "" || strings.EqualFold(s.macAddress, candidate.macAddress)
+}
+
+// guestInfoStaticAddresses returns the statically configured addresses of the
+// network config in the cloud-init metadata and of the Ignition config in
+// guestinfo, in the order they are configured.
+func guestInfoStaticAddresses(extraConfig []types.BaseOptionValue) ([]staticAddress, error) {
+	values := make(map[string]string)
+	for _, option := range extraConfig {
+		value := option.GetOptionValue()
+		if s, ok := value.Value.(string); ok {
+			values[value.Key] = s
+		}
+	}
+
+	var addresses []staticAddress
+	if metadata := values[guestInfoMetadataKey]; metadata != "" {
+		data, err := decodeGuestInfo(metadata, values[guestInfoMetadataEncodingKey])
+		if err != nil {
+			return nil, err
+		}
+		metadataAddresses, err := cloudInitMetadataAddresses(data)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, metadataAddresses...)
+	}
+	if ignition := values[guestInfoIgnitionConfigKey]; ignition != "" {
+		data, err := decodeGuestInfo(ignition, values[guestInfoIgnitionConfigEncodingKey])
+		if err != nil {
+			return nil, err
+		}
+		ignitionAddresses, err := ignitionAddresses(data)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, ignitionAddresses...)
+	}
+	return addresses, nil
+}
+
+// decodeGuestInfo decodes a guestinfo value with the encodings supported by
+// cloud-init and Ignition
+func decodeGuestInfo(value, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "":
+		return []byte(value), nil
+	case "base64", "b64":
+		return base64.StdEncoding.DecodeString(value)
+	case "gzip+base64", "gz+b64":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		return gunzip(data)
+	default:
+		return nil, fmt.Errorf("unsupported guestinfo encoding %q", encoding)
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// cloudInitMetadataAddresses returns the static addresses of the network config
+// in the cloud-init metadata. The network config may be encoded itself.
+func cloudInitMetadataAddresses(data []byte) ([]staticAddress, error) {
+	metadata := yaml.MapSlice{}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	network, ok := mapSliceValue(metadata, "network")
+	if !ok {
+		return nil, nil
+	}
+	if encoded, ok := network.(string); ok {
+		encoding, _ := mapSliceValue(metadata, "network"+cloudInitNetworkConfigEncodingSuffix)
+		encodingString, _ := encoding.(string)
+		decoded, err := decodeGuestInfo(encoded, encodingString)
+		if err != nil {
+			return nil, err
+		}
+		config := yaml.MapSlice{}
+		if err := yaml.Unmarshal(decoded, &config); err != nil {
+			return nil, err
+		}
+		network = config
+	}
+	return networkConfigAddresses(network)
+}
+
+// networkConfigAddresses returns the static addresses of a cloud-init network
+// config version 1 or version 2 (netplan), optionally nested in a network key
+func networkConfigAddresses(network interface{}) ([]staticAddress, error) {
+	config, ok := network.(yaml.MapSlice)
+	if !ok {
+		return nil, nil
+	}
+	if nested, ok := mapSliceValue(config, "network"); ok {
+		return networkConfigAddresses(nested)
+	}
+	version, _ := mapSliceValue(config, "version")
+	if fmt.Sprint(version) == "1" {
+		return networkConfigV1Addresses(config)
+	}
+	return netplanAddresses(config)
+}
+
+type netplanDevice struct {
+	Addresses []interface{} `yaml:"addresses"`
+	Match     struct {
+		MACAddress string `yaml:"macaddress"`
+	} `yaml:"match"`
+	SetName    string `yaml:"set-name"`
+	MACAddress string `yaml:"macaddress"`
+	Link       string `yaml:"link"`
+}
+
+// netplanAddresses returns the static addresses of the ethernets, bonds, vlans
+// and bridges of a netplan config. VLANs inherit the MAC address of their link.
+func netplanAddresses(config yaml.MapSlice) ([]staticAddress, error) {
+	var addresses []staticAddress
+	macAddresses := make(map[string]string)
+	for _, deviceType := range netplanDeviceTypes {
+		devices, _ := mapSliceValue(config, deviceType)
+		deviceSlice, _ := devices.(yaml.MapSlice)
+		for _, item := range deviceSlice {
+			id := fmt.Sprint(item.Key)
+			device := &netplanDevice{}
+			if err := remarshalYAML(item.Value, device); err != nil {
+				return nil, fmt.Errorf("invalid netplan %s %s: %v", deviceType, id, err)
+			}
+			name := id
+			if device.SetName != "" {
+				name = device.SetName
+			}
+			macAddress := device.Match.MACAddress
+			if macAddress == "" {
+				macAddress = device.MACAddress
+			}
+			if macAddress == "" && device.Link != "" {
+				macAddress = macAddresses[device.Link]
+			}
+			macAddresses[id] = macAddress
+			for _, address := range device.Addresses {
+				// addresses are either strings or maps of an address to its options
+				if options, ok := address.(map[interface{}]interface{}); ok && len(options) == 1 {
+					for key := range options {
+						address = key
+					}
+				}
+				if ip := parseStaticIP(fmt.Sprint(address)); ip != "" {
+					addresses = append(addresses, staticAddress{ip: ip, iface: name, macAddress: macAddress})
+				}
+			}
+		}
+	}
+	return addresses, nil
+}
+
+type networkConfigV1 struct {
+	Config []struct {
+		Type       string `yaml:"type"`
+		Name       string `yaml:"name"`
+		MACAddress string `yaml:"mac_address"`
+		VlanLink   string `yaml:"vlan_link"`
+		Subnets    []struct {
+			Type    string `yaml:"type"`
+			Address string `yaml:"address"`
+		} `yaml:"subnets"`
+	} `yaml:"config"`
+}
+
+// networkConfigV1Addresses returns the static addresses of the physical, bond,
+// vlan and bridge interfaces of a cloud-init network config version 1
+func networkConfigV1Addresses(config yaml.MapSlice) ([]staticAddress, error) {
+	v1Config := &networkConfigV1{}
+	if err := remarshalYAML(config, v1Config); err != nil {
+		return nil, fmt.Errorf("invalid network config version 1: %v", err)
+	}
+	var addresses []staticAddress
+	macAddresses := make(map[string]string)
+	for _, iface := range v1Config.Config {
+		macAddress := iface.MACAddress
+		if macAddress == "" && iface.VlanLink != "" {
+			macAddress = macAddresses[iface.VlanLink]
+		}
+		macAddresses[iface.Name] = macAddress
+		for _, subnet := range iface.Subnets {
+			if !strings.HasPrefix(subnet.Type, "static") {
+				continue
+			}
+			if ip := parseStaticIP(subnet.Address); ip != "" {
+				addresses = append(addresses, staticAddress{ip: ip, iface: iface.Name, macAddress: macAddress})
+			}
+		}
+	}
+	return addresses, nil
+}
+
+type ignitionConfig struct {
+	Storage struct {
+		Files []struct {
+			Path     string `json:"path"`
+			Contents struct {
+				Source      string `json:"source"`
+				Compression string `json:"compression"`
+			} `json:"contents"`
+		} `json:"files"`
+	} `json:"storage"`
+}
+
+// ignitionAddresses returns the static addresses of the systemd-networkd and
+// NetworkManager files written by an Ignition config
+func ignitionAddresses(data []byte) ([]staticAddress, error) {
+	config := &ignitionConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid ignition config: %v", err)
+	}
+	var addresses []staticAddress
+	for _, file := range config.Storage.Files {
+		var parse func([]byte) []staticAddress
+		switch {
+		case strings.HasPrefix(file.Path, "/etc/systemd/network/") && path.Ext(file.Path) == ".network":
+			parse = networkdAddresses
+		case strings.HasPrefix(file.Path, "/etc/NetworkManager/system-connections/"):
+			parse = networkManagerAddresses
+		default:
+			continue
+		}
+		contents, err := decodeDataURL(file.Contents.Source)
+		if err != nil {
+			klog.Warningf("Skipping ignition file %s: %v", file.Path, err)
+			continue
+		}
+		if file.Contents.Compression == "gzip" {
+			if contents, err = gunzip(contents); err != nil {
+				klog.Warningf("Skipping ignition file %s: %v", file.Path, err)
+				continue
+			}
+		}
+		addresses = append(addresses, parse(contents)...)
+	}
+	return addresses, nil
+}
+
+// decodeDataURL returns the data of a data URL, other URLs are not fetched
+func decodeDataURL(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "data:") {
+		return nil, fmt.Errorf("unsupported source %q", source)
+	}
+	header, data, found := strings.Cut(strings.TrimPrefix(source, "data:"), ",")
+	if !found {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// networkdAddresses returns the addresses of a systemd-networkd .network file
+func networkdAddresses(contents []byte) []staticAddress {
+	var addresses []staticAddress
+	var name, macAddress string
+	for _, entry := range parseINIEntries(contents) {
+		switch {
+		case entry.section == "Match" && entry.key == "Name":
+			name = entry.value
+		case entry.section == "Match" && entry.key == "MACAddress":
+			macAddress = strings.Fields(entry.value + " ")[0]
+		case (entry.section == "Network" || entry.section == "Address") && entry.key == "Address":
+			if ip := parseStaticIP(entry.value); ip != "" {
+				addresses = append(addresses, staticAddress{ip: ip})
+			}
+		}
+	}
+	for i := range addresses {
+		addresses[i].iface = name
+		addresses[i].macAddress = macAddress
+	}
+	return addresses
+}
+
+// networkManagerAddresses returns the addresses of a NetworkManager keyfile
+func networkManagerAddresses(contents []byte) []staticAddress {
+	var addresses []staticAddress
+	var name, macAddress string
+	for _, entry := range parseINIEntries(contents) {
+		switch {
+		case entry.section == "connection" && entry.key == "interface-name":
+			name = entry.value
+		case entry.section == "ethernet" && entry.key == "mac-address":
+			macAddress = entry.value
+		case (entry.section == "ipv4" || entry.section == "ipv6") && strings.HasPrefix(entry.key, "address"):
+			// addressN=ADDRESS[/PREFIX][,GATEWAY]
+			address := strings.Split(entry.value, ",")[0]
+			if ip := parseStaticIP(address); ip != "" {
+				addresses = append(addresses, staticAddress{ip: ip})
+			}
+		}
+	}
+	for i := range addresses {
+		addresses[i].iface = name
+		addresses[i].macAddress = macAddress
+	}
+	return addresses
+}
+
+type iniEntry struct {
+	section string
+	key     string
+	value   string
+}
+
+// parseINIEntries returns the key value entries of an INI style file in order
+func parseINIEntries(contents []byte) []iniEntry {
+	var entries []iniEntry
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+		default:
+			if key, value, found := strings.Cut(line, "="); found {
+				entries = append(entries, iniEntry{section: section, key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+			}
+		}
+	}
+	return entries
+}
+
+// parseStaticIP returns the normalized IP of an address with optional prefix length
+func parseStaticIP(address string) string {
+	ip := net.ParseIP(strings.TrimSpace(strings.Split(address, "/")[0]))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// mapSliceValue returns the value of a key of a YAML map
+func mapSliceValue(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// remarshalYAML converts a generic YAML value into a struct
+func remarshalYAML(in interface{}, out interface{}) error {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"testing"
+
+	vimtypes "github.com/vmware/govmomi/vim25/types"
+)
+
+const netplanBondVlanMetadata = `instance-id: "node1"
+network:
+  version: 2
+  ethernets:
+    id0:
+      match:
+        macaddress: "00:50:56:00:00:01"
+      set-name: eth0
+      addresses:
+        - 10.0.0.10/24
+    id1:
+      match:
+        macaddress: "00:50:56:00:00:02"
+    id2:
+      match:
+        macaddress: "00:50:56:00:00:03"
+  bonds:
+    bond0:
+      interfaces: [id1, id2]
+      addresses:
+        - "192.168.10.10/24":
+            label: bond0-main
+  vlans:
+    vlan100:
+      id: 100
+      link: id0
+      addresses: [172.16.100.10/24, "fd00:100::10/64"]
+  bridges:
+    br0:
+      interfaces: [bond0]
+      addresses: [192.168.20.10/24]
+`
+
+const networkConfigV1Metadata = `network:
+  version: 1
+  config:
+    - type: physical
+      name: eth0
+      mac_address: "00:50:56:00:00:01"
+      subnets:
+        - type: dhcp
+        - type: static
+          address: 10.0.0.10/24
+    - type: vlan
+      name: eth0.200
+      vlan_link: eth0
+      vlan_id: 200
+      subnets:
+        - type: static6
+          address: fd00:200::10/64
+`
+
+const ignitionConfigData = `{
+  "ignition": {"version": "3.3.0"},
+  "storage": {
+    "files": [
+      {
+        "path": "/etc/systemd/network/10-eth0.network",
+        "contents": {"source": "data:,NETWORKD"}
+      },
+      {
+        "path": "/etc/NetworkManager/system-connections/bond0.nmconnection",
+        "contents": {"source": "data:text/plain;base64,NMCONNECTION"}
+      },
+      {
+        "path": "/etc/hostname",
+        "contents": {"source": "data:,node1"}
+      }
+    ]
+  }
+}`
+
+const networkdFile = `[Match]
+Name=eth0
+MACAddress=00:50:56:00:00:01
+
+[Network]
+Address=10.0.0.10/24
+Gateway=10.0.0.1
+
+[Address]
+Address=fd00::10/64
+`
+
+const networkManagerFile = `[connection]
+id=bond0
+type=bond
+interface-name=bond0
+
+[ipv4]
+method=manual
+address1=192.168.10.10/24,192.168.10.1
+`
+
+func gzipBase64(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func extraConfig(values map[string]string) []vimtypes.BaseOptionValue {
+	var options []vimtypes.BaseOptionValue
+	for key, value := range values {
+		options = append(options, &vimtypes.OptionValue{Key: key, Value: value})
+	}
+	return options
+}
+
+func TestDecodeGuestInfo(t *testing.T) {
+	plain := "network: {}"
+	for _, tc := range []struct {
+		encoding string
+		value    string
+	}{
+		{encoding: "", value: plain},
+		{encoding: "base64", value: base64.StdEncoding.EncodeToString([]byte(plain))},
+		{encoding: "b64", value: base64.StdEncoding.EncodeToString([]byte(plain))},
+		{encoding: "gzip+base64", value: gzipBase64(t, plain)},
+		{encoding: "gz+b64", value: gzipBase64(t, plain)},
+	} {
+		data, err := decodeGuestInfo(tc.value, tc.encoding)
+		if err != nil {
+			t.Errorf("failed to decode %q encoding: %v", tc.encoding, err)
+		} else if string(data) != plain {
+			t.Errorf("unexpected %q decoded value %q", tc.encoding, data)
+		}
+	}
+	if _, err := decodeGuestInfo(plain, "rot13"); err == nil {
+		t.Errorf("expected error for unsupported encoding")
+	}
+}
+
+func TestGuestInfoStaticAddressesNetplan(t *testing.T) {
+	addresses, err := guestInfoStaticAddresses(extraConfig(map[string]string{
+		guestInfoMetadataKey:         gzipBase64(t, netplanBondVlanMetadata),
+		guestInfoMetadataEncodingKey: "gzip+base64",
+	}))
+	if err != nil {
+		t.Fatalf("failed to parse guestinfo: %v", err)
+	}
+	expected := []staticAddress{
+		{ip: "10.0.0.10", iface: "eth0", macAddress: "00:50:56:00:00:01"},
+		{ip: "192.168.10.10", iface: "bond0"},
+		{ip: "172.16.100.10", iface: "vlan100", macAddress: "00:50:56:00:00:01"},
+		{ip: "fd00:100::10", iface: "vlan100", macAddress: "00:50:56:00:00:01"},
+		{ip: "192.168.20.10", iface: "br0"},
+	}
+	if !reflect.DeepEqual(expected, addresses) {
+		t.Errorf("expected addresses %v, got %v", expected, addresses)
+	}
+}
+
+func TestGuestInfoStaticAddressesEncodedNetworkConfigV1(t *testing.T) {
+	metadata := "instance-id: node1\nnetwork: " + base64.StdEncoding.EncodeToString([]byte(networkConfigV1Metadata)) +
+		"\nnetwork.encoding: base64\n"
+	addresses, err := guestInfoStaticAddresses(extraConfig(map[string]string{
+		guestInfoMetadataKey: metadata,
+	}))
+	if err != nil {
+		t.Fatalf("failed to parse guestinfo: %v", err)
+	}
+	expected := []staticAddress{
+		{ip: "10.0.0.10", iface: "eth0", macAddress: "00:50:56:00:00:01"},
+		{ip: "fd00:200::10", iface: "eth0.200", macAddress: "00:50:56:00:00:01"},
+	}
+	if !reflect.DeepEqual(expected, addresses) {
+		t.Errorf("expected addresses %v, got %v", expected, addresses)
+	}
+}
+
+func TestGuestInfoStaticAddressesIgnition(t *testing.T) {
+	config := bytes.ReplaceAll([]byte(ignitionConfigData), []byte("NETWORKD"), []byte(url.PathEscape(networkdFile)))
+	config = bytes.ReplaceAll(config, []byte("NMCONNECTION"), []byte(base64.StdEncoding.EncodeToString([]byte(networkManagerFile))))
+	addresses, err := guestInfoStaticAddresses(extraConfig(map[string]string{
+		guestInfoIgnitionConfigKey:         base64.StdEncoding.EncodeToString(config),
+		guestInfoIgnitionConfigEncodingKey: "base64",
+	}))
+	if err != nil {
+		t.Fatalf("failed to parse guestinfo: %v", err)
+	}
+	expected := []staticAddress{
+		{ip: "10.0.0.10", iface: "eth0", macAddress: "00:50:56:00:00:01"},
+		{ip: "fd00::10", iface: "eth0", macAddress: "00:50:56:00:00:01"},
+		{ip: "192.168.10.10", iface: "bond0"},
+	}
+	if !reflect.DeepEqual(expected, addresses) {
+		t.Errorf("expected addresses %v, got %v", expected, addresses)
+	}
+}
+
+func TestGuestInfoStaticAddressesInvalid(t *testing.T) {
+	if _, err := guestInfoStaticAddresses(extraConfig(map[string]string{
+		guestInfoIgnitionConfigKey: "{not json",
+	})); err == nil {
+		t.Errorf("expected error for invalid ignition config")
+	}
+	if _, err := guestInfoStaticAddresses(extraConfig(map[string]string{
+		guestInfoMetadataKey:         "not base64",
+		guestInfoMetadataEncodingKey: "base64",
+	})); err == nil {
+		t.Errorf("expected error for invalid metadata encoding")
+	}
+}
+
+func TestSortStaticallyConfiguredAddressesFirstByMACAddress(t *testing.T) {
+	// the VLAN address is configured for the interface with MAC 00:50:56:00:00:01 only
+	candidates := []*ipAddrNetworkName{
+		{ipAddr: "10.0.0.10", macAddress: "00:50:56:00:00:09"},
+		{ipAddr: "192.168.30.10", macAddress: "00:50:56:00:00:02"},
+		{ipAddr: "172.16.100.10", macAddress: "00:50:56:00:00:01"},
+		{ipAddr: "192.168.10.10", macAddress: "00:50:56:00:00:02"},
+	}
+	sorted, err := sortStaticallyConfiguredAddressesFirst(extraConfig(map[string]string{
+		guestInfoMetadataKey: netplanBondVlanMetadata,
+	}), candidates)
+	if err != nil {
+		t.Fatalf("failed to sort addresses: %v", err)
+	}
+	var ips []string
+	for _, candidate := range sorted {
+		ips = append(ips, candidate.ipAddr)
+	}
+	expected := []string{"192.168.10.10", "172.16.100.10", "10.0.0.10", "192.168.30.10"}
+	if !reflect.DeepEqual(expected, ips) {
+		t.Errorf("expected order %v, got %v", expected, ips)
+	}
+}
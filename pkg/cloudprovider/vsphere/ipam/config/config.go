@@ -0,0 +1,90 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+	klog "k8s.io/klog/v2"
+)
+
+/*
+	TODO:
+	When the INI based cloud-config is deprecated, the references to the
+	INI based code (ie the call to ReadConfigINI) below should be deleted.
+*/
+
+// ReadIPAMConfig parses vSphere cloud config file and stores it into Config.
+// It returns ErrIPBlockPathRequired if no IP block is configured.
+func ReadIPAMConfig(configData []byte) (*Config, error) {
+	if len(configData) == 0 {
+		return nil, fmt.Errorf("Invalid YAML/INI file")
+	}
+
+	cfg, err := ReadConfigYAML(configData)
+	if err != nil {
+		var iniErr error
+		cfg, iniErr = ReadConfigINI(configData)
+		if iniErr != nil {
+			// a valid YAML file failed the validation, its error is more helpful
+			if yaml.Unmarshal(configData, &IPAMConfigYAML{}) == nil {
+				return nil, err
+			}
+			return nil, iniErr
+		}
+
+		klog.Info("ReadConfig INI succeeded. IPAM INI-based cloud-config is deprecated and will be removed in 2.0. Please use YAML based cloud-config.")
+	} else {
+		klog.Info("ReadIPAMConfig YAML succeeded")
+	}
+
+	klog.Info("IPAM Config initialized")
+	return cfg, nil
+}
+
+// complete sets the default IP pool ID and prefix lengths
+func (ic *IPAMConfig) complete() {
+	if ic.IPPoolID == "" {
+		ic.IPPoolID = DefaultIPPoolID
+	}
+	if ic.IPBlockPath != "" && ic.PrefixLength == 0 {
+		ic.PrefixLength = DefaultPrefixLength
+	}
+	if ic.IPv6IPBlockPath != "" && ic.IPv6PrefixLength == 0 {
+		ic.IPv6PrefixLength = DefaultIPv6PrefixLength
+	}
+}
+
+func (ic *IPAMConfig) validate() error {
+	if ic.IPBlockPath == "" && ic.IPv6IPBlockPath == "" {
+		return ErrIPBlockPathRequired
+	}
+	for _, path := range []string{ic.IPBlockPath, ic.IPv6IPBlockPath} {
+		if path != "" && !strings.HasPrefix(path, IPBlockPathPrefix) {
+			return fmt.Errorf("invalid IP block path %q: expected IP block policy path", path)
+		}
+	}
+	if ic.IPBlockPath != "" && (ic.PrefixLength < 1 || ic.PrefixLength > 32) {
+		return fmt.Errorf("invalid prefix length %d: expected value between 1 and 32", ic.PrefixLength)
+	}
+	if ic.IPv6IPBlockPath != "" && (ic.IPv6PrefixLength < 1 || ic.IPv6PrefixLength > 128) {
+		return fmt.Errorf("invalid IPv6 prefix length %d: expected value between 1 and 128", ic.IPv6PrefixLength)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+)
+
+/*
+	TODO:
+	When the INI based cloud-config is deprecated. This file should be deleted.
+*/
+
+func TestReadINIConfig(t *testing.T) {
+	contents := `
+[IPAM]
+ip-pool-id = pool
+ip-block-path = /infra/ip-blocks/pods
+prefix-length = 26
+`
+	config, err := ReadIPAMConfig([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IPAM.IPPoolID != "pool" || config.IPAM.IPBlockPath != "/infra/ip-blocks/pods" || config.IPAM.PrefixLength != 26 {
+		t.Errorf("unexpected config %+v", config.IPAM)
+	}
+	if config.IPAM.IPv6IPBlockPath != "" || config.IPAM.IPv6PrefixLength != 0 {
+		t.Errorf("unexpected IPv6 config %+v", config.IPAM)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+)
+
+/*
+	TODO:
+	When the INI based cloud-config is deprecated. This file should be deleted.
+*/
+
+func TestReadYAMLConfig(t *testing.T) {
+	contents := `
+ipam:
+  ipBlockPath: /infra/ip-blocks/pods
+  ipv6IPBlockPath: /infra/ip-blocks/pods-v6
+  ipv6PrefixLength: 80
+`
+	config, err := ReadConfigYAML([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IPAM.IPPoolID != DefaultIPPoolID || config.IPAM.IPBlockPath != "/infra/ip-blocks/pods" || config.IPAM.PrefixLength != DefaultPrefixLength {
+		t.Errorf("unexpected IPv4 config %+v", config.IPAM)
+	}
+	if config.IPAM.IPv6IPBlockPath != "/infra/ip-blocks/pods-v6" || config.IPAM.IPv6PrefixLength != 80 {
+		t.Errorf("unexpected IPv6 config %+v", config.IPAM)
+	}
+
+	for _, invalid := range []string{
+		"ipam:\n  ipPoolID: pool\n",
+		"ipam:\n  ipBlockPath: /infra/ip-pools/pods\n",
+		"ipam:\n  ipBlockPath: /infra/ip-blocks/pods\n  prefixLength: 33\n",
+		"ipam:\n  ipv6IPBlockPath: /infra/ip-blocks/pods\n  ipv6PrefixLength: 129\n",
+	} {
+		if _, err := ReadConfigYAML([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestReadIPAMConfig(t *testing.T) {
+	// a cloud config without IPAM section disables node IPAM
+	if _, err := ReadIPAMConfig([]byte("global:\n  server: vc\n")); err != ErrIPBlockPathRequired {
+		t.Errorf("expected ErrIPBlockPathRequired, got %v", err)
+	}
+	_, err := ReadIPAMConfig([]byte("ipam:\n  ipBlockPath: /infra/ip-blocks/pods\n  prefixLength: 33\n"))
+	if err == nil || err.Error() != "invalid prefix length 33: expected value between 1 and 32" {
+		t.Errorf("expected the validation error of the YAML config, got %v", err)
+	}
+	if _, err := ReadIPAMConfig([]byte("[IPAM]\nip-block-path = /infra/ip-blocks/pods\n")); err != nil {
+		t.Errorf("unexpected error for INI config: %v", err)
+	}
+}
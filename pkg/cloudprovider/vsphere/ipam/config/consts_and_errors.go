@@ -0,0 +1,40 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import "errors"
+
+const (
+	// ClusterNameTagScope is the scope of the clusterName tag of the node subnets
+	ClusterNameTagScope = "vsphere.k8s.io/cluster-name"
+	// NodeNameTagScope is the scope of the nodeName tag of the node subnets
+	NodeNameTagScope = "vsphere.k8s.io/node-name"
+
+	// IPBlockPathPrefix is the policy path prefix of IP blocks
+	IPBlockPathPrefix = "/infra/ip-blocks/"
+	// DefaultIPPoolID is the ID of the IP pool holding the node subnets if none is configured
+	DefaultIPPoolID = "kubernetes-pod-subnets"
+	// DefaultPrefixLength is the default prefix length of the IPv4 node subnets
+	DefaultPrefixLength = 24
+	// DefaultIPv6PrefixLength is the default prefix length of the IPv6 node subnets
+	DefaultIPv6PrefixLength = 64
+)
+
+var (
+	// ErrIPBlockPathRequired is returned if no IP block is configured, node IPAM is disabled then
+	ErrIPBlockPathRequired = errors.New("IP block path is required")
+)
@@ -0,0 +1,36 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+// Config is used to read and store information from the cloud configuration file
+type Config struct {
+	IPAM IPAMConfig
+}
+
+// IPAMConfig contains the configuration of the node IPAM
+type IPAMConfig struct {
+	// IPPoolID is the ID of the NSX-T IP pool holding the node subnets, it is created if it does not exist
+	IPPoolID string
+	// IPBlockPath is the policy path of the IP block the IPv4 node subnets are allocated from
+	IPBlockPath string
+	// PrefixLength is the prefix length of the IPv4 node subnets
+	PrefixLength int
+	// IPv6IPBlockPath is the policy path of the IP block the IPv6 node subnets are allocated from
+	IPv6IPBlockPath string
+	// IPv6PrefixLength is the prefix length of the IPv6 node subnets
+	IPv6PrefixLength int
+}
@@ -0,0 +1,31 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+// IPAMConfigINI is used to read and store information from the cloud configuration file
+type IPAMConfigINI struct {
+	IPAM IPAMINI `gcfg:"ipam"`
+}
+
+// IPAMINI contains the configuration for the node IPAM
+type IPAMINI struct {
+	IPPoolID         string `gcfg:"ip-pool-id"`
+	IPBlockPath      string `gcfg:"ip-block-path"`
+	PrefixLength     int    `gcfg:"prefix-length"`
+	IPv6IPBlockPath  string `gcfg:"ipv6-ip-block-path"`
+	IPv6PrefixLength int    `gcfg:"ipv6-prefix-length"`
+}
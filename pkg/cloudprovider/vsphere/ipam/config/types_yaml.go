@@ -0,0 +1,39 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+/*
+	TODO:
+	When the INI based cloud-config is deprecated, this file should be renamed
+	from types_yaml.go to types.go and the structs within this file should be named:
+
+	IPAMConfigYAML -> IPAMConfig
+*/
+
+// IPAMConfigYAML is used to read and store information from the cloud configuration file
+type IPAMConfigYAML struct {
+	IPAM IPAMYAML `yaml:"ipam"`
+}
+
+// IPAMYAML contains the configuration for the node IPAM
+type IPAMYAML struct {
+	IPPoolID         string `yaml:"ipPoolID"`
+	IPBlockPath      string `yaml:"ipBlockPath"`
+	PrefixLength     int    `yaml:"prefixLength"`
+	IPv6IPBlockPath  string `yaml:"ipv6IPBlockPath"`
+	IPv6PrefixLength int    `yaml:"ipv6PrefixLength"`
+}
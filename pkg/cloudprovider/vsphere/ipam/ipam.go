@@ -0,0 +1,404 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/protocol/client"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam/config"
+	klog "k8s.io/klog/v2"
+)
+
+// ClusterName contains the cluster-name flag injected from main, used to tag the node subnets
+var ClusterName string
+
+const (
+	controllerName = "vsphere-node-ipam"
+
+	ipFamilyIPv4 = "ipv4"
+	ipFamilyIPv6 = "ipv6"
+
+	// gcPeriod is the interval of releasing the subnets of nodes deleted while the controller was down
+	gcPeriod = 10 * time.Minute
+	// realizedCIDRAttribute is the extended attribute of a realized IP block subnet holding its CIDR
+	realizedCIDRAttribute = "cidr"
+	// realizedState and errorState are the realized states of IP block subnets
+	realizedState = "REALIZED"
+	errorState    = "ERROR"
+
+	// reasons used for node events
+	reasonCIDRAssigned         = "CIDRAssigned"
+	reasonCIDRAssignmentFailed = "CIDRAssignmentFailed"
+)
+
+// errSubnetNotRealized is returned while NSX-T has not carved out the subnet of a node yet
+var errSubnetNotRealized = errors.New("subnet not realized yet")
+
+// NodeIPAM allocates the pod CIDRs of the nodes from NSX-T IP blocks. Each node gets
+// a subnet of the configured size from the IP block of each IP family, which is released
+// when the node is deleted. The node IPAM of kube-controller-manager must be disabled
+// (--allocate-node-cidrs=false), since node.Spec.PodCIDRs can only be set once.
+type NodeIPAM interface {
+	AddNode(*v1.Node)
+	DeleteNode(*v1.Node)
+	// Initialize starts the allocation workers using the given client
+	Initialize(client clientset.Interface, stop <-chan struct{})
+}
+
+// subnetFamily is the IP block and prefix length of the node subnets of an IP family
+type subnetFamily struct {
+	ipFamily     string
+	ipBlockPath  string
+	prefixLength int
+}
+
+type nodeIPAM struct {
+	broker      NsxtBroker
+	ipPoolID    string
+	families    []subnetFamily
+	clusterName string
+
+	client   clientset.Interface
+	recorder record.EventRecorder
+	queue    workqueue.RateLimitingInterface
+
+	ipPoolLock    sync.Mutex
+	ipPoolCreated bool
+}
+
+var _ NodeIPAM = &nodeIPAM{}
+
+// NewNodeIPAM creates a new NodeIPAM, it returns nil if no IP block is configured
+func NewNodeIPAM(cfg *config.Config, connector client.Connector) (NodeIPAM, error) {
+	if cfg == nil || (cfg.IPAM.IPBlockPath == "" && cfg.IPAM.IPv6IPBlockPath == "") {
+		return nil, nil
+	}
+	nsxtbroker, err := NewNsxtBroker(connector)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating nsxt broker failed")
+	}
+	return newNodeIPAM(&cfg.IPAM, nsxtbroker), nil
+}
+
+func newNodeIPAM(cfg *config.IPAMConfig, broker NsxtBroker) *nodeIPAM {
+	var families []subnetFamily
+	if cfg.IPBlockPath != "" {
+		families = append(families, subnetFamily{ipFamily: ipFamilyIPv4, ipBlockPath: cfg.IPBlockPath, prefixLength: cfg.PrefixLength})
+	}
+	if cfg.IPv6IPBlockPath != "" {
+		families = append(families, subnetFamily{ipFamily: ipFamilyIPv6, ipBlockPath: cfg.IPv6IPBlockPath, prefixLength: cfg.IPv6PrefixLength})
+	}
+	return &nodeIPAM{
+		broker:      broker,
+		ipPoolID:    cfg.IPPoolID,
+		families:    families,
+		clusterName: ClusterName,
+		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "NodeIPAM"),
+	}
+}
+
+// Initialize starts the allocation workers and the periodic release of the subnets of deleted nodes
+func (p *nodeIPAM) Initialize(client clientset.Interface, stop <-chan struct{}) {
+	if client == nil {
+		klog.Errorf("node IPAM requires a kubernetes client")
+		return
+	}
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	p.client = client
+	p.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: controllerName})
+
+	go wait.Until(p.runWorker, time.Second, stop)
+	go wait.Until(func() {
+		if err := p.releaseDeletedNodeSubnets(); err != nil {
+			utilruntime.HandleError(fmt.Errorf("releasing subnets of deleted nodes failed: %v", err))
+		}
+	}, gcPeriod, stop)
+	go func() {
+		<-stop
+		p.queue.ShutDown()
+	}()
+}
+
+// AddNode queues the allocation of the pod CIDRs of a node without pod CIDRs
+func (p *nodeIPAM) AddNode(node *v1.Node) {
+	if node.Spec.PodCIDR == "" || len(node.Spec.PodCIDRs) == 0 {
+		p.queue.Add(node.Name)
+	}
+}
+
+// DeleteNode queues the release of the subnets of a node
+func (p *nodeIPAM) DeleteNode(node *v1.Node) {
+	p.queue.Add(node.Name)
+}
+
+func (p *nodeIPAM) runWorker() {
+	for p.processNextWorkItem() {
+	}
+}
+
+func (p *nodeIPAM) processNextWorkItem() bool {
+	key, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(key)
+
+	nodeName := key.(string)
+	if err := p.syncNode(nodeName); err != nil {
+		if err != errSubnetNotRealized {
+			utilruntime.HandleError(fmt.Errorf("error syncing node IPAM of %s: %v, requeuing", nodeName, err))
+		}
+		p.queue.AddRateLimited(key)
+		return true
+	}
+	p.queue.Forget(key)
+	return true
+}
+
+// syncNode allocates the pod CIDRs of an existing node or releases the subnets of a deleted node
+func (p *nodeIPAM) syncNode(nodeName string) error {
+	ctx := context.Background()
+	node, err := p.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return p.releaseSubnets(nodeName)
+	}
+	if err != nil {
+		return err
+	}
+	if node.Spec.PodCIDR != "" && len(node.Spec.PodCIDRs) != 0 {
+		return nil
+	}
+
+	if err := p.ensureIPPool(); err != nil {
+		return err
+	}
+	var cidrs []string
+	for _, family := range p.families {
+		cidr, err := p.allocateSubnet(nodeName, family)
+		if err != nil {
+			if err != errSubnetNotRealized {
+				p.recorder.Eventf(node, v1.EventTypeWarning, reasonCIDRAssignmentFailed, "allocating %s subnet from %s failed: %v", family.ipFamily, family.ipBlockPath, err)
+			}
+			return err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+
+	if err := p.patchNodeCIDRs(nodeName, cidrs); err != nil {
+		p.recorder.Eventf(node, v1.EventTypeWarning, reasonCIDRAssignmentFailed, "setting pod CIDRs %v failed: %v", cidrs, err)
+		return err
+	}
+	klog.Infof("node %s: assigned pod CIDRs %v", nodeName, cidrs)
+	p.recorder.Eventf(node, v1.EventTypeNormal, reasonCIDRAssigned, "assigned pod CIDRs %v", cidrs)
+	return nil
+}
+
+// ensureIPPool creates the IP pool holding the node subnets once
+func (p *nodeIPAM) ensureIPPool() error {
+	p.ipPoolLock.Lock()
+	defer p.ipPoolLock.Unlock()
+	if p.ipPoolCreated {
+		return nil
+	}
+	ipPool := model.IpAddressPool{
+		DisplayName: &p.ipPoolID,
+		Tags:        p.tags(""),
+	}
+	if err := p.broker.CreateIPPool(p.ipPoolID, ipPool); err != nil {
+		return errors.Wrapf(err, "creating IP pool %s failed", p.ipPoolID)
+	}
+	p.ipPoolCreated = true
+	return nil
+}
+
+// allocateSubnet creates the IP block subnet of a node and returns its CIDR once realized
+func (p *nodeIPAM) allocateSubnet(nodeName string, family subnetFamily) (string, error) {
+	subnetID := p.subnetID(nodeName, family.ipFamily)
+	size := subnetSize(family)
+	autoAssignGateway := false
+	subnet := model.IpAddressPoolBlockSubnet{
+		DisplayName:       &subnetID,
+		IpBlockPath:       &family.ipBlockPath,
+		SubnetSize:        &size,
+		AutoAssignGateway: &autoAssignGateway,
+		Tags:              p.tags(nodeName),
+	}
+	// patching an existing subnet with the same size is a no-op
+	if err := p.broker.CreateIPBlockSubnet(p.ipPoolID, subnetID, subnet); err != nil {
+		return "", errors.Wrapf(err, "creating IP block subnet %s failed", subnetID)
+	}
+	return p.realizedCIDR(p.subnetPath(subnetID))
+}
+
+// realizedCIDR returns the CIDR of a realized IP block subnet
+func (p *nodeIPAM) realizedCIDR(path string) (string, error) {
+	result, err := p.broker.ListRealizedEntities(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "querying realized state of %s failed", path)
+	}
+	for _, entity := range result.Results {
+		if entity.State != nil && *entity.State == errorState {
+			message := ""
+			if entity.RuntimeError != nil {
+				message = *entity.RuntimeError
+			}
+			return "", fmt.Errorf("realization of %s failed: %s", path, message)
+		}
+		if entity.State == nil || *entity.State != realizedState {
+			continue
+		}
+		for _, attr := range entity.ExtendedAttributes {
+			if attr.Key != nil && *attr.Key == realizedCIDRAttribute && len(attr.Values) > 0 {
+				return attr.Values[0], nil
+			}
+		}
+	}
+	return "", errSubnetNotRealized
+}
+
+type nodeForCIDRMergePatch struct {
+	Spec nodeSpecForMergePatch `json:"spec"`
+}
+
+type nodeSpecForMergePatch struct {
+	PodCIDR  string   `json:"podCIDR"`
+	PodCIDRs []string `json:"podCIDRs,omitempty"`
+}
+
+// patchNodeCIDRs sets the pod CIDRs of a node, the first CIDR is the primary pod CIDR
+func (p *nodeIPAM) patchNodeCIDRs(nodeName string, cidrs []string) error {
+	patch := nodeForCIDRMergePatch{
+		Spec: nodeSpecForMergePatch{
+			PodCIDR:  cidrs[0],
+			PodCIDRs: cidrs,
+		},
+	}
+	patchBytes, err := json.Marshal(&patch)
+	if err != nil {
+		return errors.Wrap(err, "marshalling pod CIDRs failed")
+	}
+	_, err = p.client.CoreV1().Nodes().Patch(context.Background(), nodeName, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
+
+// releaseSubnets deletes the IP block subnets of a deleted node
+func (p *nodeIPAM) releaseSubnets(nodeName string) error {
+	for _, family := range p.families {
+		subnetID := p.subnetID(nodeName, family.ipFamily)
+		if err := p.broker.DeleteIPBlockSubnet(p.ipPoolID, subnetID); err != nil {
+			return errors.Wrapf(err, "deleting IP block subnet %s failed", subnetID)
+		}
+		klog.Infof("node %s: released IP block subnet %s", nodeName, subnetID)
+	}
+	return nil
+}
+
+// releaseDeletedNodeSubnets deletes the IP block subnets of the cluster whose nodes do not exist anymore
+func (p *nodeIPAM) releaseDeletedNodeSubnets() error {
+	subnets, err := p.broker.ListIPBlockSubnets(p.ipPoolID)
+	if err != nil {
+		return errors.Wrapf(err, "listing subnets of IP pool %s failed", p.ipPoolID)
+	}
+	nodes, err := p.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, node := range nodes.Items {
+		existing[node.Name] = true
+	}
+	for _, subnet := range subnets {
+		clusterName, nodeName := tagValue(subnet.Tags, config.ClusterNameTagScope), tagValue(subnet.Tags, config.NodeNameTagScope)
+		if clusterName != p.clusterName || nodeName == "" || existing[nodeName] || subnet.Id == nil {
+			continue
+		}
+		if err := p.broker.DeleteIPBlockSubnet(p.ipPoolID, *subnet.Id); err != nil {
+			return errors.Wrapf(err, "deleting IP block subnet %s failed", *subnet.Id)
+		}
+		klog.Infof("node %s: released IP block subnet %s of deleted node", nodeName, *subnet.Id)
+	}
+	return nil
+}
+
+// subnetID returns the ID of the IP block subnet of a node
+func (p *nodeIPAM) subnetID(nodeName, ipFamily string) string {
+	parts := []string{nodeName, ipFamily}
+	if p.clusterName != "" {
+		parts = append([]string{p.clusterName}, parts...)
+	}
+	return strings.Join(parts, "_")
+}
+
+// subnetPath returns the policy path of an IP block subnet
+func (p *nodeIPAM) subnetPath(subnetID string) string {
+	return fmt.Sprintf("/infra/ip-pools/%s/ip-subnets/%s", p.ipPoolID, subnetID)
+}
+
+// tags returns the cluster tag and the node tag if a node name is given
+func (p *nodeIPAM) tags(nodeName string) []model.Tag {
+	clusterScope := config.ClusterNameTagScope
+	clusterName := p.clusterName
+	tags := []model.Tag{{Scope: &clusterScope, Tag: &clusterName}}
+	if nodeName != "" {
+		nodeScope := config.NodeNameTagScope
+		tags = append(tags, model.Tag{Scope: &nodeScope, Tag: &nodeName})
+	}
+	return tags
+}
+
+// tagValue returns the value of the tag with the given scope
+func tagValue(tags []model.Tag, scope string) string {
+	for _, tag := range tags {
+		if tag.Scope != nil && *tag.Scope == scope && tag.Tag != nil {
+			return *tag.Tag
+		}
+	}
+	return ""
+}
+
+// subnetSize returns the number of addresses of a node subnet as decimal string,
+// which holds the sizes of IPv6 subnets
+func subnetSize(family subnetFamily) string {
+	bits := 32
+	if family.ipFamily == ipFamilyIPv6 {
+		bits = 128
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-family.prefixLength)).String()
+}
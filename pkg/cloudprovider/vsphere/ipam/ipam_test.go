@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam/config"
+)
+
+func buildFakeNode(nodeName string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+		},
+	}
+}
+
+func newTestNodeIPAM(t *testing.T, broker NsxtBroker, nodes ...*v1.Node) *nodeIPAM {
+	cfg := &config.IPAMConfig{
+		IPPoolID:         "pool",
+		IPBlockPath:      "/infra/ip-blocks/block-v4",
+		PrefixLength:     24,
+		IPv6IPBlockPath:  "/infra/ip-blocks/block-v6",
+		IPv6PrefixLength: 64,
+	}
+	p := newNodeIPAM(cfg, broker)
+	p.clusterName = "cluster1"
+	client := fake.NewSimpleClientset()
+	for _, node := range nodes {
+		if _, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+	}
+	p.client = client
+	p.recorder = record.NewFakeRecorder(10)
+	return p
+}
+
+func realizedSubnet(cidr string) model.GenericPolicyRealizedResourceListResult {
+	state := realizedState
+	key := realizedCIDRAttribute
+	return model.GenericPolicyRealizedResourceListResult{
+		Results: []model.GenericPolicyRealizedResource{{
+			State:              &state,
+			ExtendedAttributes: []model.AttributeVal{{Key: &key, Values: []string{cidr}}},
+		}},
+	}
+}
+
+func subnetWithTags(id, clusterName, nodeName string) model.IpAddressPoolBlockSubnet {
+	p := &nodeIPAM{clusterName: clusterName}
+	return model.IpAddressPoolBlockSubnet{Id: &id, Tags: p.tags(nodeName)}
+}
+
+func TestSyncNodeAllocatesPodCIDRs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker, buildFakeNode("node1"))
+
+	mockBroker.EXPECT().CreateIPPool("pool", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().CreateIPBlockSubnet("pool", "cluster1_node1_ipv4", gomock.Any()).DoAndReturn(
+		func(_, _ string, subnet model.IpAddressPoolBlockSubnet) error {
+			assert.Equal(t, "/infra/ip-blocks/block-v4", *subnet.IpBlockPath)
+			assert.Equal(t, "256", *subnet.SubnetSize)
+			assert.Equal(t, "node1", tagValue(subnet.Tags, config.NodeNameTagScope))
+			return nil
+		})
+	mockBroker.EXPECT().CreateIPBlockSubnet("pool", "cluster1_node1_ipv6", gomock.Any()).DoAndReturn(
+		func(_, _ string, subnet model.IpAddressPoolBlockSubnet) error {
+			assert.Equal(t, "18446744073709551616", *subnet.SubnetSize)
+			return nil
+		})
+	mockBroker.EXPECT().ListRealizedEntities("/infra/ip-pools/pool/ip-subnets/cluster1_node1_ipv4").Return(realizedSubnet("100.96.1.0/24"), nil)
+	mockBroker.EXPECT().ListRealizedEntities("/infra/ip-pools/pool/ip-subnets/cluster1_node1_ipv6").Return(realizedSubnet("fd00:0:0:1::/64"), nil)
+
+	assert.NoError(t, p.syncNode("node1"))
+	node, err := p.client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "100.96.1.0/24", node.Spec.PodCIDR)
+	assert.Equal(t, []string{"100.96.1.0/24", "fd00:0:0:1::/64"}, node.Spec.PodCIDRs)
+
+	// the IP pool is created only once and nodes with pod CIDRs are skipped
+	assert.NoError(t, p.syncNode("node1"))
+}
+
+func TestSyncNodeSubnetNotRealized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker, buildFakeNode("node1"))
+
+	mockBroker.EXPECT().CreateIPPool("pool", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().CreateIPBlockSubnet("pool", "cluster1_node1_ipv4", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().ListRealizedEntities("/infra/ip-pools/pool/ip-subnets/cluster1_node1_ipv4").Return(model.GenericPolicyRealizedResourceListResult{}, nil)
+
+	assert.Equal(t, errSubnetNotRealized, p.syncNode("node1"))
+	node, err := p.client.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, node.Spec.PodCIDRs)
+}
+
+func TestSyncNodeRealizationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker, buildFakeNode("node1"))
+
+	state := errorState
+	message := "ip block exhausted"
+	mockBroker.EXPECT().CreateIPPool("pool", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().CreateIPBlockSubnet("pool", "cluster1_node1_ipv4", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().ListRealizedEntities("/infra/ip-pools/pool/ip-subnets/cluster1_node1_ipv4").Return(
+		model.GenericPolicyRealizedResourceListResult{
+			Results: []model.GenericPolicyRealizedResource{{State: &state, RuntimeError: &message}},
+		}, nil)
+
+	err := p.syncNode("node1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), message)
+}
+
+func TestSyncNodeCreateIPPoolError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker, buildFakeNode("node1"))
+
+	mockBroker.EXPECT().CreateIPPool("pool", gomock.Any()).Return(errors.New("mock error"))
+	assert.Error(t, p.syncNode("node1"))
+}
+
+func TestSyncNodeReleasesSubnetsOfDeletedNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker)
+
+	mockBroker.EXPECT().DeleteIPBlockSubnet("pool", "cluster1_node1_ipv4").Return(nil)
+	mockBroker.EXPECT().DeleteIPBlockSubnet("pool", "cluster1_node1_ipv6").Return(nil)
+	assert.NoError(t, p.syncNode("node1"))
+}
+
+func TestReleaseDeletedNodeSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := newTestNodeIPAM(t, mockBroker, buildFakeNode("node1"))
+
+	mockBroker.EXPECT().ListIPBlockSubnets("pool").Return([]model.IpAddressPoolBlockSubnet{
+		subnetWithTags("cluster1_node1_ipv4", "cluster1", "node1"),
+		subnetWithTags("cluster1_node2_ipv4", "cluster1", "node2"),
+		subnetWithTags("cluster2_node3_ipv4", "cluster2", "node3"),
+	}, nil)
+	mockBroker.EXPECT().DeleteIPBlockSubnet("pool", "cluster1_node2_ipv4").Return(nil)
+
+	assert.NoError(t, p.releaseDeletedNodeSubnets())
+}
+
+func TestAddNode(t *testing.T) {
+	p := newNodeIPAM(&config.IPAMConfig{IPPoolID: "pool", IPBlockPath: "/infra/ip-blocks/block-v4", PrefixLength: 24}, nil)
+
+	node := buildFakeNode("node1")
+	node.Spec.PodCIDR = "100.96.1.0/24"
+	node.Spec.PodCIDRs = []string{"100.96.1.0/24"}
+	p.AddNode(node)
+	assert.Equal(t, 0, p.queue.Len())
+
+	p.AddNode(buildFakeNode("node2"))
+	assert.Equal(t, 1, p.queue.Len())
+}
+
+func TestSubnetSize(t *testing.T) {
+	assert.Equal(t, "256", subnetSize(subnetFamily{ipFamily: ipFamilyIPv4, prefixLength: 24}))
+	assert.Equal(t, "65536", subnetSize(subnetFamily{ipFamily: ipFamilyIPv6, prefixLength: 112}))
+}
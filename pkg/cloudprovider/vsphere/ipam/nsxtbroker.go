@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ipam
+
+import (
+	"fmt"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/bindings"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/protocol/client"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/ip_pools"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/realized_state"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+)
+
+// NsxtBroker is an internal interface to access nsxt backend
+type NsxtBroker interface {
+	CreateIPPool(ipPoolID string, ipPool model.IpAddressPool) error
+	ListIPBlockSubnets(ipPoolID string) ([]model.IpAddressPoolBlockSubnet, error)
+	CreateIPBlockSubnet(ipPoolID string, ipSubnetID string, subnet model.IpAddressPoolBlockSubnet) error
+	DeleteIPBlockSubnet(ipPoolID string, ipSubnetID string) error
+	ListRealizedEntities(path string) (model.GenericPolicyRealizedResourceListResult, error)
+}
+
+// nsxtBroker includes NSXT API clients
+type nsxtBroker struct {
+	ipPoolsClient          infra.IpPoolsClient
+	ipSubnetsClient        ip_pools.IpSubnetsClient
+	realizedEntitiesClient realized_state.RealizedEntitiesClient
+	converter              *bindings.TypeConverter
+}
+
+// NewNsxtBroker creates a new NsxtBroker to the NSXT API
+func NewNsxtBroker(connector client.Connector) (NsxtBroker, error) {
+	return &nsxtBroker{
+		ipPoolsClient:          infra.NewIpPoolsClient(connector),
+		ipSubnetsClient:        ip_pools.NewIpSubnetsClient(connector),
+		realizedEntitiesClient: realized_state.NewRealizedEntitiesClient(connector),
+		converter:              bindings.NewTypeConverter(),
+	}, nil
+}
+
+func (b *nsxtBroker) CreateIPPool(ipPoolID string, ipPool model.IpAddressPool) error {
+	return b.ipPoolsClient.Patch(ipPoolID, ipPool)
+}
+
+func (b *nsxtBroker) ListIPBlockSubnets(ipPoolID string) ([]model.IpAddressPoolBlockSubnet, error) {
+	var subnets []model.IpAddressPoolBlockSubnet
+	var cursor *string
+	for {
+		result, err := b.ipSubnetsClient.List(ipPoolID, cursor, nil, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Results {
+			resourceType, err := item.String("resource_type")
+			if err != nil || resourceType != model.IpAddressPoolSubnet_RESOURCE_TYPE_IPADDRESSPOOLBLOCKSUBNET {
+				continue
+			}
+			itf, errs := b.converter.ConvertToGolang(item, model.IpAddressPoolBlockSubnetBindingType())
+			if len(errs) > 0 {
+				return nil, errs[0]
+			}
+			subnets = append(subnets, itf.(model.IpAddressPoolBlockSubnet))
+		}
+		if result.Cursor == nil || *result.Cursor == "" {
+			return subnets, nil
+		}
+		cursor = result.Cursor
+	}
+}
+
+func (b *nsxtBroker) CreateIPBlockSubnet(ipPoolID string, ipSubnetID string, subnet model.IpAddressPoolBlockSubnet) error {
+	subnet.ResourceType = model.IpAddressPoolSubnet_RESOURCE_TYPE_IPADDRESSPOOLBLOCKSUBNET
+	dataValue, errs := b.converter.ConvertToVapi(subnet, model.IpAddressPoolBlockSubnetBindingType())
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	structValue, ok := dataValue.(*data.StructValue)
+	if !ok {
+		return fmt.Errorf("unexpected data value %T of IP block subnet %s", dataValue, ipSubnetID)
+	}
+	return b.ipSubnetsClient.Patch(ipPoolID, ipSubnetID, structValue)
+}
+
+func (b *nsxtBroker) DeleteIPBlockSubnet(ipPoolID string, ipSubnetID string) error {
+	return b.ipSubnetsClient.Delete(ipPoolID, ipSubnetID)
+}
+
+func (b *nsxtBroker) ListRealizedEntities(path string) (model.GenericPolicyRealizedResourceListResult, error) {
+	return b.realizedEntitiesClient.List(path, nil)
+}
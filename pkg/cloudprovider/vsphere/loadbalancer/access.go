@@ -18,6 +18,7 @@ package loadbalancer
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -43,6 +44,8 @@ const (
 	ScopeIPPoolID = "ippoolid"
 	// ScopeLBClass is the load balancer class scope
 	ScopeLBClass = "lbclass"
+	// ScopeAppProfileSettings is the scope for the settings of shared application profiles
+	ScopeAppProfileSettings = "appprofilesettings"
 )
 
 type access struct {
@@ -208,21 +211,167 @@ func (a *access) GetAppProfilePath(class LBClass, protocol corev1.Protocol) (str
 	return a.findAppProfilePathByName(profileReference.Name, resourceType)
 }
 
+func (a *access) CreateHTTPAppProfile(clusterName string, objectName types.NamespacedName, xForwardedFor string) (*model.LBHttpProfile, error) {
+	profile := model.LBHttpProfile{
+		Description: strptr(fmt.Sprintf("http profile for cluster %s, service %s created by %s",
+			clusterName, objectName, AppName)),
+		DisplayName:   strptr(fmt.Sprintf("cluster:%s:%s:xff-%s", clusterName, objectName, strings.ToLower(xForwardedFor))),
+		Tags:          a.standardTags.Append(clusterTag(clusterName), serviceTag(objectName)).Normalize(),
+		XForwardedFor: strptr(xForwardedFor),
+	}
+	result, err := a.broker.CreateLoadBalancerHTTPAppProfile(profile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating http profile failed for %s:%s", clusterName, objectName)
+	}
+	return &result, nil
+}
+
+func (a *access) FindHTTPAppProfiles(clusterName string, objectName types.NamespacedName) ([]*model.LBHttpProfile, error) {
+	return a.listHTTPAppProfiles(a.ownerTag, clusterTag(clusterName), serviceTag(objectName))
+}
+
+func (a *access) ListHTTPAppProfiles(clusterName string) ([]*model.LBHttpProfile, error) {
+	return a.listHTTPAppProfiles(a.ownerTag, clusterTag(clusterName))
+}
+
+func (a *access) listHTTPAppProfiles(tags ...model.Tag) ([]*model.LBHttpProfile, error) {
+	list, err := a.broker.ListAppProfiles()
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing application profiles failed")
+	}
+	result := []*model.LBHttpProfile{}
+	converter := newNsxtTypeConverter()
+	for _, item := range list {
+		resourceType, err := item.String("resource_type")
+		if err != nil || resourceType != model.LBAppProfile_RESOURCE_TYPE_LBHTTPPROFILE {
+			continue
+		}
+		profile, err := converter.convertStructValueToLBHTTPProfile(item)
+		if err != nil {
+			return nil, err
+		}
+		if checkTags(profile.Tags, tags...) {
+			result = append(result, &profile)
+		}
+	}
+	return result, nil
+}
+
+func (a *access) UnmanageHTTPAppProfile(profile *model.LBHttpProfile) error {
+	profile.Tags = a.unmanagedTags(profile.Tags)
+	_, err := a.broker.UpdateLoadBalancerHTTPAppProfile(*profile)
+	if err != nil {
+		return errors.Wrapf(err, "updating http profile %s failed", *profile.Id)
+	}
+	return nil
+}
+
+func (a *access) CreateSettingsAppProfile(clusterName string, protocol corev1.Protocol, settings AppProfileSettings) (*AppProfile, error) {
+	key := settings.Key(protocol)
+	id := settingsAppProfileID(*a.ownerTag.Tag, clusterName, key)
+	description := strptr(fmt.Sprintf("%s profile for cluster %s with settings %s created by %s", protocol, clusterName, key, AppName))
+	displayName := strptr(fmt.Sprintf("cluster:%s:%s", clusterName, key))
+	tags := a.standardTags.Append(clusterTag(clusterName), newTag(ScopeAppProfileSettings, key)).Normalize()
+	switch protocol {
+	case corev1.ProtocolTCP:
+		profile := model.LBFastTcpProfile{
+			Id:                     strptr(id),
+			Description:            description,
+			DisplayName:            displayName,
+			Tags:                   tags,
+			IdleTimeout:            settings.IdleTimeout,
+			CloseTimeout:           settings.CloseTimeout,
+			HaFlowMirroringEnabled: settings.FlowMirroring,
+		}
+		result, err := a.broker.CreateLoadBalancerFastTCPAppProfile(profile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating TCP application profile %s failed", key)
+		}
+		return newAppProfile(result.Id, result.Path, result.DisplayName, result.Tags), nil
+	case corev1.ProtocolUDP:
+		profile := model.LBFastUdpProfile{
+			Id:                   strptr(id),
+			Description:          description,
+			DisplayName:          displayName,
+			Tags:                 tags,
+			IdleTimeout:          settings.IdleTimeout,
+			FlowMirroringEnabled: settings.FlowMirroring,
+		}
+		result, err := a.broker.CreateLoadBalancerFastUDPAppProfile(profile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating UDP application profile %s failed", key)
+		}
+		return newAppProfile(result.Id, result.Path, result.DisplayName, result.Tags), nil
+	default:
+		return nil, fmt.Errorf("Unsupported protocol %s", protocol)
+	}
+}
+
+func (a *access) ListSettingsAppProfiles(clusterName string) ([]*AppProfile, error) {
+	list, err := a.broker.ListAppProfiles()
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing application profiles failed")
+	}
+	tags := []model.Tag{a.ownerTag, clusterTag(clusterName)}
+	result := []*AppProfile{}
+	converter := newNsxtTypeConverter()
+	for _, item := range list {
+		resourceType, err := item.String("resource_type")
+		if err != nil {
+			continue
+		}
+		var profile *AppProfile
+		switch resourceType {
+		case model.LBAppProfile_RESOURCE_TYPE_LBFASTTCPPROFILE:
+			tcp, err := converter.convertStructValueToLBFastTCPProfile(item)
+			if err != nil {
+				return nil, err
+			}
+			profile = newAppProfile(tcp.Id, tcp.Path, tcp.DisplayName, tcp.Tags)
+		case model.LBAppProfile_RESOURCE_TYPE_LBFASTUDPPROFILE:
+			udp, err := converter.convertStructValueToLBFastUDPProfile(item)
+			if err != nil {
+				return nil, err
+			}
+			profile = newAppProfile(udp.Id, udp.Path, udp.DisplayName, udp.Tags)
+		default:
+			continue
+		}
+		if getTag(profile.Tags, ScopeAppProfileSettings) != "" && checkTags(profile.Tags, tags...) {
+			result = append(result, profile)
+		}
+	}
+	return result, nil
+}
+
+func (a *access) DeleteAppProfile(id string) error {
+	err := a.broker.DeleteLoadBalancerAppProfile(id)
+	if isNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "deleting application profile %s failed", id)
+	}
+	return nil
+}
+
 func (a *access) CreateVirtualServer(clusterName string, objectName types.NamespacedName, class LBClass, ipAddress string,
-	mapping Mapping, lbServicePath, applicationProfilePath string, poolPath *string) (*model.LBVirtualServer, error) {
+	mapping Mapping, lbServicePath, applicationProfilePath string, poolPath *string, limits VirtualServerLimits) (*model.LBVirtualServer, error) {
 	allTags := append(class.Tags(), clusterTag(clusterName), serviceTag(objectName), portTag(mapping))
 	virtualServer := model.LBVirtualServer{
 		Description: strptr(fmt.Sprintf("virtual server for cluster %s, service %s created by %s",
 			clusterName, objectName, AppName)),
-		DisplayName:            displayNameObject(clusterName, objectName),
-		Tags:                   a.standardTags.Append(allTags...).Normalize(),
-		DefaultPoolMemberPorts: []string{fmt.Sprintf("%d", mapping.NodePort)},
-		Enabled:                boolptr(true),
-		IpAddress:              strptr(ipAddress),
-		ApplicationProfilePath: strptr(applicationProfilePath),
-		PoolPath:               poolPath,
-		Ports:                  []string{fmt.Sprintf("%d", mapping.SourcePort)},
-		LbServicePath:          strptr(lbServicePath),
+		DisplayName:              displayNameObject(clusterName, objectName),
+		Tags:                     a.standardTags.Append(allTags...).Normalize(),
+		DefaultPoolMemberPorts:   []string{fmt.Sprintf("%d", mapping.NodePort)},
+		Enabled:                  boolptr(true),
+		IpAddress:                strptr(ipAddress),
+		ApplicationProfilePath:   strptr(applicationProfilePath),
+		PoolPath:                 poolPath,
+		Ports:                    []string{fmt.Sprintf("%d", mapping.SourcePort)},
+		LbServicePath:            strptr(lbServicePath),
+		MaxConcurrentConnections: limits.MaxConcurrentConnections,
+		MaxNewConnectionRate:     limits.MaxNewConnectionRate,
 	}
 	result, err := a.broker.CreateLoadBalancerVirtualServer(virtualServer)
 	if err != nil {
@@ -231,6 +380,14 @@ func (a *access) CreateVirtualServer(clusterName string, objectName types.Namesp
 	return &result, nil
 }
 
+func (a *access) GetVirtualServer(id string) (*model.LBVirtualServer, error) {
+	server, err := a.broker.ReadLoadBalancerVirtualServer(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading virtual server %s failed", id)
+	}
+	return &server, nil
+}
+
 func (a *access) FindVirtualServers(clusterName string, objectName types.NamespacedName) ([]*model.LBVirtualServer, error) {
 	return a.listVirtualServers(a.ownerTag, clusterTag(clusterName), serviceTag(objectName))
 }
@@ -239,6 +396,10 @@ func (a *access) ListVirtualServers(clusterName string) ([]*model.LBVirtualServe
 	return a.listVirtualServers(a.ownerTag, clusterTag(clusterName))
 }
 
+func (a *access) ListAllVirtualServers() ([]*model.LBVirtualServer, error) {
+	return a.listVirtualServers()
+}
+
 func (a *access) listVirtualServers(tags ...model.Tag) ([]*model.LBVirtualServer, error) {
 	list, err := a.broker.ListLoadBalancerVirtualServers()
 	if err != nil {
@@ -273,6 +434,24 @@ func (a *access) DeleteVirtualServer(id string) error {
 	return nil
 }
 
+func (a *access) AdoptVirtualServer(clusterName string, objectName types.NamespacedName, class LBClass, mapping Mapping, server *model.LBVirtualServer) error {
+	allTags := append(class.Tags(), clusterTag(clusterName), serviceTag(objectName), portTag(mapping))
+	tags, modified, err := a.adoptTags(server.Tags, clusterName, objectName, allTags...)
+	if err != nil {
+		return errors.Wrapf(err, "adopting virtual server %s failed", *server.Id)
+	}
+	if !modified {
+		return nil
+	}
+	server.Tags = tags
+	return a.UpdateVirtualServer(server)
+}
+
+func (a *access) UnmanageVirtualServer(server *model.LBVirtualServer) error {
+	server.Tags = a.unmanagedTags(server.Tags)
+	return a.UpdateVirtualServer(server)
+}
+
 func (a *access) CreatePool(clusterName string, objectName types.NamespacedName, mapping Mapping, members []model.LBPoolMember, activeMonitorPaths []string) (*model.LBPool, error) {
 	var snatTranslation *data.StructValue
 	var err error
@@ -366,6 +545,23 @@ func (a *access) DeletePool(id string) error {
 	return nil
 }
 
+func (a *access) AdoptPool(clusterName string, objectName types.NamespacedName, mapping Mapping, pool *model.LBPool) error {
+	tags, modified, err := a.adoptTags(pool.Tags, clusterName, objectName, clusterTag(clusterName), serviceTag(objectName), portTag(mapping))
+	if err != nil {
+		return errors.Wrapf(err, "adopting pool %s failed", *pool.Id)
+	}
+	if !modified {
+		return nil
+	}
+	pool.Tags = tags
+	return a.UpdatePool(pool)
+}
+
+func (a *access) UnmanagePool(pool *model.LBPool) error {
+	pool.Tags = a.unmanagedTags(pool.Tags)
+	return a.UpdatePool(pool)
+}
+
 func (a *access) CreateTCPMonitorProfile(clusterName string, objectName types.NamespacedName, mapping Mapping) (*model.LBTcpMonitorProfile, error) {
 	profile := model.LBTcpMonitorProfile{
 		Description: strptr(fmt.Sprintf("tcp monitor for cluster %s, service %s, port %d created by %s",
@@ -439,6 +635,11 @@ func (a *access) DeleteTCPMonitorProfile(id string) error {
 	return nil
 }
 
+func (a *access) UnmanageTCPMonitorProfile(monitor *model.LBTcpMonitorProfile) error {
+	monitor.Tags = a.unmanagedTags(monitor.Tags)
+	return a.UpdateTCPMonitorProfile(monitor)
+}
+
 func (a *access) AllocateExternalIPAddress(ipPoolID string, clusterName string, objectName types.NamespacedName) (*model.IpAddressAllocation, *string, error) {
 	allocation := model.IpAddressAllocation{
 		Tags: a.standardTags.Append(clusterTag(clusterName), serviceTag(objectName)).Normalize(),
@@ -504,6 +705,51 @@ func (a *access) ReleaseExternalIPAddress(ipPoolID string, id string) error {
 	return nil
 }
 
+func (a *access) UnmanageExternalIPAddress(ipPoolID string, allocation *model.IpAddressAllocation) error {
+	allocation.Tags = a.unmanagedTags(allocation.Tags)
+	err := a.broker.UpdateIPPoolAllocation(ipPoolID, *allocation)
+	if err != nil {
+		return errors.Wrapf(err, "updating external IP address allocation id=%s failed", *allocation.Id)
+	}
+	return nil
+}
+
+// isManagedScope returns true if tags with the given scope are set by the controller
+func (a *access) isManagedScope(scope string) bool {
+	switch scope {
+	case ScopeOwner, ScopeCluster, ScopeService, ScopePort, ScopeIPPoolID, ScopeLBClass, ScopeAppProfileSettings:
+		return true
+	}
+	_, ok := a.standardTags[scope]
+	return ok
+}
+
+// unmanagedTags returns the tags not set by the controller
+func (a *access) unmanagedTags(tags []model.Tag) []model.Tag {
+	result := []model.Tag{}
+	for _, tag := range tags {
+		if tag.Scope == nil || !a.isManagedScope(*tag.Scope) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// adoptTags adds the management tags for an object to its foreign tags.
+// It fails if the object is already managed for another service and
+// reports no modification if it is already managed for the given one.
+func (a *access) adoptTags(tags []model.Tag, clusterName string, objectName types.NamespacedName, managed ...model.Tag) ([]model.Tag, bool, error) {
+	if service := getTag(tags, ScopeService); service != "" {
+		if checkTags(tags, a.ownerTag, clusterTag(clusterName), serviceTag(objectName)) {
+			return tags, false, nil
+		}
+		return nil, false, fmt.Errorf("already managed for service %s of cluster %s", service, getTag(tags, ScopeCluster))
+	}
+	result := a.unmanagedTags(tags)
+	result = append(result, a.standardTags.Append(managed...).Normalize()...)
+	return result, true, nil
+}
+
 func displayName(clusterName string) *string {
 	return strptr(fmt.Sprintf("cluster:%s", clusterName))
 }
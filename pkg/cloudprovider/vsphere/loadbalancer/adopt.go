@@ -0,0 +1,199 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+)
+
+const (
+	// AdoptVirtualServersAnnotation is the optional annotation at the service listing
+	// existing virtual servers (by path or id) to be taken over by the controller
+	AdoptVirtualServersAnnotation = "loadbalancer.vmware.io/adopt-virtual-servers"
+	// AdoptPoolsAnnotation is the optional annotation at the service listing
+	// existing pools (by path or id) to be taken over by the controller
+	AdoptPoolsAnnotation = "loadbalancer.vmware.io/adopt-pools"
+	// ReleaseOnDeleteAnnotation is the optional annotation at the service to keep
+	// the NSX-T objects in place if the service is deleted
+	ReleaseOnDeleteAnnotation = "loadbalancer.vmware.io/release-on-delete"
+)
+
+// annotationList returns the comma separated entries of a service annotation
+func annotationList(service *corev1.Service, name string) []string {
+	var result []string
+	for _, item := range strings.Split(service.GetAnnotations()[name], ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// releaseOnDelete returns true if the NSX-T objects of the service should be kept on deletion
+func releaseOnDelete(service *corev1.Service) bool {
+	value, ok := service.GetAnnotations()[ReleaseOnDeleteAnnotation]
+	if !ok {
+		return false
+	}
+	release, err := strconv.ParseBool(strings.TrimSpace(value))
+	return err == nil && release
+}
+
+// idFromReference returns the identifier of an object given by policy path or identifier
+func idFromReference(ref string) string {
+	return path.Base(ref)
+}
+
+// adopt takes over the virtual servers and pools listed in the adoption annotations
+// by tagging them for the service. Pools must be used by one of the adopted virtual
+// servers, because the port mapping is derived from the virtual server.
+// As the annotations are evaluated on every reconcile, objects already managed for
+// the service are skipped and objects removed meanwhile are ignored.
+func (s *state) adopt(class *loadBalancerClass) error {
+	serverRefs := annotationList(s.service, AdoptVirtualServersAnnotation)
+	poolRefs := annotationList(s.service, AdoptPoolsAnnotation)
+	if len(serverRefs) == 0 && len(poolRefs) == 0 {
+		return nil
+	}
+
+	adoptedPools := sets.NewString()
+	for _, ref := range serverRefs {
+		server, err := s.access.GetVirtualServer(idFromReference(ref))
+		if isNotFoundError(err) {
+			s.CtxInfof("LBVirtualServer %s to adopt not found", ref)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if s.managedForService(server.Tags) {
+			if server.PoolPath != nil {
+				adoptedPools.Insert(idFromReference(*server.PoolPath))
+			}
+			continue
+		}
+		if len(server.Ports) == 0 {
+			s.CtxInfof("LBVirtualServer %s to adopt has no port", ref)
+			continue
+		}
+		if len(server.Ports) != 1 {
+			return fmt.Errorf("virtual server %s cannot be adopted: exactly one port required, found %v", ref, server.Ports)
+		}
+		var mapping *Mapping
+		for _, servicePort := range s.service.Spec.Ports {
+			m := NewMapping(servicePort)
+			if server.Ports[0] == formatPort(m.SourcePort) {
+				mapping = &m
+				break
+			}
+		}
+		if mapping == nil {
+			s.CtxInfof("LBVirtualServer %s to adopt has no service port %s", ref, server.Ports[0])
+			continue
+		}
+		if server.PoolPath != nil {
+			pool, err := s.access.GetPool(idFromReference(*server.PoolPath))
+			if err != nil && !isNotFoundError(err) {
+				return err
+			}
+			if pool != nil {
+				err = s.access.AdoptPool(s.clusterName, s.objectName, *mapping, pool)
+				if err != nil {
+					return err
+				}
+				adoptedPools.Insert(*pool.Id)
+			}
+		}
+		err = s.access.AdoptVirtualServer(s.clusterName, s.objectName, class, *mapping, server)
+		if err != nil {
+			return err
+		}
+		s.CtxInfof("adopted LBVirtualServer %s for %s", *server.Id, mapping)
+	}
+
+	for _, ref := range poolRefs {
+		if adoptedPools.Has(idFromReference(ref)) {
+			continue
+		}
+		pool, err := s.access.GetPool(idFromReference(ref))
+		if isNotFoundError(err) {
+			s.CtxInfof("LBPool %s to adopt not found", ref)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !s.managedForService(pool.Tags) {
+			return fmt.Errorf("pool %s cannot be adopted: not used by an adopted virtual server", ref)
+		}
+	}
+	return nil
+}
+
+// managedForService returns true if the tags mark an object as managed for the service
+func (s *state) managedForService(tags []model.Tag) bool {
+	return getTag(tags, ScopeCluster) == s.clusterName && getTag(tags, ScopeService) == s.objectName.String()
+}
+
+// Release removes the management tags from all objects of the load balancer,
+// so that they are neither deleted nor handled by the cleanup anymore.
+func (s *state) Release(class *loadBalancerClass) error {
+	err := s.load(class)
+	if err != nil {
+		return err
+	}
+	for _, server := range s.servers {
+		s.CtxInfof("releasing LBVirtualServer %s", *server.Id)
+		if err := s.access.UnmanageVirtualServer(server); err != nil {
+			return err
+		}
+	}
+	for _, pool := range s.pools {
+		s.CtxInfof("releasing LbPool %s", *pool.Id)
+		if err := s.access.UnmanagePool(pool); err != nil {
+			return err
+		}
+	}
+	for _, monitor := range s.tcpMonitors {
+		s.CtxInfof("releasing LbTcpMonitor %s", *monitor.Id)
+		if err := s.access.UnmanageTCPMonitorProfile(monitor); err != nil {
+			return err
+		}
+	}
+	for _, profile := range s.httpProfiles {
+		s.CtxInfof("releasing LBHttpProfile %s", *profile.Id)
+		if err := s.access.UnmanageHTTPAppProfile(profile); err != nil {
+			return err
+		}
+	}
+	if s.ipAddressAlloc != nil {
+		s.CtxInfof("releasing IP address allocation %s", *s.ipAddressAlloc.Id)
+		if err := s.access.UnmanageExternalIPAddress(s.class.ipPool.Identifier, s.ipAddressAlloc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
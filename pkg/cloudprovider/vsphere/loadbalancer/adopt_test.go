@@ -0,0 +1,220 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vapi_errors "github.com/vmware/vsphere-automation-sdk-go/lib/vapi/std/errors"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+)
+
+func TestAdoptTags(t *testing.T) {
+	cfg := &config.LBConfig{}
+	cfg.LoadBalancer.AdditionalTags = map[string]string{"env": "test"}
+	itf, _ := NewNSXTAccess(nil, cfg)
+	a := itf.(*access)
+
+	objectName := types.NamespacedName{Namespace: "ns1", Name: "svc"}
+	mapping := Mapping{SourcePort: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}
+	foreign := []model.Tag{newTag("team", "network"), newTag("env", "prod")}
+
+	tags, modified, err := a.adoptTags(foreign, "c1", objectName, clusterTag("c1"), serviceTag(objectName), portTag(mapping))
+	if err != nil || !modified {
+		t.Fatalf("adoption failed: modified=%t, err=%v", modified, err)
+	}
+	_checkNormTags(t, "adopted tags", tags,
+		newTag("team", "network"),
+		clusterTag("c1"), newTag("env", "test"), a.ownerTag, portTag(mapping), serviceTag(objectName))
+
+	_, modified, err = a.adoptTags(tags, "c1", objectName)
+	if err != nil || modified {
+		t.Errorf("adopting own object again: modified=%t, err=%v", modified, err)
+	}
+	_, _, err = a.adoptTags(tags, "c1", types.NamespacedName{Namespace: "ns1", Name: "other"})
+	if err == nil {
+		t.Errorf("adopting object of other service should fail")
+	}
+
+	_checkNormTags(t, "unmanaged tags", a.unmanagedTags(tags), newTag("team", "network"))
+}
+
+func TestAdoptionAnnotations(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				AdoptVirtualServersAnnotation: "/infra/lb-virtual-servers/vs1, vs2,",
+				ReleaseOnDeleteAnnotation:     "true",
+			},
+		},
+	}
+	refs := annotationList(service, AdoptVirtualServersAnnotation)
+	if len(refs) != 2 || idFromReference(refs[0]) != "vs1" || idFromReference(refs[1]) != "vs2" {
+		t.Errorf("unexpected references %v", refs)
+	}
+	if len(annotationList(service, AdoptPoolsAnnotation)) != 0 {
+		t.Errorf("expected no pool references")
+	}
+	if !releaseOnDelete(service) {
+		t.Errorf("expected release on delete")
+	}
+}
+
+// objectBroker keeps virtual servers and pools in memory
+type objectBroker struct {
+	NsxtBroker
+	servers  map[string]model.LBVirtualServer
+	pools    map[string]model.LBPool
+	profiles []*data.StructValue
+	updates  int
+	deleted  []string
+	patchErr error
+}
+
+func (b *objectBroker) ReadLoadBalancerVirtualServer(id string) (model.LBVirtualServer, error) {
+	server, ok := b.servers[id]
+	if !ok {
+		return server, vapi_errors.NotFound{}
+	}
+	return server, nil
+}
+
+func (b *objectBroker) UpdateLoadBalancerVirtualServer(server model.LBVirtualServer) (model.LBVirtualServer, error) {
+	b.updates++
+	b.servers[*server.Id] = server
+	return server, nil
+}
+
+func (b *objectBroker) ListLoadBalancerVirtualServers() ([]model.LBVirtualServer, error) {
+	var list []model.LBVirtualServer
+	for _, server := range b.servers {
+		list = append(list, server)
+	}
+	return list, nil
+}
+
+func (b *objectBroker) ListLoadBalancerPools() ([]model.LBPool, error) {
+	var list []model.LBPool
+	for _, pool := range b.pools {
+		list = append(list, pool)
+	}
+	return list, nil
+}
+
+func (b *objectBroker) ListLoadBalancerMonitorProfiles() ([]*data.StructValue, error) {
+	return nil, nil
+}
+
+func (b *objectBroker) ListAppProfiles() ([]*data.StructValue, error) {
+	return b.profiles, nil
+}
+
+func (b *objectBroker) DeleteLoadBalancerAppProfile(id string) error {
+	b.deleted = append(b.deleted, id)
+	return nil
+}
+
+func (b *objectBroker) DeleteLoadBalancerVirtualServer(id string) error {
+	delete(b.servers, id)
+	return nil
+}
+
+func (b *objectBroker) ReadLoadBalancerService(id string) (model.LBService, error) {
+	return model.LBService{Id: strptr(id)}, nil
+}
+
+func (b *objectBroker) DeleteLoadBalancerService(id string) error {
+	b.deleted = append(b.deleted, id)
+	return nil
+}
+
+func (b *objectBroker) PatchInfra(children []*data.StructValue) error {
+	return b.patchErr
+}
+
+func (b *objectBroker) ReadLoadBalancerPool(id string) (model.LBPool, error) {
+	pool, ok := b.pools[id]
+	if !ok {
+		return pool, vapi_errors.NotFound{}
+	}
+	return pool, nil
+}
+
+func (b *objectBroker) UpdateLoadBalancerPool(pool model.LBPool) (model.LBPool, error) {
+	b.updates++
+	b.pools[*pool.Id] = pool
+	return pool, nil
+}
+
+func TestAdopt(t *testing.T) {
+	broker := &objectBroker{
+		servers: map[string]model.LBVirtualServer{
+			"vs1": {Id: strptr("vs1"), DisplayName: strptr("vs1"), Ports: []string{"80"}, PoolPath: strptr("/infra/lb-pools/pool1")},
+			"vs2": {Id: strptr("vs2"), DisplayName: strptr("vs2")},
+		},
+		pools: map[string]model.LBPool{
+			"pool1": {Id: strptr("pool1"), DisplayName: strptr("pool1")},
+		},
+	}
+	itf, _ := NewNSXTAccess(broker, &config.LBConfig{})
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "svc",
+			Annotations: map[string]string{
+				AdoptVirtualServersAnnotation: "vs1,vs2,vs-removed",
+				AdoptPoolsAnnotation:          "pool1,pool-removed",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	s := &state{access: itf, clusterName: "c1", service: service, objectName: namespacedNameFromService(service)}
+	class := &loadBalancerClass{className: "default"}
+
+	if err := s.adopt(class); err != nil {
+		t.Fatalf("adoption failed: %s", err)
+	}
+	if broker.updates != 2 {
+		t.Errorf("expected 2 updates, but found %d", broker.updates)
+	}
+	if !s.managedForService(broker.servers["vs1"].Tags) || !s.managedForService(broker.pools["pool1"].Tags) {
+		t.Errorf("expected vs1 and pool1 to be adopted")
+	}
+	if s.managedForService(broker.servers["vs2"].Tags) {
+		t.Errorf("expected vs2 without port to be skipped")
+	}
+
+	// adopted objects are skipped on the next reconcile, even without port
+	vs1 := broker.servers["vs1"]
+	vs1.Ports = nil
+	broker.servers["vs1"] = vs1
+	if err := s.adopt(class); err != nil {
+		t.Fatalf("repeated adoption failed: %s", err)
+	}
+	if broker.updates != 2 {
+		t.Errorf("expected no further updates, but found %d", broker.updates)
+	}
+}
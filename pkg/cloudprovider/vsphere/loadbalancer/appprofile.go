@@ -0,0 +1,347 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+)
+
+const (
+	// XForwardedForAnnotation is the optional annotation at the service to insert or
+	// replace the X-Forwarded-For header (values `insert` or `replace`). The selected TCP
+	// ports of the service are served with a dedicated HTTP application profile then.
+	XForwardedForAnnotation = "loadbalancer.vmware.io/x-forwarded-for"
+	// XForwardedForPortsAnnotation is the optional comma separated list of service ports
+	// handling the X-Forwarded-For header (default: DefaultXForwardedForPort)
+	XForwardedForPortsAnnotation = "loadbalancer.vmware.io/x-forwarded-for-ports"
+	// ProxyProtocolAnnotation is the annotation at the service requesting the PROXY protocol.
+	// It is rejected, as the application profiles of NSX-T load balancer virtual servers
+	// have no PROXY protocol setting (only NSX Advanced Load Balancer profiles have).
+	ProxyProtocolAnnotation = "loadbalancer.vmware.io/proxy-protocol"
+
+	// DefaultXForwardedForPort is the only service port handling the X-Forwarded-For header
+	// if no ports are annotated. Other ports may carry TLS or non HTTP traffic, which an
+	// HTTP virtual server would break.
+	DefaultXForwardedForPort = 80
+
+	// TCPIdleTimeoutAnnotation is the optional annotation at the service for the idle timeout of TCP connections in seconds
+	TCPIdleTimeoutAnnotation = "loadbalancer.vmware.io/tcp-idle-timeout"
+	// TCPCloseTimeoutAnnotation is the optional annotation at the service for the close timeout of TCP connections in seconds
+	TCPCloseTimeoutAnnotation = "loadbalancer.vmware.io/tcp-close-timeout"
+	// UDPIdleTimeoutAnnotation is the optional annotation at the service for the idle timeout of UDP flows in seconds
+	UDPIdleTimeoutAnnotation = "loadbalancer.vmware.io/udp-idle-timeout"
+	// ConnectionMirroringAnnotation is the optional annotation at the service to mirror flows to the standby edge node
+	ConnectionMirroringAnnotation = "loadbalancer.vmware.io/connection-mirroring"
+	// MaxConcurrentConnectionsAnnotation is the optional annotation at the service limiting the concurrent connections per virtual server
+	MaxConcurrentConnectionsAnnotation = "loadbalancer.vmware.io/max-concurrent-connections"
+	// MaxNewConnectionRateAnnotation is the optional annotation at the service limiting the new connections per second per virtual server
+	MaxNewConnectionRateAnnotation = "loadbalancer.vmware.io/max-new-connection-rate"
+)
+
+// AppProfileSettings contains the settings of an application profile shared by all
+// services of a cluster requesting the same settings
+type AppProfileSettings struct {
+	IdleTimeout   *int64
+	CloseTimeout  *int64
+	FlowMirroring *bool
+}
+
+// IsEmpty returns true if no setting is given
+func (s AppProfileSettings) IsEmpty() bool {
+	return s.IdleTimeout == nil && s.CloseTimeout == nil && s.FlowMirroring == nil
+}
+
+// Key returns the canonical representation of the settings for a protocol
+func (s AppProfileSettings) Key(protocol corev1.Protocol) string {
+	parts := []string{string(protocol)}
+	if s.IdleTimeout != nil {
+		parts = append(parts, fmt.Sprintf("idle=%d", *s.IdleTimeout))
+	}
+	if s.CloseTimeout != nil {
+		parts = append(parts, fmt.Sprintf("close=%d", *s.CloseTimeout))
+	}
+	if s.FlowMirroring != nil {
+		parts = append(parts, fmt.Sprintf("mirroring=%t", *s.FlowMirroring))
+	}
+	return strings.Join(parts, "/")
+}
+
+// VirtualServerLimits contains the connection limits of a virtual server
+type VirtualServerLimits struct {
+	MaxConcurrentConnections *int64
+	MaxNewConnectionRate     *int64
+}
+
+// AppProfile is an application profile shared by the services of a cluster
+type AppProfile struct {
+	ID          string
+	Path        string
+	DisplayName string
+	Tags        []model.Tag
+}
+
+func newAppProfile(id, path, displayName *string, tags []model.Tag) *AppProfile {
+	profile := &AppProfile{Tags: tags}
+	if id != nil {
+		profile.ID = *id
+	}
+	if path != nil {
+		profile.Path = *path
+	}
+	if displayName != nil {
+		profile.DisplayName = *displayName
+	}
+	return profile
+}
+
+// settingsAppProfileID returns a stable id for a shared application profile, so that
+// concurrent reconciles of services with the same settings refer to the same profile
+func settingsAppProfileID(owner, clusterName, key string) string {
+	hash := sha256.Sum256([]byte(owner + "/" + clusterName + "/" + key))
+	return "lb-app-profile-" + hex.EncodeToString(hash[:])[:24]
+}
+
+// appProfileOptions contains the application profile settings requested by service annotations
+type appProfileOptions struct {
+	xForwardedFor      string
+	xForwardedForPorts sets.Int
+	tcp                AppProfileSettings
+	udp                AppProfileSettings
+	limits             VirtualServerLimits
+}
+
+func newAppProfileOptions(service *corev1.Service) (*appProfileOptions, error) {
+	opts := &appProfileOptions{xForwardedForPorts: sets.NewInt()}
+	annos := service.GetAnnotations()
+	if value := strings.TrimSpace(annos[ProxyProtocolAnnotation]); value != "" {
+		return nil, fmt.Errorf("annotation %s: PROXY protocol is not supported by the application profiles of NSX-T load balancer virtual servers, use annotation %s for HTTP ports instead",
+			ProxyProtocolAnnotation, XForwardedForAnnotation)
+	}
+	switch value := strings.ToUpper(strings.TrimSpace(annos[XForwardedForAnnotation])); value {
+	case "":
+	case model.LBHttpProfile_X_FORWARDED_FOR_INSERT, model.LBHttpProfile_X_FORWARDED_FOR_REPLACE:
+		opts.xForwardedFor = value
+	default:
+		return nil, fmt.Errorf("annotation %s: invalid value %q (expected insert or replace)", XForwardedForAnnotation, annos[XForwardedForAnnotation])
+	}
+	for _, item := range annotationList(service, XForwardedForPortsAnnotation) {
+		port, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: invalid port %q", XForwardedForPortsAnnotation, item)
+		}
+		opts.xForwardedForPorts.Insert(port)
+	}
+	if opts.xForwardedForPorts.Len() == 0 {
+		opts.xForwardedForPorts.Insert(DefaultXForwardedForPort)
+	}
+
+	var err error
+	if opts.tcp.IdleTimeout, err = int64Annotation(annos, TCPIdleTimeoutAnnotation); err != nil {
+		return nil, err
+	}
+	if opts.tcp.CloseTimeout, err = int64Annotation(annos, TCPCloseTimeoutAnnotation); err != nil {
+		return nil, err
+	}
+	if opts.udp.IdleTimeout, err = int64Annotation(annos, UDPIdleTimeoutAnnotation); err != nil {
+		return nil, err
+	}
+	if value, ok := annos[ConnectionMirroringAnnotation]; ok {
+		mirroring, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: invalid value %q", ConnectionMirroringAnnotation, value)
+		}
+		opts.tcp.FlowMirroring = &mirroring
+		opts.udp.FlowMirroring = &mirroring
+	}
+	if opts.limits.MaxConcurrentConnections, err = int64Annotation(annos, MaxConcurrentConnectionsAnnotation); err != nil {
+		return nil, err
+	}
+	if opts.limits.MaxNewConnectionRate, err = int64Annotation(annos, MaxNewConnectionRateAnnotation); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// int64Annotation parses an optional positive integer annotation
+func int64Annotation(annos map[string]string, name string) (*int64, error) {
+	value, ok := annos[name]
+	if !ok {
+		return nil, nil
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || i <= 0 {
+		return nil, fmt.Errorf("annotation %s: invalid value %q (expected positive integer)", name, value)
+	}
+	return &i, nil
+}
+
+// settings returns the shared application profile settings for a port mapping
+func (o *appProfileOptions) settings(mapping Mapping) AppProfileSettings {
+	if o == nil {
+		return AppProfileSettings{}
+	}
+	switch mapping.Protocol {
+	case corev1.ProtocolTCP:
+		return o.tcp
+	case corev1.ProtocolUDP:
+		return o.udp
+	}
+	return AppProfileSettings{}
+}
+
+// virtualServerLimits returns the connection limits requested for the virtual servers
+func (o *appProfileOptions) virtualServerLimits() VirtualServerLimits {
+	if o == nil {
+		return VirtualServerLimits{}
+	}
+	return o.limits
+}
+
+// xForwardedForMode returns the X-Forwarded-For mode for a port mapping or an empty string
+func (o *appProfileOptions) xForwardedForMode(mapping Mapping) string {
+	if o == nil || o.xForwardedFor == "" || mapping.Protocol != corev1.ProtocolTCP {
+		return ""
+	}
+	if !o.xForwardedForPorts.Has(mapping.SourcePort) {
+		return ""
+	}
+	return o.xForwardedFor
+}
+
+// getAppProfilePath returns the application profile for a port mapping. Managed
+// HTTP profiles of the service or shared profiles of the cluster are used or
+// created if requested by annotations, otherwise the profile of the load
+// balancer class is used.
+func (s *state) getAppProfilePath(mapping Mapping) (string, error) {
+	mode := s.appProfileOptions.xForwardedForMode(mapping)
+	if mode == "" {
+		settings := s.appProfileOptions.settings(mapping)
+		if settings.IsEmpty() {
+			return s.access.GetAppProfilePath(s.class, mapping.Protocol)
+		}
+		return s.getSettingsAppProfilePath(mapping.Protocol, settings)
+	}
+	for _, profile := range s.httpProfiles {
+		if safeEquals(profile.XForwardedFor, &mode) {
+			return *profile.Path, nil
+		}
+	}
+	profile, err := s.access.CreateHTTPAppProfile(s.clusterName, s.objectName, mode)
+	if err != nil {
+		return "", err
+	}
+	s.CtxInfof("created LBHttpProfile %s for X-Forwarded-For %s", *profile.Id, mode)
+	s.httpProfiles = append(s.httpProfiles, profile)
+	return *profile.Path, nil
+}
+
+func (s *state) getSettingsAppProfilePath(protocol corev1.Protocol, settings AppProfileSettings) (string, error) {
+	key := settings.Key(protocol)
+	for _, profile := range s.sharedProfiles {
+		if getTag(profile.Tags, ScopeAppProfileSettings) == key {
+			return profile.Path, nil
+		}
+	}
+	profile, err := s.access.CreateSettingsAppProfile(s.clusterName, protocol, settings)
+	if err != nil {
+		return "", err
+	}
+	s.CtxInfof("created shared application profile %s for %s", profile.ID, key)
+	s.sharedProfiles = append(s.sharedProfiles, profile)
+	return profile.Path, nil
+}
+
+// usedSharedProfilePaths returns the paths of the shared application profiles used by the virtual servers
+func (s *state) usedSharedProfilePaths() sets.String {
+	shared := sets.NewString()
+	for _, profile := range s.sharedProfiles {
+		shared.Insert(profile.Path)
+	}
+	used := sets.NewString()
+	for _, server := range s.servers {
+		if server.ApplicationProfilePath != nil && shared.Has(*server.ApplicationProfilePath) {
+			used.Insert(*server.ApplicationProfilePath)
+		}
+	}
+	return used
+}
+
+// deleteUnusedSharedAppProfiles deletes the shared application profiles no longer
+// used by this service if they are not used by any other virtual server. Released
+// virtual servers are not managed anymore, but may still use a shared profile.
+func (s *state) deleteUnusedSharedAppProfiles(previouslyUsed sets.String, validAppProfilePaths sets.String) error {
+	candidates := previouslyUsed.Difference(validAppProfilePaths)
+	if candidates.Len() == 0 {
+		return nil
+	}
+	servers, err := s.access.ListAllVirtualServers()
+	if err != nil {
+		return err
+	}
+	for _, server := range servers {
+		if server.ApplicationProfilePath != nil {
+			candidates.Delete(*server.ApplicationProfilePath)
+		}
+	}
+	for _, profile := range s.sharedProfiles {
+		if candidates.Has(profile.Path) {
+			s.CtxInfof("deleting unused shared application profile %s", profile.ID)
+			err := s.access.DeleteAppProfile(profile.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *state) validAppProfilePaths() sets.String {
+	validAppProfilePaths := sets.NewString()
+	for _, server := range s.servers {
+		for _, servicePort := range s.service.Spec.Ports {
+			if NewMapping(servicePort).MatchVirtualServer(server) && server.ApplicationProfilePath != nil {
+				validAppProfilePaths.Insert(*server.ApplicationProfilePath)
+				break
+			}
+		}
+	}
+	return validAppProfilePaths
+}
+
+func (s *state) deleteOrphanAppProfiles(previouslyUsedShared sets.String) error {
+	validAppProfilePaths := s.validAppProfilePaths()
+	for _, profile := range s.httpProfiles {
+		if profile.Path != nil && validAppProfilePaths.Has(*profile.Path) {
+			continue
+		}
+		s.CtxInfof("deleting LBHttpProfile %s", *profile.Id)
+		err := s.access.DeleteAppProfile(*profile.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return s.deleteUnusedSharedAppProfiles(previouslyUsedShared, validAppProfilePaths)
+}
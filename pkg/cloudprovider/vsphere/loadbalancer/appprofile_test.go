@@ -0,0 +1,166 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+)
+
+func TestAppProfileOptions(t *testing.T) {
+	newService := func(annos map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: annos}}
+	}
+	http := Mapping{SourcePort: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}
+	https := Mapping{SourcePort: 443, NodePort: 30443, Protocol: corev1.ProtocolTCP}
+	alt := Mapping{SourcePort: 8080, NodePort: 30880, Protocol: corev1.ProtocolTCP}
+	dns := Mapping{SourcePort: 53, NodePort: 30053, Protocol: corev1.ProtocolUDP}
+
+	opts, err := newAppProfileOptions(newService(nil))
+	if err != nil || opts.xForwardedForMode(http) != "" {
+		t.Errorf("expected no X-Forwarded-For without annotation: %v", err)
+	}
+
+	opts, err = newAppProfileOptions(newService(map[string]string{XForwardedForAnnotation: "Insert"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.xForwardedForMode(http) != model.LBHttpProfile_X_FORWARDED_FOR_INSERT || opts.xForwardedForMode(https) != "" {
+		t.Errorf("expected X-Forwarded-For insert only for port 80 by default")
+	}
+	if opts.xForwardedForMode(dns) != "" {
+		t.Errorf("expected no X-Forwarded-For for UDP ports")
+	}
+
+	opts, err = newAppProfileOptions(newService(map[string]string{
+		XForwardedForAnnotation:      "replace",
+		XForwardedForPortsAnnotation: "8080",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.xForwardedForMode(alt) != model.LBHttpProfile_X_FORWARDED_FOR_REPLACE || opts.xForwardedForMode(http) != "" || opts.xForwardedForMode(https) != "" {
+		t.Errorf("expected X-Forwarded-For replace only for port 8080")
+	}
+
+	for _, annos := range []map[string]string{
+		{XForwardedForAnnotation: "append"},
+		{XForwardedForAnnotation: "insert", XForwardedForPortsAnnotation: "http"},
+		{ProxyProtocolAnnotation: "v2"},
+	} {
+		if _, err := newAppProfileOptions(newService(annos)); err == nil {
+			t.Errorf("expected error for annotations %v", annos)
+		}
+	}
+}
+
+func TestAppProfileSettings(t *testing.T) {
+	newService := func(annos map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: annos}}
+	}
+	tcp := Mapping{SourcePort: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}
+	udp := Mapping{SourcePort: 53, NodePort: 30053, Protocol: corev1.ProtocolUDP}
+
+	opts, err := newAppProfileOptions(newService(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.settings(tcp).IsEmpty() || !opts.settings(udp).IsEmpty() {
+		t.Errorf("expected no settings without annotations")
+	}
+	if limits := opts.virtualServerLimits(); limits.MaxConcurrentConnections != nil || limits.MaxNewConnectionRate != nil {
+		t.Errorf("expected no limits without annotations")
+	}
+
+	opts, err = newAppProfileOptions(newService(map[string]string{
+		TCPIdleTimeoutAnnotation:           "3600",
+		TCPCloseTimeoutAnnotation:          "10",
+		UDPIdleTimeoutAnnotation:           "60",
+		ConnectionMirroringAnnotation:      "true",
+		MaxConcurrentConnectionsAnnotation: "1000",
+		MaxNewConnectionRateAnnotation:     "100",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := opts.settings(tcp).Key(corev1.ProtocolTCP); key != "TCP/idle=3600/close=10/mirroring=true" {
+		t.Errorf("unexpected TCP settings key %s", key)
+	}
+	if key := opts.settings(udp).Key(corev1.ProtocolUDP); key != "UDP/idle=60/mirroring=true" {
+		t.Errorf("unexpected UDP settings key %s", key)
+	}
+	limits := opts.virtualServerLimits()
+	if *limits.MaxConcurrentConnections != 1000 || *limits.MaxNewConnectionRate != 100 {
+		t.Errorf("unexpected limits %d %d", *limits.MaxConcurrentConnections, *limits.MaxNewConnectionRate)
+	}
+
+	id1 := settingsAppProfileID("owner", "cluster", "TCP/idle=3600")
+	id2 := settingsAppProfileID("owner", "cluster", "TCP/idle=3600")
+	id3 := settingsAppProfileID("owner", "cluster", "TCP/idle=1800")
+	if id1 != id2 || id1 == id3 {
+		t.Errorf("expected stable and distinct profile ids: %s %s %s", id1, id2, id3)
+	}
+
+	for _, annos := range []map[string]string{
+		{TCPIdleTimeoutAnnotation: "-1"},
+		{UDPIdleTimeoutAnnotation: "1m"},
+		{ConnectionMirroringAnnotation: "maybe"},
+		{MaxConcurrentConnectionsAnnotation: "0"},
+	} {
+		if _, err := newAppProfileOptions(newService(annos)); err == nil {
+			t.Errorf("expected error for annotations %v", annos)
+		}
+	}
+}
+
+func TestDeleteUnusedSharedAppProfiles(t *testing.T) {
+	profilePath := "/infra/lb-app-profiles/shared"
+	// a released virtual server has no controller tags anymore
+	broker := &objectBroker{
+		servers: map[string]model.LBVirtualServer{
+			"released": {Id: strptr("released"), ApplicationProfilePath: strptr(profilePath), Tags: []model.Tag{newTag("team", "network")}},
+		},
+	}
+	itf, _ := NewNSXTAccess(broker, &config.LBConfig{})
+	s := &state{
+		access:         itf,
+		clusterName:    "c1",
+		sharedProfiles: []*AppProfile{{ID: "shared", Path: profilePath}},
+	}
+
+	if err := s.deleteUnusedSharedAppProfiles(sets.NewString(profilePath), sets.NewString()); err != nil {
+		t.Fatal(err)
+	}
+	if len(broker.deleted) != 0 {
+		t.Errorf("profile used by released virtual server must not be deleted: %v", broker.deleted)
+	}
+
+	delete(broker.servers, "released")
+	if err := s.deleteUnusedSharedAppProfiles(sets.NewString(profilePath), sets.NewString()); err != nil {
+		t.Fatal(err)
+	}
+	if len(broker.deleted) != 1 || broker.deleted[0] != "shared" {
+		t.Errorf("expected unused profile to be deleted: %v", broker.deleted)
+	}
+}
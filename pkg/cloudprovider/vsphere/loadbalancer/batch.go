@@ -0,0 +1,189 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+)
+
+const (
+	pathLBVirtualServers  = "/infra/lb-virtual-servers/"
+	pathLBPools           = "/infra/lb-pools/"
+	pathLBMonitorProfiles = "/infra/lb-monitor-profiles/"
+)
+
+// batchBroker collects the changes of virtual servers, pools and TCP monitor
+// profiles and applies them with a single hierarchical API call on commit.
+// Identifiers and paths of new objects are assigned on the client side, so that
+// objects created in the same batch can refer to each other.
+// All other calls are passed to the underlying broker immediately.
+type batchBroker struct {
+	NsxtBroker
+	converter *nsxtTypeConverter
+	children  []*data.StructValue
+}
+
+var _ NsxtBroker = &batchBroker{}
+
+func newBatchBroker(broker NsxtBroker) *batchBroker {
+	return &batchBroker{NsxtBroker: broker, converter: newNsxtTypeConverter()}
+}
+
+// Len returns the number of collected changes
+func (b *batchBroker) Len() int {
+	return len(b.children)
+}
+
+// Commit applies all collected changes atomically
+func (b *batchBroker) Commit() error {
+	if len(b.children) == 0 {
+		return nil
+	}
+	children := b.children
+	b.children = nil
+	return b.NsxtBroker.PatchInfra(children)
+}
+
+func (b *batchBroker) add(child interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	var value *data.StructValue
+	switch c := child.(type) {
+	case model.ChildLBVirtualServer:
+		value, err = b.converter.convertChildToStructValue(c, model.ChildLBVirtualServerBindingType())
+	case model.ChildLBPool:
+		value, err = b.converter.convertChildToStructValue(c, model.ChildLBPoolBindingType())
+	case model.ChildLBMonitorProfile:
+		value, err = b.converter.convertChildToStructValue(c, model.ChildLBMonitorProfileBindingType())
+	default:
+		err = errors.Errorf("unsupported child type %T", child)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "converting %T failed", child)
+	}
+	b.children = append(b.children, value)
+	return nil
+}
+
+func (b *batchBroker) CreateLoadBalancerVirtualServer(server model.LBVirtualServer) (model.LBVirtualServer, error) {
+	id := uuid.New().String()
+	server.Id = strptr(id)
+	server.Path = strptr(pathLBVirtualServers + id)
+	return b.UpdateLoadBalancerVirtualServer(server)
+}
+
+func (b *batchBroker) UpdateLoadBalancerVirtualServer(server model.LBVirtualServer) (model.LBVirtualServer, error) {
+	server.ResourceType = strptr("LBVirtualServer")
+	err := b.add(model.ChildLBVirtualServer{
+		LbVirtualServer: &server,
+		ResourceType:    "ChildLBVirtualServer",
+	}, nil)
+	return server, err
+}
+
+func (b *batchBroker) DeleteLoadBalancerVirtualServer(id string) error {
+	return b.add(model.ChildLBVirtualServer{
+		LbVirtualServer: &model.LBVirtualServer{Id: strptr(id), ResourceType: strptr("LBVirtualServer")},
+		ResourceType:    "ChildLBVirtualServer",
+		MarkedForDelete: boolptr(true),
+	}, nil)
+}
+
+func (b *batchBroker) CreateLoadBalancerPool(pool model.LBPool) (model.LBPool, error) {
+	id := uuid.New().String()
+	pool.Id = strptr(id)
+	pool.Path = strptr(pathLBPools + id)
+	return b.UpdateLoadBalancerPool(pool)
+}
+
+func (b *batchBroker) UpdateLoadBalancerPool(pool model.LBPool) (model.LBPool, error) {
+	pool.ResourceType = strptr("LBPool")
+	err := b.add(model.ChildLBPool{
+		LbPool:       &pool,
+		ResourceType: "ChildLBPool",
+	}, nil)
+	return pool, err
+}
+
+func (b *batchBroker) DeleteLoadBalancerPool(id string) error {
+	return b.add(model.ChildLBPool{
+		LbPool:          &model.LBPool{Id: strptr(id), ResourceType: strptr("LBPool")},
+		ResourceType:    "ChildLBPool",
+		MarkedForDelete: boolptr(true),
+	}, nil)
+}
+
+func (b *batchBroker) CreateLoadBalancerTCPMonitorProfile(monitor model.LBTcpMonitorProfile) (model.LBTcpMonitorProfile, error) {
+	id := uuid.New().String()
+	monitor.Id = strptr(id)
+	monitor.Path = strptr(pathLBMonitorProfiles + id)
+	return b.UpdateLoadBalancerTCPMonitorProfile(monitor)
+}
+
+func (b *batchBroker) UpdateLoadBalancerTCPMonitorProfile(monitor model.LBTcpMonitorProfile) (model.LBTcpMonitorProfile, error) {
+	monitor.ResourceType = model.LBMonitorProfile_RESOURCE_TYPE_LBTCPMONITORPROFILE
+	value, err := b.converter.convertLBTCPMonitorProfileToStructValue(monitor)
+	err = b.add(model.ChildLBMonitorProfile{
+		LbMonitorProfile: value,
+		ResourceType:     "ChildLBMonitorProfile",
+	}, err)
+	return monitor, err
+}
+
+// DeleteLoadBalancerMonitorProfile marks a monitor profile for deletion.
+// Only TCP monitor profiles are managed by the controller.
+func (b *batchBroker) DeleteLoadBalancerMonitorProfile(id string) error {
+	monitor := model.LBTcpMonitorProfile{
+		Id:           strptr(id),
+		ResourceType: model.LBMonitorProfile_RESOURCE_TYPE_LBTCPMONITORPROFILE,
+	}
+	value, err := b.converter.convertLBTCPMonitorProfileToStructValue(monitor)
+	return b.add(model.ChildLBMonitorProfile{
+		LbMonitorProfile: value,
+		ResourceType:     "ChildLBMonitorProfile",
+		MarkedForDelete:  boolptr(true),
+	}, err)
+}
+
+// batchAccess is an NSXTAccess using a batchBroker
+type batchAccess struct {
+	*access
+	batch *batchBroker
+}
+
+var _ BatchAccess = &batchAccess{}
+
+func (a *access) BeginBatch() BatchAccess {
+	batch := newBatchBroker(a.broker)
+	batched := *a
+	batched.broker = batch
+	return &batchAccess{access: &batched, batch: batch}
+}
+
+func (a *batchAccess) Commit() error {
+	count := a.batch.Len()
+	err := a.batch.Commit()
+	if err != nil {
+		return errors.Wrapf(err, "applying %d changes with hierarchical API failed", count)
+	}
+	return nil
+}
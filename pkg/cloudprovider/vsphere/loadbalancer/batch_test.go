@@ -0,0 +1,155 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+)
+
+type patchRecorder struct {
+	NsxtBroker
+	patches [][]*data.StructValue
+}
+
+func (r *patchRecorder) PatchInfra(children []*data.StructValue) error {
+	r.patches = append(r.patches, children)
+	return nil
+}
+
+func TestBatchAccess(t *testing.T) {
+	recorder := &patchRecorder{}
+	itf, _ := NewNSXTAccess(recorder, &config.LBConfig{})
+	batch := itf.BeginBatch()
+
+	objectName := types.NamespacedName{Namespace: "ns1", Name: "svc"}
+	mapping := Mapping{SourcePort: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}
+
+	monitor, err := batch.CreateTCPMonitorProfile("c1", objectName, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if monitor.Id == nil || *monitor.Path != pathLBMonitorProfiles+*monitor.Id {
+		t.Errorf("unexpected monitor path %v", monitor.Path)
+	}
+	pool, err := batch.CreatePool("c1", objectName, mapping, nil, []string{*monitor.Path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.Id == nil || *pool.Path != pathLBPools+*pool.Id {
+		t.Errorf("unexpected pool path %v", pool.Path)
+	}
+	server, err := batch.CreateVirtualServer("c1", objectName, &loadBalancerClass{className: "default"}, "10.0.0.1", mapping,
+		"/infra/lb-services/lbs", "/infra/lb-app-profiles/default-tcp-lb-app-profile", pool.Path, VirtualServerLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.Id == nil || *server.Path != pathLBVirtualServers+*server.Id {
+		t.Errorf("unexpected virtual server path %v", server.Path)
+	}
+	if err := batch.DeleteVirtualServer("vs-old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.DeletePool("pool-old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.DeleteTCPMonitorProfile("monitor-old"); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.patches) != 0 {
+		t.Fatalf("no changes expected before commit")
+	}
+
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.patches) != 1 || len(recorder.patches[0]) != 6 {
+		t.Fatalf("expected one patch with 6 children, got %v", recorder.patches)
+	}
+	kinds := []string{"ChildLBMonitorProfile", "ChildLBPool", "ChildLBVirtualServer", "ChildLBVirtualServer", "ChildLBPool", "ChildLBMonitorProfile"}
+	for i, child := range recorder.patches[0] {
+		kind, err := child.String("resource_type")
+		if err != nil || kind != kinds[i] {
+			t.Errorf("child %d: expected %s, got %s (%v)", i, kinds[i], kind, err)
+		}
+		if i >= 3 {
+			deleted, err := child.Field("marked_for_delete")
+			if err != nil || deleted == nil {
+				t.Errorf("child %d: expected marked_for_delete: %v", i, err)
+			}
+		}
+	}
+
+	if err := batch.Commit(); err != nil || len(recorder.patches) != 1 {
+		t.Errorf("empty commit should not send a patch")
+	}
+}
+
+func TestInBatchFallback(t *testing.T) {
+	objectName := types.NamespacedName{Namespace: "ns1", Name: "svc"}
+	broker := &objectBroker{patchErr: fmt.Errorf("patch failed")}
+	itf, _ := NewNSXTAccess(broker, &config.LBConfig{})
+	a := itf.(*access)
+	broker.servers = map[string]model.LBVirtualServer{
+		"vs1": {Id: strptr("vs1"), Tags: a.standardTags.Append(clusterTag("c1"), serviceTag(objectName)).Normalize()},
+	}
+	s := &state{
+		lbService:       &lbService{access: itf, lbServiceID: "lbs", managed: true},
+		access:          itf,
+		clusterName:     "c1",
+		objectName:      objectName,
+		hierarchicalAPI: true,
+	}
+	if err := s.loadBatchedObjects(); err != nil || len(s.servers) != 1 {
+		t.Fatalf("loading virtual servers failed: %v", err)
+	}
+
+	calls := 0
+	err := s.inBatch(func() error {
+		calls++
+		for _, server := range s.servers {
+			if err := s.deleteVirtualServer(server); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(broker.servers) != 0 {
+		t.Errorf("expected deletion with single calls after failed patch: calls=%d, servers=%v", calls, broker.servers)
+	}
+	if s.access != itf {
+		t.Errorf("expected access to be restored")
+	}
+
+	if err := s.removeUnusedLoadBalancerService(); err != nil {
+		t.Fatal(err)
+	}
+	if len(broker.deleted) != 1 || broker.deleted[0] != "lbs" {
+		t.Errorf("expected unused load balancer service to be deleted: %v", broker.deleted)
+	}
+}
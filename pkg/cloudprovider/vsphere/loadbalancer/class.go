@@ -18,6 +18,7 @@ package loadbalancer
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -28,8 +29,14 @@ import (
 )
 
 type loadBalancerClasses struct {
+	lock    sync.RWMutex
 	size    string
 	classes map[string]*loadBalancerClass
+	// static holds the names of the classes of the cloud config, which cannot
+	// be changed at runtime
+	static   map[string]bool
+	defaults *loadBalancerClass
+	resolver *ipPoolResolver
 }
 
 type loadBalancerClass struct {
@@ -46,12 +53,14 @@ func setupClasses(access NSXTAccess, cfg *config.LBConfig) (*loadBalancerClasses
 		return nil, fmt.Errorf("invalid load balancer size %s", cfg.LoadBalancer.Size)
 	}
 
+	resolver := &ipPoolResolver{access: access, knownIPPools: map[string]string{}}
 	lbClasses := &loadBalancerClasses{
-		size:    cfg.LoadBalancer.Size,
-		classes: map[string]*loadBalancerClass{},
+		size:     cfg.LoadBalancer.Size,
+		classes:  map[string]*loadBalancerClass{},
+		static:   map[string]bool{},
+		resolver: resolver,
 	}
 
-	resolver := &ipPoolResolver{access: access, knownIPPools: map[string]string{}}
 	defaultClass, err := newLBClass(config.DefaultLoadBalancerClass, &cfg.LoadBalancer.LoadBalancerClassConfig, nil, resolver)
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid LoadBalancerClass %s", config.DefaultLoadBalancerClass)
@@ -75,11 +84,44 @@ func setupClasses(access NSXTAccess, cfg *config.LBConfig) (*loadBalancerClasses
 		}
 		lbClasses.add(class)
 	}
+	lbClasses.defaults = defaultClass
+	for name := range lbClasses.classes {
+		lbClasses.static[name] = true
+	}
 
 	return lbClasses, nil
 }
 
+// set adds or replaces a class defined at runtime
+func (c *loadBalancerClasses) set(name string, classConfig *config.LoadBalancerClassConfig) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.static[name] {
+		return fmt.Errorf("LoadBalancerClass %s is defined in the cloud config", name)
+	}
+	class, err := newLBClass(name, classConfig, c.defaults, c.resolver)
+	if err != nil {
+		return errors.Wrapf(err, "invalid LoadBalancerClass %s", name)
+	}
+	c.classes[name] = class
+	return nil
+}
+
+// remove removes a class defined at runtime
+func (c *loadBalancerClasses) remove(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.static[name] {
+		delete(c.classes, name)
+	}
+}
+
 func (c *loadBalancerClasses) GetClassNames() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	names := make([]string, 0, len(c.classes))
 	for name := range c.classes {
 		names = append(names, name)
@@ -88,6 +130,9 @@ func (c *loadBalancerClasses) GetClassNames() []string {
 }
 
 func (c *loadBalancerClasses) GetClass(name string) *loadBalancerClass {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	return c.classes[name]
 }
 
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"testing"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+)
+
+func TestSetLoadBalancerClass(t *testing.T) {
+	cfg := &config.LBConfig{
+		LoadBalancer: config.LoadBalancerConfig{
+			Size: model.LBService_SIZE_SMALL,
+			LoadBalancerClassConfig: config.LoadBalancerClassConfig{
+				IPPoolID:          "pool-default",
+				TCPAppProfileName: "tcp-profile",
+				UDPAppProfileName: "udp-profile",
+			},
+		},
+		LoadBalancerClass: map[string]*config.LoadBalancerClassConfig{
+			"public": {IPPoolID: "pool-public"},
+		},
+	}
+	classes, err := setupClasses(nil, cfg)
+	if err != nil {
+		t.Fatalf("setupClasses failed: %s", err)
+	}
+	p := &lbProvider{classes: classes}
+
+	if err := p.SetLoadBalancerClass("public", &config.LoadBalancerClassConfig{IPPoolID: "pool-other"}); err == nil {
+		t.Errorf("replacing a class of the cloud config should fail")
+	}
+	if err := p.SetLoadBalancerClass("private", &config.LoadBalancerClassConfig{IPPoolID: "pool-private"}); err != nil {
+		t.Fatalf("adding a class failed: %s", err)
+	}
+	class := classes.GetClass("private")
+	if class == nil || class.ipPool.Identifier != "pool-private" || class.tcpAppProfile.Name != "tcp-profile" {
+		t.Errorf("class should use its IP pool and inherit the app profiles: %+v", class)
+	}
+	if len(classes.GetClassNames()) != 3 {
+		t.Errorf("expected classes default, public and private but got %v", classes.GetClassNames())
+	}
+
+	p.RemoveLoadBalancerClass("private")
+	p.RemoveLoadBalancerClass("public")
+	if classes.GetClass("private") != nil || classes.GetClass("public") == nil {
+		t.Errorf("only the class added at runtime should be removed: %v", classes.GetClassNames())
+	}
+}
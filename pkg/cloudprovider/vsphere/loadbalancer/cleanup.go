@@ -18,6 +18,10 @@ package loadbalancer
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -27,6 +31,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	klog "k8s.io/klog/v2"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
 )
 
 const maxPeriod = 30 * time.Minute
@@ -45,6 +51,9 @@ const maxPeriod = 30 * time.Minute
 // to identify all elements originally created by this controller. By
 // comparing this set with the actually required objects it is possible
 // to identify those that are orphaned and safely delete them.
+// To protect shared infrastructure against a misconfigured cluster name
+// the cleanup can be run in dry-run mode, where orphaned objects are only
+// reported, and the number of objects deleted in one pass can be limited.
 func (p *lbProvider) cleanup(clusterName string, client clientcorev1.ServiceInterface, stop <-chan struct{}) {
 	timer := time.NewTimer(1 * time.Second)
 	lastErrNext := 0 * time.Second
@@ -55,6 +64,11 @@ func (p *lbProvider) cleanup(clusterName string, client clientcorev1.ServiceInte
 		case <-timer.C:
 			var next time.Duration
 			err := p.doCleanupStep(clusterName, client)
+			if limitErr, ok := err.(*CleanupLimitExceededError); ok {
+				// retrying sooner will not change the decision
+				klog.Warningf("cleanup skipped: %s", limitErr)
+				err = nil
+			}
 			if err == nil {
 				next = maxPeriod
 				lastErrNext = 0
@@ -92,23 +106,139 @@ func (p *lbProvider) doCleanupStep(clusterName string, client clientcorev1.Servi
 	return p.CleanupServices(clusterName, services, false)
 }
 
+// CleanupOptions controls how a cleanup plan is executed
+type CleanupOptions struct {
+	// DryRun only reports the orphaned objects without deleting them
+	DryRun bool
+	// MaxDeletions is the maximum number of objects deleted in one pass (0 means unlimited)
+	MaxDeletions int
+	// Confirmed allows to exceed MaxDeletions
+	Confirmed bool
+}
+
+// CleanupObject describes a single NSX-T object found during cleanup
+type CleanupObject struct {
+	// Kind is the NSX-T resource type
+	Kind string
+	// ID is the NSX-T identifier
+	ID string
+	// DisplayName is the NSX-T display name
+	DisplayName string
+	// Tags are the NSX-T tags of the object
+	Tags []model.Tag
+}
+
+// CleanupPlan contains the NSX-T objects a cleanup pass would delete,
+// grouped by the service they were created for
+type CleanupPlan struct {
+	ClusterName string
+	Orphans     map[types.NamespacedName][]CleanupObject
+	// Unused contains the shared NSX-T objects of the cluster not used by any valid service
+	Unused []CleanupObject
+
+	// managedServices is the number of services with NSX-T objects, valid or not
+	managedServices int
+}
+
+// CleanupLimitExceededError is returned if a cleanup pass would delete more
+// objects than allowed and the deletion has not been confirmed.
+type CleanupLimitExceededError struct {
+	Count int
+	Limit int
+}
+
+func (e *CleanupLimitExceededError) Error() string {
+	return fmt.Sprintf("cleanup would delete %d NSX-T objects, which exceeds the limit of %d; confirm the deletion explicitly or check the cluster name", e.Count, e.Limit)
+}
+
+// Count returns the number of NSX-T objects contained in the plan
+func (p *CleanupPlan) Count() int {
+	count := 0
+	for _, objects := range p.Orphans {
+		count += len(objects)
+	}
+	return count + len(p.Unused)
+}
+
+// Services returns the names of the orphaned services in sorted order
+func (p *CleanupPlan) Services() []types.NamespacedName {
+	names := make([]types.NamespacedName, 0, len(p.Orphans))
+	for name := range p.Orphans {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+	return names
+}
+
+// Print writes a human readable representation of the plan
+func (p *CleanupPlan) Print(w io.Writer) {
+	fmt.Fprintf(w, "cluster %s: %d NSX-T objects of %d orphaned services\n", p.ClusterName, p.Count(), len(p.Orphans))
+	for _, name := range p.Services() {
+		fmt.Fprintf(w, "service %s:\n", name)
+		for _, obj := range p.Orphans[name] {
+			fmt.Fprintf(w, "  %s\n", obj)
+		}
+	}
+	if len(p.Unused) > 0 {
+		fmt.Fprintf(w, "unused shared objects:\n")
+		for _, obj := range p.Unused {
+			fmt.Fprintf(w, "  %s\n", obj)
+		}
+	}
+}
+
+func (o CleanupObject) String() string {
+	tags := make([]string, 0, len(o.Tags))
+	for _, tag := range o.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%s", safeString(tag.Scope), safeString(tag.Tag)))
+	}
+	return fmt.Sprintf("%s %s (%s) [%s]", o.Kind, o.ID, o.DisplayName, strings.Join(tags, ", "))
+}
+
+func (p *CleanupPlan) add(tags []model.Tag, kind string, id, displayName *string) {
+	tag := getTag(tags, ScopeService)
+	if tag == "" {
+		return
+	}
+	name := parseNamespacedName(tag)
+	obj := CleanupObject{Kind: kind, Tags: tags}
+	if id != nil {
+		obj.ID = *id
+	}
+	if displayName != nil {
+		obj.DisplayName = *displayName
+	}
+	p.Orphans[name] = append(p.Orphans[name], obj)
+}
+
+// CleanupServices deletes all NSX-T objects of the cluster not belonging to one of the given valid services
+// using the cleanup options from the configuration.
 func (p *lbProvider) CleanupServices(clusterName string, validServices map[types.NamespacedName]corev1.Service, ensureLBServiceDeleted bool) error {
+	plan, err := p.PlanCleanup(clusterName, validServices)
+	if err != nil {
+		return err
+	}
+	return p.ExecuteCleanup(plan, ensureLBServiceDeleted, p.cleanupOptions)
+}
+
+// PlanCleanup determines the NSX-T objects of the cluster not belonging to one of the given valid services
+func (p *lbProvider) PlanCleanup(clusterName string, validServices map[types.NamespacedName]corev1.Service) (*CleanupPlan, error) {
 	ipPoolIds := sets.NewString()
 	for _, name := range p.classes.GetClassNames() {
 		class := p.classes.GetClass(name)
 		ipPoolIds.Insert(class.ipPool.Identifier)
 	}
 
-	lbs := map[types.NamespacedName]struct{}{}
+	plan := &CleanupPlan{
+		ClusterName: clusterName,
+		Orphans:     map[types.NamespacedName][]CleanupObject{},
+	}
 	servers, err := p.access.ListVirtualServers(clusterName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, server := range servers {
-		tag := getTag(server.Tags, ScopeService)
-		if tag != "" {
-			lbs[parseNamespacedName(tag)] = struct{}{}
-		}
+		plan.add(server.Tags, "LBVirtualServer", server.Id, server.DisplayName)
 		ipPoolID := getTag(server.Tags, ScopeIPPoolID)
 		ipPoolIds.Insert(ipPoolID)
 	}
@@ -116,59 +246,120 @@ func (p *lbProvider) CleanupServices(clusterName string, validServices map[types
 
 	pools, err := p.access.ListPools(clusterName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, pool := range pools {
-		tag := getTag(pool.Tags, ScopeService)
-		if tag != "" {
-			lbs[parseNamespacedName(tag)] = struct{}{}
-		}
+		plan.add(pool.Tags, "LBPool", pool.Id, pool.DisplayName)
 	}
 
 	monitors, err := p.access.ListTCPMonitorProfiles(clusterName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, pool := range monitors {
-		tag := getTag(pool.Tags, ScopeService)
-		if tag != "" {
-			lbs[parseNamespacedName(tag)] = struct{}{}
-		}
+	for _, monitor := range monitors {
+		plan.add(monitor.Tags, "LBTcpMonitorProfile", monitor.Id, monitor.DisplayName)
+	}
+
+	profiles, err := p.access.ListHTTPAppProfiles(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		plan.add(profile.Tags, "LBHttpProfile", profile.Id, profile.DisplayName)
+	}
+
+	sharedProfiles, err := p.access.ListSettingsAppProfiles(clusterName)
+	if err != nil {
+		return nil, err
 	}
 
 	for ipPoolID := range ipPoolIds {
 		ipAddressAllocs, err := p.access.ListExternalIPAddresses(ipPoolID, clusterName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for _, ipAddressAlloc := range ipAddressAllocs {
-			tag := getTag(ipAddressAlloc.Tags, ScopeService)
-			if tag != "" {
-				lbs[parseNamespacedName(tag)] = struct{}{}
-			}
+			plan.add(ipAddressAlloc.Tags, "IpAddressAllocation", ipAddressAlloc.Id, ipAddressAlloc.AllocationIp)
 		}
 	}
 
-	klog.Infof("cleanup: %d existing services, artefacts for %d services", len(validServices), len(lbs))
-	for lb := range lbs {
-		if svc, ok := validServices[lb]; !ok || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
-			service := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: lb.Namespace,
-					Name:      lb.Name,
-				},
-			}
-			klog.Infof("deleting artefacts for non-existing service %s/%s", lb.Namespace, lb.Name)
-			err = p.EnsureLoadBalancerDeleted(context.TODO(), clusterName, service)
-			if err != nil {
-				return err
+	plan.managedServices = len(plan.Orphans)
+	klog.Infof("cleanup: %d existing services, artefacts for %d services", len(validServices), plan.managedServices)
+	for lb := range plan.Orphans {
+		if svc, ok := validServices[lb]; ok && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			delete(plan.Orphans, lb)
+		}
+	}
+
+	// shared profiles are still used by virtual servers of existing services and by
+	// virtual servers not managed by the controller, e.g. released ones
+	orphanServers := sets.NewString()
+	for _, server := range servers {
+		if _, orphan := plan.Orphans[parseNamespacedName(getTag(server.Tags, ScopeService))]; orphan {
+			orphanServers.Insert(*server.Id)
+		}
+	}
+	allServers, err := p.access.ListAllVirtualServers()
+	if err != nil {
+		return nil, err
+	}
+	usedProfilePaths := sets.NewString()
+	for _, server := range allServers {
+		if server.ApplicationProfilePath != nil && !orphanServers.Has(safeString(server.Id)) {
+			usedProfilePaths.Insert(*server.ApplicationProfilePath)
+		}
+	}
+	for _, profile := range sharedProfiles {
+		if !usedProfilePaths.Has(profile.Path) {
+			plan.Unused = append(plan.Unused, CleanupObject{Kind: "LBAppProfile", ID: profile.ID, DisplayName: profile.DisplayName, Tags: profile.Tags})
+		}
+	}
+	return plan, nil
+}
+
+// ExecuteCleanup deletes the NSX-T objects of a cleanup plan
+func (p *lbProvider) ExecuteCleanup(plan *CleanupPlan, ensureLBServiceDeleted bool, opts CleanupOptions) error {
+	if opts.DryRun {
+		klog.Infof("cleanup (dry-run): would delete %d NSX-T objects of %d services", plan.Count(), len(plan.Orphans))
+		for _, lb := range plan.Services() {
+			for _, obj := range plan.Orphans[lb] {
+				klog.Infof("cleanup (dry-run): would delete %s for non-existing service %s", obj, lb)
 			}
 		}
+		for _, obj := range plan.Unused {
+			klog.Infof("cleanup (dry-run): would delete unused %s", obj)
+		}
+		return nil
+	}
+	if count := plan.Count(); opts.MaxDeletions > 0 && count > opts.MaxDeletions && !opts.Confirmed {
+		return &CleanupLimitExceededError{Count: count, Limit: opts.MaxDeletions}
+	}
+
+	for _, lb := range plan.Services() {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: lb.Namespace,
+				Name:      lb.Name,
+			},
+		}
+		klog.Infof("deleting artefacts for non-existing service %s/%s", lb.Namespace, lb.Name)
+		err := p.EnsureLoadBalancerDeleted(context.TODO(), plan.ClusterName, service)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range plan.Unused {
+		klog.Infof("deleting unused shared %s", obj)
+		err := p.access.DeleteAppProfile(obj.ID)
+		if err != nil && !isNotFoundError(err) {
+			return err
+		}
 	}
 
 	// check for orphan unmanaged load balancer service if there are no virtual servers and flag ensureLBServiceDeleted == true
-	if len(lbs) == 0 && ensureLBServiceDeleted {
-		err = p.removeLoadBalancerServiceIfUnused(clusterName)
+	if plan.managedServices == 0 && ensureLBServiceDeleted {
+		err := p.removeLoadBalancerServiceIfUnused(plan.ClusterName)
 		if err != nil && !isNotFoundError(err) {
 			return errors.Wrap(err, "removeLoadBalancerServiceIfUnused failed")
 		}
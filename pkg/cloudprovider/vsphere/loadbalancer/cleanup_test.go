@@ -0,0 +1,135 @@
+/*
+ Copyright 2020 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
+)
+
+func newTestCleanupPlan() *CleanupPlan {
+	plan := &CleanupPlan{
+		ClusterName: "c1",
+		Orphans:     map[types.NamespacedName][]CleanupObject{},
+	}
+	for _, svc := range []string{"ns1/svc-b", "ns1/svc-a"} {
+		tags := []model.Tag{clusterTag("c1"), newTag(ScopeService, svc)}
+		plan.add(tags, "LBVirtualServer", strptr("vs-"+svc), strptr("cluster:c1:"+svc))
+		plan.add(tags, "LBPool", strptr("pool-"+svc), nil)
+	}
+	// objects without service tag are ignored
+	plan.add([]model.Tag{clusterTag("c1")}, "LBPool", strptr("other"), nil)
+	// tags without scope or value are printed empty
+	tags := []model.Tag{clusterTag("c1"), newTag(ScopeService, "ns1/svc-a"), {Tag: strptr("untagged")}, {Scope: strptr("empty")}}
+	plan.add(tags, "LBService", strptr("lbs"), nil)
+	return plan
+}
+
+func TestCleanupPlan(t *testing.T) {
+	plan := newTestCleanupPlan()
+	if plan.Count() != 5 {
+		t.Errorf("expected 5 objects, but found %d", plan.Count())
+	}
+	services := plan.Services()
+	if len(services) != 2 || services[0].String() != "ns1/svc-a" || services[1].String() != "ns1/svc-b" {
+		t.Errorf("unexpected services %v", services)
+	}
+
+	buf := &bytes.Buffer{}
+	plan.Print(buf)
+	out := buf.String()
+	for _, expected := range []string{
+		"cluster c1: 5 NSX-T objects of 2 orphaned services",
+		"LBVirtualServer vs-ns1/svc-a (cluster:c1:ns1/svc-a) [cluster=c1, service=ns1/svc-a]",
+		"LBPool pool-ns1/svc-b () [cluster=c1, service=ns1/svc-b]",
+		"LBService lbs () [cluster=c1, service=ns1/svc-a, =untagged, empty=]",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("missing %q in output:\n%s", expected, out)
+		}
+	}
+}
+
+func TestExecuteCleanupSafety(t *testing.T) {
+	// the provider has no access configured, any deletion attempt would panic
+	p := &lbProvider{}
+	plan := newTestCleanupPlan()
+
+	if err := p.ExecuteCleanup(plan, false, CleanupOptions{DryRun: true}); err != nil {
+		t.Errorf("dry-run failed: %s", err)
+	}
+
+	err := p.ExecuteCleanup(plan, false, CleanupOptions{MaxDeletions: 3})
+	limitErr, ok := err.(*CleanupLimitExceededError)
+	if !ok {
+		t.Fatalf("expected CleanupLimitExceededError, but got %v", err)
+	}
+	if limitErr.Count != 5 || limitErr.Limit != 3 {
+		t.Errorf("unexpected limit error %v", limitErr)
+	}
+}
+
+func TestPlanCleanupSharedProfiles(t *testing.T) {
+	broker := &objectBroker{}
+	itf, _ := NewNSXTAccess(broker, &config.LBConfig{})
+	a := itf.(*access)
+	profilePath := "/infra/lb-app-profiles/shared"
+	profile, err := newNsxtTypeConverter().convertLBFastTCPProfileToStructValue(model.LBFastTcpProfile{
+		Id:           strptr("shared"),
+		Path:         strptr(profilePath),
+		ResourceType: model.LBAppProfile_RESOURCE_TYPE_LBFASTTCPPROFILE,
+		Tags:         a.standardTags.Append(clusterTag("c1"), newTag(ScopeAppProfileSettings, "tcp-idle-60")).Normalize(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker.profiles = append(broker.profiles, profile)
+	broker.servers = map[string]model.LBVirtualServer{
+		"orphan": {
+			Id:                     strptr("orphan"),
+			ApplicationProfilePath: strptr(profilePath),
+			Tags:                   a.standardTags.Append(clusterTag("c1"), serviceTag(types.NamespacedName{Namespace: "ns1", Name: "gone"})).Normalize(),
+		},
+		// a released virtual server has no controller tags anymore
+		"released": {Id: strptr("released"), ApplicationProfilePath: strptr(profilePath), Tags: []model.Tag{newTag("team", "network")}},
+	}
+	p := &lbProvider{lbService: &lbService{access: itf}, classes: &loadBalancerClasses{}}
+
+	plan, err := p.PlanCleanup("c1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Orphans) != 1 || len(plan.Unused) != 0 {
+		t.Errorf("expected orphan virtual server and profile used by released virtual server: %v, %v", plan.Orphans, plan.Unused)
+	}
+
+	delete(broker.servers, "released")
+	plan, err = p.PlanCleanup("c1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Unused) != 1 || plan.Unused[0].ID != "shared" {
+		t.Errorf("expected unused profile: %v", plan.Unused)
+	}
+}
@@ -51,6 +51,9 @@ func (lbc *LBConfigINI) CreateConfig() *LBConfig {
 	cfg.LoadBalancer.Tier1GatewayPath = lbc.LoadBalancer.Tier1GatewayPath
 	cfg.LoadBalancer.SnatDisabled = lbc.LoadBalancer.SnatDisabled
 	cfg.LoadBalancer.AdditionalTags = lbc.LoadBalancer.AdditionalTags
+	cfg.LoadBalancer.CleanupDryRun = lbc.LoadBalancer.CleanupDryRun
+	cfg.LoadBalancer.CleanupMaxDeletions = lbc.LoadBalancer.CleanupMaxDeletions
+	cfg.LoadBalancer.HierarchicalAPI = lbc.LoadBalancer.HierarchicalAPI
 
 	//LoadBalancerClass
 	for key, value := range lbc.LoadBalancerClass {
@@ -87,6 +90,11 @@ func (lbc *LBConfigINI) validateConfig() error {
 		klog.Errorf(msg)
 		return fmt.Errorf(msg)
 	}
+	if lbc.LoadBalancer.CleanupMaxDeletions < 0 {
+		msg := "load balancer cleanupMaxDeletions must not be negative"
+		klog.Errorf(msg)
+		return fmt.Errorf(msg)
+	}
 	if !LoadBalancerSizes.Has(lbc.LoadBalancer.Size) {
 		msg := fmt.Sprintf("load balancer size is invalid. Valid values are: %s", strings.Join(LoadBalancerSizes.List(), ","))
 		klog.Errorf(msg)
@@ -37,6 +37,9 @@ tier1-gateway-path = 1234
 tcp-app-profile-name = default-tcp-lb-app-profile
 udp-app-profile-name = default-udp-lb-app-profile
 snat-disabled = false
+cleanup-dry-run = true
+cleanup-max-deletions = 20
+hierarchical-api = true
 tags = {\"tag1\": \"value1\", \"tag2\": \"value 2\"}
 
 [LoadBalancerClass "public"]
@@ -65,6 +68,9 @@ udp-app-profile-name = udp2
 	assertEquals("LoadBalancer.udpAppProfileName", config.LoadBalancer.UDPAppProfileName, "default-udp-lb-app-profile")
 	assertEquals("LoadBalancer.size", config.LoadBalancer.Size, "MEDIUM")
 	assert.Equal(t, false, config.LoadBalancer.SnatDisabled)
+	assert.Equal(t, true, config.LoadBalancer.CleanupDryRun)
+	assert.Equal(t, 20, config.LoadBalancer.CleanupMaxDeletions)
+	assert.Equal(t, true, config.LoadBalancer.HierarchicalAPI)
 	if len(config.LoadBalancerClass) != 2 {
 		t.Errorf("expected two LoadBalancerClass subsections, but got %d", len(config.LoadBalancerClass))
 	}
@@ -37,6 +37,9 @@ loadBalancer:
   tcpAppProfileName: default-tcp-lb-app-profile
   udpAppProfileName: default-udp-lb-app-profile
   snatDisabled: false
+  cleanupDryRun: true
+  cleanupMaxDeletions: 20
+  hierarchicalAPI: true
   tags:
     tag1: value1
     tag2: value 2
@@ -67,6 +70,9 @@ loadBalancerClass:
 	assertEquals("loadBalancer.udpAppProfileName", config.LoadBalancer.UDPAppProfileName, "default-udp-lb-app-profile")
 	assertEquals("loadBalancer.size", config.LoadBalancer.Size, "MEDIUM")
 	assert.Equal(t, false, config.LoadBalancer.SnatDisabled)
+	assert.Equal(t, true, config.LoadBalancer.CleanupDryRun)
+	assert.Equal(t, 20, config.LoadBalancer.CleanupMaxDeletions)
+	assert.Equal(t, true, config.LoadBalancer.HierarchicalAPI)
 	if len(config.LoadBalancerClass) != 2 {
 		t.Errorf("expected two LoadBalancerClass subsections, but got %d", len(config.LoadBalancerClass))
 	}
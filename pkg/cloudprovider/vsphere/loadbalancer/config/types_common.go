@@ -25,11 +25,14 @@ type LBConfig struct {
 // LoadBalancerConfig contains the configuration for the load balancer itself
 type LoadBalancerConfig struct {
 	LoadBalancerClassConfig
-	Size             string
-	LBServiceID      string
-	Tier1GatewayPath string
-	SnatDisabled     bool
-	AdditionalTags   map[string]string
+	Size                string
+	LBServiceID         string
+	Tier1GatewayPath    string
+	SnatDisabled        bool
+	AdditionalTags      map[string]string
+	CleanupDryRun       bool
+	CleanupMaxDeletions int
+	HierarchicalAPI     bool
 }
 
 // LoadBalancerClassConfig contains the configuration for a load balancer class
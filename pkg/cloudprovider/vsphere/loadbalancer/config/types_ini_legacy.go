@@ -31,6 +31,11 @@ type LoadBalancerConfigINI struct {
 	SnatDisabled     bool   `gcfg:"snat-disabled"`
 	RawTags          string `gcfg:"tags"`
 	AdditionalTags   map[string]string
+
+	CleanupDryRun       bool `gcfg:"cleanup-dry-run"`
+	CleanupMaxDeletions int  `gcfg:"cleanup-max-deletions"`
+
+	HierarchicalAPI bool `gcfg:"hierarchical-api"`
 }
 
 // LoadBalancerClassConfigINI contains the configuration for a load balancer class
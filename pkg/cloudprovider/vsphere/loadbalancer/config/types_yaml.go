@@ -40,6 +40,11 @@ type LoadBalancerConfigYAML struct {
 	SnatDisabled     bool              `yaml:"snatDisabled"`
 	AdditionalTags   map[string]string `yaml:"tags"`
 
+	CleanupDryRun       bool `yaml:"cleanupDryRun"`
+	CleanupMaxDeletions int  `yaml:"cleanupMaxDeletions"`
+
+	HierarchicalAPI bool `yaml:"hierarchicalAPI"`
+
 	// this struct use to inherit from LoadBalancerClassConfigYAML, but the YAML parser
 	// wasnt able to indirectly parse inherited fields
 	IPPoolName        string `yaml:"ipPoolName"`
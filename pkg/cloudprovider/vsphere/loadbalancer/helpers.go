@@ -19,6 +19,7 @@ package loadbalancer
 import (
 	"strings"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -51,9 +52,20 @@ func strptr(s string) *string {
 	return &s
 }
 
+// safeString returns the string pointed to or an empty string for nil.
+func safeString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func isNotFoundError(err error) bool {
-	_, ok := err.(vapi_errors.NotFound)
-	return ok
+	switch errors.Cause(err).(type) {
+	case vapi_errors.NotFound, notFoundError:
+		return true
+	}
+	return false
 }
 
 func boolptr(b bool) *bool {
@@ -70,3 +82,10 @@ func safeEquals(a, b *string) bool {
 	}
 	return *a == *b
 }
+
+func safeEqualsInt64(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -23,6 +23,8 @@ import (
 	cloudprovider "k8s.io/cloud-provider"
 
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
 )
 
 // LBProvider is the interface used call the load balancer functionality
@@ -32,6 +34,15 @@ type LBProvider interface {
 	cloudprovider.LoadBalancer
 	Initialize(clusterName string, client clientset.Interface, stop <-chan struct{})
 	CleanupServices(clusterName string, services map[types.NamespacedName]corev1.Service, ensureLBServiceDeleted bool) error
+	// PlanCleanup determines the orphaned NSX-T objects without deleting them
+	PlanCleanup(clusterName string, services map[types.NamespacedName]corev1.Service) (*CleanupPlan, error)
+	// ExecuteCleanup deletes the objects of a cleanup plan according to the given options
+	ExecuteCleanup(plan *CleanupPlan, ensureLBServiceDeleted bool, opts CleanupOptions) error
+	// SetLoadBalancerClass adds or replaces a load balancer class at runtime.
+	// The classes of the cloud config cannot be replaced.
+	SetLoadBalancerClass(name string, cfg *config.LoadBalancerClassConfig) error
+	// RemoveLoadBalancerClass removes a load balancer class added at runtime
+	RemoveLoadBalancerClass(name string)
 }
 
 // NSXTAccess provides methods for dealing with NSX-T objects
@@ -47,15 +58,23 @@ type NSXTAccess interface {
 
 	// CreateVirtualServer creates a virtual server
 	CreateVirtualServer(clusterName string, objectName types.NamespacedName, class LBClass, ipAddress string, mapping Mapping,
-		lbServicePath, applicationProfilePath string, poolPath *string) (*model.LBVirtualServer, error)
+		lbServicePath, applicationProfilePath string, poolPath *string, limits VirtualServerLimits) (*model.LBVirtualServer, error)
+	// GetVirtualServer gets a virtual server by id
+	GetVirtualServer(id string) (*model.LBVirtualServer, error)
 	// FindVirtualServers finds a virtual server by cluster and object name
 	FindVirtualServers(clusterName string, objectName types.NamespacedName) ([]*model.LBVirtualServer, error)
 	// ListVirtualServers finds all virtual servers for a cluster
 	ListVirtualServers(clusterName string) ([]*model.LBVirtualServer, error)
+	// ListAllVirtualServers lists all virtual servers, including the ones not managed by the controller
+	ListAllVirtualServers() ([]*model.LBVirtualServer, error)
 	// UpdateVirtualServer updates a virtual server
 	UpdateVirtualServer(server *model.LBVirtualServer) error
 	// DeleteVirtualServer deletes a virtual server by id
 	DeleteVirtualServer(id string) error
+	// AdoptVirtualServer tags an existing virtual server to be managed for a service port
+	AdoptVirtualServer(clusterName string, objectName types.NamespacedName, class LBClass, mapping Mapping, server *model.LBVirtualServer) error
+	// UnmanageVirtualServer removes the management tags from a virtual server
+	UnmanageVirtualServer(server *model.LBVirtualServer) error
 
 	// CreatePool creates a LbPool
 	CreatePool(clusterName string, objectName types.NamespacedName, mapping Mapping, members []model.LBPoolMember,
@@ -72,6 +91,10 @@ type NSXTAccess interface {
 	UpdatePool(*model.LBPool) error
 	// DeletePool deletes a LbPool by id
 	DeletePool(id string) error
+	// AdoptPool tags an existing LbPool to be managed for a service port
+	AdoptPool(clusterName string, objectName types.NamespacedName, mapping Mapping, pool *model.LBPool) error
+	// UnmanagePool removes the management tags from a LbPool
+	UnmanagePool(pool *model.LBPool) error
 
 	// FindIPPoolByName finds an IP pool by name
 	FindIPPoolByName(poolName string) (string, error)
@@ -79,6 +102,21 @@ type NSXTAccess interface {
 	// GetAppProfilePath gets the application profile for given loadbalancer class and protocol
 	GetAppProfilePath(class LBClass, protocol corev1.Protocol) (string, error)
 
+	// CreateHTTPAppProfile creates a LBHttpProfile for a service
+	CreateHTTPAppProfile(clusterName string, objectName types.NamespacedName, xForwardedFor string) (*model.LBHttpProfile, error)
+	// FindHTTPAppProfiles finds the LBHttpProfiles by cluster and object name
+	FindHTTPAppProfiles(clusterName string, objectName types.NamespacedName) ([]*model.LBHttpProfile, error)
+	// ListHTTPAppProfiles lists the LBHttpProfiles by cluster
+	ListHTTPAppProfiles(clusterName string) ([]*model.LBHttpProfile, error)
+	// UnmanageHTTPAppProfile removes the management tags from a LBHttpProfile
+	UnmanageHTTPAppProfile(profile *model.LBHttpProfile) error
+	// CreateSettingsAppProfile creates an application profile shared by all services of a cluster with the same settings
+	CreateSettingsAppProfile(clusterName string, protocol corev1.Protocol, settings AppProfileSettings) (*AppProfile, error)
+	// ListSettingsAppProfiles lists the shared application profiles of a cluster
+	ListSettingsAppProfiles(clusterName string) ([]*AppProfile, error)
+	// DeleteAppProfile deletes an application profile by id
+	DeleteAppProfile(id string) error
+
 	// AllocateExternalIPAddress allocates an IP address from the given IP pool
 	AllocateExternalIPAddress(ipPoolID string, clusterName string, objectName types.NamespacedName) (allocation *model.IpAddressAllocation, ipAddress *string, err error)
 	// ListExternalIPAddresses finds all IP addresses belonging to a clusterName from the given IP pool
@@ -87,6 +125,8 @@ type NSXTAccess interface {
 	FindExternalIPAddressForObject(ipPoolID string, clusterName string, objectName types.NamespacedName) (allocation *model.IpAddressAllocation, ipAddress *string, err error)
 	// ReleaseExternalIPAddress releases an allocated IP address
 	ReleaseExternalIPAddress(ipPoolID string, id string) error
+	// UnmanageExternalIPAddress removes the management tags from an IP address allocation without releasing it
+	UnmanageExternalIPAddress(ipPoolID string, allocation *model.IpAddressAllocation) error
 
 	// CreateTCPMonitorProfile creates a LBTcpMonitorProfile
 	CreateTCPMonitorProfile(clusterName string, objectName types.NamespacedName, mapping Mapping) (*model.LBTcpMonitorProfile, error)
@@ -98,6 +138,20 @@ type NSXTAccess interface {
 	UpdateTCPMonitorProfile(monitor *model.LBTcpMonitorProfile) error
 	// DeleteTCPMonitorProfile deletes a LBTcpMonitorProfile by id
 	DeleteTCPMonitorProfile(id string) error
+	// UnmanageTCPMonitorProfile removes the management tags from a LBTcpMonitorProfile
+	UnmanageTCPMonitorProfile(monitor *model.LBTcpMonitorProfile) error
+
+	// BeginBatch returns an access collecting the changes of virtual servers, pools and
+	// TCP monitor profiles until they are committed
+	BeginBatch() BatchAccess
+}
+
+// BatchAccess is a NSXTAccess applying changes of virtual servers, pools and
+// TCP monitor profiles only on commit
+type BatchAccess interface {
+	NSXTAccess
+	// Commit applies all collected changes atomically with a single hierarchical API call
+	Commit() error
 }
 
 // Reference references an object either by identifier or name
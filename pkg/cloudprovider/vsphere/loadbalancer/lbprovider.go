@@ -43,8 +43,11 @@ var (
 
 type lbProvider struct {
 	*lbService
-	classes *loadBalancerClasses
-	keyLock *keyLock
+	classes        *loadBalancerClasses
+	keyLock        *keyLock
+	cleanupOptions CleanupOptions
+	// hierarchicalAPI enables batching of the changes of a service
+	hierarchicalAPI bool
 }
 
 // ClusterName contains the cluster-name flag injected from main, needed for cleanup
@@ -77,9 +80,24 @@ func NewLBProvider(cfg *config.LBConfig, connector client.Connector) (LBProvider
 		lbService: newLbService(access, cfg.LoadBalancer.LBServiceID),
 		classes:   classes,
 		keyLock:   newKeyLock(),
+		cleanupOptions: CleanupOptions{
+			DryRun:       cfg.LoadBalancer.CleanupDryRun,
+			MaxDeletions: cfg.LoadBalancer.CleanupMaxDeletions,
+		},
+		hierarchicalAPI: cfg.LoadBalancer.HierarchicalAPI,
 	}, nil
 }
 
+// SetLoadBalancerClass adds or replaces a load balancer class at runtime
+func (p *lbProvider) SetLoadBalancerClass(name string, cfg *config.LoadBalancerClassConfig) error {
+	return p.classes.set(name, cfg)
+}
+
+// RemoveLoadBalancerClass removes a load balancer class added at runtime
+func (p *lbProvider) RemoveLoadBalancerClass(name string) {
+	p.classes.remove(name)
+}
+
 func (p *lbProvider) Initialize(clusterName string, client clientset.Interface, stop <-chan struct{}) {
 	if clusterName != "" {
 		go p.cleanup(clusterName, client.CoreV1().Services(""), stop)
@@ -130,7 +148,7 @@ func (p *lbProvider) EnsureLoadBalancer(_ context.Context, clusterName string, s
 		return nil, err
 	}
 
-	state := newState(p.lbService, clusterName, service, nodes)
+	state := p.newState(clusterName, service, nodes)
 	err = state.Process(class)
 	status, err2 := state.Finish()
 	if err != nil {
@@ -166,7 +184,7 @@ func (p *lbProvider) UpdateLoadBalancer(_ context.Context, clusterName string, s
 	p.keyLock.Lock(key)
 	defer p.keyLock.Unlock(key)
 
-	state := newState(p.lbService, clusterName, service, nodes)
+	state := p.newState(clusterName, service, nodes)
 
 	return state.UpdatePoolMembers()
 }
@@ -180,8 +198,32 @@ func (p *lbProvider) UpdateLoadBalancer(_ context.Context, clusterName string, s
 // Implementations must treat the *corev1.Service parameter as read-only and not modify it.
 // Parameter 'clusterName' is the name of the cluster as presented to kube-controller-manager
 func (p *lbProvider) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *corev1.Service) error {
+	if releaseOnDelete(service) {
+		return p.releaseLoadBalancer(clusterName, service)
+	}
 	emptyService := service.DeepCopy()
 	emptyService.Spec.Ports = nil
 	_, err := p.EnsureLoadBalancer(ctx, clusterName, emptyService, nil)
 	return err
 }
+
+func (p *lbProvider) newState(clusterName string, service *corev1.Service, nodes []*corev1.Node) *state {
+	state := newState(p.lbService, clusterName, service, nodes)
+	state.hierarchicalAPI = p.hierarchicalAPI
+	return state
+}
+
+// releaseLoadBalancer keeps the NSX-T objects of the service, but removes them from management
+func (p *lbProvider) releaseLoadBalancer(clusterName string, service *corev1.Service) error {
+	key := namespacedNameFromService(service).String()
+	p.keyLock.Lock(key)
+	defer p.keyLock.Unlock(key)
+
+	class, err := p.classFromService(service)
+	if err != nil {
+		return err
+	}
+
+	state := p.newState(clusterName, service, nil)
+	return state.Release(class)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/bindings"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/protocol/client"
+	nsx_policy "github.com/vmware/vsphere-automation-sdk-go/services/nsxt"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/ip_pools"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/realized_state"
@@ -42,6 +43,7 @@ type NsxtBroker interface {
 	UpdateLoadBalancerService(service model.LBService) (model.LBService, error)
 	DeleteLoadBalancerService(id string) error
 	CreateLoadBalancerVirtualServer(server model.LBVirtualServer) (model.LBVirtualServer, error)
+	ReadLoadBalancerVirtualServer(id string) (model.LBVirtualServer, error)
 	ListLoadBalancerVirtualServers() ([]model.LBVirtualServer, error)
 	UpdateLoadBalancerVirtualServer(server model.LBVirtualServer) (model.LBVirtualServer, error)
 	DeleteLoadBalancerVirtualServer(id string) error
@@ -53,18 +55,28 @@ type NsxtBroker interface {
 	ListIPPools() ([]model.IpAddressPool, error)
 	AllocateFromIPPool(ipPoolID string, allocation model.IpAddressAllocation) (model.IpAddressAllocation, string, error)
 	ListIPPoolAllocations(ipPoolID string) ([]model.IpAddressAllocation, error)
+	UpdateIPPoolAllocation(ipPoolID string, allocation model.IpAddressAllocation) error
 	ReleaseFromIPPool(ipPoolID, ipAllocationID string) error
 	GetRealizedExternalIPAddress(ipAllocationPath string, timeout time.Duration) (*string, error)
 	ListAppProfiles() ([]*data.StructValue, error)
+	CreateLoadBalancerHTTPAppProfile(profile model.LBHttpProfile) (model.LBHttpProfile, error)
+	UpdateLoadBalancerHTTPAppProfile(profile model.LBHttpProfile) (model.LBHttpProfile, error)
+	CreateLoadBalancerFastTCPAppProfile(profile model.LBFastTcpProfile) (model.LBFastTcpProfile, error)
+	CreateLoadBalancerFastUDPAppProfile(profile model.LBFastUdpProfile) (model.LBFastUdpProfile, error)
+	DeleteLoadBalancerAppProfile(id string) error
 
 	CreateLoadBalancerTCPMonitorProfile(monitor model.LBTcpMonitorProfile) (model.LBTcpMonitorProfile, error)
 	ListLoadBalancerMonitorProfiles() ([]*data.StructValue, error)
 	ReadLoadBalancerTCPMonitorProfile(id string) (model.LBTcpMonitorProfile, error)
 	UpdateLoadBalancerTCPMonitorProfile(monitor model.LBTcpMonitorProfile) (model.LBTcpMonitorProfile, error)
 	DeleteLoadBalancerMonitorProfile(id string) error
+
+	// PatchInfra applies the given children of the infra root with a single hierarchical API call
+	PatchInfra(children []*data.StructValue) error
 }
 
 type nsxtBroker struct {
+	infraClient             nsx_policy.InfraClient
 	lbServicesClient        infra.LbServicesClient
 	lbVirtServersClient     infra.LbVirtualServersClient
 	lbPoolsClient           infra.LbPoolsClient
@@ -88,6 +100,7 @@ func NewNsxtBroker(connector client.Connector) (NsxtBroker, error) {
 // NewNsxtBrokerFromConnector creates a new NsxtBroker to the real API
 func NewNsxtBrokerFromConnector(connector client.Connector) NsxtBroker {
 	return &nsxtBroker{
+		infraClient:             nsx_policy.NewInfraClient(connector),
 		lbServicesClient:        infra.NewLbServicesClient(connector),
 		lbVirtServersClient:     infra.NewLbVirtualServersClient(connector),
 		lbPoolsClient:           infra.NewLbPoolsClient(connector),
@@ -142,6 +155,11 @@ func (b *nsxtBroker) CreateLoadBalancerVirtualServer(server model.LBVirtualServe
 	return result, nicerVAPIError(err)
 }
 
+func (b *nsxtBroker) ReadLoadBalancerVirtualServer(id string) (model.LBVirtualServer, error) {
+	result, err := b.lbVirtServersClient.Get(id)
+	return result, nicerVAPIError(err)
+}
+
 func (b *nsxtBroker) ListLoadBalancerVirtualServers() ([]model.LBVirtualServer, error) {
 	result, err := b.lbVirtServersClient.List(nil, nil, nil, nil, nil, nil)
 	if err != nil {
@@ -224,6 +242,66 @@ func (b *nsxtBroker) ListAppProfiles() ([]*data.StructValue, error) {
 	return list, nil
 }
 
+func (b *nsxtBroker) CreateLoadBalancerHTTPAppProfile(profile model.LBHttpProfile) (model.LBHttpProfile, error) {
+	id := uuid.New().String()
+	result, err := b.createOrUpdateLoadBalancerHTTPAppProfile(id, profile)
+	return result, nicerVAPIError(err)
+}
+
+func (b *nsxtBroker) createOrUpdateLoadBalancerHTTPAppProfile(id string, profile model.LBHttpProfile) (model.LBHttpProfile, error) {
+	profile.ResourceType = model.LBAppProfile_RESOURCE_TYPE_LBHTTPPROFILE
+	converter := newNsxtTypeConverter()
+	value, err := converter.convertLBHTTPProfileToStructValue(profile)
+	if err != nil {
+		return model.LBHttpProfile{}, errors.Wrapf(err, "converting LBHttpProfile failed")
+	}
+	result, err := b.lbAppProfilesClient.Update(id, value)
+	if err != nil {
+		return model.LBHttpProfile{}, nicerVAPIError(err)
+	}
+	return converter.convertStructValueToLBHTTPProfile(result)
+}
+
+func (b *nsxtBroker) UpdateLoadBalancerHTTPAppProfile(profile model.LBHttpProfile) (model.LBHttpProfile, error) {
+	result, err := b.createOrUpdateLoadBalancerHTTPAppProfile(*profile.Id, profile)
+	return result, nicerVAPIError(err)
+}
+
+// CreateLoadBalancerFastTCPAppProfile creates a LBFastTcpProfile with the id given in the profile
+func (b *nsxtBroker) CreateLoadBalancerFastTCPAppProfile(profile model.LBFastTcpProfile) (model.LBFastTcpProfile, error) {
+	profile.ResourceType = model.LBAppProfile_RESOURCE_TYPE_LBFASTTCPPROFILE
+	converter := newNsxtTypeConverter()
+	value, err := converter.convertLBFastTCPProfileToStructValue(profile)
+	if err != nil {
+		return model.LBFastTcpProfile{}, errors.Wrapf(err, "converting LBFastTcpProfile failed")
+	}
+	result, err := b.lbAppProfilesClient.Update(*profile.Id, value)
+	if err != nil {
+		return model.LBFastTcpProfile{}, nicerVAPIError(err)
+	}
+	return converter.convertStructValueToLBFastTCPProfile(result)
+}
+
+// CreateLoadBalancerFastUDPAppProfile creates a LBFastUdpProfile with the id given in the profile
+func (b *nsxtBroker) CreateLoadBalancerFastUDPAppProfile(profile model.LBFastUdpProfile) (model.LBFastUdpProfile, error) {
+	profile.ResourceType = model.LBAppProfile_RESOURCE_TYPE_LBFASTUDPPROFILE
+	converter := newNsxtTypeConverter()
+	value, err := converter.convertLBFastUDPProfileToStructValue(profile)
+	if err != nil {
+		return model.LBFastUdpProfile{}, errors.Wrapf(err, "converting LBFastUdpProfile failed")
+	}
+	result, err := b.lbAppProfilesClient.Update(*profile.Id, value)
+	if err != nil {
+		return model.LBFastUdpProfile{}, nicerVAPIError(err)
+	}
+	return converter.convertStructValueToLBFastUDPProfile(result)
+}
+
+func (b *nsxtBroker) DeleteLoadBalancerAppProfile(id string) error {
+	err := b.lbAppProfilesClient.Delete(id, nil)
+	return nicerVAPIError(err)
+}
+
 func (b *nsxtBroker) CreateLoadBalancerTCPMonitorProfile(monitor model.LBTcpMonitorProfile) (model.LBTcpMonitorProfile, error) {
 	id := uuid.New().String()
 	result, err := b.createOrUpdateLoadBalancerTCPMonitorProfile(id, monitor)
@@ -279,6 +357,15 @@ func (b *nsxtBroker) DeleteLoadBalancerMonitorProfile(id string) error {
 	return nicerVAPIError(err)
 }
 
+func (b *nsxtBroker) PatchInfra(children []*data.StructValue) error {
+	infra := model.Infra{
+		ResourceType: strptr("Infra"),
+		Children:     children,
+	}
+	err := b.infraClient.Patch(infra, nil)
+	return nicerVAPIError(err)
+}
+
 func (b *nsxtBroker) ListIPPools() ([]model.IpAddressPool, error) {
 	result, err := b.ipPoolsClient.List(nil, nil, nil, nil, nil, nil)
 	if err != nil {
@@ -333,6 +420,11 @@ func (b *nsxtBroker) ListIPPoolAllocations(ipPoolID string) ([]model.IpAddressAl
 	return list, nil
 }
 
+func (b *nsxtBroker) UpdateIPPoolAllocation(ipPoolID string, allocation model.IpAddressAllocation) error {
+	err := b.ipAllocationsClient.Patch(ipPoolID, *allocation.Id, allocation)
+	return nicerVAPIError(err)
+}
+
 func (b *nsxtBroker) ReleaseFromIPPool(ipPoolID, ipAllocationID string) error {
 	err := b.ipAllocationsClient.Delete(ipPoolID, ipAllocationID)
 	return nicerVAPIError(err)
@@ -371,7 +463,7 @@ func nicerVAPIError(err error) error {
 		// Connection errors end up here
 		return nicerVapiErrorData("InvalidRequest", vapiError.Data, vapiError.Messages)
 	case vapi_errors.NotFound:
-		return nicerVapiErrorData("NotFound", vapiError.Data, vapiError.Messages)
+		return notFoundError{nicerVapiErrorData("NotFound", vapiError.Data, vapiError.Messages)}
 	case vapi_errors.Unauthorized:
 		return nicerVapiErrorData("Unauthorized", vapiError.Data, vapiError.Messages)
 	case vapi_errors.Unauthenticated:
@@ -385,6 +477,11 @@ func nicerVAPIError(err error) error {
 	return err
 }
 
+// notFoundError keeps a NotFound error recognizable for isNotFoundError
+type notFoundError struct {
+	error
+}
+
 func nicerVapiErrorData(errorMsg string, apiErrorDataValue *data.StructValue, messages []std.LocalizableMessage) error {
 	if apiErrorDataValue == nil {
 		if len(messages) > 0 {
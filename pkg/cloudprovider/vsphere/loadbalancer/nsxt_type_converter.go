@@ -68,6 +68,72 @@ func (c *nsxtTypeConverter) convertLBTCPMonitorProfileToStructValue(monitor mode
 	return dataValue.(*data.StructValue), nil
 }
 
+func (c *nsxtTypeConverter) convertLBHTTPProfileToStructValue(profile model.LBHttpProfile) (*data.StructValue, error) {
+	dataValue, errs := c.ConvertToVapi(profile, model.LBHttpProfileBindingType())
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	return dataValue.(*data.StructValue), nil
+}
+
+func (c *nsxtTypeConverter) convertStructValueToLBHTTPProfile(dataValue *data.StructValue) (model.LBHttpProfile, error) {
+	itf, errs := c.ConvertToGolang(dataValue, model.LBHttpProfileBindingType())
+	if errs != nil {
+		return model.LBHttpProfile{}, errs[0]
+	}
+
+	profile, ok := itf.(model.LBHttpProfile)
+	if !ok {
+		return model.LBHttpProfile{}, fmt.Errorf("converting struct value to LBHttpProfile failed")
+	}
+	return profile, nil
+}
+
+func (c *nsxtTypeConverter) convertLBFastTCPProfileToStructValue(profile model.LBFastTcpProfile) (*data.StructValue, error) {
+	dataValue, errs := c.ConvertToVapi(profile, model.LBFastTcpProfileBindingType())
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	return dataValue.(*data.StructValue), nil
+}
+
+func (c *nsxtTypeConverter) convertStructValueToLBFastTCPProfile(dataValue *data.StructValue) (model.LBFastTcpProfile, error) {
+	itf, errs := c.ConvertToGolang(dataValue, model.LBFastTcpProfileBindingType())
+	if errs != nil {
+		return model.LBFastTcpProfile{}, errs[0]
+	}
+
+	profile, ok := itf.(model.LBFastTcpProfile)
+	if !ok {
+		return model.LBFastTcpProfile{}, fmt.Errorf("converting struct value to LBFastTcpProfile failed")
+	}
+	return profile, nil
+}
+
+func (c *nsxtTypeConverter) convertLBFastUDPProfileToStructValue(profile model.LBFastUdpProfile) (*data.StructValue, error) {
+	dataValue, errs := c.ConvertToVapi(profile, model.LBFastUdpProfileBindingType())
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	return dataValue.(*data.StructValue), nil
+}
+
+func (c *nsxtTypeConverter) convertStructValueToLBFastUDPProfile(dataValue *data.StructValue) (model.LBFastUdpProfile, error) {
+	itf, errs := c.ConvertToGolang(dataValue, model.LBFastUdpProfileBindingType())
+	if errs != nil {
+		return model.LBFastUdpProfile{}, errs[0]
+	}
+
+	profile, ok := itf.(model.LBFastUdpProfile)
+	if !ok {
+		return model.LBFastUdpProfile{}, fmt.Errorf("converting struct value to LBFastUdpProfile failed")
+	}
+	return profile, nil
+}
+
 func (c *nsxtTypeConverter) convertStructValueToLBTCPMonitorProfile(dataValue *data.StructValue) (model.LBTcpMonitorProfile, error) {
 	itf, errs := c.ConvertToGolang(dataValue, model.LBTcpMonitorProfileBindingType())
 	if errs != nil {
@@ -80,3 +146,12 @@ func (c *nsxtTypeConverter) convertStructValueToLBTCPMonitorProfile(dataValue *d
 	}
 	return profile, nil
 }
+
+func (c *nsxtTypeConverter) convertChildToStructValue(child interface{}, bindingType bindings.BindingType) (*data.StructValue, error) {
+	dataValue, errs := c.ConvertToVapi(child, bindingType)
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	return dataValue.(*data.StructValue), nil
+}
@@ -33,6 +33,7 @@ import (
 
 type state struct {
 	*lbService
+	access         NSXTAccess
 	clusterName    string
 	objectName     types.NamespacedName
 	service        *corev1.Service
@@ -40,14 +41,23 @@ type state struct {
 	servers        []*model.LBVirtualServer
 	pools          []*model.LBPool
 	tcpMonitors    []*model.LBTcpMonitorProfile
+	httpProfiles   []*model.LBHttpProfile
+	sharedProfiles []*AppProfile
 	ipAddressAlloc *model.IpAddressAllocation
 	ipAddress      *string
 	class          *loadBalancerClass
+
+	appProfileOptions *appProfileOptions
+	hierarchicalAPI   bool
+	// serversDeleted is set if virtual servers have been deleted, so that the
+	// load balancer service may be unused now
+	serversDeleted bool
 }
 
 func newState(lbService *lbService, clusterName string, service *corev1.Service, nodes []*corev1.Node) *state {
 	return &state{
 		lbService:   lbService,
+		access:      lbService.access,
 		clusterName: clusterName,
 		service:     service,
 		nodes:       nodes,
@@ -63,37 +73,46 @@ func (s *state) CtxInfof(format string, args ...interface{}) {
 // Process processes a load balancer and ensures that all needed objects are existing
 func (s *state) Process(class *loadBalancerClass) error {
 	var err error
-	s.ipAddressAlloc, s.ipAddress, err = s.access.FindExternalIPAddressForObject(class.ipPool.Identifier, s.clusterName, s.objectName)
+	if len(s.service.Spec.Ports) > 0 {
+		s.appProfileOptions, err = newAppProfileOptions(s.service)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.adopt(class)
 	if err != nil {
 		return err
 	}
-	s.servers, err = s.access.FindVirtualServers(s.clusterName, s.objectName)
+	err = s.load(class)
 	if err != nil {
 		return err
 	}
-	s.pools, err = s.access.FindPools(s.clusterName, s.objectName)
+	previouslyUsedShared := s.usedSharedProfilePaths()
+
+	err = s.inBatch(s.processPorts)
 	if err != nil {
 		return err
 	}
-	s.tcpMonitors, err = s.access.FindTCPMonitorProfiles(s.clusterName, s.objectName)
+	err = s.removeUnusedLoadBalancerService()
 	if err != nil {
 		return err
 	}
-	if len(s.servers) > 0 {
-		className := getTag(s.servers[0].Tags, ScopeLBClass)
-		ipPoolID := getTag(s.servers[0].Tags, ScopeIPPoolID)
-		if class.className != className || class.ipPool.Identifier != ipPoolID {
-			classConfig := &config.LoadBalancerClassConfig{
-				IPPoolID: ipPoolID,
-			}
-			class, err = newLBClass(className, classConfig, class, nil)
-			if err != nil {
-				return err
-			}
-		}
+	return s.deleteOrphanAppProfiles(previouslyUsedShared)
+}
+
+// removeUnusedLoadBalancerService removes the load balancer service after virtual servers
+// have been deleted. In hierarchical API mode the deletions are only applied on commit,
+// so this must not be done on deleting the virtual servers.
+func (s *state) removeUnusedLoadBalancerService() error {
+	if !s.serversDeleted {
+		return nil
 	}
-	s.class = class
+	return s.lbService.removeLoadBalancerServiceIfUnused(s.clusterName)
+}
 
+// processPorts ensures the virtual servers, pools and TCP monitors of the service ports
+// and deletes the orphaned ones
+func (s *state) processPorts() error {
 	for _, servicePort := range s.service.Spec.Ports {
 		mapping := NewMapping(servicePort)
 
@@ -120,13 +139,93 @@ func (s *state) Process(class *loadBalancerClass) error {
 		return err
 	}
 	s.CtxInfof("validTCPMonitorPaths: %v", validTCPMonitorPaths.List())
-	err = s.deleteOrphanTCPMonitors(validTCPMonitorPaths)
+	return s.deleteOrphanTCPMonitors(validTCPMonitorPaths)
+}
+
+// inBatch runs the given function and applies the changes of virtual servers, pools
+// and TCP monitors with a single hierarchical API call if enabled. If the function
+// fails, none of the collected changes is applied. If the hierarchical API call fails,
+// the objects are reloaded and the function is run again with single API calls.
+func (s *state) inBatch(f func() error) error {
+	if !s.hierarchicalAPI {
+		return f()
+	}
+	access := s.access
+	defer func() { s.access = access }()
+	batch := access.BeginBatch()
+	s.access = batch
+	err := f()
+	if err != nil {
+		return err
+	}
+	err = batch.Commit()
+	if err == nil {
+		return nil
+	}
+	klog.Warningf("%s: %s, retrying with single API calls", s.objectName, err)
+	s.access = access
+	err = s.loadBatchedObjects()
 	if err != nil {
 		return err
 	}
+	return f()
+}
+
+// load finds all existing objects of the load balancer
+func (s *state) load(class *loadBalancerClass) error {
+	var err error
+	s.ipAddressAlloc, s.ipAddress, err = s.access.FindExternalIPAddressForObject(class.ipPool.Identifier, s.clusterName, s.objectName)
+	if err != nil {
+		return err
+	}
+	err = s.loadBatchedObjects()
+	if err != nil {
+		return err
+	}
+	s.httpProfiles, err = s.access.FindHTTPAppProfiles(s.clusterName, s.objectName)
+	if err != nil {
+		return err
+	}
+	s.sharedProfiles, err = s.access.ListSettingsAppProfiles(s.clusterName)
+	if err != nil {
+		return err
+	}
+	if len(s.servers) > 0 {
+		className := getTag(s.servers[0].Tags, ScopeLBClass)
+		ipPoolID := getTag(s.servers[0].Tags, ScopeIPPoolID)
+		if class.className != className || class.ipPool.Identifier != ipPoolID {
+			classConfig := &config.LoadBalancerClassConfig{
+				IPPoolID: ipPoolID,
+			}
+			class, err = newLBClass(className, classConfig, class, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.class = class
+	if s.ipAddress == nil && len(s.servers) > 0 {
+		// adopted virtual servers keep their address without allocation
+		s.ipAddress = s.servers[0].IpAddress
+	}
 	return nil
 }
 
+// loadBatchedObjects finds the existing virtual servers, pools and TCP monitors
+func (s *state) loadBatchedObjects() error {
+	var err error
+	s.servers, err = s.access.FindVirtualServers(s.clusterName, s.objectName)
+	if err != nil {
+		return err
+	}
+	s.pools, err = s.access.FindPools(s.clusterName, s.objectName)
+	if err != nil {
+		return err
+	}
+	s.tcpMonitors, err = s.access.FindTCPMonitorProfiles(s.clusterName, s.objectName)
+	return err
+}
+
 func (s *state) deleteOrphanVirtualServers() (sets.String, error) {
 	validPoolPaths := sets.String{}
 	for _, server := range s.servers {
@@ -196,7 +295,7 @@ func (s *state) deleteOrphanTCPMonitors(validTCPMonitorPaths sets.String) error
 }
 
 func (s *state) allocateResources() (allocated bool, err error) {
-	if s.ipAddressAlloc == nil {
+	if s.ipAddressAlloc == nil && s.ipAddress == nil {
 		ipPoolID := s.class.ipPool.Identifier
 		s.ipAddressAlloc, s.ipAddress, err = s.access.AllocateExternalIPAddress(ipPoolID, s.clusterName, s.objectName)
 		if err != nil {
@@ -309,18 +408,20 @@ func (s *state) UpdatePoolMembers() error {
 	if err != nil {
 		return err
 	}
-	for _, servicePort := range s.service.Spec.Ports {
-		mapping := NewMapping(servicePort)
-		for _, pool := range pools {
-			if mapping.MatchPool(pool) {
-				err = s.updatePool(pool, mapping, pool.ActiveMonitorPaths)
-				if err != nil {
-					return err
+	return s.inBatch(func() error {
+		for _, servicePort := range s.service.Spec.Ports {
+			mapping := NewMapping(servicePort)
+			for _, pool := range pools {
+				if mapping.MatchPool(pool) {
+					err := s.updatePool(pool, mapping, pool.ActiveMonitorPaths)
+					if err != nil {
+						return err
+					}
 				}
 			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (s *state) updatePool(pool *model.LBPool, mapping Mapping, activeMonitorPaths []string) error {
@@ -404,13 +505,13 @@ func (s *state) createVirtualServer(mapping Mapping, poolPath *string) (*model.L
 		return nil, errors.Wrapf(err, "get or create LBService failed")
 	}
 
-	applicationProfilePath, err := s.access.GetAppProfilePath(s.class, mapping.Protocol)
+	applicationProfilePath, err := s.getAppProfilePath(mapping)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Lookup of application profile failed for %s", mapping.Protocol)
 	}
 
 	server, err := s.access.CreateVirtualServer(s.clusterName, s.objectName, s.class, *s.ipAddress, mapping,
-		lbServicePath, applicationProfilePath, poolPath)
+		lbServicePath, applicationProfilePath, poolPath, s.appProfileOptions.virtualServerLimits())
 	if err != nil {
 		if allocated {
 			s.loggedReleaseResources()
@@ -423,12 +524,17 @@ func (s *state) createVirtualServer(mapping Mapping, poolPath *string) (*model.L
 }
 
 func (s *state) updateVirtualServer(server *model.LBVirtualServer, mapping Mapping, poolPath *string) error {
-	applicationProfilePath, err := s.access.GetAppProfilePath(s.class, mapping.Protocol)
+	applicationProfilePath, err := s.getAppProfilePath(mapping)
 	if err != nil {
 		return errors.Wrapf(err, "Lookup of application profile failed for %s", mapping.Protocol)
 	}
-	if !mapping.MatchNodePort(server) || !safeEquals(server.PoolPath, poolPath) || !safeEquals(server.ApplicationProfilePath, &applicationProfilePath) {
+	limits := s.appProfileOptions.virtualServerLimits()
+	if !mapping.MatchNodePort(server) || !safeEquals(server.PoolPath, poolPath) || !safeEquals(server.ApplicationProfilePath, &applicationProfilePath) ||
+		!safeEqualsInt64(server.MaxConcurrentConnections, limits.MaxConcurrentConnections) ||
+		!safeEqualsInt64(server.MaxNewConnectionRate, limits.MaxNewConnectionRate) {
 		server.ApplicationProfilePath = strptr(applicationProfilePath)
+		server.MaxConcurrentConnections = limits.MaxConcurrentConnections
+		server.MaxNewConnectionRate = limits.MaxNewConnectionRate
 		server.DefaultPoolMemberPorts = []string{formatPort(mapping.NodePort)}
 		server.PoolPath = poolPath
 		s.CtxInfof("updating LbVirtualServer %s for %s", *server.Id, mapping)
@@ -450,5 +556,6 @@ func (s *state) deleteVirtualServer(server *model.LBVirtualServer) error {
 	if err != nil {
 		return err
 	}
-	return s.lbService.removeLoadBalancerServiceIfUnused(s.clusterName)
+	s.serversDeleted = true
+	return nil
 }
@@ -94,8 +94,8 @@ outer:
 
 func getTag(tags []model.Tag, scope string) string {
 	for _, tag := range tags {
-		if *tag.Scope == scope {
-			return *tag.Tag
+		if tag.Scope != nil && *tag.Scope == scope {
+			return safeString(tag.Tag)
 		}
 	}
 	return ""
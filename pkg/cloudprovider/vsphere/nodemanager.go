@@ -18,14 +18,12 @@ package vsphere
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
 	"sort"
 	"strings"
 
-	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
 	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
 	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
@@ -52,15 +50,6 @@ var (
 	ErrVMNotFound = errors.New("VM not found")
 )
 
-type cloudInitConfig struct {
-	Network struct {
-		Ethernets map[string]struct {
-			Name      string   `yaml:"set-name"`
-			Addresses []string `yaml:"addresses"`
-		} `yaml:"ethernets"`
-	} `yaml:"network"`
-}
-
 func newNodeManager(cfg *ccfg.CPIConfig, cm *cm.ConnectionManager) *NodeManager {
 	return &NodeManager{
 		nodeNameMap:       make(map[string]*NodeInfo),
@@ -103,6 +92,39 @@ func (nm *NodeManager) addNodeInfo(node *NodeInfo) {
 	nm.nodeInfoLock.Unlock()
 }
 
+// forgetVCenter removes the discovered nodes of the vCenter of tenantRef, so
+// that they are discovered again once it was replaced or removed
+func (nm *NodeManager) forgetVCenter(tenantRef string) {
+	nm.nodeInfoLock.Lock()
+	defer nm.nodeInfoLock.Unlock()
+
+	for name, node := range nm.nodeNameMap {
+		if node.tenantRef == tenantRef {
+			delete(nm.nodeNameMap, name)
+		}
+	}
+	for uuid, node := range nm.nodeUUIDMap {
+		if node.tenantRef == tenantRef {
+			delete(nm.nodeUUIDMap, uuid)
+		}
+	}
+	for address, vc := range nm.vcList {
+		for name, dc := range vc.dcList {
+			for uuid, node := range dc.vmList {
+				if node.tenantRef == tenantRef {
+					delete(dc.vmList, uuid)
+				}
+			}
+			if len(dc.vmList) == 0 {
+				delete(vc.dcList, name)
+			}
+		}
+		if len(vc.dcList) == 0 {
+			delete(nm.vcList, address)
+		}
+	}
+}
+
 func (nm *NodeManager) addNode(uuid string, node *v1.Node) {
 	nm.nodeRegInfoLock.Lock()
 	klog.V(4).Info("addNode NodeName: ", node.GetName(), ", UID: ", uuid)
@@ -181,6 +203,8 @@ func (nm *NodeManager) shakeOutNodeIDLookup(ctx context.Context, nodeID string,
 type ipAddrNetworkName struct {
 	ipAddr      string
 	networkName string
+	macAddress  string
+	deviceKey   int32
 }
 
 func (c *ipAddrNetworkName) ip() net.IP {
@@ -227,7 +251,7 @@ func (nm *NodeManager) DiscoverNode(nodeID string, searchBy cm.FindVM) error {
 	if vmDI.TenantRef != "" {
 		tenantRef = vmDI.TenantRef
 	}
-	vcInstance := nm.connectionManager.VsphereInstanceMap[tenantRef]
+	vcInstance := nm.connectionManager.Instances()[tenantRef]
 
 	ipFamilies := []string{vcfg.DefaultIPFamily}
 	if vcInstance != nil {
@@ -242,26 +266,31 @@ func (nm *NodeManager) DiscoverNode(nodeID string, searchBy cm.FindVM) error {
 	var excludeExternalNetworkSubnets []*net.IPNet
 	var internalVMNetworkName string
 	var externalVMNetworkName string
+	var addressRules []*addressRule
 
-	if nm.cfg != nil {
-		internalNetworkSubnets, err = parseCIDRs(nm.cfg.Nodes.InternalNetworkSubnetCIDR)
+	if nodes := nm.nodes(); nodes != nil {
+		internalNetworkSubnets, err = parseCIDRs(nodes.InternalNetworkSubnetCIDR)
+		if err != nil {
+			return err
+		}
+		externalNetworkSubnets, err = parseCIDRs(nodes.ExternalNetworkSubnetCIDR)
 		if err != nil {
 			return err
 		}
-		externalNetworkSubnets, err = parseCIDRs(nm.cfg.Nodes.ExternalNetworkSubnetCIDR)
+		excludeInternalNetworkSubnets, err = parseCIDRs(nodes.ExcludeInternalNetworkSubnetCIDR)
 		if err != nil {
 			return err
 		}
-		excludeInternalNetworkSubnets, err = parseCIDRs(nm.cfg.Nodes.ExcludeInternalNetworkSubnetCIDR)
+		excludeExternalNetworkSubnets, err = parseCIDRs(nodes.ExcludeExternalNetworkSubnetCIDR)
 		if err != nil {
 			return err
 		}
-		excludeExternalNetworkSubnets, err = parseCIDRs(nm.cfg.Nodes.ExcludeExternalNetworkSubnetCIDR)
+		internalVMNetworkName = nodes.InternalVMNetworkName
+		externalVMNetworkName = nodes.ExternalVMNetworkName
+		addressRules, err = newAddressRules(nodes.AddressPolicy)
 		if err != nil {
 			return err
 		}
-		internalVMNetworkName = nm.cfg.Nodes.InternalVMNetworkName
-		externalVMNetworkName = nm.cfg.Nodes.ExternalVMNetworkName
 	}
 
 	addrs := []v1.NodeAddress{}
@@ -305,9 +334,24 @@ func (nm *NodeManager) DiscoverNode(nodeID string, searchBy cm.FindVM) error {
 
 	sortedNonLocalhostIPs, err := sortStaticallyConfiguredAddressesFirst(oVM.Config.ExtraConfig, nonLocalhostIPs)
 	if err != nil {
-		klog.Errorf("Error sorting statically configured addresses for vm=%+v in vc=%s and datacenter=%s: %v",
+		// the guestinfo only gives a hint on the order of the addresses
+		klog.Warningf("Ignoring guestinfo network config of vm=%+v in vc=%s and datacenter=%s: %v",
 			vmDI.VM, vmDI.VcServer, vmDI.DataCenter.Name(), err)
-		return err
+		sortedNonLocalhostIPs = nonLocalhostIPs
+	}
+
+	if len(addressRules) > 0 {
+		policyAddrs := applyAddressPolicy(addressRules, sortedNonLocalhostIPs, ipFamilies,
+			oVM.Guest.HostName, guestDNSDomains(oVM.Guest.IpStack))
+		if !hasIPAddress(policyAddrs) {
+			klog.V(4).Infof("oVM.Guest.Net=%v", oVM.Guest.Net)
+			return fmt.Errorf("unable to find suitable IP address for node %s with address policy", nodeID)
+		}
+		for _, addr := range policyAddrs {
+			v1helper.AddToNodeAddresses(&addrs, addr)
+		}
+		// the address policy replaces the discovery by subnets and network names
+		ipFamilies = nil
 	}
 
 	for _, ipFamily := range ipFamilies {
@@ -362,8 +406,14 @@ func (nm *NodeManager) DiscoverNode(nodeID string, searchBy cm.FindVM) error {
 		os,
 	)
 
+	networkAddrs := make(map[string][]string)
+	for _, v := range sortedNonLocalhostIPs {
+		networkAddrs[v.networkName] = append(networkAddrs[v.networkName], v.ipAddr)
+	}
+
 	nodeInfo := &NodeInfo{tenantRef: tenantRef, dataCenter: vmDI.DataCenter, vm: vmDI.VM, vcServer: vmDI.VcServer,
-		UUID: vmDI.UUID, NodeName: vmDI.NodeName, NodeType: instanceType, NodeAddresses: addrs}
+		UUID: vmDI.UUID, NodeName: vmDI.NodeName, NodeType: instanceType, NodeAddresses: addrs,
+		NetworkAddresses: networkAddrs}
 	nm.addNodeInfo(nodeInfo)
 
 	return nil
@@ -472,6 +522,28 @@ func collectNonVNICDevices(guestNicInfos []types.GuestNicInfo) []types.GuestNicI
 	return toReturn
 }
 
+// SetNodeNetworkPolicy replaces the node networking of the cloud config, or
+// restores it if nodes is nil. The addresses of the nodes are selected again
+// on their next discovery, which every address lookup does.
+func (nm *NodeManager) SetNodeNetworkPolicy(nodes *ccfg.Nodes) {
+	nm.nodesLock.Lock()
+	defer nm.nodesLock.Unlock()
+	nm.nodesOverride = nodes
+}
+
+// nodes returns the node networking in use, nil if there is none.
+func (nm *NodeManager) nodes() *ccfg.Nodes {
+	nm.nodesLock.RLock()
+	defer nm.nodesLock.RUnlock()
+	if nm.nodesOverride != nil {
+		return nm.nodesOverride
+	}
+	if nm.cfg != nil {
+		return &nm.cfg.Nodes
+	}
+	return nil
+}
+
 // parseCIDRs converts a comma delimited string of CIDRs to
 // a slice of IPNet pointers.
 func parseCIDRs(cidrsString string) ([]*net.IPNet, error) {
@@ -495,7 +567,8 @@ func toIPAddrNetworkNames(guestNicInfos []types.GuestNicInfo) []*ipAddrNetworkNa
 	var candidates []*ipAddrNetworkName
 	for _, v := range guestNicInfos {
 		for _, ip := range v.IpAddress {
-			candidates = append(candidates, &ipAddrNetworkName{ipAddr: ip, networkName: v.Network})
+			candidates = append(candidates, &ipAddrNetworkName{ipAddr: ip, networkName: v.Network,
+				macAddress: v.MacAddress, deviceKey: v.DeviceConfigId})
 		}
 	}
 	return candidates
@@ -662,6 +735,17 @@ func (nm *NodeManager) FindNodeInfo(UUID string) (*NodeInfo, error) {
 	return nodeInfo, nil
 }
 
+// NodeNetworkAddresses returns the IP addresses of the VM of a node by network name
+func (nm *NodeManager) NodeNetworkAddresses(nodeName string) map[string][]string {
+	nm.nodeInfoLock.RLock()
+	defer nm.nodeInfoLock.RUnlock()
+
+	if nodeInfo, ok := nm.nodeNameMap[nodeName]; ok {
+		return nodeInfo.NetworkAddresses
+	}
+	return nil
+}
+
 func (nm *NodeManager) getNodeNameByUUID(UUID string) string {
 	for k, v := range nm.nodeNameMap {
 		if v.UUID == UUID {
@@ -672,58 +756,42 @@ func (nm *NodeManager) getNodeNameByUUID(UUID string) string {
 	return ""
 }
 
-func guestInfoMetadata(extraConfig []types.BaseOptionValue) (string, string) {
-	var guestInfo, encoding string
-	for _, option := range extraConfig {
-		value := option.GetOptionValue()
-		switch value.Key {
-		case "guestinfo.metadata":
-			guestInfo, _ = value.Value.(string)
-		case "guestinfo.metadata.encoding":
-			encoding, _ = value.Value.(string)
-		}
-	}
-	return guestInfo, encoding
-}
-
-// sortStaticallyConfiguredAddressesFirst prefers addresses that are from the
-// guestInfo but only if they are on a NIC already. It preserves the order in which
-// the addresses appear in the guestInfo. For addresses not found in the guestInfo,
-// it preserves the order in which they appear in nonlocalhostIPs.
+// sortStaticallyConfiguredAddressesFirst prefers addresses that are statically
+// configured in the guestinfo network config but only if they are on a NIC already,
+// and on the NIC with the MAC address of the configured interface if known. This
+// covers the addresses of ethernets, bonds, VLANs and bridges. It preserves the
+// order in which the addresses appear in the guestInfo. For addresses not found
+// in the guestInfo, it preserves the order in which they appear in nonlocalhostIPs.
 func sortStaticallyConfiguredAddressesFirst(extraConfig []types.BaseOptionValue, nonLocalhostIPs []*ipAddrNetworkName) ([]*ipAddrNetworkName, error) {
-	guestInfo, encoding := guestInfoMetadata(extraConfig)
-
-	if guestInfo != "" && encoding == "base64" {
-		value, err := base64.StdEncoding.DecodeString(guestInfo)
-		if err != nil {
-			return nil, err
-		}
-
-		guestInfo := &cloudInitConfig{}
-		err = yaml.Unmarshal(value, guestInfo)
-		if err != nil {
-			return nil, err
-		}
+	staticAddresses, err := guestInfoStaticAddresses(extraConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(staticAddresses) == 0 {
+		return nonLocalhostIPs, nil
+	}
 
-		// Map of guestInfo IP -> index that describes the order they appear in the guestInfo
-		guestInfoAddresses := make(map[string]int)
-		for _, eth := range guestInfo.Network.Ethernets {
-			for _, address := range eth.Addresses {
-				ip := net.ParseIP(strings.Split(address, "/")[0])
-				guestInfoAddresses[ip.String()] = len(guestInfoAddresses)
+	// Map of nonLocalhostIPs -> index of the first matching address in the guestInfo
+	guestInfoIndex := make(map[*ipAddrNetworkName]int)
+	for _, candidate := range nonLocalhostIPs {
+		for i := range staticAddresses {
+			if staticAddresses[i].matches(candidate) {
+				klog.V(4).Infof("IP %s is statically configured for interface %q", candidate.ipAddr, staticAddresses[i].iface)
+				guestInfoIndex[candidate] = i
+				break
 			}
 		}
+	}
 
-		// Sort nonlocalhostIPs by the following comparator for two IP addresses: a and b
-		// if a is statically configured, but b is not then a should be prioritized before b
-		// if b is statically configured, but a is not then a should not be prioritized before b
-		// if a and b are both statically configured, then use the index from the guest info
-		sort.SliceStable(nonLocalhostIPs, func(i, j int) bool {
-			aIndex, aFound := guestInfoAddresses[nonLocalhostIPs[i].ipAddr]
-			bIndex, bFound := guestInfoAddresses[nonLocalhostIPs[j].ipAddr]
+	// Sort nonlocalhostIPs by the following comparator for two IP addresses: a and b
+	// if a is statically configured, but b is not then a should be prioritized before b
+	// if b is statically configured, but a is not then a should not be prioritized before b
+	// if a and b are both statically configured, then use the index from the guest info
+	sort.SliceStable(nonLocalhostIPs, func(i, j int) bool {
+		aIndex, aFound := guestInfoIndex[nonLocalhostIPs[i]]
+		bIndex, bFound := guestInfoIndex[nonLocalhostIPs[j]]
 
-			return aFound && !bFound || aFound && bFound && aIndex < bIndex
-		})
-	}
+		return aFound && !bFound || aFound && bFound && aIndex < bIndex
+	})
 	return nonLocalhostIPs, nil
 }
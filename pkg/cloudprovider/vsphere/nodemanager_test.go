@@ -21,16 +21,23 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/simulator"
 	vimtypes "github.com/vmware/govmomi/vim25/types"
 	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
 	cm "k8s.io/cloud-provider-vsphere/pkg/common/connectionmanager"
 	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
 )
@@ -241,6 +248,68 @@ func TestDiscoverNodeIPs(t *testing.T) {
 		expectedErrorSubstring string
 	}{
 
+		{
+			testName: "ByAddressPolicy",
+			setup: testSetup{
+				ipFamilyPriority: []string{"ipv4", "ipv6"},
+				cpiConfig: &ccfg.CPIConfig{
+					Nodes: ccfg.Nodes{
+						// ignored in favor of the address policy
+						InternalNetworkSubnetCIDR: "172.15.0.0/16",
+						AddressPolicy: []ccfg.AddressRule{
+							{Type: "InternalIP", PortGroups: []string{"internal_net"}, Multiple: true},
+							{Type: "ExternalIP", MACPrefixes: []string{"00:0c:29"}},
+						},
+					},
+				},
+				networks: []vimtypes.GuestNicInfo{
+					{
+						Network:    "internal_net",
+						MacAddress: "00:50:56:00:00:01",
+						IpAddress: []string{
+							"127.0.0.6",
+							"10.10.1.22",
+							"10.10.1.23",
+							"fd00:10::22",
+						},
+					},
+					{
+						Network:    "external_net",
+						MacAddress: "00:0C:29:00:00:02",
+						IpAddress: []string{
+							"172.15.108.10",
+							"172.15.108.11",
+						},
+					},
+				},
+			},
+			expectedIPs: []v1.NodeAddress{
+				{Type: "InternalIP", Address: "10.10.1.22"},
+				{Type: "InternalIP", Address: "10.10.1.23"},
+				{Type: "InternalIP", Address: "fd00:10::22"},
+				{Type: "ExternalIP", Address: "172.15.108.10"},
+			},
+		},
+		{
+			testName: "ByAddressPolicyWithoutMatch",
+			setup: testSetup{
+				ipFamilyPriority: []string{"ipv4"},
+				cpiConfig: &ccfg.CPIConfig{
+					Nodes: ccfg.Nodes{
+						AddressPolicy: []ccfg.AddressRule{
+							{Type: "InternalIP", PortGroups: []string{"internal_net"}},
+						},
+					},
+				},
+				networks: []vimtypes.GuestNicInfo{
+					{
+						Network:   "net_123abc",
+						IpAddress: []string{"10.10.1.22"},
+					},
+				},
+			},
+			expectedErrorSubstring: "unable to find suitable IP address for node",
+		},
 		{
 			testName: "BySubnet",
 			setup: testSetup{
@@ -1761,19 +1830,24 @@ func TestDiscoverNodeIPs(t *testing.T) {
 			},
 		},
 		{
-			testName: "StaticAddresses_errorsOnInvalidGuestInfoFormat",
+			testName: "StaticAddresses_ignoresInvalidGuestInfoFormat",
 			setup: testSetup{
-				guestinfo: "not-valid-yaml this should error",
+				ipFamilyPriority: []string{"ipv4"},
+				guestinfo:        "not-valid-yaml this should be ignored",
 				networks: []vimtypes.GuestNicInfo{
 					{
 						Network: "VM Network",
 						IpAddress: []string{
 							"192.168.1.10",
+							"192.168.1.12",
 						},
 					},
 				},
 			},
-			expectedErrorSubstring: "cannot unmarshal",
+			expectedIPs: []v1.NodeAddress{
+				{Type: "InternalIP", Address: "192.168.1.10"},
+				{Type: "ExternalIP", Address: "192.168.1.10"},
+			},
 		},
 	}
 
@@ -2165,3 +2239,116 @@ network:
       dhcp6: false`,
 		addresses)
 }
+
+func TestDiscoveredNodeAfterCredentialRotation(t *testing.T) {
+	cfg, cleanup := configFromSim(false)
+	defer cleanup()
+
+	dir := t.TempDir()
+	writeSecret := func(password string) {
+		t.Helper()
+		files := map[string]string{
+			cfg.Global.VCenterIP + ".username": cfg.Global.User,
+			cfg.Global.VCenterIP + ".password": password,
+		}
+		for name, value := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeSecret(cfg.Global.Password)
+	cfg.Global.SecretsDirectory = dir
+	cfg.VirtualCenter[cfg.Global.VCenterIP].SecretRef = vcfg.DefaultCredentialManager
+
+	connMgr := cm.NewConnectionManager(cfg, nil, nil)
+	defer connMgr.Logout()
+	nm := newNodeManager(nil, connMgr)
+
+	ctx := context.Background()
+	vsi := connMgr.Instances()[cfg.Global.VCenterIP]
+	if err := connMgr.Connect(ctx, vsi); err != nil {
+		t.Fatalf("Failed to Connect to vSphere: %s", err)
+	}
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vm.Guest.HostName = strings.ToLower(vm.Name)
+	vm.Guest.Net = []vimtypes.GuestNicInfo{{Network: "foo-bar", IpAddress: []string{"10.0.0.1"}}}
+	if err := nm.DiscoverNode(vm.Config.Uuid, cm.FindVMByUUID); err != nil {
+		t.Fatalf("Failed DiscoverNode: %s", err)
+	}
+	nodeInfo := nm.nodeUUIDMap[strings.ToLower(vm.Config.Uuid)]
+	if nodeInfo == nil {
+		t.Fatal("Expected the node to be discovered")
+	}
+
+	sessionKey := func() string {
+		connMgr.Lock()
+		defer connMgr.Unlock()
+		userSession, err := session.NewManager(vsi.Conn.Client).UserSession(ctx)
+		if err != nil || userSession == nil {
+			return ""
+		}
+		return userSession.Key
+	}
+	initialSession := sessionKey()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	connMgr.WatchCredentials(stop)
+
+	// the cached VM keeps working once the rotated credentials are used
+	writeSecret("rotated")
+	err := wait.PollImmediate(50*time.Millisecond, 10*time.Second, func() (bool, error) {
+		key := sessionKey()
+		return key != "" && key != initialSession, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	connMgr.Lock()
+	defer connMgr.Unlock()
+	if _, err := nodeInfo.vm.IsActive(ctx); err != nil {
+		t.Errorf("Expected the cached VM to use the new session: %s", err)
+	}
+	if _, err := nodeInfo.dataCenter.Folders(ctx); err != nil {
+		t.Errorf("Expected the cached datacenter to use the new session: %s", err)
+	}
+}
+
+func TestNodeNetworkPolicyAppliesToDiscoveredNodes(t *testing.T) {
+	cfg, cleanup := configFromSim(false)
+	defer cleanup()
+
+	connMgr := cm.NewConnectionManager(cfg, nil, nil)
+	defer connMgr.Logout()
+	nm := newNodeManager(&ccfg.CPIConfig{Config: *cfg}, connMgr)
+	i := newInstances(nm)
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vm.Guest.HostName = strings.ToLower(vm.Name)
+	vm.Guest.Net = []vimtypes.GuestNicInfo{
+		{Network: "VM Network", IpAddress: []string{"10.0.0.10", "192.168.0.10"}},
+	}
+	internalIP := func() string {
+		t.Helper()
+		addrs, err := i.NodeAddresses(context.Background(), types.NodeName(vm.Guest.HostName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, addr := range addrs {
+			if addr.Type == v1.NodeInternalIP {
+				return addr.Address
+			}
+		}
+		return ""
+	}
+
+	if ip := internalIP(); ip != "10.0.0.10" {
+		t.Fatalf("Expected the first address as internal IP but got %q", ip)
+	}
+	// the addresses of a discovered node follow a policy set afterwards
+	nm.SetNodeNetworkPolicy(&ccfg.Nodes{InternalNetworkSubnetCIDR: "192.168.0.0/16"})
+	if ip := internalIP(); ip != "192.168.0.10" {
+		t.Errorf("Expected the internal IP of the policy but got %q", ip)
+	}
+}
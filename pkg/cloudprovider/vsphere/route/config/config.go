@@ -17,7 +17,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"strings"
 
 	klog "k8s.io/klog/v2"
 )
@@ -50,3 +53,47 @@ func ReadRouteConfig(configData []byte) (*Config, error) {
 	klog.Info("Route Config initialized")
 	return cfg, nil
 }
+
+// RouterPaths returns the default router path and all zone router paths
+func (rc *RouteConfig) RouterPaths() []string {
+	var paths []string
+	if rc.RouterPath != "" {
+		paths = append(paths, rc.RouterPath)
+	}
+	for _, path := range rc.ZoneRouterPaths {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func (rc *RouteConfig) validate() error {
+	if rc.RouterPath == "" && len(rc.ZoneRouterPaths) == 0 {
+		return errors.New("router path is required")
+	}
+	for _, path := range rc.RouterPaths() {
+		if !strings.HasPrefix(path, Tier0PathPrefix) && !strings.HasPrefix(path, Tier1PathPrefix) {
+			return fmt.Errorf("invalid router path %q: expected Tier-0 or Tier-1 policy path", path)
+		}
+	}
+	for scope := range rc.AdditionalTags {
+		if scope == ClusterNameTagScope || scope == NodeNameTagScope {
+			return fmt.Errorf("route tag scope %s is reserved", scope)
+		}
+	}
+	if rc.AdminDistance < 0 || rc.AdminDistance > 255 {
+		return fmt.Errorf("invalid admin distance %d: expected value between 1 and 255, or 0 for the NSX-T default", rc.AdminDistance)
+	}
+	for _, subnet := range rc.NextHopSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("invalid next hop subnet %q: %s", subnet, err)
+		}
+	}
+	if rc.BFD {
+		for _, path := range rc.RouterPaths() {
+			if !strings.HasPrefix(path, Tier0PathPrefix) {
+				return fmt.Errorf("BFD requires Tier-0 routers, but router %q is not a Tier-0 router", path)
+			}
+		}
+	}
+	return nil
+}
@@ -17,8 +17,9 @@
 package config
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"gopkg.in/gcfg.v1"
 )
@@ -33,18 +34,38 @@ import (
 func (rci *RouteConfigINI) CreateConfig() *Config {
 	cfg := &Config{}
 	cfg.Route.RouterPath = rci.Route.RouterPath
+	cfg.Route.ZoneRouterPaths = rci.Route.ZoneRouterPaths
+	cfg.Route.AdditionalTags = rci.Route.AdditionalTags
+	cfg.Route.AdminDistance = rci.Route.AdminDistance
+	cfg.Route.ECMP = rci.Route.ECMP
+	for _, subnet := range strings.Split(rci.Route.NextHopSubnets, ",") {
+		if subnet = strings.TrimSpace(subnet); subnet != "" {
+			cfg.Route.NextHopSubnets = append(cfg.Route.NextHopSubnets, subnet)
+		}
+	}
+	cfg.Route.NextHopNetworkName = rci.Route.NextHopNetworkName
+	cfg.Route.BFD = rci.Route.BFD
 	return cfg
 }
 
 func (rci *RouteConfigINI) validateConfig() error {
-	if rci.Route.RouterPath == "" {
-		return errors.New("router path is required")
-	}
-	return nil
+	return rci.CreateConfig().Route.validate()
 }
 
 // CompleteAndValidate sets default values, overrides by env and validates the resulting config
 func (rci *RouteConfigINI) CompleteAndValidate() error {
+	if rci.Route.RawZoneRouterPaths != "" {
+		err := json.Unmarshal([]byte(rci.Route.RawZoneRouterPaths), &rci.Route.ZoneRouterPaths)
+		if err != nil {
+			return fmt.Errorf("unmarshalling zone router paths failed: %s", err)
+		}
+	}
+	if rci.Route.RawTags != "" {
+		err := json.Unmarshal([]byte(rci.Route.RawTags), &rci.Route.AdditionalTags)
+		if err != nil {
+			return fmt.Errorf("unmarshalling route tags failed: %s", err)
+		}
+	}
 	return rci.validateConfig()
 }
 
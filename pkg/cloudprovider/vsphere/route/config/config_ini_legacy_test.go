@@ -43,3 +43,44 @@ router-path = /infra/tier-1s/test-router
 	}
 	assertEquals("Route.routerPath", config.Route.RouterPath, "/infra/tier-1s/test-router")
 }
+
+func TestReadINIConfigZoneRouters(t *testing.T) {
+	contents := `
+[Route]
+router-path = /infra/tier-1s/test-router
+zone-router-paths = {\"zone-a\": \"/infra/tier-1s/router-a\"}
+tags = {\"env\": \"test\"}
+admin-distance = 3
+`
+	config, err := ReadConfigINI([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Route.ZoneRouterPaths["zone-a"] != "/infra/tier-1s/router-a" {
+		t.Errorf("unexpected zone router paths %v", config.Route.ZoneRouterPaths)
+	}
+	if config.Route.AdditionalTags["env"] != "test" || config.Route.AdminDistance != 3 {
+		t.Errorf("unexpected tags %v or admin distance %d", config.Route.AdditionalTags, config.Route.AdminDistance)
+	}
+}
+
+func TestReadINIConfigNextHops(t *testing.T) {
+	contents := `
+[Route]
+router-path = /infra/tier-0s/test-router
+ecmp = true
+next-hop-subnets = 10.0.0.0/24, 10.0.1.0/24
+next-hop-network-name = uplink
+bfd = true
+`
+	config, err := ReadConfigINI([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Route.ECMP || !config.Route.BFD || config.Route.NextHopNetworkName != "uplink" {
+		t.Errorf("unexpected next hop config %+v", config.Route)
+	}
+	if len(config.Route.NextHopSubnets) != 2 || config.Route.NextHopSubnets[1] != "10.0.1.0/24" {
+		t.Errorf("unexpected next hop subnets %v", config.Route.NextHopSubnets)
+	}
+}
@@ -17,7 +17,6 @@
 package config
 
 import (
-	"errors"
 	"fmt"
 
 	yaml "gopkg.in/yaml.v2"
@@ -34,14 +33,18 @@ import (
 func (rcy *RouteConfigYAML) CreateConfig() *Config {
 	cfg := &Config{}
 	cfg.Route.RouterPath = rcy.Route.RouterPath
+	cfg.Route.ZoneRouterPaths = rcy.Route.ZoneRouterPaths
+	cfg.Route.AdditionalTags = rcy.Route.AdditionalTags
+	cfg.Route.AdminDistance = rcy.Route.AdminDistance
+	cfg.Route.ECMP = rcy.Route.ECMP
+	cfg.Route.NextHopSubnets = rcy.Route.NextHopSubnets
+	cfg.Route.NextHopNetworkName = rcy.Route.NextHopNetworkName
+	cfg.Route.BFD = rcy.Route.BFD
 	return cfg
 }
 
 func (rcy *RouteConfigYAML) validateConfig() error {
-	if rcy.Route.RouterPath == "" {
-		return errors.New("router path is required")
-	}
-	return nil
+	return rcy.CreateConfig().Route.validate()
 }
 
 // CompleteAndValidate sets default values, overrides by env and validates the resulting config
@@ -43,3 +43,64 @@ route:
 	}
 	assertEquals("route.routerPath", config.Route.RouterPath, "/infra/tier-1s/test-router")
 }
+
+func TestReadYAMLConfigZoneRouters(t *testing.T) {
+	contents := `
+route:
+  zoneRouterPaths:
+    zone-a: /infra/tier-1s/router-a
+    zone-b: /infra/tier-0s/router-b
+  tags:
+    env: test
+  adminDistance: 2
+`
+	config, err := ReadConfigYAML([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Route.RouterPath != "" || len(config.Route.RouterPaths()) != 2 {
+		t.Errorf("unexpected router paths %v", config.Route.RouterPaths())
+	}
+	if config.Route.ZoneRouterPaths["zone-b"] != "/infra/tier-0s/router-b" {
+		t.Errorf("unexpected zone router path %s", config.Route.ZoneRouterPaths["zone-b"])
+	}
+	if config.Route.AdditionalTags["env"] != "test" || config.Route.AdminDistance != 2 {
+		t.Errorf("unexpected tags %v or admin distance %d", config.Route.AdditionalTags, config.Route.AdminDistance)
+	}
+
+	for _, invalid := range []string{
+		"route:\n  adminDistance: 1\n",
+		"route:\n  routerPath: /infra/segments/s1\n",
+		"route:\n  routerPath: /infra/tier-1s/r\n  adminDistance: 256\n",
+		"route:\n  routerPath: /infra/tier-1s/r\n  adminDistance: -1\n",
+		"route:\n  routerPath: /infra/tier-1s/r\n  tags:\n    vsphere.k8s.io/node-name: n\n",
+		"route:\n  routerPath: /infra/tier-1s/r\n  nextHopSubnets:\n  - 10.0.0.0\n",
+		"route:\n  routerPath: /infra/tier-1s/r\n  bfd: true\n",
+	} {
+		if _, err := ReadConfigYAML([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestReadYAMLConfigNextHops(t *testing.T) {
+	contents := `
+route:
+  routerPath: /infra/tier-0s/test-router
+  ecmp: true
+  nextHopSubnets:
+  - 10.0.0.0/24
+  nextHopNetworkName: uplink
+  bfd: true
+`
+	config, err := ReadConfigYAML([]byte(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Route.ECMP || !config.Route.BFD || config.Route.NextHopNetworkName != "uplink" {
+		t.Errorf("unexpected next hop config %+v", config.Route)
+	}
+	if len(config.Route.NextHopSubnets) != 1 || config.Route.NextHopSubnets[0] != "10.0.0.0/24" {
+		t.Errorf("unexpected next hop subnets %v", config.Route.NextHopSubnets)
+	}
+}
@@ -30,13 +30,19 @@ const (
 	// for example {"scope": "vsphere.k8s.io/node-name", "tag": "worker-node-1"}
 	NodeNameTagScope = "vsphere.k8s.io/node-name"
 
-	// RealizedStateTimeout is the timeout duration for realized state check
-	RealizedStateTimeout = 10 * time.Second
-	// RealizedStateSleepTime is the interval between realized state check
-	RealizedStateSleepTime = 1 * time.Second
+	// RealizedStateTimeout is the duration after which a static route not yet realized is reported as failed
+	RealizedStateTimeout = 2 * time.Minute
+	// RealizedStateSleepTime is the interval between realized state checks of pending static routes
+	RealizedStateSleepTime = 5 * time.Second
 	// RealizedState is the realized state
 	RealizedState = "REALIZED"
+	// ErrorState is the realized state of a failed realization
+	ErrorState = "ERROR"
 
+	// Tier0PathPrefix is the policy path prefix of Tier-0 routers
+	Tier0PathPrefix = "/infra/tier-0s/"
+	// Tier1PathPrefix is the policy path prefix of Tier-1 routers
+	Tier1PathPrefix = "/infra/tier-1s/"
 	// DisplayNameMaxLength is the maximum length of static route display name
 	DisplayNameMaxLength = 255
 )
@@ -23,5 +23,20 @@ type Config struct {
 
 // RouteConfig contains the configuration for the route itself
 type RouteConfig struct {
+	// RouterPath is the policy path of the default Tier-0 or Tier-1 router
 	RouterPath string
+	// ZoneRouterPaths maps zones to the router used for the routes of the nodes in the zone
+	ZoneRouterPaths map[string]string
+	// AdditionalTags are additional tags for the static routes
+	AdditionalTags map[string]string
+	// AdminDistance is the admin distance of the next hops (0 for the NSX-T default)
+	AdminDistance int64
+	// ECMP creates next hops to all matching node addresses instead of the first one
+	ECMP bool
+	// NextHopSubnets restricts the next hops to node addresses in these CIDRs
+	NextHopSubnets []string
+	// NextHopNetworkName restricts the next hops to node addresses on this VM network
+	NextHopNetworkName string
+	// BFD enables BFD peers for the next hops (Tier-0 routers only)
+	BFD bool
 }
@@ -23,5 +23,14 @@ type RouteConfigINI struct {
 
 // RouteINI contains the configuration for route
 type RouteINI struct {
-	RouterPath string `gcfg:"router-path"`
+	RouterPath         string `gcfg:"router-path"`
+	RawZoneRouterPaths string `gcfg:"zone-router-paths"`
+	RawTags            string `gcfg:"tags"`
+	AdminDistance      int64  `gcfg:"admin-distance"`
+	ECMP               bool   `gcfg:"ecmp"`
+	NextHopSubnets     string `gcfg:"next-hop-subnets"`
+	NextHopNetworkName string `gcfg:"next-hop-network-name"`
+	BFD                bool   `gcfg:"bfd"`
+	ZoneRouterPaths    map[string]string
+	AdditionalTags     map[string]string
 }
@@ -31,5 +31,12 @@ type RouteConfigYAML struct {
 
 // RouteYAML contains the configuration for route
 type RouteYAML struct {
-	RouterPath string `yaml:"routerPath"`
+	RouterPath         string            `yaml:"routerPath"`
+	ZoneRouterPaths    map[string]string `yaml:"zoneRouterPaths"`
+	AdditionalTags     map[string]string `yaml:"tags"`
+	AdminDistance      int64             `yaml:"adminDistance"`
+	ECMP               bool              `yaml:"ecmp"`
+	NextHopSubnets     []string          `yaml:"nextHopSubnets"`
+	NextHopNetworkName string            `yaml:"nextHopNetworkName"`
+	BFD                bool              `yaml:"bfd"`
 }
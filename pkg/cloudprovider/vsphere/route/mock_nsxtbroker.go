@@ -91,3 +91,31 @@ func (mr *MockNsxtBrokerMockRecorder) ListRealizedEntities(path interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRealizedEntities", reflect.TypeOf((*MockNsxtBroker)(nil).ListRealizedEntities), path)
 }
+
+// CreateStaticRouteBfdPeer mocks base method
+func (m *MockNsxtBroker) CreateStaticRouteBfdPeer(routerPath, bfdPeerID string, bfdPeer model.StaticRouteBfdPeer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStaticRouteBfdPeer", routerPath, bfdPeerID, bfdPeer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateStaticRouteBfdPeer indicates an expected call of CreateStaticRouteBfdPeer
+func (mr *MockNsxtBrokerMockRecorder) CreateStaticRouteBfdPeer(routerPath, bfdPeerID, bfdPeer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStaticRouteBfdPeer", reflect.TypeOf((*MockNsxtBroker)(nil).CreateStaticRouteBfdPeer), routerPath, bfdPeerID, bfdPeer)
+}
+
+// DeleteStaticRouteBfdPeer mocks base method
+func (m *MockNsxtBroker) DeleteStaticRouteBfdPeer(routerPath, bfdPeerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaticRouteBfdPeer", routerPath, bfdPeerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStaticRouteBfdPeer indicates an expected call of DeleteStaticRouteBfdPeer
+func (mr *MockNsxtBrokerMockRecorder) DeleteStaticRouteBfdPeer(routerPath, bfdPeerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaticRouteBfdPeer", reflect.TypeOf((*MockNsxtBroker)(nil).DeleteStaticRouteBfdPeer), routerPath, bfdPeerID)
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package route
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/protocol/client"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/realized_state"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/tier_0s"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/tier_0s/static_routes"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/infra/tier_1s"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
 	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/search"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route/config"
 )
 
 // NsxtBroker is an internal interface to access nsxt backend
@@ -32,12 +37,15 @@ type NsxtBroker interface {
 	CreateStaticRoute(routerPath string, staticRouteID string, staticRoute model.StaticRoutes) error
 	DeleteStaticRoute(routerPath string, staticRouteID string) error
 	ListRealizedEntities(path string) (model.GenericPolicyRealizedResourceListResult, error)
+	CreateStaticRouteBfdPeer(routerPath string, bfdPeerID string, bfdPeer model.StaticRouteBfdPeer) error
+	DeleteStaticRouteBfdPeer(routerPath string, bfdPeerID string) error
 }
 
 // nsxtBroker includes NSXT API clients
 type nsxtBroker struct {
-	// TODO: will add tier0 static routes client
+	tier0StaticRoutesClient tier_0s.StaticRoutesClient
 	tier1StaticRoutesClient tier_1s.StaticRoutesClient
+	tier0BfdPeersClient     static_routes.BfdPeersClient
 	realizedEntitiesClient  realized_state.RealizedEntitiesClient
 	queryClient             search.QueryClient
 }
@@ -45,7 +53,9 @@ type nsxtBroker struct {
 // NewNsxtBroker creates a new NsxtBroker to the NSXT API
 func NewNsxtBroker(connector client.Connector) (NsxtBroker, error) {
 	return &nsxtBroker{
+		tier0StaticRoutesClient: tier_0s.NewStaticRoutesClient(connector),
 		tier1StaticRoutesClient: tier_1s.NewStaticRoutesClient(connector),
+		tier0BfdPeersClient:     static_routes.NewBfdPeersClient(connector),
 		realizedEntitiesClient:  realized_state.NewRealizedEntitiesClient(connector),
 		queryClient:             search.NewQueryClient(connector),
 	}, nil
@@ -58,11 +68,17 @@ func (b *nsxtBroker) QueryEntities(queryParam string) (model.SearchResponse, err
 
 func (b *nsxtBroker) CreateStaticRoute(routerPath string, staticRouteID string, staticRoute model.StaticRoutes) error {
 	routerID := getRouterID(routerPath)
+	if isTier0(routerPath) {
+		return b.tier0StaticRoutesClient.Patch(routerID, staticRouteID, staticRoute)
+	}
 	return b.tier1StaticRoutesClient.Patch(routerID, staticRouteID, staticRoute)
 }
 
 func (b *nsxtBroker) DeleteStaticRoute(routerPath string, staticRouteID string) error {
 	routerID := getRouterID(routerPath)
+	if isTier0(routerPath) {
+		return b.tier0StaticRoutesClient.Delete(routerID, staticRouteID)
+	}
 	return b.tier1StaticRoutesClient.Delete(routerID, staticRouteID)
 }
 
@@ -70,8 +86,27 @@ func (b *nsxtBroker) ListRealizedEntities(path string) (model.GenericPolicyReali
 	return b.realizedEntitiesClient.List(path, nil)
 }
 
+func (b *nsxtBroker) CreateStaticRouteBfdPeer(routerPath string, bfdPeerID string, bfdPeer model.StaticRouteBfdPeer) error {
+	if !isTier0(routerPath) {
+		return fmt.Errorf("BFD peers are not supported on router %s", routerPath)
+	}
+	return b.tier0BfdPeersClient.Patch(getRouterID(routerPath), bfdPeerID, bfdPeer)
+}
+
+func (b *nsxtBroker) DeleteStaticRouteBfdPeer(routerPath string, bfdPeerID string) error {
+	if !isTier0(routerPath) {
+		return fmt.Errorf("BFD peers are not supported on router %s", routerPath)
+	}
+	return b.tier0BfdPeersClient.Delete(getRouterID(routerPath), bfdPeerID)
+}
+
 // getRouterID returns router ID from router path
 func getRouterID(routerPath string) string {
 	path := strings.Split(routerPath, "/")
 	return path[len(path)-1]
 }
+
+// isTier0 returns true if the router path references a Tier-0 router
+func isTier0(routerPath string) bool {
+	return strings.HasPrefix(routerPath, config.Tier0PathPrefix)
+}
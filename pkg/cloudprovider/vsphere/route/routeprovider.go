@@ -20,9 +20,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/data"
@@ -31,6 +31,8 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientset "k8s.io/client-go/kubernetes"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route/config"
 	"k8s.io/cloud-provider-vsphere/pkg/util"
@@ -42,34 +44,102 @@ type RoutesProvider interface {
 	cloudprovider.Routes
 	AddNode(*v1.Node)
 	DeleteNode(*v1.Node)
+	// Initialize starts the background realization tracking of static routes
+	Initialize(client clientset.Interface, stop <-chan struct{})
+	// SetNodeNetworkAddresses sets the lookup for the VM network addresses of nodes
+	SetNodeNetworkAddresses(lookup NodeNetworkAddressesFunc)
 }
 
+// NodeNetworkAddressesFunc returns the IP addresses of the VM of a node by network name
+type NodeNetworkAddressesFunc func(nodeName string) map[string][]string
+
 type routeProvider struct {
-	routerPath  string
+	// routerPath is the default router for nodes without zone or with unmapped zone
+	routerPath string
+	// zoneRouterPaths maps zones to routers
+	zoneRouterPaths map[string]string
+	additionalTags  map[string]string
+	adminDistance   int64
+	// ecmp enables next hops to all matching node addresses
+	ecmp bool
+	// nextHopSubnets and nextHopNetworkName restrict the node addresses used as next hops
+	nextHopSubnets     []*net.IPNet
+	nextHopNetworkName string
+	bfd                bool
+	networkAddresses   NodeNetworkAddressesFunc
+	// routeRouterPaths maps route names to the router path the route was found on or created at
+	routeRouterPaths     map[string]string
+	routeRouterPathsLock sync.Mutex
+	// nodeRoutes maps node names to the static routes of the node by route ID,
+	// as found on listing or written on creation
+	nodeRoutes     map[string]map[string]*nodeRoute
+	nodeRoutesLock sync.Mutex
+	// bfdLock serializes the synchronization of the shared BFD peers
+	bfdLock sync.Mutex
+
 	broker      NsxtBroker
+	tracker     *realizationTracker
 	nodeMap     map[string]*v1.Node
 	nodeMapLock sync.RWMutex
 }
 
 var _ RoutesProvider = &routeProvider{}
 
+// nodeRoute is a static route of a node on a configured router
+type nodeRoute struct {
+	clusterName string
+	routerPath  string
+	routeID     string
+	cidr        string
+	nexthops    []string
+}
+
 // NewRouteProvider creates a new RouteProvider
 func NewRouteProvider(cfg *config.Config, connector client.Connector) (RoutesProvider, error) {
-	if cfg == nil || cfg.Route.RouterPath == "" {
+	if cfg == nil || len(cfg.Route.RouterPaths()) == 0 {
 		return nil, nil
 	}
 	nsxtbroker, err := NewNsxtBroker(connector)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating nsxt broker failed")
 	}
+	var subnets []*net.IPNet
+	for _, subnet := range cfg.Route.NextHopSubnets {
+		_, ipnet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing next hop subnet %s failed", subnet)
+		}
+		subnets = append(subnets, ipnet)
+	}
 	return &routeProvider{
-		broker:     nsxtbroker,
-		routerPath: cfg.Route.RouterPath,
-		nodeMap:    make(map[string]*v1.Node),
+		broker:             nsxtbroker,
+		routerPath:         cfg.Route.RouterPath,
+		zoneRouterPaths:    cfg.Route.ZoneRouterPaths,
+		additionalTags:     cfg.Route.AdditionalTags,
+		adminDistance:      cfg.Route.AdminDistance,
+		ecmp:               cfg.Route.ECMP,
+		nextHopSubnets:     subnets,
+		nextHopNetworkName: cfg.Route.NextHopNetworkName,
+		bfd:                cfg.Route.BFD,
+		routeRouterPaths:   make(map[string]string),
+		nodeRoutes:         make(map[string]map[string]*nodeRoute),
+		tracker:            newRealizationTracker(nsxtbroker),
+		nodeMap:            make(map[string]*v1.Node),
 	}, nil
 }
 
+// SetNodeNetworkAddresses sets the lookup for the VM network addresses of nodes
+func (p *routeProvider) SetNodeNetworkAddresses(lookup NodeNetworkAddressesFunc) {
+	p.networkAddresses = lookup
+}
+
+// Initialize starts the background realization tracking of static routes
+func (p *routeProvider) Initialize(client clientset.Interface, stop <-chan struct{}) {
+	p.tracker.Run(client, stop)
+}
+
 // ListRoutes returns a list of routes which have static routes on NSXT
+// The static routes of all configured routers are merged.
 func (p *routeProvider) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
 	queryParam := fmt.Sprintf("resource_type:StaticRoutes AND tags.scope:%s AND tags.tag:%s",
 		config.ClusterNameTagScope, clusterName)
@@ -81,7 +151,223 @@ func (p *routeProvider) ListRoutes(ctx context.Context, clusterName string) ([]*
 	if *staticRoutes.ResultCount == 0 {
 		return []*cloudprovider.Route{}, nil
 	}
-	return p.generateRoutes(staticRoutes), nil
+	configured := p.configuredRouterPaths()
+	var routes []*cloudprovider.Route
+	for i, route := range p.generateRoutes(staticRoutes) {
+		routerPath := routerPathOfStaticRoute(staticRoutes.Results[i], p.routerPath)
+		if !configured.Has(routerPath) {
+			// routes on routers removed from the config are listed as blackholes to be
+			// deleted by the route controller, named by their path to be distinguishable
+			// from the routes of the same node on the configured routers
+			klog.V(4).Infof("static route %s on router %s is not on a configured router, listed for deletion", route.Name, routerPath)
+			route.Name = p.staticRoutePath(routerPath, route.Name)
+			route.Blackhole = true
+			routes = append(routes, route)
+			continue
+		}
+		p.setRouteRouterPath(route.Name, routerPath)
+		p.setNodeRoute(string(route.TargetNode), &nodeRoute{
+			clusterName: clusterName,
+			routerPath:  routerPath,
+			routeID:     route.Name,
+			cidr:        route.DestinationCIDR,
+			nexthops:    nextHopsOfStaticRoute(staticRoutes.Results[i]),
+		})
+		// routes failed to realize are hidden to let the route controller create them again
+		if p.tracker != nil && p.tracker.IsFailed(p.staticRoutePath(routerPath, route.Name)) {
+			klog.V(4).Infof("static route %s for node %s not realized, listing skipped", route.Name, route.TargetNode)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// updateNodeRoutes updates the next hops of the static routes of a node if
+// the addresses of the node have changed
+func (p *routeProvider) updateNodeRoutes(nodeName string) {
+	for _, route := range p.routesOfNode(nodeName) {
+		expected, err := p.getNodeNextHops(nodeName, util.IsIPv4(route.cidr))
+		if err != nil {
+			klog.Warningf("getting next hops for node %s failed: %v", nodeName, err)
+			continue
+		}
+		if sets.NewString(expected...).Equal(sets.NewString(route.nexthops...)) {
+			continue
+		}
+		klog.Infof("updating next hops of static route %s for node %s: %v -> %v", route.routeID, nodeName, route.nexthops, expected)
+		err = p.patchStaticRoute(route.clusterName, route.routerPath, route.routeID, nodeName, route.cidr, expected)
+		if err != nil {
+			klog.Errorf("updating static route %s for node %s failed: %s", route.routeID, nodeName, err)
+		}
+	}
+}
+
+// nextHopsOfStaticRoute returns the next hop addresses of a static route search result
+func nextHopsOfStaticRoute(item *data.StructValue) []string {
+	field, err := item.Field("next_hops")
+	if err != nil {
+		return nil
+	}
+	list, ok := field.(*data.ListValue)
+	if !ok {
+		return nil
+	}
+	var nexthops []string
+	for _, element := range list.List() {
+		nexthop, ok := element.(*data.StructValue)
+		if !ok {
+			continue
+		}
+		address, err := nexthop.Field("ip_address")
+		if err != nil {
+			continue
+		}
+		if value, ok := address.(*data.StringValue); ok {
+			nexthops = append(nexthops, value.Value())
+		}
+	}
+	return nexthops
+}
+
+// routerPathOfStaticRoute returns the router path of a static route search result
+func routerPathOfStaticRoute(item *data.StructValue, defaultRouterPath string) string {
+	field, err := item.Field("path")
+	if err != nil {
+		return defaultRouterPath
+	}
+	value, ok := field.(*data.StringValue)
+	if !ok {
+		return defaultRouterPath
+	}
+	if routerPath, _, ok := splitStaticRoutePath(value.Value()); ok {
+		return routerPath
+	}
+	return defaultRouterPath
+}
+
+// splitStaticRoutePath splits the policy path of a static route into the router
+// path and the route ID
+func splitStaticRoutePath(path string) (string, string, bool) {
+	index := strings.LastIndex(path, "/static-routes/")
+	if index <= 0 {
+		return "", "", false
+	}
+	return path[:index], path[index+len("/static-routes/"):], true
+}
+
+// configuredRouterPaths returns the paths of all configured routers
+func (p *routeProvider) configuredRouterPaths() sets.String {
+	paths := sets.NewString()
+	if p.routerPath != "" {
+		paths.Insert(p.routerPath)
+	}
+	for _, path := range p.zoneRouterPaths {
+		paths.Insert(path)
+	}
+	return paths
+}
+
+// routerPathForNode returns the router for the static routes of a node
+// according to its zone label
+func (p *routeProvider) routerPathForNode(nodeName string) (string, error) {
+	node, err := p.getNode(nodeName)
+	if err != nil {
+		return "", err
+	}
+	zone := node.Labels[v1.LabelTopologyZone]
+	if zone == "" {
+		zone = node.Labels[v1.LabelFailureDomainBetaZone]
+	}
+	if path, ok := p.zoneRouterPaths[zone]; ok && zone != "" {
+		return path, nil
+	}
+	if p.routerPath == "" {
+		return "", fmt.Errorf("no router configured for zone %q of node %s", zone, nodeName)
+	}
+	return p.routerPath, nil
+}
+
+func (p *routeProvider) setRouteRouterPath(routeName, routerPath string) {
+	p.routeRouterPathsLock.Lock()
+	defer p.routeRouterPathsLock.Unlock()
+	if p.routeRouterPaths == nil {
+		p.routeRouterPaths = make(map[string]string)
+	}
+	p.routeRouterPaths[routeName] = routerPath
+}
+
+// forgetRouteRouterPath removes the router of a deleted route
+func (p *routeProvider) forgetRouteRouterPath(routeName, routerPath string) {
+	p.routeRouterPathsLock.Lock()
+	defer p.routeRouterPathsLock.Unlock()
+	if p.routeRouterPaths[routeName] == routerPath {
+		delete(p.routeRouterPaths, routeName)
+	}
+}
+
+// routeRouterPath returns the router a route was found on or created at
+func (p *routeProvider) routeRouterPath(routeName string) string {
+	p.routeRouterPathsLock.Lock()
+	defer p.routeRouterPathsLock.Unlock()
+	if path, ok := p.routeRouterPaths[routeName]; ok {
+		return path
+	}
+	return p.routerPath
+}
+
+func (p *routeProvider) setNodeRoute(nodeName string, route *nodeRoute) {
+	p.nodeRoutesLock.Lock()
+	defer p.nodeRoutesLock.Unlock()
+	if p.nodeRoutes == nil {
+		p.nodeRoutes = make(map[string]map[string]*nodeRoute)
+	}
+	if p.nodeRoutes[nodeName] == nil {
+		p.nodeRoutes[nodeName] = make(map[string]*nodeRoute)
+	}
+	p.nodeRoutes[nodeName][route.routeID] = route
+}
+
+// forgetNodeRoute removes a deleted static route of a node
+func (p *routeProvider) forgetNodeRoute(nodeName, routeID, routerPath string) {
+	p.nodeRoutesLock.Lock()
+	defer p.nodeRoutesLock.Unlock()
+	if route, ok := p.nodeRoutes[nodeName][routeID]; ok && route.routerPath == routerPath {
+		delete(p.nodeRoutes[nodeName], routeID)
+		if len(p.nodeRoutes[nodeName]) == 0 {
+			delete(p.nodeRoutes, nodeName)
+		}
+	}
+}
+
+// routesOfNode returns the known static routes of a node
+func (p *routeProvider) routesOfNode(nodeName string) []nodeRoute {
+	p.nodeRoutesLock.Lock()
+	defer p.nodeRoutesLock.Unlock()
+	var routes []nodeRoute
+	for _, route := range p.nodeRoutes[nodeName] {
+		routes = append(routes, *route)
+	}
+	return routes
+}
+
+// nextHopsOfRouter returns the next hops of the known static routes of a router
+// mapped to their node names
+func (p *routeProvider) nextHopsOfRouter(routerPath string) map[string]string {
+	p.nodeRoutesLock.Lock()
+	defer p.nodeRoutesLock.Unlock()
+	nexthops := map[string]string{}
+	for nodeName, routes := range p.nodeRoutes {
+		for _, route := range routes {
+			if route.routerPath != routerPath {
+				continue
+			}
+			for _, ip := range route.nexthops {
+				nexthops[ip] = nodeName
+			}
+		}
+	}
+	return nexthops
 }
 
 // generateRoutes generates cloudprovider Routes based on NSXT static routes
@@ -112,87 +398,289 @@ func (p *routeProvider) generateRoutes(staticRoutes model.SearchResponse) []*clo
 }
 
 // CreateRoute creates a static route on NSXT for a Node
+// It returns as soon as the static route is written, the realization is
+// watched in the background. An existing static route is updated.
 func (p *routeProvider) CreateRoute(ctx context.Context, clusterName string, nameHint string, route *cloudprovider.Route) error {
 	nodeName := string(route.TargetNode)
 	klog.V(6).Infof("Creating static route for node %s", nodeName)
 
-	nodeIP, err := p.getNodeIPAddress(nodeName, util.IsIPv4(route.DestinationCIDR))
+	nodeIPs, err := p.getNodeNextHops(nodeName, util.IsIPv4(route.DestinationCIDR))
 	if err != nil {
 		klog.Errorf("getting node %s IP address failed: %v", nodeName, err)
 		return err
 	}
-	routeID, staticRoute := p.generateStaticRoute(clusterName, nameHint, nodeName, route.DestinationCIDR, nodeIP)
-	err = p.broker.CreateStaticRoute(p.routerPath, routeID, staticRoute)
+	routerPath, err := p.routerPathForNode(nodeName)
+	if err != nil {
+		klog.Errorf("getting router for node %s failed: %v", nodeName, err)
+		return err
+	}
+	routeID := staticRouteID(nameHint, route.DestinationCIDR)
+	err = p.patchStaticRoute(clusterName, routerPath, routeID, nodeName, route.DestinationCIDR, nodeIPs)
 	if err != nil {
 		klog.Errorf("creating static route %s for node %s failed: %s", routeID, nodeName, err)
 		return err
 	}
+	return nil
+}
+
+// patchStaticRoute creates or updates a static route and its BFD peers
+func (p *routeProvider) patchStaticRoute(clusterName, routerPath, routeID, nodeName, cidr string, nodeIPs []string) error {
+	staticRoute := p.generateStaticRoute(clusterName, nodeName, cidr, nodeIPs)
+	err := p.broker.CreateStaticRoute(routerPath, routeID, staticRoute)
+	if err != nil {
+		return err
+	}
+	p.setRouteRouterPath(routeID, routerPath)
+	p.setNodeRoute(nodeName, &nodeRoute{
+		clusterName: clusterName,
+		routerPath:  routerPath,
+		routeID:     routeID,
+		cidr:        cidr,
+		nexthops:    nodeIPs,
+	})
+	if p.bfd {
+		err = p.syncBfdPeers(clusterName, routerPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.tracker != nil {
+		p.tracker.Track(p.staticRoutePath(routerPath, routeID), nodeName, cidr)
+	}
+	return nil
+}
+
+// syncBfdPeers creates the BFD peers for the next hops of the static routes of a
+// router and deletes the peers of next hops no longer used.
+// The peers are named after the peer address, so that static routes with the
+// same next hop share a peer.
+func (p *routeProvider) syncBfdPeers(clusterName, routerPath string) error {
+	p.bfdLock.Lock()
+	defer p.bfdLock.Unlock()
+	nexthops := p.nextHopsOfRouter(routerPath)
+	wanted := map[string]string{}
+	for ip := range nexthops {
+		wanted[bfdPeerID(ip)] = ip
+	}
+	existing, err := p.listBfdPeerIDs(clusterName, routerPath)
+	if err != nil {
+		return err
+	}
+	for _, id := range existing.List() {
+		if _, ok := wanted[id]; ok {
+			continue
+		}
+		if err := p.broker.DeleteStaticRouteBfdPeer(routerPath, id); err != nil {
+			return errors.Wrapf(err, "deleting BFD peer %s failed", id)
+		}
+	}
+	for id, ip := range wanted {
+		if existing.Has(id) {
+			continue
+		}
+		peer := p.generateBfdPeer(clusterName, nexthops[ip], ip)
+		if err := p.broker.CreateStaticRouteBfdPeer(routerPath, id, peer); err != nil {
+			return errors.Wrapf(err, "creating BFD peer %s failed", id)
+		}
+	}
+	return nil
+}
+
+// listBfdPeerIDs returns the IDs of the BFD peers of the cluster on a router
+func (p *routeProvider) listBfdPeerIDs(clusterName, routerPath string) (sets.String, error) {
+	queryParam := fmt.Sprintf("resource_type:StaticRouteBfdPeer AND tags.scope:%s AND tags.tag:%s",
+		config.ClusterNameTagScope, clusterName)
+	peers, err := p.broker.QueryEntities(queryParam)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying BFD peers of router %s failed", routerPath)
+	}
+	ids := sets.NewString()
+	for _, item := range peers.Results {
+		id, err := item.String("id")
+		if err != nil {
+			continue
+		}
+		if path, err := item.String("path"); err == nil && !strings.HasPrefix(path, routerPath+"/") {
+			continue
+		}
+		ids.Insert(id)
+	}
+	return ids, nil
+}
+
+// bfdPeerID returns the ID of the BFD peer of a next hop
+func bfdPeerID(ip string) string {
+	return "bfd_" + strings.NewReplacer(".", "_", ":", "_").Replace(ip)
+}
+
+// generateBfdPeer generates NSXT static route BFD peer for a next hop
+func (p *routeProvider) generateBfdPeer(clusterName, nodeName, ip string) model.StaticRouteBfdPeer {
+	clusterNameScope := config.ClusterNameTagScope
+	nodeNameScope := config.NodeNameTagScope
+	enabled := true
+	displayName := clusterName + "_" + nodeName + "_" + ip
+	return model.StaticRouteBfdPeer{
+		DisplayName: &displayName,
+		Enabled:     &enabled,
+		PeerAddress: &ip,
+		Tags: []model.Tag{
+			{Scope: &clusterNameScope, Tag: &clusterName},
+			{Scope: &nodeNameScope, Tag: &nodeName},
+		},
+	}
+}
+
+// staticRouteID returns the ID of a static route
+func staticRouteID(nameHint, cidr string) string {
+	return strings.ReplaceAll(nameHint+"_"+cidr, "/", "_")
+}
 
-	// Get realized state
-	return p.checkStaticRouteRealizedState(routeID)
+// staticRoutePath returns the policy path of a static route
+func (p *routeProvider) staticRoutePath(routerPath, routeID string) string {
+	return routerPath + "/static-routes/" + routeID
 }
 
-// generateStaticRoute generates NSXT static route
-func (p *routeProvider) generateStaticRoute(clusterName string, nameHint string, nodeName string, cidr string, nodeIP string) (
-	routeID string, staticRoute model.StaticRoutes) {
+// generateStaticRoute generates NSXT static route with a next hop for each node IP
+func (p *routeProvider) generateStaticRoute(clusterName string, nodeName string, cidr string, nodeIPs []string) model.StaticRoutes {
 	var tags []model.Tag
 	clusterNameScope := config.ClusterNameTagScope
 	nodeNameScope := config.NodeNameTagScope
 	tags = append(tags, model.Tag{Scope: &clusterNameScope, Tag: &clusterName})
 	tags = append(tags, model.Tag{Scope: &nodeNameScope, Tag: &nodeName})
+	scopes := make([]string, 0, len(p.additionalTags))
+	for scope := range p.additionalTags {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	for _, scope := range scopes {
+		scope, tag := scope, p.additionalTags[scope]
+		tags = append(tags, model.Tag{Scope: &scope, Tag: &tag})
+	}
 	var nexthops []model.RouterNexthop
-	nexthops = append(nexthops, model.RouterNexthop{IpAddress: &nodeIP})
-	routeID = nameHint + "_" + cidr
-	routeID = strings.ReplaceAll(routeID, "/", "_")
+	for _, nodeIP := range nodeIPs {
+		nodeIP := nodeIP
+		nexthop := model.RouterNexthop{IpAddress: &nodeIP}
+		if p.adminDistance > 0 {
+			adminDistance := p.adminDistance
+			nexthop.AdminDistance = &adminDistance
+		}
+		nexthops = append(nexthops, nexthop)
+	}
 	routeName := clusterName + "_" + nodeName + "_" + cidr
-	staticRoute = model.StaticRoutes{
+	return model.StaticRoutes{
 		DisplayName: &routeName,
 		Network:     &cidr,
 		NextHops:    nexthops,
 		Tags:        tags,
 	}
-	return routeID, staticRoute
 }
 
 // DeleteRoute deletes Node's static route on NSXT
+// Routes on routers removed from the config are named by their policy path.
 func (p *routeProvider) DeleteRoute(ctx context.Context, clusterName string, route *cloudprovider.Route) error {
+	routerPath, routeID, ok := splitStaticRoutePath(route.Name)
+	if !ok {
+		routerPath, routeID = p.routeRouterPath(route.Name), route.Name
+	}
 	klog.V(6).Infof("Deleting static route %s on router %s in cluster %s",
-		route.Name, p.routerPath, clusterName)
-	err := p.broker.DeleteStaticRoute(p.routerPath, route.Name)
+		routeID, routerPath, clusterName)
+	err := p.broker.DeleteStaticRoute(routerPath, routeID)
 	if err != nil {
-		klog.Errorf("deleting static route %s failed: %s", route.Name, err)
+		klog.Errorf("deleting static route %s failed: %s", routeID, err)
 		return err
 	}
+	p.forgetRouteRouterPath(routeID, routerPath)
+	p.forgetNodeRoute(string(route.TargetNode), routeID, routerPath)
+	if p.tracker != nil {
+		p.tracker.Forget(p.staticRoutePath(routerPath, routeID))
+	}
+	if p.bfd {
+		err = p.syncBfdPeers(clusterName, routerPath)
+		if err != nil {
+			klog.Errorf("deleting BFD peers of static route %s failed: %s", routeID, err)
+			return err
+		}
+	}
 	return nil
 }
 
-// checkStaticRouteRealizedState checks static route realized state
-// The check happends every 1 second and the default timeout is 10 seconds
-// Do not delete the creating static route after the timeout
-func (p *routeProvider) checkStaticRouteRealizedState(routeID string) error {
-	path := p.routerPath + "/static-routes/" + routeID
-	timeout := time.After(config.RealizedStateTimeout)
-	ticker := time.NewTicker(config.RealizedStateSleepTime)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("timed out waiting for static route %s", path)
-		case <-ticker.C:
-			list, err := p.broker.ListRealizedEntities(path)
-			if err != nil {
-				return fmt.Errorf("get route %s realized state failed: %s", path, err)
-			}
-			for _, resource := range list.Results {
-				if len(resource.IntentPaths) == 0 {
-					continue
-				}
-				if resource.IntentPaths[0] == path && *resource.State == config.RealizedState {
-					return nil
-				}
+// getNodeNextHops returns the next hop addresses for the static routes of a node.
+// Without ECMP, subnets or network name it is the node IP address. Otherwise
+// the InternalIPs of the node and the addresses of the VM networks are filtered
+// by subnets and network name, with ECMP all of them are used.
+func (p *routeProvider) getNodeNextHops(nodeName string, isIPv4 bool) ([]string, error) {
+	if !p.ecmp && len(p.nextHopSubnets) == 0 && p.nextHopNetworkName == "" {
+		nodeIP, err := p.getNodeIPAddress(nodeName, isIPv4)
+		if err != nil {
+			return nil, err
+		}
+		return []string{nodeIP}, nil
+	}
+	node, err := p.getNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks map[string][]string
+	if p.networkAddresses != nil {
+		networks = p.networkAddresses(nodeName)
+	}
+	networkNames := make([]string, 0, len(networks))
+	networkOfIP := map[string]string{}
+	for name, ips := range networks {
+		networkNames = append(networkNames, name)
+		for _, ip := range ips {
+			networkOfIP[ip] = name
+		}
+	}
+	sort.Strings(networkNames)
+
+	seen := sets.NewString()
+	var nexthops []string
+	add := func(address, networkName string) {
+		ip := net.ParseIP(address)
+		if ip == nil || (ip.To4() != nil) != isIPv4 || seen.Has(ip.String()) {
+			return
+		}
+		if p.nextHopNetworkName != "" && !strings.EqualFold(p.nextHopNetworkName, networkName) {
+			return
+		}
+		if len(p.nextHopSubnets) > 0 && !subnetsContain(p.nextHopSubnets, ip) {
+			return
+		}
+		seen.Insert(ip.String())
+		nexthops = append(nexthops, ip.String())
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			add(addr.Address, networkOfIP[addr.Address])
+		}
+	}
+	// VM network addresses are only considered if restricted by subnets or network name
+	if len(p.nextHopSubnets) > 0 || p.nextHopNetworkName != "" {
+		for _, name := range networkNames {
+			for _, ip := range networks[name] {
+				add(ip, name)
 			}
 		}
 	}
+	if len(nexthops) == 0 {
+		return nil, fmt.Errorf("node %s has no address of the IP family of the podCIDR matching the next hop subnets or network name", nodeName)
+	}
+	if !p.ecmp {
+		return nexthops[:1], nil
+	}
+	return nexthops, nil
+}
+
+func subnetsContain(subnets []*net.IPNet, ip net.IP) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 // getNodeIPAddress gets node IP address
@@ -233,11 +721,13 @@ func (p *routeProvider) getNodeIPAddress(nodeName string, isIPv4 bool) (string,
 	return "", fmt.Errorf("node %s does not have the same IP family with podCIDR", nodeName)
 }
 
-// AddNode adds v1.Node in nodeMap
+// AddNode adds v1.Node in nodeMap and updates the next hops of the static
+// routes of the node if its addresses have changed
 func (p *routeProvider) AddNode(node *v1.Node) {
 	p.nodeMapLock.Lock()
 	p.nodeMap[node.Name] = node
 	p.nodeMapLock.Unlock()
+	p.updateNodeRoutes(node.Name)
 }
 
 // DeleteNode deletes v1.Node from nodeMap
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"strings"
 	"testing"
 
@@ -200,7 +201,8 @@ func TestGenerateIPv4StaticRoute(t *testing.T) {
 	nodeIP := "172.50.0.13"
 
 	p := &routeProvider{}
-	routeID, staticRoute := p.generateStaticRoute(clusterName, nameHint, nodeName, cidr, nodeIP)
+	routeID := staticRouteID(nameHint, cidr)
+	staticRoute := p.generateStaticRoute(clusterName, nodeName, cidr, []string{nodeIP})
 
 	assert.Equal(t, "nameHint_100.96.0.0_24", routeID, "routeID should be nameHint_100.96.0.0_24")
 	assert.Equal(t, "cluster1_node1_100.96.0.0/24", *staticRoute.DisplayName,
@@ -224,7 +226,8 @@ func TestGenerateIPv6StaticRoute(t *testing.T) {
 	nodeIP := "21DA:00D3:0000:2F3B:02AC:00FF:FE28:9C5A"
 
 	p := &routeProvider{}
-	routeID, staticRoute := p.generateStaticRoute(clusterName, nameHint, nodeName, cidr, nodeIP)
+	routeID := staticRouteID(nameHint, cidr)
+	staticRoute := p.generateStaticRoute(clusterName, nodeName, cidr, []string{nodeIP})
 
 	assert.Equal(t, "nameHint_21DA:00D3:0000:2F3B::_64", routeID, "routeID should be nameHint_21DA:00D3:0000:2F3B::_64")
 	assert.Equal(t, "cluster1_node1_21DA:00D3:0000:2F3B::/64", *staticRoute.DisplayName,
@@ -240,65 +243,6 @@ func TestGenerateIPv6StaticRoute(t *testing.T) {
 	assert.Equal(t, "node1", *tag.Tag, "Tag should be node1")
 }
 
-func TestCheckStaticRouteRealizedState(t *testing.T) {
-	response := `
-{
-  "results" : [ {
-    "extended_attributes" : [ {
-      "data_type" : "STRING",
-      "multivalue" : false,
-      "values" : [ "47cb5dd7-7f2a-41e5-886d-b36ef8c31bf4" ],
-      "key" : "logical-router-id"
-    } ],
-    "intent_paths" : [ "/infra/tier-1s/test-t1/static-routes/a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24" ],
-    "resource_type" : "GenericPolicyRealizedResource",
-    "id" : "test-t1-a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24",
-    "display_name" : "test-t1-a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24",
-    "path" : "/infra/realized-state/enforcement-points/default/tier-1-static-routes/test-t1-a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24",
-    "relative_path" : "test-t1-a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24",
-    "parent_path" : "/infra/realized-state/enforcement-points/default",
-    "unique_id" : "7111cdae-6d03-434a-846f-7685eab433e2",
-    "intent_reference" : [ "/infra/tier-1s/test-t1/static-routes/a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24" ],
-    "realization_specific_identifier" : "3598b02f-4fe6-47f0-89b8-494a035c10ff",
-    "realization_api" : "/api/v1/logical-routers/47cb5dd7-7f2a-41e5-886d-b36ef8c31bf4/routing/static-routes/3598b02f-4fe6-47f0-89b8-494a035c10ff",
-    "state" : "REALIZED",
-    "alarms" : [ ],
-    "runtime_status" : "UNINITIALIZED",
-    "_create_user" : "system",
-    "_create_time" : 1606960154089,
-    "_last_modified_user" : "system",
-    "_last_modified_time" : 1606960154204,
-    "_system_owned" : false,
-    "_protection" : "NOT_PROTECTED",
-    "_revision" : 1
-  } ],
-  "result_count" : 1
-}
-`
-	d := json.NewDecoder(strings.NewReader(response))
-	d.UseNumber()
-	var jsondata interface{}
-	d.Decode(&jsondata)
-	decoder := cleanjson.NewJsonToDataValueDecoder()
-	dataValue, _ := decoder.Decode(jsondata)
-	typeConverter := bindings.NewTypeConverter()
-	output, _ := typeConverter.ConvertToGolang(dataValue,
-		bindings.NewReferenceType(model.GenericPolicyRealizedResourceListResultBindingType))
-
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-	mockBroker := NewMockNsxtBroker(ctrl)
-	routePath := "/infra/tier-1s/test-t1/static-routes/a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24"
-	mockBroker.EXPECT().ListRealizedEntities(routePath).Return(output, nil)
-	p := &routeProvider{
-		routerPath: "/infra/tier-1s/test-t1",
-		broker:     mockBroker,
-	}
-
-	err := p.checkStaticRouteRealizedState("a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24")
-	assert.Equal(t, nil, err, "Should not return error")
-}
-
 func TestDeleteRoute(t *testing.T) {
 	clusterName := "kubernetes"
 	routerName := "a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24"
@@ -390,3 +334,256 @@ func TestGetNode(t *testing.T) {
 	assert.Equal(t, node, nodeInMap, "Node should be the same")
 	assert.Equal(t, nil, err, "Should not return any error")
 }
+
+func TestZoneRouters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := &routeProvider{
+		routerPath:      "/infra/tier-1s/default",
+		zoneRouterPaths: map[string]string{"zone-a": "/infra/tier-1s/router-a", "zone-b": "/infra/tier-0s/router-b"},
+		additionalTags:  map[string]string{"team": "net", "env": "test"},
+		adminDistance:   3,
+		broker:          mockBroker,
+		nodeMap:         make(map[string]*v1.Node),
+	}
+	nodeA := buildFakeNode("node-a")
+	nodeA.Labels = map[string]string{v1.LabelTopologyZone: "zone-a"}
+	p.AddNode(nodeA)
+	nodeB := buildFakeNode("node-b")
+	nodeB.Labels = map[string]string{v1.LabelFailureDomainBetaZone: "zone-b"}
+	p.AddNode(nodeB)
+	nodeC := buildFakeNode("node-c")
+	nodeC.Labels = map[string]string{v1.LabelTopologyZone: "zone-c"}
+	p.AddNode(nodeC)
+
+	for node, expected := range map[string]string{
+		"node-a": "/infra/tier-1s/router-a",
+		"node-b": "/infra/tier-0s/router-b",
+		"node-c": "/infra/tier-1s/default",
+	} {
+		path, err := p.routerPathForNode(node)
+		assert.Equal(t, nil, err, "Should not return error")
+		assert.Equal(t, expected, path, "Unexpected router for %s", node)
+	}
+
+	staticRoute := p.generateStaticRoute("cluster1", "node-a", "100.96.0.0/24", []string{"172.50.0.13"})
+	assert.Equal(t, 4, len(staticRoute.Tags), "Should have 4 tags")
+	assert.Equal(t, "env", *staticRoute.Tags[2].Scope, "Additional tags should be sorted")
+	assert.Equal(t, int64(3), *staticRoute.NextHops[0].AdminDistance, "Admin distance should be 3")
+
+	route := cloudprovider.Route{TargetNode: "node-a", DestinationCIDR: "100.96.0.0/24"}
+	mockBroker.EXPECT().CreateStaticRoute("/infra/tier-1s/router-a", "hint_100.96.0.0_24", gomock.Any()).Return(nil)
+	err := p.CreateRoute(context.TODO(), "cluster1", "hint", &route)
+	assert.Equal(t, nil, err, "Should not return error")
+
+	mockBroker.EXPECT().DeleteStaticRoute("/infra/tier-1s/router-a", "hint_100.96.0.0_24").Return(nil)
+	err = p.DeleteRoute(context.TODO(), "cluster1", &cloudprovider.Route{Name: "hint_100.96.0.0_24"})
+	assert.Equal(t, nil, err, "Should not return error")
+
+	p.routerPath = ""
+	_, err = p.routerPathForNode("node-c")
+	assert.NotEqual(t, nil, err, "Should return error for unmapped zone without default router")
+}
+
+func TestListRoutesMultipleRouters(t *testing.T) {
+	response := `
+{
+  "results" : [ {
+    "network" : "100.96.0.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node1" } ],
+    "path" : "/infra/tier-1s/router-a/static-routes/route1",
+    "id" : "route1"
+  }, {
+    "network" : "100.96.1.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node2" } ],
+    "path" : "/infra/tier-0s/router-b/static-routes/route2",
+    "id" : "route2"
+  }, {
+    "network" : "100.96.2.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node3" } ],
+    "path" : "/infra/tier-1s/other/static-routes/route3",
+    "id" : "route3"
+  } ],
+  "result_count" : 3
+}
+`
+	d := json.NewDecoder(strings.NewReader(response))
+	d.UseNumber()
+	var jsondata interface{}
+	d.Decode(&jsondata)
+	dataValue, _ := cleanjson.NewJsonToDataValueDecoder().Decode(jsondata)
+	output, _ := bindings.NewTypeConverter().ConvertToGolang(dataValue, bindings.NewReferenceType(model.SearchResponseBindingType))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := &routeProvider{
+		zoneRouterPaths: map[string]string{"zone-a": "/infra/tier-1s/router-a", "zone-b": "/infra/tier-0s/router-b"},
+		broker:          mockBroker,
+	}
+	mockBroker.EXPECT().QueryEntities(gomock.Any()).Return(output, nil)
+	routes, err := p.ListRoutes(context.TODO(), "kubernetes")
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, 3, len(routes), "Should have 3 routes")
+	assert.False(t, routes[0].Blackhole || routes[1].Blackhole, "Routes of configured routers should not be blackholes")
+	assert.Equal(t, "/infra/tier-0s/router-b", p.routeRouterPath("route2"), "Router of route2 should be remembered")
+
+	mockBroker.EXPECT().DeleteStaticRoute("/infra/tier-0s/router-b", "route2").Return(nil)
+	err = p.DeleteRoute(context.TODO(), "kubernetes", routes[1])
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.NotContains(t, p.routeRouterPaths, "route2", "Router of deleted route2 should be forgotten")
+
+	// routes of routers removed from the config are listed for deletion
+	assert.True(t, routes[2].Blackhole, "Route of removed router should be a blackhole")
+	assert.Equal(t, "/infra/tier-1s/other/static-routes/route3", routes[2].Name, "Route of removed router should be named by path")
+	mockBroker.EXPECT().DeleteStaticRoute("/infra/tier-1s/other", "route3").Return(nil)
+	err = p.DeleteRoute(context.TODO(), "kubernetes", routes[2])
+	assert.Equal(t, nil, err, "Should not return error")
+}
+
+func buildFakeMultiNICNode(nodeName string) *v1.Node {
+	node := buildFakeNode(nodeName)
+	node.Status.Addresses = append(node.Status.Addresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.1.5"})
+	return node
+}
+
+func TestGetNodeNextHops(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.0.0.0/16")
+	p := &routeProvider{
+		nodeMap: make(map[string]*v1.Node),
+	}
+	p.AddNode(buildFakeMultiNICNode("node1"))
+
+	nexthops, err := p.getNodeNextHops("node1", true)
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, []string{"172.50.0.13"}, nexthops, "Should use node IP without ECMP")
+
+	p.ecmp = true
+	nexthops, err = p.getNodeNextHops("node1", true)
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, []string{"172.50.0.13", "10.0.1.5"}, nexthops, "Should use all InternalIPs with ECMP")
+
+	p.nextHopSubnets = []*net.IPNet{subnet}
+	p.SetNodeNetworkAddresses(func(nodeName string) map[string][]string {
+		return map[string][]string{
+			"uplink-b": {"10.0.2.5"},
+			"uplink-a": {"10.0.1.5"},
+			"storage":  {"192.168.0.5"},
+		}
+	})
+	nexthops, err = p.getNodeNextHops("node1", true)
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, []string{"10.0.1.5", "10.0.2.5"}, nexthops, "Should use VM addresses in subnet")
+
+	p.nextHopSubnets = nil
+	p.nextHopNetworkName = "Uplink-B"
+	nexthops, err = p.getNodeNextHops("node1", true)
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, []string{"10.0.2.5"}, nexthops, "Should use VM addresses of network")
+
+	_, err = p.getNodeNextHops("node1", false)
+	assert.NotEqual(t, nil, err, "Should return error without matching address")
+}
+
+func TestAddNodeUpdatesNextHops(t *testing.T) {
+	response := `
+{
+  "results" : [ {
+    "network" : "100.96.0.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node1" } ],
+    "path" : "/infra/tier-0s/t0/static-routes/route1",
+    "id" : "route1",
+    "next_hops" : [ { "ip_address" : "172.50.0.13" } ]
+  }, {
+    "network" : "100.96.1.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node2" } ],
+    "path" : "/infra/tier-0s/t0/static-routes/route2",
+    "id" : "route2",
+    "next_hops" : [ { "ip_address" : "10.0.1.5" } ]
+  } ],
+  "result_count" : 2
+}
+`
+	d := json.NewDecoder(strings.NewReader(response))
+	d.UseNumber()
+	var jsondata interface{}
+	d.Decode(&jsondata)
+	dataValue, _ := cleanjson.NewJsonToDataValueDecoder().Decode(jsondata)
+	output, _ := bindings.NewTypeConverter().ConvertToGolang(dataValue, bindings.NewReferenceType(model.SearchResponseBindingType))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := &routeProvider{
+		routerPath: "/infra/tier-0s/t0",
+		ecmp:       true,
+		bfd:        true,
+		broker:     mockBroker,
+		nodeMap:    make(map[string]*v1.Node),
+	}
+
+	// listing does not change the static routes
+	mockBroker.EXPECT().QueryEntities(gomock.Any()).Return(output, nil)
+	routes, err := p.ListRoutes(context.TODO(), "kubernetes")
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, 2, len(routes), "Should have 2 routes")
+
+	// the BFD peer of 10.0.1.5 is shared with route2
+	mockBroker.EXPECT().CreateStaticRoute("/infra/tier-0s/t0", "route1", gomock.Any()).DoAndReturn(
+		func(routerPath, routeID string, staticRoute model.StaticRoutes) error {
+			assert.Equal(t, 2, len(staticRoute.NextHops), "Should have 2 next hops")
+			return nil
+		})
+	mockBroker.EXPECT().QueryEntities(gomock.Any()).Return(model.SearchResponse{}, nil)
+	mockBroker.EXPECT().CreateStaticRouteBfdPeer("/infra/tier-0s/t0", "bfd_172_50_0_13", gomock.Any()).Return(nil)
+	mockBroker.EXPECT().CreateStaticRouteBfdPeer("/infra/tier-0s/t0", "bfd_10_0_1_5", gomock.Any()).Return(nil)
+	p.AddNode(buildFakeMultiNICNode("node1"))
+
+	// unchanged next hops are not updated
+	p.AddNode(buildFakeMultiNICNode("node1"))
+}
+
+func TestDeleteRouteBfdPeers(t *testing.T) {
+	response := `
+{
+  "results" : [ {
+    "id" : "bfd_10_0_1_5",
+    "path" : "/infra/tier-0s/t0/static-routes/bfd-peers/bfd_10_0_1_5"
+  }, {
+    "id" : "bfd_10_0_1_6",
+    "path" : "/infra/tier-0s/t0/static-routes/bfd-peers/bfd_10_0_1_6"
+  }, {
+    "id" : "bfd_10_0_1_7",
+    "path" : "/infra/tier-0s/t0/static-routes/bfd-peers/bfd_10_0_1_7"
+  } ],
+  "result_count" : 3
+}
+`
+	d := json.NewDecoder(strings.NewReader(response))
+	d.UseNumber()
+	var jsondata interface{}
+	d.Decode(&jsondata)
+	dataValue, _ := cleanjson.NewJsonToDataValueDecoder().Decode(jsondata)
+	output, _ := bindings.NewTypeConverter().ConvertToGolang(dataValue, bindings.NewReferenceType(model.SearchResponseBindingType))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := &routeProvider{
+		routerPath: "/infra/tier-0s/t0",
+		bfd:        true,
+		broker:     mockBroker,
+	}
+	p.setNodeRoute("node1", &nodeRoute{routerPath: "/infra/tier-0s/t0", routeID: "route1", nexthops: []string{"10.0.1.5", "10.0.1.6"}})
+	p.setNodeRoute("node2", &nodeRoute{routerPath: "/infra/tier-0s/t0", routeID: "route2", nexthops: []string{"10.0.1.6"}})
+	p.setNodeRoute("node3", &nodeRoute{routerPath: "/infra/tier-0s/t0", routeID: "route3", nexthops: []string{"10.0.1.7"}})
+
+	// the peer of 10.0.1.6 is still used by route2
+	mockBroker.EXPECT().DeleteStaticRoute("/infra/tier-0s/t0", "route1").Return(nil)
+	mockBroker.EXPECT().QueryEntities(gomock.Any()).Return(output, nil)
+	mockBroker.EXPECT().DeleteStaticRouteBfdPeer("/infra/tier-0s/t0", "bfd_10_0_1_5").Return(nil)
+	err := p.DeleteRoute(context.TODO(), "kubernetes", &cloudprovider.Route{Name: "route1", TargetNode: "node1"})
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, 0, len(p.routesOfNode("node1")), "Route of node1 should be forgotten")
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/bindings"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route/config"
+	nodeutil "k8s.io/component-helpers/node/util"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// realizedStateQueryBatchSize is the maximum number of intent paths checked with one query
+	realizedStateQueryBatchSize = 20
+
+	// reasons used for node conditions and events
+	reasonRouteCreated            = "RouteCreated"
+	reasonRouteRealizationFailed  = "RouteRealizationFailed"
+	reasonRouteRealizationTimeout = "RouteRealizationTimeout"
+)
+
+// trackedRoute is a static route whose realization is watched by the realizationTracker
+type trackedRoute struct {
+	path     string
+	nodeName string
+	cidr     string
+	since    time.Time
+	// failed is set if the realization of the route failed before
+	failed bool
+}
+
+// realizationTracker watches the realized state of created static routes in the
+// background. Routes in error are reported as node condition and event and are
+// hidden from the route list, so that the route controller creates them again.
+type realizationTracker struct {
+	broker    NsxtBroker
+	converter *bindings.TypeConverter
+	interval  time.Duration
+	timeout   time.Duration
+
+	lock    sync.Mutex
+	pending map[string]*trackedRoute
+	failed  map[string]*trackedRoute
+
+	client   clientset.Interface
+	recorder record.EventRecorder
+}
+
+func newRealizationTracker(broker NsxtBroker) *realizationTracker {
+	return &realizationTracker{
+		broker:    broker,
+		converter: bindings.NewTypeConverter(),
+		interval:  config.RealizedStateSleepTime,
+		timeout:   config.RealizedStateTimeout,
+		pending:   map[string]*trackedRoute{},
+		failed:    map[string]*trackedRoute{},
+	}
+}
+
+// Run reports realization results for the nodes using the given client and
+// checks the pending routes periodically until the stop channel is closed.
+func (t *realizationTracker) Run(client clientset.Interface, stop <-chan struct{}) {
+	if client != nil {
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartLogging(klog.Infof)
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+		t.lock.Lock()
+		t.client = client
+		t.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "vsphere-route-tracker"})
+		t.lock.Unlock()
+	}
+	go wait.Until(t.checkPending, t.interval, stop)
+}
+
+// Track starts watching the realization of a static route
+func (t *realizationTracker) Track(path, nodeName, cidr string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	route := &trackedRoute{path: path, nodeName: nodeName, cidr: cidr, since: time.Now()}
+	if old := t.failed[path]; old != nil {
+		route.failed = true
+		delete(t.failed, path)
+	}
+	t.pending[path] = route
+}
+
+// Forget stops watching a static route
+func (t *realizationTracker) Forget(path string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.pending, path)
+	delete(t.failed, path)
+}
+
+// IsFailed returns true if the realization of the static route failed
+func (t *realizationTracker) IsFailed(path string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	_, ok := t.failed[path]
+	return ok
+}
+
+// checkPending queries the realized state of all pending routes
+func (t *realizationTracker) checkPending() {
+	t.lock.Lock()
+	paths := make([]string, 0, len(t.pending))
+	for path := range t.pending {
+		paths = append(paths, path)
+	}
+	t.lock.Unlock()
+
+	for start := 0; start < len(paths); start += realizedStateQueryBatchSize {
+		end := start + realizedStateQueryBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		states, err := t.queryRealizedStates(paths[start:end])
+		if err != nil {
+			klog.Errorf("querying realized state of %d static routes failed: %s", end-start, err)
+			continue
+		}
+		for _, path := range paths[start:end] {
+			t.update(path, states[path])
+		}
+	}
+}
+
+// queryRealizedStates returns the realized states of the given intent paths
+func (t *realizationTracker) queryRealizedStates(paths []string) (map[string]*model.GenericPolicyRealizedResource, error) {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = fmt.Sprintf("%q", path)
+	}
+	queryParam := fmt.Sprintf("resource_type:GenericPolicyRealizedResource AND intent_paths:(%s)", strings.Join(quoted, " OR "))
+	response, err := t.broker.QueryEntities(queryParam)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]*model.GenericPolicyRealizedResource{}
+	for _, item := range response.Results {
+		itf, errs := t.converter.ConvertToGolang(item, model.GenericPolicyRealizedResourceBindingType())
+		if errs != nil {
+			return nil, errs[0]
+		}
+		resource := itf.(model.GenericPolicyRealizedResource)
+		if len(resource.IntentPaths) == 0 || resource.State == nil {
+			continue
+		}
+		states[resource.IntentPaths[0]] = &resource
+	}
+	return states, nil
+}
+
+// update processes the realized state of a pending route
+func (t *realizationTracker) update(path string, resource *model.GenericPolicyRealizedResource) {
+	t.lock.Lock()
+	route := t.pending[path]
+	if route == nil {
+		t.lock.Unlock()
+		return
+	}
+	var reason, message string
+	switch {
+	case resource != nil && *resource.State == config.RealizedState:
+		delete(t.pending, path)
+	case resource != nil && *resource.State == config.ErrorState:
+		reason = reasonRouteRealizationFailed
+		message = fmt.Sprintf("realization of static route %s for %s failed", path, route.cidr)
+		if len(resource.Alarms) > 0 && resource.Alarms[0].Message != nil {
+			message += ": " + *resource.Alarms[0].Message
+		}
+	case time.Since(route.since) > t.timeout:
+		reason = reasonRouteRealizationTimeout
+		message = fmt.Sprintf("static route %s for %s not realized within %s", path, route.cidr, t.timeout)
+	default:
+		t.lock.Unlock()
+		return
+	}
+	if reason != "" {
+		delete(t.pending, path)
+		t.failed[path] = route
+	}
+	t.lock.Unlock()
+
+	if reason != "" {
+		klog.Warningf("node %s: %s", route.nodeName, message)
+		t.report(route.nodeName, v1.ConditionTrue, v1.EventTypeWarning, reason, message)
+	} else if route.failed {
+		klog.Infof("node %s: static route %s for %s realized", route.nodeName, path, route.cidr)
+		t.report(route.nodeName, v1.ConditionFalse, v1.EventTypeNormal, reasonRouteCreated,
+			fmt.Sprintf("static route %s for %s realized", path, route.cidr))
+	}
+}
+
+// report sets the NetworkUnavailable condition of the node and records an event
+func (t *realizationTracker) report(nodeName string, status v1.ConditionStatus, eventType, reason, message string) {
+	t.lock.Lock()
+	client, recorder := t.client, t.recorder
+	t.lock.Unlock()
+	if client == nil {
+		return
+	}
+	err := nodeutil.SetNodeCondition(client, types.NodeName(nodeName), v1.NodeCondition{
+		Type:               v1.NodeNetworkUnavailable,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+	if err != nil {
+		klog.Errorf("updating network condition of node %s failed: %s", nodeName, err)
+	}
+	nodeRef := &v1.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+	recorder.Event(nodeRef, eventType, reason, message)
+}
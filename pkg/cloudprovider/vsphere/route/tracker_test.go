@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/bindings"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/data/serializers/cleanjson"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/model"
+)
+
+func realizedStateResponse(path, state string) model.SearchResponse {
+	response := fmt.Sprintf(`
+{
+  "results" : [ {
+    "intent_paths" : [ "%s" ],
+    "resource_type" : "GenericPolicyRealizedResource",
+    "id" : "test-t1-a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24",
+    "state" : "%s",
+    "alarms" : [ ],
+    "runtime_status" : "UNINITIALIZED"
+  } ],
+  "result_count" : 1
+}
+`, path, state)
+	d := json.NewDecoder(strings.NewReader(response))
+	d.UseNumber()
+	var jsondata interface{}
+	d.Decode(&jsondata)
+	decoder := cleanjson.NewJsonToDataValueDecoder()
+	dataValue, _ := decoder.Decode(jsondata)
+	typeConverter := bindings.NewTypeConverter()
+	output, _ := typeConverter.ConvertToGolang(dataValue, bindings.NewReferenceType(model.SearchResponseBindingType))
+	return output.(model.SearchResponse)
+}
+
+func TestRealizationTracker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	routePath := "/infra/tier-1s/test-t1/static-routes/a4775ec4-8b68-42ea-86fc-d17390e4c373_100.96.1.0_24"
+	queryParam := fmt.Sprintf("resource_type:GenericPolicyRealizedResource AND intent_paths:(%q)", routePath)
+
+	client := fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}})
+	recorder := record.NewFakeRecorder(10)
+	tracker := newRealizationTracker(mockBroker)
+	tracker.client = client
+	tracker.recorder = recorder
+
+	// route in error is reported and marked as failed
+	tracker.Track(routePath, "node2", "100.96.1.0/24")
+	mockBroker.EXPECT().QueryEntities(queryParam).Return(realizedStateResponse(routePath, "ERROR"), nil)
+	tracker.checkPending()
+	assert.True(t, tracker.IsFailed(routePath), "route should be failed")
+	node, _ := client.CoreV1().Nodes().Get(context.TODO(), "node2", metav1.GetOptions{})
+	assert.Equal(t, 1, len(node.Status.Conditions), "node should have a condition")
+	assert.Equal(t, v1.NodeNetworkUnavailable, node.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionTrue, node.Status.Conditions[0].Status)
+	assert.Equal(t, reasonRouteRealizationFailed, node.Status.Conditions[0].Reason)
+	assert.True(t, strings.Contains(<-recorder.Events, reasonRouteRealizationFailed), "expected warning event")
+
+	// retried route is realized and reported as recovered
+	tracker.Track(routePath, "node2", "100.96.1.0/24")
+	assert.False(t, tracker.IsFailed(routePath), "route should be pending again")
+	mockBroker.EXPECT().QueryEntities(queryParam).Return(realizedStateResponse(routePath, "REALIZED"), nil)
+	tracker.checkPending()
+	assert.Equal(t, 0, len(tracker.pending), "no route should be pending")
+	node, _ = client.CoreV1().Nodes().Get(context.TODO(), "node2", metav1.GetOptions{})
+	assert.Equal(t, v1.ConditionFalse, node.Status.Conditions[0].Status)
+	assert.Equal(t, reasonRouteCreated, node.Status.Conditions[0].Reason)
+	assert.True(t, strings.Contains(<-recorder.Events, reasonRouteCreated), "expected normal event")
+
+	// route not realized within the timeout is marked as failed
+	tracker.timeout = time.Millisecond
+	tracker.Track(routePath, "node2", "100.96.1.0/24")
+	time.Sleep(2 * time.Millisecond)
+	mockBroker.EXPECT().QueryEntities(queryParam).Return(realizedStateResponse(routePath, "IN_PROGRESS"), nil)
+	tracker.checkPending()
+	assert.True(t, tracker.IsFailed(routePath), "route should be failed after timeout")
+
+	tracker.Forget(routePath)
+	assert.False(t, tracker.IsFailed(routePath), "route should be forgotten")
+}
+
+func TestListRoutesSkipsFailedRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockBroker := NewMockNsxtBroker(ctrl)
+	p := &routeProvider{
+		routerPath: "/infra/tier-1s/test-t1",
+		broker:     mockBroker,
+		tracker:    newRealizationTracker(mockBroker),
+	}
+	p.tracker.failed[p.staticRoutePath(p.routerPath, "route1")] = &trackedRoute{nodeName: "node1"}
+
+	response := `
+{
+  "results" : [ {
+    "network" : "100.96.0.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node1" } ],
+    "id" : "route1"
+  }, {
+    "network" : "100.96.1.0/24",
+    "tags" : [ { "scope" : "vsphere.k8s.io/node-name", "tag" : "node2" } ],
+    "id" : "route2"
+  } ],
+  "result_count" : 2
+}
+`
+	d := json.NewDecoder(strings.NewReader(response))
+	d.UseNumber()
+	var jsondata interface{}
+	d.Decode(&jsondata)
+	dataValue, _ := cleanjson.NewJsonToDataValueDecoder().Decode(jsondata)
+	output, _ := bindings.NewTypeConverter().ConvertToGolang(dataValue, bindings.NewReferenceType(model.SearchResponseBindingType))
+	mockBroker.EXPECT().QueryEntities(gomock.Any()).Return(output, nil)
+
+	routes, err := p.ListRoutes(context.TODO(), "kubernetes")
+	assert.Equal(t, nil, err, "Should not return error")
+	assert.Equal(t, 1, len(routes), "Should have 1 route")
+	assert.Equal(t, "route2", routes[0].Name)
+}
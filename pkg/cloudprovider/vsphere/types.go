@@ -23,6 +23,7 @@ import (
 	cloudprovider "k8s.io/cloud-provider"
 
 	ccfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/config"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/ipam"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer"
 	lbcfg "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/loadbalancer/config"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphere/route"
@@ -44,6 +45,7 @@ type VSphere struct {
 	cfgLB        *lbcfg.LBConfig
 	loadbalancer loadbalancer.LBProvider
 	routes       route.RoutesProvider
+	ipam         ipam.NodeIPAM
 
 	// cloud provider interfaces
 	instances cloudprovider.Instances
@@ -69,6 +71,8 @@ type NodeInfo struct {
 	NodeName      string
 	NodeType      string
 	NodeAddresses []v1.NodeAddress
+	// NetworkAddresses are the IP addresses of the VM by network name
+	NetworkAddresses map[string][]string
 }
 
 // DatacenterInfo is information about a vCenter datascenter.
@@ -98,10 +102,13 @@ type NodeManager struct {
 
 	// Reference to CPI-specific configuration
 	cfg *ccfg.CPIConfig
+	// Node networking of a VSphereNodeNetworkPolicy replacing cfg.Nodes
+	nodesOverride *ccfg.Nodes
 
 	// Mutexes
 	nodeInfoLock    sync.RWMutex
 	nodeRegInfoLock sync.RWMutex
+	nodesLock       sync.RWMutex
 }
 
 type instances struct {
@@ -142,7 +142,7 @@ func TestNewVSphere(t *testing.T) {
 		t.Skipf("No config found in environment")
 	}
 
-	_, err := newVSphere(cfg, nil, nil, nil)
+	_, err := newVSphere(cfg, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to construct/authenticate vSphere: %s", err)
 	}
@@ -155,7 +155,7 @@ func TestVSphereLogin(t *testing.T) {
 	cfg.Config = *initCfg
 
 	// Create vSphere configuration object
-	vs, err := newVSphere(cfg, nil, nil, nil)
+	vs, err := newVSphere(cfg, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to construct/authenticate vSphere: %s", err)
 	}
@@ -190,7 +190,7 @@ func TestVSphereLoginByToken(t *testing.T) {
 	cfg.Global.Password = localhostKey
 
 	// Create vSphere configuration object
-	vs, err := newVSphere(cfg, nil, nil, nil)
+	vs, err := newVSphere(cfg, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to construct/authenticate vSphere: %s", err)
 	}
@@ -278,7 +278,7 @@ func TestAlphaDualStackConfig(t *testing.T) {
 				}()
 			}
 
-			_, err = buildVSphereFromConfig(cfg, nil, nil, nil)
+			_, err = buildVSphereFromConfig(cfg, nil, nil, nil, nil)
 			if !reflect.DeepEqual(err, testcase.expectedError) {
 				t.Logf("actual error: %v", err)
 				t.Logf("expected error: %v", err)
@@ -519,7 +519,7 @@ func TestSecretVSphereConfig(t *testing.T) {
 				t.Fatalf("readConfig: unexpected error returned: %v", err)
 			}
 		}
-		vs, err = buildVSphereFromConfig(cfg, nil, nil, nil)
+		vs, err = buildVSphereFromConfig(cfg, nil, nil, nil, nil)
 		if err != nil { // testcase.expectedError {
 			t.Fatalf("buildVSphereFromConfig: Should succeed when a valid config is provided: %v", err)
 		}
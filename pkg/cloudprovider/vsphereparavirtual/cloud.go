@@ -30,7 +30,6 @@ import (
 
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/vmservice"
-	cpcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
 	k8s "k8s.io/cloud-provider-vsphere/pkg/common/kubernetes"
 )
 
@@ -71,7 +70,7 @@ func init() {
 			return nil, fmt.Errorf("unable to read cloud configuration from %q [%v]", config, err)
 		}
 
-		var cfg cpcfg.Config
+		var cfg ParavirtualConfig
 		err = yaml.Unmarshal(data, &cfg)
 		if err != nil {
 			// we got an error where the decode wasn't related to a missing type
@@ -85,9 +84,14 @@ func init() {
 }
 
 // Creates new Controller node interface and returns
-func newVSphereParavirtual(cfg *cpcfg.Config) (*VSphereParavirtual, error) {
+func newVSphereParavirtual(cfg *ParavirtualConfig) (*VSphereParavirtual, error) {
+	if err := cfg.RoutablePod.Validate(); err != nil {
+		return nil, err
+	}
+	subnetFamilies, _ := cfg.RoutablePod.SubnetFamilies()
 	cp := &VSphereParavirtual{
-		cfg: cfg,
+		cfg:            cfg,
+		subnetFamilies: subnetFamilies,
 	}
 
 	return cp, nil
@@ -121,13 +125,13 @@ func (cp *VSphereParavirtual) Initialize(clientBuilder cloudprovider.ControllerC
 		klog.Fatalf("Failed to get cluster namespace: %v", err)
 	}
 
-	routes, err := NewRoutes(clusterNS, kcfg, *cp.ownerReference)
+	routes, err := NewRoutes(clusterNS, kcfg, *cp.ownerReference, client)
 	if err != nil {
 		klog.Errorf("Failed to init Route: %v", err)
 	}
 	cp.routes = routes
 
-	cp.informMgr.AddNodeListener(cp.nodeAdded, cp.nodeDeleted, nil)
+	cp.informMgr.AddNodeListener(cp.nodeAdded, cp.nodeDeleted, cp.nodeUpdated)
 
 	lb, err := NewLoadBalancer(clusterNS, kcfg, cp.ownerReference)
 	if err != nil {
@@ -144,7 +148,8 @@ func (cp *VSphereParavirtual) Initialize(clientBuilder cloudprovider.ControllerC
 	if RouteEnabled {
 		klog.V(0).Info("Starting routable pod controllers")
 
-		if err := routablepod.StartControllers(kcfg, client, cp.informMgr, ClusterName, clusterNS, ownerRef); err != nil {
+		if err := routablepod.StartControllers(kcfg, client, cp.informMgr, ClusterName, clusterNS,
+			cp.subnetFamilies, cp.cfg.RoutablePod.NodesPerIPPool, cp.cfg.RoutablePod.AdditionalSubnets, ownerRef); err != nil {
 			klog.Errorf("Failed to start Routable pod controllers: %v", err)
 		}
 	}
@@ -228,6 +233,21 @@ func (cp *VSphereParavirtual) nodeAdded(obj interface{}) {
 	}
 }
 
+// Notification handler when node is updated, the additional pod CIDRs of
+// the node may have changed.
+func (cp *VSphereParavirtual) nodeUpdated(oldObj, newObj interface{}) {
+	node, ok := newObj.(*v1.Node)
+	if node == nil || !ok {
+		klog.Warningf("nodeUpdated: unrecognized object %+v", newObj)
+		return
+	}
+
+	if cp.routes != nil {
+		klog.V(6).Infof("updating node: %s", node.Name)
+		cp.routes.AddNode(node)
+	}
+}
+
 // Notification handler when node is removed from k8s cluster.
 func (cp *VSphereParavirtual) nodeDeleted(obj interface{}) {
 	node, ok := obj.(*v1.Node)
@@ -18,6 +18,7 @@ package vsphereparavirtual
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"os"
 
@@ -25,6 +26,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/expansion"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
+	cpcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
 )
 
 const (
@@ -57,6 +62,89 @@ type SupervisorEndpoint struct {
 	Port string
 }
 
+// ParavirtualConfig is the vSphere paravirtual cloud provider config
+type ParavirtualConfig struct {
+	cpcfg.Config
+
+	// RoutablePod configures the pod CIDRs requested for the nodes
+	RoutablePod RoutablePodConfig
+}
+
+// RoutablePodConfig configures the pod CIDRs requested from the IPPool for each node
+type RoutablePodConfig struct {
+	// IPFamilies are the IP families of the pod CIDRs in the order of node.Spec.PodCIDRs, default is ipv4
+	IPFamilies []string
+	// IPv4PrefixLength is the prefix length of the IPv4 pod CIDR of a node
+	IPv4PrefixLength int
+	// IPv6PrefixLength is the prefix length of the IPv6 pod CIDR of a node
+	IPv6PrefixLength int
+	// AdditionalSubnets enables requesting additional subnets for nodes running out of pod IPs
+	AdditionalSubnets *expansion.Config
+	// NodesPerIPPool is the maximum number of nodes per IPPool shard, 0 keeps all nodes in one IPPool
+	NodesPerIPPool int
+}
+
+// Validate validates the routable pod configuration
+func (c *RoutablePodConfig) Validate() error {
+	if _, err := c.SubnetFamilies(); err != nil {
+		return err
+	}
+	if c.NodesPerIPPool < 0 {
+		return fmt.Errorf("invalid number of nodes per IPPool %d", c.NodesPerIPPool)
+	}
+	if c.AdditionalSubnets != nil {
+		if c.AdditionalSubnets.UsageThresholdPercent < 0 || c.AdditionalSubnets.UsageThresholdPercent > 100 {
+			return fmt.Errorf("invalid usage threshold %d%%", c.AdditionalSubnets.UsageThresholdPercent)
+		}
+		if c.AdditionalSubnets.MaxAdditionalSubnets < 0 {
+			return fmt.Errorf("invalid maximum number of additional subnets %d", c.AdditionalSubnets.MaxAdditionalSubnets)
+		}
+	}
+	return nil
+}
+
+// SubnetFamilies returns the validated IP families and prefix lengths of the pod CIDRs
+func (c *RoutablePodConfig) SubnetFamilies() ([]helper.SubnetFamily, error) {
+	families := c.IPFamilies
+	if len(families) == 0 {
+		families = []string{helper.IPFamilyDefault}
+	}
+	if len(families) > 2 {
+		return nil, fmt.Errorf("at most two IP families are supported, got %v", families)
+	}
+	var result []helper.SubnetFamily
+	seen := make(map[string]bool)
+	for _, family := range families {
+		if seen[family] {
+			return nil, fmt.Errorf("duplicate IP family %s", family)
+		}
+		seen[family] = true
+		switch family {
+		case helper.IPFamilyDefault:
+			prefixLength := c.IPv4PrefixLength
+			if prefixLength == 0 {
+				prefixLength = helper.PrefixLengthDefault
+			}
+			if prefixLength < 1 || prefixLength > 32 {
+				return nil, fmt.Errorf("invalid IPv4 prefix length %d", prefixLength)
+			}
+			result = append(result, helper.SubnetFamily{IPFamily: family, PrefixLength: prefixLength})
+		case helper.IPFamilyIPv6:
+			prefixLength := c.IPv6PrefixLength
+			if prefixLength == 0 {
+				prefixLength = helper.PrefixLengthIPv6Default
+			}
+			if prefixLength < 1 || prefixLength > 128 {
+				return nil, fmt.Errorf("invalid IPv6 prefix length %d", prefixLength)
+			}
+			result = append(result, helper.SubnetFamily{IPFamily: family, PrefixLength: prefixLength})
+		default:
+			return nil, fmt.Errorf("invalid IP family %q, expected %s or %s", family, helper.IPFamilyDefault, helper.IPFamilyIPv6)
+		}
+	}
+	return result, nil
+}
+
 func readOwnerRef(path string) (*metav1.OwnerReference, error) {
 	ownerRef := &metav1.OwnerReference{}
 	d, err := os.ReadFile(path)
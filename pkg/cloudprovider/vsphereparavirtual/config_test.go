@@ -21,10 +21,15 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"reflect"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/expansion"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 )
 
 func TestReadOwnerRef(t *testing.T) {
@@ -282,3 +287,78 @@ func createTestFile(dir, filename, content string) error {
 
 	return nil
 }
+
+func TestRoutablePodSubnetFamilies(t *testing.T) {
+	var cfg ParavirtualConfig
+	data := []byte(`
+global:
+  vCenterPort: "443"
+routablePod:
+  ipFamilies:
+  - ipv4
+  - ipv6
+  ipv4PrefixLength: 26
+`)
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("unmarshalling config failed: %v", err)
+	}
+	if cfg.Global.VCenterPort != "443" {
+		t.Errorf("unexpected global config %+v", cfg.Global)
+	}
+	families, err := cfg.RoutablePod.SubnetFamilies()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []helper.SubnetFamily{
+		{IPFamily: helper.IPFamilyDefault, PrefixLength: 26},
+		{IPFamily: helper.IPFamilyIPv6, PrefixLength: helper.PrefixLengthIPv6Default},
+	}
+	if !reflect.DeepEqual(expected, families) {
+		t.Errorf("expected subnet families %v, got %v", expected, families)
+	}
+
+	families, err = (&RoutablePodConfig{}).SubnetFamilies()
+	if err != nil || !reflect.DeepEqual(helper.DefaultSubnetFamilies(), families) {
+		t.Errorf("expected default subnet families, got %v, %v", families, err)
+	}
+
+	for _, invalid := range []RoutablePodConfig{
+		{IPFamilies: []string{"ipv5"}},
+		{IPFamilies: []string{"ipv4", "ipv4"}},
+		{IPFamilies: []string{"ipv6"}, IPv6PrefixLength: 129},
+		{IPv4PrefixLength: 33},
+	} {
+		if _, err := invalid.SubnetFamilies(); err == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestRoutablePodValidate(t *testing.T) {
+	var cfg ParavirtualConfig
+	data := []byte(`
+routablePod:
+  nodesPerIPPool: 200
+  additionalSubnets:
+    usageThresholdPercent: 80
+`)
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("unmarshalling config failed: %v", err)
+	}
+	if err := cfg.RoutablePod.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RoutablePod.NodesPerIPPool != 200 || cfg.RoutablePod.AdditionalSubnets.UsageThresholdPercent != 80 {
+		t.Errorf("unexpected routable pod config %+v", cfg.RoutablePod)
+	}
+
+	for _, invalid := range []RoutablePodConfig{
+		{NodesPerIPPool: -1},
+		{AdditionalSubnets: &expansion.Config{UsageThresholdPercent: 101}},
+		{AdditionalSubnets: &expansion.Config{MaxAdditionalSubnets: -1}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+}
@@ -26,6 +26,8 @@ import (
 	ippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned"
 	ippoolscheme "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/scheme"
 	ippoolinformers "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/informers/externalversions"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/expansion"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/ippool"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/node"
 	k8s "k8s.io/cloud-provider-vsphere/pkg/common/kubernetes"
@@ -36,7 +38,12 @@ const (
 )
 
 // StartControllers starts ippool_controller and node_controller
-func StartControllers(scCfg *rest.Config, client kubernetes.Interface, informerManager *k8s.InformerManager, clusterName, clusterNS string, ownerRef *metav1.OwnerReference) error {
+// subnetFamilies are the IP families and prefix lengths of the pod CIDRs of each node.
+// nodesPerIPPool limits the number of nodes per ippool shard, 0 keeps all nodes in one ippool.
+// If additionalSubnets is set, the expansion controller requests additional subnets
+// for nodes running out of pod IPs.
+func StartControllers(scCfg *rest.Config, client kubernetes.Interface, informerManager *k8s.InformerManager, clusterName, clusterNS string,
+	subnetFamilies []helper.SubnetFamily, nodesPerIPPool int, additionalSubnets *expansion.Config, ownerRef *metav1.OwnerReference) error {
 	if clusterName == "" {
 		return fmt.Errorf("cluster name can't be empty")
 	}
@@ -56,12 +63,18 @@ func StartControllers(scCfg *rest.Config, client kubernetes.Interface, informerM
 	ippoolInformerFactory := ippoolinformers.NewSharedInformerFactoryWithOptions(ipcs, defaultResyncTime, ippoolinformers.WithNamespace(clusterNS))
 	ippoolInformer := ippoolInformerFactory.Nsx().V1alpha1().IPPools()
 
-	ippoolController := ippool.NewController(client, ipcs, ippoolInformer)
+	ippoolController := ippool.NewController(client, ipcs, ippoolInformer, informerManager, clusterName, subnetFamilies)
 	go ippoolController.Run(context.Background().Done())
 
+	// the expansion controller shares the ippool informer, it must be registered before the start
+	if additionalSubnets != nil {
+		expansionController := expansion.NewController(ipcs, ippoolInformer, informerManager, clusterName, clusterNS, subnetFamilies, *additionalSubnets)
+		go expansionController.Run(context.Background().Done())
+	}
+
 	ippoolInformerFactory.Start(wait.NeverStop)
 
-	nodeController := node.NewController(client, ipcs, informerManager, clusterName, clusterNS, ownerRef)
+	nodeController := node.NewController(client, ipcs, informerManager, clusterName, clusterNS, subnetFamilies, nodesPerIPPool, ownerRef)
 	go nodeController.Run(context.Background().Done())
 
 	return nil
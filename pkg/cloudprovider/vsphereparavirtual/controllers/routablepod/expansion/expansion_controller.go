@@ -0,0 +1,354 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expansion
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
+	ippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned"
+	ippoolinformers "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/informers/externalversions/nsxnetworking/v1alpha1"
+	ippoollisters "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/listers/nsxnetworking/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
+	k8s "k8s.io/cloud-provider-vsphere/pkg/common/kubernetes"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// DefaultUsageThresholdPercent is the default pod IP usage triggering an additional subnet request
+	DefaultUsageThresholdPercent = 90
+	// DefaultMaxAdditionalSubnets is the default number of additional subnets per node and IP family
+	DefaultMaxAdditionalSubnets = 1
+	// syncKey is the only workqueue key, the usage of all nodes is checked at once
+	// so that the requests of a shard are patched together
+	syncKey = "additional-subnets"
+)
+
+// Config configures the requests of additional subnets
+type Config struct {
+	// UsageThresholdPercent is the pod IP usage of a node in percent to request an additional subnet
+	UsageThresholdPercent int
+	// MaxAdditionalSubnets is the maximum number of additional subnets per node and IP family
+	MaxAdditionalSubnets int
+}
+
+// Controller requests additional subnets in the ippool for nodes whose pod IPs are nearly used up.
+// The usage is checked whenever pods are scheduled, or nodes or ippools change.
+// The ippool controller publishes the allocated subnets on the node.
+type Controller struct {
+	ippoolclientset ippoolclientset.Interface
+
+	ippoolLister ippoollisters.IPPoolLister
+	nodesLister  corelisters.NodeLister
+	podsLister   corelisters.PodLister
+	cacheSynced  []cache.InformerSynced
+
+	workqueue workqueue.RateLimitingInterface
+
+	clusterName string
+	clusterNS   string
+
+	subnetFamilies []helper.SubnetFamily
+	config         Config
+}
+
+// NewController returns a Controller requesting additional subnets
+func NewController(
+	ippoolclientset ippoolclientset.Interface,
+	ippoolInformer ippoolinformers.IPPoolInformer,
+	informerManager *k8s.InformerManager,
+	clusterName string,
+	clusterNS string,
+	subnetFamilies []helper.SubnetFamily,
+	config Config) *Controller {
+
+	if config.UsageThresholdPercent == 0 {
+		config.UsageThresholdPercent = DefaultUsageThresholdPercent
+	}
+	if config.MaxAdditionalSubnets == 0 {
+		config.MaxAdditionalSubnets = DefaultMaxAdditionalSubnets
+	}
+	if len(subnetFamilies) == 0 {
+		subnetFamilies = helper.DefaultSubnetFamilies()
+	}
+	c := &Controller{
+		ippoolclientset: ippoolclientset,
+		ippoolLister:    ippoolInformer.Lister(),
+		nodesLister:     informerManager.GetNodeLister(),
+		podsLister:      informerManager.GetPodLister(),
+		cacheSynced: []cache.InformerSynced{
+			ippoolInformer.Informer().HasSynced,
+			informerManager.IsNodeInformerSynced(),
+			informerManager.IsPodInformerSynced(),
+		},
+
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AdditionalSubnets"),
+
+		clusterName:    clusterName,
+		clusterNS:      clusterNS,
+		subnetFamilies: subnetFamilies,
+		config:         config,
+	}
+
+	// the usage only grows when pods are scheduled, removed pods need no check
+	informerManager.AddPodListener(
+		// add
+		func(cur interface{}) {
+			if pod, ok := cur.(*corev1.Pod); ok && pod.Spec.NodeName != "" {
+				c.enqueue()
+			}
+		},
+		// remove
+		func(interface{}) {},
+		// update
+		func(old, cur interface{}) {
+			oldPod, ok := old.(*corev1.Pod)
+			if !ok {
+				return
+			}
+			curPod, ok := cur.(*corev1.Pod)
+			if !ok {
+				return
+			}
+			if oldPod.Spec.NodeName != curPod.Spec.NodeName {
+				c.enqueue()
+			}
+		})
+	informerManager.AddNodeListener(
+		// add
+		func(interface{}) { c.enqueue() },
+		// remove
+		func(interface{}) {},
+		// update
+		func(old, cur interface{}) {
+			oldNode, ok := old.(*corev1.Node)
+			if !ok {
+				return
+			}
+			curNode, ok := cur.(*corev1.Node)
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(oldNode.Spec.PodCIDRs, curNode.Spec.PodCIDRs) ||
+				!reflect.DeepEqual(oldNode.Annotations, curNode.Annotations) ||
+				!reflect.DeepEqual(oldNode.Labels, curNode.Labels) {
+				c.enqueue()
+			}
+		})
+	ippoolInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { c.enqueue() },
+		UpdateFunc: func(old, cur interface{}) {
+			oldIPPool, ok := old.(*ippoolv1alpha1.IPPool)
+			if !ok {
+				return
+			}
+			curIPPool, ok := cur.(*ippoolv1alpha1.IPPool)
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(oldIPPool.Spec.Subnets, curIPPool.Spec.Subnets) ||
+				!reflect.DeepEqual(oldIPPool.Status.Subnets, curIPPool.Status.Subnets) {
+				c.enqueue()
+			}
+		},
+	})
+	return c
+}
+
+func (c *Controller) enqueue() {
+	c.workqueue.Add(syncKey)
+}
+
+// Run starts the worker checking the pod IP usage of the nodes
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	klog.V(4).Info("Waiting cache to be synced.")
+
+	if !cache.WaitForNamedCacheSync("additional subnets", stopCh, c.cacheSynced...) {
+		return
+	}
+
+	klog.V(4).Info("Starting additional subnet worker.")
+	go wait.Until(c.runWorker, time.Second, stopCh)
+
+	<-stopCh
+}
+
+// runWorker processes the workqueue until it is shut down
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem syncs the additional subnets once per dequeued key
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.workqueue.Done(obj)
+
+	if err := c.sync(); err != nil {
+		// Put the item back on the workqueue to handle any transient errors.
+		c.workqueue.AddRateLimited(obj)
+		utilruntime.HandleError(fmt.Errorf("error syncing additional subnets: %v, requeuing", err))
+		return true
+	}
+	c.workqueue.Forget(obj)
+	return true
+}
+
+// sync adds additional subnet requests to the ippool shards for all nodes
+// exceeding the usage threshold
+func (c *Controller) sync() error {
+	ctx := context.Background()
+	ippools, err := c.ippoolLister.IPPools(c.clusterNS).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	shards := helper.IppoolShards(c.clusterName, ippools)
+	if len(shards) == 0 {
+		return nil
+	}
+	nodes, err := c.nodesLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pods, err := c.podsLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	usage := make(map[string]int64)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Spec.HostNetwork ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		usage[pod.Spec.NodeName]++
+	}
+
+	allocated := make(map[string]string)
+	var requested []ippoolv1alpha1.SubnetRequest
+	// the additional subnets are requested in the shard of the primary subnet of the node
+	shardOfNode := make(map[string]string)
+	for _, shard := range shards {
+		for _, sub := range shard.Status.Subnets {
+			allocated[sub.Name] = sub.CIDR
+		}
+		for _, sub := range shard.Spec.Subnets {
+			requested = append(requested, sub)
+			if _, ok := shardOfNode[helper.NodeNameOfSubnetRequest(sub)]; !ok {
+				shardOfNode[helper.NodeNameOfSubnetRequest(sub)] = shard.Name
+			}
+		}
+	}
+	requests := make(map[string][]ippoolv1alpha1.SubnetRequest)
+	for _, node := range nodes {
+		shardName, ok := shardOfNode[node.Name]
+		if !ok {
+			continue
+		}
+		for _, family := range c.subnetFamilies {
+			if request := c.additionalSubnetRequest(node, family, usage[node.Name], requested, allocated); request != nil {
+				requests[shardName] = append(requests[shardName], *request)
+			}
+		}
+	}
+
+	for shardName, shardRequests := range requests {
+		err := helper.PatchIPPool(ctx, c.ippoolclientset, c.clusterNS, shardName, func(ippool *ippoolv1alpha1.IPPool) bool {
+			// the lister may not show requests added by the previous sync yet
+			existing := make(map[string]bool)
+			for _, sub := range ippool.Spec.Subnets {
+				existing[sub.Name] = true
+			}
+			added := false
+			for _, request := range shardRequests {
+				if !existing[request.Name] {
+					ippool.Spec.Subnets = append(ippool.Spec.Subnets, request)
+					added = true
+				}
+			}
+			return added
+		})
+		if err != nil {
+			return err
+		}
+		klog.V(4).Infof("Requested %d additional subnets in IPPool %s/%s", len(shardRequests), c.clusterNS, shardName)
+	}
+	return nil
+}
+
+// additionalSubnetRequest returns the additional subnet request for a node and
+// IP family, or nil if no subnet is needed or the previous request is pending
+func (c *Controller) additionalSubnetRequest(node *corev1.Node, family helper.SubnetFamily, used int64,
+	requested []ippoolv1alpha1.SubnetRequest, allocated map[string]string) *ippoolv1alpha1.SubnetRequest {
+	var capacity int64
+	for _, cidr := range append(append([]string{}, node.Spec.PodCIDRs...), helper.AdditionalPodCIDRs(node)...) {
+		if helper.IPFamilyOfCIDR(cidr) == family.IPFamily {
+			capacity += helper.CIDRSize(cidr)
+		}
+	}
+	if capacity == 0 {
+		// the primary subnet is not allocated yet
+		return nil
+	}
+
+	count := 0
+	for _, sub := range requested {
+		nodeName, ipFamily, _, ok := helper.ParseAdditionalSubnetName(sub.Name)
+		if !ok || nodeName != node.Name || ipFamily != family.IPFamily {
+			continue
+		}
+		if allocated[sub.Name] == "" {
+			klog.V(4).Infof("additional subnet %s of node %s is pending", sub.Name, node.Name)
+			return nil
+		}
+		count++
+	}
+	if count >= c.config.MaxAdditionalSubnets {
+		return nil
+	}
+	if used*100 < capacity*int64(c.config.UsageThresholdPercent) {
+		return nil
+	}
+
+	prefixLength, err := helper.PrefixLengthForNode(node, family)
+	if err != nil {
+		klog.Warningf("%v, using prefix length %d", err, family.PrefixLength)
+		prefixLength = family.PrefixLength
+	}
+	klog.Infof("node %s uses %d of %d %s pod IPs, requesting additional subnet", node.Name, used, capacity, family.IPFamily)
+	return &ippoolv1alpha1.SubnetRequest{
+		Name:         helper.AdditionalSubnetName(node.Name, family.IPFamily, count+1),
+		IPFamily:     family.IPFamily,
+		PrefixLength: prefixLength,
+	}
+}
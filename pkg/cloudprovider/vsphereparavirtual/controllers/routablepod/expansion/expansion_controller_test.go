@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expansion
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
+	fakeippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/fake"
+	ippoollisters "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/listers/nsxnetworking/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
+)
+
+const (
+	testClusterNS   = "ns"
+	testClusterName = "n"
+)
+
+func createNode(name string, podCIDRs ...string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{PodCIDRs: podCIDRs},
+	}
+}
+
+func createPods(nodeName string, count int, hostNetwork bool) []runtime.Object {
+	var pods []runtime.Object
+	for i := 0; i < count; i++ {
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%t-%d", nodeName, hostNetwork, i), Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: nodeName, HostNetwork: hostNetwork},
+		})
+	}
+	return pods
+}
+
+func createIPPool(requests []ippoolv1alpha1.SubnetRequest, results []ippoolv1alpha1.SubnetResult) *ippoolv1alpha1.IPPool {
+	return &ippoolv1alpha1.IPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helper.IppoolNameFromClusterName(testClusterName),
+			Namespace: testClusterNS,
+		},
+		Spec:   ippoolv1alpha1.IPPoolSpec{Subnets: requests},
+		Status: ippoolv1alpha1.IPPoolStatus{Subnets: results},
+	}
+}
+
+func TestSync(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		node             *corev1.Node
+		pods             []runtime.Object
+		requests         []ippoolv1alpha1.SubnetRequest
+		results          []ippoolv1alpha1.SubnetResult
+		expectedRequests []string
+	}{
+		{
+			desc:             "below threshold",
+			node:             createNode("n1", "10.0.0.0/28"),
+			pods:             append(createPods("n1", 10, false), createPods("n1", 5, true)...),
+			requests:         []ippoolv1alpha1.SubnetRequest{{Name: "n1"}},
+			expectedRequests: []string{"n1"},
+		},
+		{
+			desc:             "above threshold",
+			node:             createNode("n1", "10.0.0.0/28"),
+			pods:             createPods("n1", 15, false),
+			requests:         []ippoolv1alpha1.SubnetRequest{{Name: "n1"}},
+			expectedRequests: []string{"n1", "n1_ipv4_extra_1"},
+		},
+		{
+			desc:             "primary subnet not allocated",
+			node:             createNode("n1"),
+			pods:             createPods("n1", 15, false),
+			requests:         []ippoolv1alpha1.SubnetRequest{{Name: "n1"}},
+			expectedRequests: []string{"n1"},
+		},
+		{
+			desc:             "additional subnet pending",
+			node:             createNode("n1", "10.0.0.0/28"),
+			pods:             createPods("n1", 15, false),
+			requests:         []ippoolv1alpha1.SubnetRequest{{Name: "n1"}, {Name: "n1_ipv4_extra_1"}},
+			expectedRequests: []string{"n1", "n1_ipv4_extra_1"},
+		},
+		{
+			desc:             "maximum additional subnets reached",
+			node:             createNode("n1", "10.0.0.0/28"),
+			pods:             createPods("n1", 30, false),
+			requests:         []ippoolv1alpha1.SubnetRequest{{Name: "n1"}, {Name: "n1_ipv4_extra_1"}},
+			results:          []ippoolv1alpha1.SubnetResult{{Name: "n1", CIDR: "10.0.0.0/28"}, {Name: "n1_ipv4_extra_1", CIDR: "10.0.1.0/28"}},
+			expectedRequests: []string{"n1", "n1_ipv4_extra_1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ippool := createIPPool(tc.requests, tc.results)
+			ippoolClient := fakeippoolclientset.NewSimpleClientset(ippool)
+			c := newController(t, ippoolClient, append(tc.pods, tc.node, ippool))
+
+			if err := c.sync(); err != nil {
+				t.Fatalf("sync failed: %v", err)
+			}
+			ippool, err := ippoolClient.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get ippool: %v", err)
+			}
+			var names []string
+			for _, sub := range ippool.Spec.Subnets {
+				names = append(names, sub.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tc.expectedRequests) {
+				t.Errorf("expected subnet requests %v, got %v", tc.expectedRequests, names)
+			}
+		})
+	}
+}
+
+func TestSyncWithStaleIPPoolLister(t *testing.T) {
+	// the lister does not show the request of the previous sync yet
+	stale := createIPPool([]ippoolv1alpha1.SubnetRequest{{Name: "n1"}}, nil)
+	ippoolClient := fakeippoolclientset.NewSimpleClientset(createIPPool([]ippoolv1alpha1.SubnetRequest{{Name: "n1"}, {Name: "n1_ipv4_extra_1"}}, nil))
+	c := newController(t, ippoolClient, append(createPods("n1", 15, false), createNode("n1", "10.0.0.0/28"), stale))
+	ippoolClient.ClearActions()
+
+	if err := c.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	for _, action := range ippoolClient.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("unexpected ippool patch for a request already present")
+		}
+	}
+}
+
+func TestSyncIgnoresTerminatedPods(t *testing.T) {
+	pods := createPods("n1", 15, false)
+	for _, obj := range pods[:5] {
+		obj.(*corev1.Pod).Status.Phase = corev1.PodSucceeded
+	}
+	ippoolClient := fakeippoolclientset.NewSimpleClientset(createIPPool([]ippoolv1alpha1.SubnetRequest{{Name: "n1"}}, nil))
+	c := newController(t, ippoolClient, append(pods, createNode("n1", "10.0.0.0/28"), createIPPool([]ippoolv1alpha1.SubnetRequest{{Name: "n1"}}, nil)))
+	ippoolClient.ClearActions()
+
+	if err := c.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(ippoolClient.Actions()) != 0 {
+		t.Errorf("expected no request for terminated pods, got %v", ippoolClient.Actions())
+	}
+}
+
+// newController returns a controller whose listers contain the given nodes, pods and ippools
+func newController(t *testing.T, ippoolClient *fakeippoolclientset.Clientset, objects []runtime.Object) *Controller {
+	ippoolIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range objects {
+		indexer := podIndexer
+		switch obj.(type) {
+		case *ippoolv1alpha1.IPPool:
+			indexer = ippoolIndexer
+		case *corev1.Node:
+			indexer = nodeIndexer
+		}
+		if err := indexer.Add(obj); err != nil {
+			t.Fatalf("failed to add %v: %v", obj, err)
+		}
+	}
+	return &Controller{
+		ippoolclientset: ippoolClient,
+		ippoolLister:    ippoollisters.NewIPPoolLister(ippoolIndexer),
+		nodesLister:     corelisters.NewNodeLister(nodeIndexer),
+		podsLister:      corelisters.NewPodLister(podIndexer),
+		clusterName:     testClusterName,
+		clusterNS:       testClusterNS,
+		subnetFamilies:  helper.DefaultSubnetFamilies(),
+		config: Config{
+			UsageThresholdPercent: DefaultUsageThresholdPercent,
+			MaxAdditionalSubnets:  DefaultMaxAdditionalSubnets,
+		},
+	}
+}
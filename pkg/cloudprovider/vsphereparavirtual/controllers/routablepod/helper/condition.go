@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NodeConditionRouteSetReady reflects the Ready condition of the RouteSet of a node,
+	// including the failure message of NSX
+	NodeConditionRouteSetReady corev1.NodeConditionType = "RouteSetReady"
+	// NodeConditionIPPoolReady reflects the Ready condition of the IPPool holding the
+	// subnet requests of a node, including the failure message of NSX
+	NodeConditionIPPoolReady corev1.NodeConditionType = "IPPoolReady"
+)
+
+// GetNodeCondition returns the condition of the given type of a node, or nil if the condition is not present
+func GetNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetNodeCondition sets a condition in the status of a node. The node is only patched
+// if status, reason or message of the condition changed, which is returned as well.
+func SetNodeCondition(ctx context.Context, client kubernetes.Interface, node *corev1.Node, condition corev1.NodeCondition) (bool, error) {
+	now := metav1.Now()
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	if existing := GetNodeCondition(node, condition.Type); existing != nil {
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false, nil
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	// PatchStatus uses a strategic merge patch, conditions are merged by type
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{condition},
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return false, fmt.Errorf("failed to json.Marshal condition: %v", err)
+	}
+	if _, err := client.CoreV1().Nodes().PatchStatus(ctx, node.Name, patchBytes); err != nil {
+		return false, fmt.Errorf("failed to patch node %s condition %s: %v", node.Name, condition.Type, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetNodeCondition(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	client := fake.NewSimpleClientset(node)
+	ctx := context.Background()
+
+	condition := corev1.NodeCondition{
+		Type:    NodeConditionRouteSetReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  "RealizationFailed",
+		Message: "static route failed",
+	}
+	changed, err := SetNodeCondition(ctx, client, node, condition)
+	if err != nil || !changed {
+		t.Fatalf("expected condition to be set, changed %v, error %v", changed, err)
+	}
+	node, err = client.CoreV1().Nodes().Get(ctx, "n1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(node.Status.Conditions) != 2 {
+		t.Fatalf("expected conditions to be merged, got %v", node.Status.Conditions)
+	}
+	got := GetNodeCondition(node, NodeConditionRouteSetReady)
+	if got == nil || got.Status != corev1.ConditionFalse || got.Message != "static route failed" {
+		t.Fatalf("unexpected condition %v", got)
+	}
+
+	changed, err = SetNodeCondition(ctx, client, node, condition)
+	if err != nil || changed {
+		t.Errorf("expected unchanged condition not to be patched, changed %v, error %v", changed, err)
+	}
+
+	condition.Status = corev1.ConditionTrue
+	condition.Reason = "RouteSetReady"
+	condition.Message = ""
+	changed, err = SetNodeCondition(ctx, client, node, condition)
+	if err != nil || !changed {
+		t.Errorf("expected condition to be updated, changed %v, error %v", changed, err)
+	}
+}
@@ -13,16 +13,149 @@ limitations under the License.
 
 package helper
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
+	ippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned"
+)
 
 const (
 	// IPFamilyDefault is default value of ipFamily
 	IPFamilyDefault = "ipv4"
+	// IPFamilyIPv6 is the ipFamily of IPv6 subnets
+	IPFamilyIPv6 = "ipv6"
 	// PrefixLengthDefault is default value of prefixLength
 	PrefixLengthDefault = 24
+	// PrefixLengthIPv6Default is default value of prefixLength for IPv6 subnets
+	PrefixLengthIPv6Default = 64
 )
 
+// SubnetFamily is the IP family and prefix length of a pod CIDR requested for each node
+type SubnetFamily struct {
+	IPFamily     string
+	PrefixLength int
+}
+
+// DefaultSubnetFamilies returns the subnet families used if none are configured
+func DefaultSubnetFamilies() []SubnetFamily {
+	return []SubnetFamily{{IPFamily: IPFamilyDefault, PrefixLength: PrefixLengthDefault}}
+}
+
+// ipv6SubnetSuffix is appended to the node name for IPv6 subnet requests. Node names
+// cannot contain underscores, so the name does not collide with other nodes.
+const ipv6SubnetSuffix = "_" + IPFamilyIPv6
+
+// SubnetName returns the name of the subnet request of a node for an IP family.
+// IPv4 subnets are named after the node to stay compatible with existing requests.
+func SubnetName(nodeName, ipFamily string) string {
+	if ipFamily == IPFamilyIPv6 {
+		return nodeName + ipv6SubnetSuffix
+	}
+	return nodeName
+}
+
 // IppoolNameFromClusterName returns the ippool name constructed using the cluster name
 func IppoolNameFromClusterName(clusterName string) string {
 	return fmt.Sprintf("%s-ippool", clusterName)
 }
+
+// IppoolShardName returns the name of an ippool shard of a cluster.
+// The first shard keeps the name of the unsharded ippool.
+func IppoolShardName(clusterName string, shard int) string {
+	if shard == 0 {
+		return IppoolNameFromClusterName(clusterName)
+	}
+	return fmt.Sprintf("%s-%d", IppoolNameFromClusterName(clusterName), shard)
+}
+
+// IppoolShardIndex returns the shard index of an ippool, or false if the ippool
+// is not a shard of the cluster
+func IppoolShardIndex(clusterName, name string) (int, bool) {
+	prefix := IppoolNameFromClusterName(clusterName)
+	if name == prefix {
+		return 0, true
+	}
+	if !strings.HasPrefix(name, prefix+"-") {
+		return 0, false
+	}
+	shard, err := strconv.Atoi(strings.TrimPrefix(name, prefix+"-"))
+	if err != nil || shard < 1 || IppoolShardName(clusterName, shard) != name {
+		return 0, false
+	}
+	return shard, true
+}
+
+// IppoolShards returns the ippool shards of a cluster sorted by shard index
+func IppoolShards(clusterName string, ippools []*ippoolv1alpha1.IPPool) []*ippoolv1alpha1.IPPool {
+	var shards []*ippoolv1alpha1.IPPool
+	for _, ippool := range ippools {
+		if _, ok := IppoolShardIndex(clusterName, ippool.Name); ok {
+			shards = append(shards, ippool)
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		a, _ := IppoolShardIndex(clusterName, shards[i].Name)
+		b, _ := IppoolShardIndex(clusterName, shards[j].Name)
+		return a < b
+	})
+	return shards
+}
+
+// NodeNameOfSubnetRequest returns the name of the node a subnet request belongs to
+func NodeNameOfSubnetRequest(sub ippoolv1alpha1.SubnetRequest) string {
+	if nodeName, _, _, ok := ParseAdditionalSubnetName(sub.Name); ok {
+		return nodeName
+	}
+	if sub.IPFamily == IPFamilyIPv6 {
+		return strings.TrimSuffix(sub.Name, ipv6SubnetSuffix)
+	}
+	return sub.Name
+}
+
+// PatchIPPool applies the changes of mutate to the subnet requests and owner references of an ippool.
+// The resourceVersion in the merge patch lets the apiserver reject concurrent changes, which are
+// retried on the latest ippool. Nothing is patched if mutate returns false.
+func PatchIPPool(ctx context.Context, client ippoolclientset.Interface, namespace, name string, mutate func(ippool *ippoolv1alpha1.IPPool) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ippool, err := client.NsxV1alpha1().IPPools(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		newIPPool := ippool.DeepCopy()
+		if !mutate(newIPPool) {
+			return nil
+		}
+
+		metadata := map[string]interface{}{
+			"resourceVersion": ippool.ResourceVersion,
+		}
+		if len(newIPPool.OwnerReferences) != 0 {
+			metadata["ownerReferences"] = newIPPool.OwnerReferences
+		}
+		subnets := newIPPool.Spec.Subnets
+		if subnets == nil {
+			subnets = []ippoolv1alpha1.SubnetRequest{}
+		}
+		patch := map[string]interface{}{
+			"metadata": metadata,
+			"spec": map[string]interface{}{
+				"subnets": subnets,
+			},
+		}
+		patchBytes, err := json.Marshal(patch)
+		if err != nil {
+			return fmt.Errorf("failed to json.Marshal ippool patch: %v", err)
+		}
+		_, err = client.NsxV1alpha1().IPPools(namespace).Patch(ctx, name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+		return err
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
+	fakeippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/fake"
+)
+
+func TestIppoolShardIndex(t *testing.T) {
+	testCases := []struct {
+		name          string
+		expectedIndex int
+		expectedOK    bool
+	}{
+		{name: "c-ippool", expectedIndex: 0, expectedOK: true},
+		{name: "c-ippool-3", expectedIndex: 3, expectedOK: true},
+		{name: "c-ippool-0", expectedOK: false},
+		{name: "c-ippool-03", expectedOK: false},
+		{name: "c-ippool-x", expectedOK: false},
+		{name: "other-ippool", expectedOK: false},
+	}
+	for _, tc := range testCases {
+		index, ok := IppoolShardIndex("c", tc.name)
+		if ok != tc.expectedOK || index != tc.expectedIndex {
+			t.Errorf("%s: expected %d/%v, got %d/%v", tc.name, tc.expectedIndex, tc.expectedOK, index, ok)
+		}
+		if ok && IppoolShardName("c", index) != tc.name {
+			t.Errorf("%s: unexpected shard name %s", tc.name, IppoolShardName("c", index))
+		}
+	}
+
+	shards := IppoolShards("c", []*ippoolv1alpha1.IPPool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-ippool-10"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-ippool"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-ippool-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-ippool"}},
+	})
+	if len(shards) != 3 || shards[0].Name != "c-ippool" || shards[1].Name != "c-ippool-2" || shards[2].Name != "c-ippool-10" {
+		t.Errorf("unexpected shards %v", shards)
+	}
+}
+
+func TestSubnetName(t *testing.T) {
+	// the IPv6 subnet of a node must not collide with the IPv4 subnet of another node
+	if SubnetName("a", IPFamilyIPv6) == SubnetName("a-ipv6", IPFamilyDefault) {
+		t.Errorf("subnet names collide: %s", SubnetName("a", IPFamilyIPv6))
+	}
+	if SubnetName("a", IPFamilyDefault) != "a" {
+		t.Errorf("unexpected IPv4 subnet name %s", SubnetName("a", IPFamilyDefault))
+	}
+}
+
+func TestNodeNameOfSubnetRequest(t *testing.T) {
+	testCases := []struct {
+		sub      ippoolv1alpha1.SubnetRequest
+		expected string
+	}{
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "node", IPFamily: IPFamilyDefault}, expected: "node"},
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "node-ipv6", IPFamily: IPFamilyDefault}, expected: "node-ipv6"},
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "node_ipv6", IPFamily: IPFamilyIPv6}, expected: "node"},
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "node-ipv6_ipv6", IPFamily: IPFamilyIPv6}, expected: "node-ipv6"},
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "node_ipv6_extra_2", IPFamily: IPFamilyIPv6}, expected: "node"},
+		{sub: ippoolv1alpha1.SubnetRequest{Name: "web-ipv4-extra-1", IPFamily: IPFamilyDefault}, expected: "web-ipv4-extra-1"},
+	}
+	for _, tc := range testCases {
+		if got := NodeNameOfSubnetRequest(tc.sub); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.sub.Name, tc.expected, got)
+		}
+	}
+}
+
+func TestPatchIPPool(t *testing.T) {
+	client := fakeippoolclientset.NewSimpleClientset(&ippoolv1alpha1.IPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "c-ippool", Namespace: "ns"},
+		Spec: ippoolv1alpha1.IPPoolSpec{
+			Subnets: []ippoolv1alpha1.SubnetRequest{{Name: "n1", IPFamily: IPFamilyDefault, PrefixLength: PrefixLengthDefault}},
+		},
+	})
+
+	err := PatchIPPool(context.Background(), client, "ns", "c-ippool", func(ippool *ippoolv1alpha1.IPPool) bool {
+		ippool.Spec.Subnets = append(ippool.Spec.Subnets, ippoolv1alpha1.SubnetRequest{Name: "n2", IPFamily: IPFamilyDefault, PrefixLength: PrefixLengthDefault})
+		ippool.OwnerReferences = []metav1.OwnerReference{{Name: "owner"}}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("failed to patch ippool: %v", err)
+	}
+	ippool, err := client.NsxV1alpha1().IPPools("ns").Get(context.Background(), "c-ippool", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	if len(ippool.Spec.Subnets) != 2 || ippool.Spec.Subnets[1].Name != "n2" || len(ippool.OwnerReferences) != 1 {
+		t.Errorf("unexpected ippool %v", ippool)
+	}
+
+	// removing all requests keeps an empty list
+	err = PatchIPPool(context.Background(), client, "ns", "c-ippool", func(ippool *ippoolv1alpha1.IPPool) bool {
+		ippool.Spec.Subnets = nil
+		return true
+	})
+	if err != nil {
+		t.Fatalf("failed to patch ippool: %v", err)
+	}
+	ippool, _ = client.NsxV1alpha1().IPPools("ns").Get(context.Background(), "c-ippool", metav1.GetOptions{})
+	if len(ippool.Spec.Subnets) != 0 || len(ippool.OwnerReferences) != 1 {
+		t.Errorf("unexpected ippool %v", ippool)
+	}
+
+	client.ClearActions()
+	if err := PatchIPPool(context.Background(), client, "ns", "c-ippool", func(*ippoolv1alpha1.IPPool) bool { return false }); err != nil {
+		t.Fatalf("failed to patch ippool: %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("unexpected patch of unchanged ippool")
+		}
+	}
+}
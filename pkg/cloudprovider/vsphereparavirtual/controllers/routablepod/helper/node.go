@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PrefixLengthAnnotation overrides the prefix length of the IPv4 pod CIDR of a node,
+	// it can be set as node annotation or node label
+	PrefixLengthAnnotation = "routablepod.vsphere.k8s.io/prefix-length"
+	// IPv6PrefixLengthAnnotation overrides the prefix length of the IPv6 pod CIDR of a node,
+	// it can be set as node annotation or node label
+	IPv6PrefixLengthAnnotation = "routablepod.vsphere.k8s.io/ipv6-prefix-length"
+	// AdditionalPodCIDRsAnnotation lists the comma separated additional subnets allocated for the pods
+	// of a node. Kubernetes does not allow to change node.Spec.PodCIDRs once set, so subnets
+	// requested for nodes running out of pod IPs are published with this annotation.
+	AdditionalPodCIDRsAnnotation = "routablepod.vsphere.k8s.io/additional-pod-cidrs"
+)
+
+// additionalSubnetNameRegexp matches the names of additional subnet requests. Node names
+// cannot contain underscores, so the names do not collide with other nodes.
+var additionalSubnetNameRegexp = regexp.MustCompile(`^([^_]+)_(ipv4|ipv6)_extra_([0-9]+)$`)
+
+// PrefixLengthForNode returns the prefix length of the pod CIDR of a node for a subnet family.
+// The node annotation takes precedence over the node label and the configured prefix length.
+func PrefixLengthForNode(node *corev1.Node, family SubnetFamily) (int, error) {
+	key := PrefixLengthAnnotation
+	if family.IPFamily == IPFamilyIPv6 {
+		key = IPv6PrefixLengthAnnotation
+	}
+	value, ok := node.Annotations[key]
+	if !ok {
+		value, ok = node.Labels[key]
+	}
+	if !ok {
+		return family.PrefixLength, nil
+	}
+	maxLength := 32
+	if family.IPFamily == IPFamilyIPv6 {
+		maxLength = 128
+	}
+	prefixLength, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || prefixLength < 1 || prefixLength > maxLength {
+		return 0, fmt.Errorf("invalid %s %q on node %s", key, value, node.Name)
+	}
+	return prefixLength, nil
+}
+
+// AdditionalSubnetName returns the name of the index-th additional subnet request of a node
+func AdditionalSubnetName(nodeName, ipFamily string, index int) string {
+	return fmt.Sprintf("%s_%s_extra_%d", nodeName, ipFamily, index)
+}
+
+// ParseAdditionalSubnetName returns node name, IP family and index of an additional subnet request
+func ParseAdditionalSubnetName(name string) (nodeName string, ipFamily string, index int, ok bool) {
+	match := additionalSubnetNameRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return "", "", 0, false
+	}
+	index, err := strconv.Atoi(match[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return match[1], match[2], index, true
+}
+
+// AdditionalPodCIDRs returns the additional pod CIDRs of a node
+func AdditionalPodCIDRs(node *corev1.Node) []string {
+	var cidrs []string
+	for _, cidr := range strings.Split(node.Annotations[AdditionalPodCIDRsAnnotation], ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+// FormatAdditionalPodCIDRs returns the sorted annotation value of additional pod CIDRs
+func FormatAdditionalPodCIDRs(cidrs []string) string {
+	sorted := append([]string{}, cidrs...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// IPFamilyOfCIDR returns the IP family of a CIDR
+func IPFamilyOfCIDR(cidr string) string {
+	ip, _, err := net.ParseCIDR(cidr)
+	if err == nil && ip.To4() == nil {
+		return IPFamilyIPv6
+	}
+	return IPFamilyDefault
+}
+
+// CIDRSize returns the number of addresses of a CIDR, capped to avoid overflows for IPv6
+func CIDRSize(cidr string) int64 {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones >= 62 {
+		return 1 << 62
+	}
+	return 1 << uint(bits-ones)
+}
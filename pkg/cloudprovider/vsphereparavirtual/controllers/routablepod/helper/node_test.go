@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrefixLengthForNode(t *testing.T) {
+	ipv4 := SubnetFamily{IPFamily: IPFamilyDefault, PrefixLength: 24}
+	ipv6 := SubnetFamily{IPFamily: IPFamilyIPv6, PrefixLength: 64}
+	testCases := []struct {
+		desc        string
+		annotations map[string]string
+		labels      map[string]string
+		family      SubnetFamily
+		expected    int
+		expectError bool
+	}{
+		{desc: "configured", family: ipv4, expected: 24},
+		{desc: "label", labels: map[string]string{PrefixLengthAnnotation: "26"}, family: ipv4, expected: 26},
+		{
+			desc:        "annotation before label",
+			annotations: map[string]string{PrefixLengthAnnotation: "23"},
+			labels:      map[string]string{PrefixLengthAnnotation: "26"},
+			family:      ipv4,
+			expected:    23,
+		},
+		{desc: "ipv6 annotation", annotations: map[string]string{IPv6PrefixLengthAnnotation: "80"}, family: ipv6, expected: 80},
+		{desc: "ipv4 annotation ignored for ipv6", annotations: map[string]string{PrefixLengthAnnotation: "26"}, family: ipv6, expected: 64},
+		{desc: "invalid", annotations: map[string]string{PrefixLengthAnnotation: "33"}, family: ipv4, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1", Annotations: tc.annotations, Labels: tc.labels}}
+			prefixLength, err := PrefixLengthForNode(node, tc.family)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prefixLength != tc.expected {
+				t.Errorf("expected prefix length %d, got %d", tc.expected, prefixLength)
+			}
+		})
+	}
+}
+
+func TestAdditionalSubnetName(t *testing.T) {
+	name := AdditionalSubnetName("node-ipv6", IPFamilyIPv6, 2)
+	nodeName, ipFamily, index, ok := ParseAdditionalSubnetName(name)
+	if !ok || nodeName != "node-ipv6" || ipFamily != IPFamilyIPv6 || index != 2 {
+		t.Errorf("unexpected parse result of %s: %s %s %d %t", name, nodeName, ipFamily, index, ok)
+	}
+	// node names may look like the additional subnet names of other nodes
+	for _, name := range []string{"node", SubnetName("node", IPFamilyIPv6), "node_ipv4_extra_", "web-ipv4-extra-1"} {
+		if _, _, _, ok := ParseAdditionalSubnetName(name); ok {
+			t.Errorf("%s should not be an additional subnet name", name)
+		}
+	}
+}
+
+func TestAdditionalPodCIDRs(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		AdditionalPodCIDRsAnnotation: FormatAdditionalPodCIDRs([]string{"10.0.1.0/24", "10.0.0.0/26"}),
+	}}}
+	cidrs := AdditionalPodCIDRs(node)
+	if len(cidrs) != 2 || cidrs[0] != "10.0.0.0/26" {
+		t.Errorf("unexpected additional pod CIDRs %v", cidrs)
+	}
+	if CIDRSize(cidrs[0]) != 64 || CIDRSize("fd00::/64") != 1<<62 || IPFamilyOfCIDR("fd00::/64") != IPFamilyIPv6 {
+		t.Errorf("unexpected CIDR size or family")
+	}
+}
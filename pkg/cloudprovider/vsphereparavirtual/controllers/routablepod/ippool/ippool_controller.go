@@ -21,12 +21,15 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -35,6 +38,8 @@ import (
 	ippoolscheme "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/scheme"
 	ippoolinformers "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/informers/externalversions/nsxnetworking/v1alpha1"
 	ippoollisters "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/listers/nsxnetworking/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
+	k8s "k8s.io/cloud-provider-vsphere/pkg/common/kubernetes"
 	klog "k8s.io/klog/v2"
 )
 
@@ -43,24 +48,40 @@ const (
 	cidrUpdateRetries = 3
 	// Interval of synchronizing ippool status from apiserver
 	ippoolSyncPeriod = 30 * time.Second
+
+	// reasons used for the IPPoolReady node condition and node events
+	reasonIPPoolReady    = "IPPoolReady"
+	reasonIPPoolNotReady = "IPPoolNotReady"
 )
 
-// Controller update node's podCIDR whenever ippool's status get updated CIDR allocation result
+// Controller update node's podCIDR whenever ippool's status get updated CIDR allocation result.
+// Only the nodes requesting subnets in the updated ippool shard are updated, with the
+// allocation results of all ippool shards of the cluster.
 type Controller struct {
 	kubeclientset      kubernetes.Interface
 	ippoolclientset    ippoolclientset.Interface
 	ippoolLister       ippoollisters.IPPoolLister
 	ippoolListerSynced cache.InformerSynced
+	nodesLister        corelisters.NodeLister
+	nodeListerSynced   cache.InformerSynced
 
 	recorder  record.EventRecorder
 	workqueue workqueue.RateLimitingInterface
+
+	// clusterName is used to find the ippool shards of the cluster
+	clusterName string
+	// subnetFamilies are the pod CIDRs requested for each node, in the order of node.Spec.PodCIDRs
+	subnetFamilies []helper.SubnetFamily
 }
 
 // NewController returns a Controller that reconciles ippool
 func NewController(
 	kubeClient kubernetes.Interface,
 	ippoolclientset ippoolclientset.Interface,
-	ippoolInformer ippoolinformers.IPPoolInformer) *Controller {
+	ippoolInformer ippoolinformers.IPPoolInformer,
+	informerManager *k8s.InformerManager,
+	clusterName string,
+	subnetFamilies []helper.SubnetFamily) *Controller {
 
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
@@ -72,9 +93,14 @@ func NewController(
 		ippoolclientset:    ippoolclientset,
 		ippoolLister:       ippoolInformer.Lister(),
 		ippoolListerSynced: ippoolInformer.Informer().HasSynced,
+		nodesLister:        informerManager.GetNodeLister(),
+		nodeListerSynced:   informerManager.IsNodeInformerSynced(),
 
 		recorder:  recorder,
 		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "IPPools"),
+
+		clusterName:    clusterName,
+		subnetFamilies: subnetFamilies,
 	}
 
 	// watch ippool change
@@ -103,9 +129,11 @@ func NewController(
 	return c
 }
 
-// if allocated subnets are updated, then need to update nodes with new subnets
+// if allocated subnets or conditions are updated, then need to update nodes with new subnets
+// and conditions
 func shouldSyncIPPool(old, cur *ippoolv1alpha1.IPPool) bool {
-	return !reflect.DeepEqual(old.Status.Subnets, cur.Status.Subnets)
+	return !reflect.DeepEqual(old.Status.Subnets, cur.Status.Subnets) ||
+		!reflect.DeepEqual(old.Status.Conditions, cur.Status.Conditions)
 }
 
 func (c *Controller) enqueueIPPool(obj interface{}) {
@@ -125,7 +153,7 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 
 	klog.V(4).Info("Waiting cache to be synced.")
 
-	if !cache.WaitForNamedCacheSync("ippool", stopCh, c.ippoolListerSynced) {
+	if !cache.WaitForNamedCacheSync("ippool", stopCh, c.ippoolListerSynced, c.nodeListerSynced) {
 		return
 	}
 
@@ -212,26 +240,43 @@ func (c *Controller) syncIPPool(key string) error {
 
 func (c *Controller) processIPPoolCreateOrUpdate(ippool *ippoolv1alpha1.IPPool) error {
 	ctx := context.Background()
+	results, err := c.aggregateSubnetResults(ippool)
+	if err != nil {
+		return err
+	}
 	// make map of allocated subnets
 	subs := make(map[string]string)
-	for _, sub := range ippool.Status.Subnets {
+	additional := make(map[string][]string)
+	for _, sub := range results {
 		subs[sub.Name] = sub.CIDR
+		if nodeName, _, _, ok := helper.ParseAdditionalSubnetName(sub.Name); ok && sub.CIDR != "" {
+			additional[nodeName] = append(additional[nodeName], sub.CIDR)
+		}
 	}
-	nodes, err := c.kubeclientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := c.requestingNodes(ippool)
 	if err != nil {
 		return err
 	}
 
-	// update node with allocated subnet
-	for _, n := range nodes.Items {
-		if v, ok := subs[n.Name]; ok {
-			// Set or overwrite the podCIDR on current node
+	c.updateNodeIPPoolConditions(ctx, ippool, nodes)
+
+	// update node with allocated subnets
+	for _, n := range nodes {
+		if err := c.updateAdditionalPodCIDRs(n, additional[n.Name]); err != nil {
+			klog.Errorf("Failed to update additional pod CIDRs of node %v: %v", n.Name, err)
+			return err
+		}
+		if v, ok := c.nodeCIDRs(n.Name, subs); ok {
+			if equalCIDRs(n.Spec.PodCIDRs, v) {
+				continue
+			}
+			// Set or overwrite the podCIDRs on current node
 			if err := c.patchNodeCIDRWithRetry(types.NodeName(n.Name), v); err == nil {
 				// continue to next node if this one succeeded
 				continue
 			}
 			klog.Errorf("Failed to update node %v PodCIDR to %v after multiple attempts: %v", n.Name, v, err)
-			c.recordNodeStatusChange(&n, "CIDRAssignmentFailed")
+			c.recordNodeStatusChange(n, "CIDRAssignmentFailed")
 			klog.Errorf("CIDR assignment for node %v failed: %v. Try again in next reconcile", n.Name, err)
 
 			return err
@@ -241,6 +286,91 @@ func (c *Controller) processIPPoolCreateOrUpdate(ippool *ippoolv1alpha1.IPPool)
 	return nil
 }
 
+// requestingNodes returns the nodes requesting subnets in an ippool. The nodes of other
+// shards are updated when their shard is processed.
+func (c *Controller) requestingNodes(ippool *ippoolv1alpha1.IPPool) ([]*corev1.Node, error) {
+	var nodes []*corev1.Node
+	seen := make(map[string]bool)
+	for _, sub := range ippool.Spec.Subnets {
+		nodeName := helper.NodeNameOfSubnetRequest(sub)
+		if seen[nodeName] {
+			continue
+		}
+		seen[nodeName] = true
+		node, err := c.nodesLister.Get(nodeName)
+		if apierrors.IsNotFound(err) {
+			// the node controller removes the requests of deleted nodes
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// equalCIDRs returns true if the pod CIDRs of a node are the given CIDRs
+func equalCIDRs(podCIDRs, cidrs []string) bool {
+	if len(podCIDRs) != len(cidrs) {
+		return false
+	}
+	for i := range cidrs {
+		if podCIDRs[i] != cidrs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregateSubnetResults returns the allocated subnets of all ippool shards of the cluster
+// the ippool belongs to, since the subnets of a node may be allocated in different shards
+func (c *Controller) aggregateSubnetResults(ippool *ippoolv1alpha1.IPPool) ([]ippoolv1alpha1.SubnetResult, error) {
+	if c.clusterName == "" {
+		return ippool.Status.Subnets, nil
+	}
+	if _, ok := helper.IppoolShardIndex(c.clusterName, ippool.Name); !ok {
+		return ippool.Status.Subnets, nil
+	}
+	ippools, err := c.ippoolLister.IPPools(ippool.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var results []ippoolv1alpha1.SubnetResult
+	for _, shard := range helper.IppoolShards(c.clusterName, ippools) {
+		if shard.Name == ippool.Name {
+			// the lister may lag behind the ippool being processed
+			shard = ippool
+		}
+		results = append(results, shard.Status.Subnets...)
+	}
+	return results, nil
+}
+
+// nodeCIDRs returns the allocated CIDRs of a node in the order of the IP families.
+// It returns false as long as not all IP families are allocated.
+func (c *Controller) nodeCIDRs(nodeName string, subs map[string]string) ([]string, bool) {
+	families := c.subnetFamilies
+	if len(families) == 0 {
+		families = helper.DefaultSubnetFamilies()
+	}
+	var cidrs []string
+	for _, family := range families {
+		cidr, ok := subs[helper.SubnetName(nodeName, family.IPFamily)]
+		if !ok {
+			return nil, false
+		}
+		if cidr != "" {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	// podCIDRs can only be set once, so wait for all families of dual-stack nodes
+	if len(families) > 1 && len(cidrs) < len(families) {
+		return nil, false
+	}
+	return cidrs, true
+}
+
 type nodeForCIDRMergePatch struct {
 	Spec nodeSpecForMergePatch `json:"spec"`
 }
@@ -250,26 +380,29 @@ type nodeSpecForMergePatch struct {
 	PodCIDRs []string `json:"podCIDRs,omitempty"`
 }
 
-// patchNodeCIDRWithRetry patches the specified node's CIDR to the given value with retries
-func (c *Controller) patchNodeCIDRWithRetry(node types.NodeName, cidr string) error {
+// patchNodeCIDRWithRetry patches the specified node's CIDRs to the given values with retries
+func (c *Controller) patchNodeCIDRWithRetry(node types.NodeName, cidrs []string) error {
 	var err error
 	for i := 0; i < cidrUpdateRetries; i++ {
-		if err = c.patchNodeCIDR(node, cidr); err == nil {
-			klog.V(4).Info("Set node %v PodCIDR to %v", node, cidr)
+		if err = c.patchNodeCIDR(node, cidrs); err == nil {
+			klog.V(4).Infof("Set node %v PodCIDRs to %v", node, cidrs)
 			return nil
 		}
 	}
 	return err
 }
 
-// patchNodeCIDR patches the specified node's CIDR to the given value.
-func (c *Controller) patchNodeCIDR(node types.NodeName, cidr string) error {
+// patchNodeCIDR patches the specified node's CIDRs to the given values.
+// The first CIDR is the primary podCIDR.
+func (c *Controller) patchNodeCIDR(node types.NodeName, cidrs []string) error {
 	patch := nodeForCIDRMergePatch{
 		Spec: nodeSpecForMergePatch{
-			PodCIDR:  cidr,
-			PodCIDRs: []string{cidr},
+			PodCIDRs: cidrs,
 		},
 	}
+	if len(cidrs) > 0 {
+		patch.Spec.PodCIDR = cidrs[0]
+	}
 	patchBytes, err := json.Marshal(&patch)
 	if err != nil {
 		return fmt.Errorf("failed to json.Marshal CIDR: %v", err)
@@ -281,15 +414,89 @@ func (c *Controller) patchNodeCIDR(node types.NodeName, cidr string) error {
 	return nil
 }
 
-// recordNodeStatusChange records a event related to a node status change. (Common to lifecycle and ipam)
-func (c *Controller) recordNodeStatusChange(node *corev1.Node, newStatus string) {
-	ref := &corev1.ObjectReference{
+// updateAdditionalPodCIDRs publishes the additional subnets allocated for a node with an annotation
+func (c *Controller) updateAdditionalPodCIDRs(node *corev1.Node, cidrs []string) error {
+	value := helper.FormatAdditionalPodCIDRs(cidrs)
+	if node.Annotations[helper.AdditionalPodCIDRsAnnotation] == value {
+		return nil
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				helper.AdditionalPodCIDRsAnnotation: value,
+			},
+		},
+	}
+	if value == "" {
+		patch["metadata"].(map[string]interface{})["annotations"] = map[string]interface{}{
+			helper.AdditionalPodCIDRsAnnotation: nil,
+		}
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to json.Marshal annotation: %v", err)
+	}
+	if _, err := c.kubeclientset.CoreV1().Nodes().Patch(context.TODO(), node.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch node annotation: %v", err)
+	}
+	klog.V(4).Infof("Set node %v additional pod CIDRs to %q", node.Name, value)
+	c.recordNodeStatusChange(node, "AdditionalPodCIDRsUpdated")
+	return nil
+}
+
+// updateNodeIPPoolConditions reflects the 'Ready' condition of an ippool on the nodes
+// whose subnets are requested in it, including the failure message of NSX
+func (c *Controller) updateNodeIPPoolConditions(ctx context.Context, ippool *ippoolv1alpha1.IPPool, nodes []*corev1.Node) {
+	var ready *ippoolv1alpha1.IPPoolCondition
+	for i := range ippool.Status.Conditions {
+		if ippool.Status.Conditions[i].Type == ippoolv1alpha1.IPPoolConditionTypeReady {
+			ready = &ippool.Status.Conditions[i]
+		}
+	}
+	if ready == nil {
+		// the ippool is not processed yet
+		return
+	}
+	condition := corev1.NodeCondition{
+		Type:    helper.NodeConditionIPPoolReady,
+		Status:  ready.Status,
+		Reason:  ready.Reason,
+		Message: ready.Message,
+	}
+	if condition.Reason == "" {
+		condition.Reason = reasonIPPoolNotReady
+		if ready.Status == corev1.ConditionTrue {
+			condition.Reason = reasonIPPoolReady
+		}
+	}
+
+	for _, node := range nodes {
+		changed, err := helper.SetNodeCondition(ctx, c.kubeclientset, node, condition)
+		if err != nil {
+			klog.Errorf("Failed to update IPPool condition of node %v: %v", node.Name, err)
+			continue
+		}
+		if changed && condition.Status == corev1.ConditionFalse {
+			c.recorder.Eventf(nodeReference(node), corev1.EventTypeWarning, reasonIPPoolNotReady, "IPPool %s/%s is not ready: %s: %s",
+				ippool.Namespace, ippool.Name, condition.Reason, condition.Message)
+		}
+	}
+}
+
+// nodeReference returns the object reference of a node for events
+func nodeReference(node *corev1.Node) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
 		APIVersion: "v1",
 		Kind:       "Node",
 		Name:       node.Name,
 		UID:        node.UID,
 		Namespace:  "",
 	}
+}
+
+// recordNodeStatusChange records a event related to a node status change. (Common to lifecycle and ipam)
+func (c *Controller) recordNodeStatusChange(node *corev1.Node, newStatus string) {
+	ref := nodeReference(node)
 	klog.V(2).Infof("Recording status change %s event message for node %s", newStatus, node.Name)
 	c.recorder.Eventf(ref, corev1.EventTypeNormal, newStatus, "Node %s status is now: %s", node.Name, newStatus)
 }
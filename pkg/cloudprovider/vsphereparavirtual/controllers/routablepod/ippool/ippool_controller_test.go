@@ -16,17 +16,23 @@ package ippool
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
 	fakeippoolclientset "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/fake"
 	ippoolscheme "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/scheme"
+	ippoollisters "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/listers/nsxnetworking/v1alpha1"
 	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 
 	klog "k8s.io/klog/v2"
@@ -65,6 +71,8 @@ func newController() (*Controller, *fake.Clientset) {
 	}
 
 	c.ippoolListerSynced = alwaysReady
+	c.nodesLister = corelisters.NewNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+	c.nodeListerSynced = alwaysReady
 	c.recorder = record.NewFakeRecorder(100)
 	ippoolclientset.ClearActions()
 	kubeClient.ClearActions()
@@ -134,7 +142,7 @@ func TestProcessIPPoolCreateOrUpdate(t *testing.T) {
 					CIDR: "10.0.0.1/24",
 				},
 			},
-			expectedNumPatches: 3,
+			expectedNumPatches: 1,
 		},
 	}
 
@@ -184,9 +192,29 @@ func TestProcessIPPoolCreateOrUpdate(t *testing.T) {
 		})
 	}
 }
+
+// syncNodeLister updates the node lister of the controller with the nodes of the clientset
+func syncNodeLister(c *Controller) error {
+	nodes, err := c.kubeclientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for i := range nodes.Items {
+		if err := indexer.Add(&nodes.Items[i]); err != nil {
+			return err
+		}
+	}
+	c.nodesLister = corelisters.NewNodeLister(indexer)
+	return nil
+}
+
 func updateIPPoolCIDRAndVerifyNodeCIDR(srs []ippoolv1alpha1.SubnetResult, c *Controller) error {
 	// add subnet allocation result updates to ippool
 	ippoolUpdatedSubnet := createIPPool(srs)
+	if err := syncNodeLister(c); err != nil {
+		return fmt.Errorf("failed to sync node lister: %w", err)
+	}
 	if err := c.processIPPoolCreateOrUpdate(ippoolUpdatedSubnet); err != nil {
 		return fmt.Errorf("failed to processIPPoolCreateOrUpdate %v: %w", ippoolUpdatedSubnet, err)
 	}
@@ -216,6 +244,8 @@ func updateIPPoolCIDRAndVerifyNodeCIDR(srs []ippoolv1alpha1.SubnetResult, c *Con
 
 	return nil
 }
+
+// createIPPool returns an ippool with the given allocation results and their requests
 func createIPPool(srs []ippoolv1alpha1.SubnetResult) *ippoolv1alpha1.IPPool {
 	ippool := &ippoolv1alpha1.IPPool{
 		ObjectMeta: metav1.ObjectMeta{
@@ -226,6 +256,9 @@ func createIPPool(srs []ippoolv1alpha1.SubnetResult) *ippoolv1alpha1.IPPool {
 			Subnets: srs,
 		},
 	}
+	for _, sr := range srs {
+		ippool.Spec.Subnets = append(ippool.Spec.Subnets, ippoolv1alpha1.SubnetRequest{Name: sr.Name, IPFamily: helper.IPFamilyDefault})
+	}
 
 	return ippool
 }
@@ -237,3 +270,184 @@ func createNode(name string) corev1.Node {
 		},
 	}
 }
+
+func TestNodeCIDRsDualStack(t *testing.T) {
+	c, _ := newController()
+	c.subnetFamilies = []helper.SubnetFamily{
+		{IPFamily: helper.IPFamilyIPv6, PrefixLength: 64},
+		{IPFamily: helper.IPFamilyDefault, PrefixLength: 24},
+	}
+
+	subs := map[string]string{n1Name: "10.0.0.0/24"}
+	if _, ok := c.nodeCIDRs(n1Name, subs); ok {
+		t.Errorf("expected node CIDRs to wait for the IPv6 allocation")
+	}
+	subs[n1Name+"_ipv6"] = ""
+	if _, ok := c.nodeCIDRs(n1Name, subs); ok {
+		t.Errorf("expected node CIDRs to wait for a non empty IPv6 allocation")
+	}
+	subs[n1Name+"_ipv6"] = "fd00::/64"
+	cidrs, ok := c.nodeCIDRs(n1Name, subs)
+	if !ok || !reflect.DeepEqual([]string{"fd00::/64", "10.0.0.0/24"}, cidrs) {
+		t.Errorf("unexpected node CIDRs %v", cidrs)
+	}
+
+	c.kubeclientset.CoreV1().Nodes().Create(context.Background(), &n1, metav1.CreateOptions{})
+	if err := c.patchNodeCIDR(types.NodeName(n1Name), cidrs); err != nil {
+		t.Fatalf("failed to patch node CIDRs: %v", err)
+	}
+	node, _ := c.kubeclientset.CoreV1().Nodes().Get(context.Background(), n1Name, metav1.GetOptions{})
+	if node.Spec.PodCIDR != "fd00::/64" || !reflect.DeepEqual(cidrs, node.Spec.PodCIDRs) {
+		t.Errorf("unexpected node pod CIDRs %s %v", node.Spec.PodCIDR, node.Spec.PodCIDRs)
+	}
+}
+
+func TestProcessIPPoolAdditionalSubnets(t *testing.T) {
+	c, cs := newController()
+	if _, err := c.kubeclientset.CoreV1().Nodes().Create(context.Background(), &n1, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create test node %s: %v", n1.Name, err)
+	}
+
+	ippool := createIPPool([]ippoolv1alpha1.SubnetResult{
+		{Name: n1Name, CIDR: "10.0.0.0/24"},
+		{Name: helper.AdditionalSubnetName(n1Name, helper.IPFamilyDefault, 1), CIDR: "10.0.5.0/24"},
+		{Name: helper.AdditionalSubnetName(n2Name, helper.IPFamilyDefault, 1), CIDR: "10.0.6.0/24"},
+	})
+	if err := syncNodeLister(c); err != nil {
+		t.Fatalf("failed to sync node lister: %v", err)
+	}
+	if err := c.processIPPoolCreateOrUpdate(ippool); err != nil {
+		t.Fatalf("failed to processIPPoolCreateOrUpdate: %v", err)
+	}
+	node, err := c.kubeclientset.CoreV1().Nodes().Get(context.Background(), n1Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if node.Spec.PodCIDR != "10.0.0.0/24" || len(node.Spec.PodCIDRs) != 1 {
+		t.Errorf("unexpected node pod CIDRs %v", node.Spec.PodCIDRs)
+	}
+	if node.Annotations[helper.AdditionalPodCIDRsAnnotation] != "10.0.5.0/24" {
+		t.Errorf("unexpected additional pod CIDRs %q", node.Annotations[helper.AdditionalPodCIDRsAnnotation])
+	}
+
+	// unchanged annotation is not patched again
+	cs.ClearActions()
+	if err := c.updateAdditionalPodCIDRs(node, []string{"10.0.5.0/24"}); err != nil {
+		t.Fatalf("failed to update additional pod CIDRs: %v", err)
+	}
+	if len(cs.Actions()) != 0 {
+		t.Errorf("expected no patch, got %v", cs.Actions())
+	}
+}
+
+func TestProcessIPPoolShards(t *testing.T) {
+	c, cs := newController()
+	c.clusterName = testClusterName
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c.ippoolLister = ippoollisters.NewIPPoolLister(indexer)
+	for _, n := range []corev1.Node{n1, n2} {
+		node := n
+		if _, err := cs.CoreV1().Nodes().Create(context.Background(), &node, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create test node %s: %v", n.Name, err)
+		}
+	}
+
+	shard0 := createIPPool([]ippoolv1alpha1.SubnetResult{{Name: n1Name, CIDR: "10.0.0.0/24"}})
+	shard1 := createIPPool([]ippoolv1alpha1.SubnetResult{
+		{Name: n2Name, CIDR: "10.0.1.0/24"},
+		{Name: helper.AdditionalSubnetName(n1Name, helper.IPFamilyDefault, 1), CIDR: "10.0.5.0/24"},
+	})
+	shard1.Name = helper.IppoolShardName(testClusterName, 1)
+	other := createIPPool([]ippoolv1alpha1.SubnetResult{{Name: n2Name, CIDR: "10.9.9.0/24"}})
+	other.Name = helper.IppoolNameFromClusterName("other")
+	for _, ippool := range []*ippoolv1alpha1.IPPool{shard0, shard1, other} {
+		if err := indexer.Add(ippool); err != nil {
+			t.Fatalf("failed to add ippool: %v", err)
+		}
+	}
+
+	if err := syncNodeLister(c); err != nil {
+		t.Fatalf("failed to sync node lister: %v", err)
+	}
+	if err := c.processIPPoolCreateOrUpdate(shard0); err != nil {
+		t.Fatalf("failed to processIPPoolCreateOrUpdate: %v", err)
+	}
+	// the subnets of a node are aggregated from all shards, but only the nodes
+	// requesting subnets in the processed shard are updated
+	node1, _ := cs.CoreV1().Nodes().Get(context.Background(), n1Name, metav1.GetOptions{})
+	node2, _ := cs.CoreV1().Nodes().Get(context.Background(), n2Name, metav1.GetOptions{})
+	if node1.Spec.PodCIDR != "10.0.0.0/24" || node1.Annotations[helper.AdditionalPodCIDRsAnnotation] != "10.0.5.0/24" {
+		t.Errorf("unexpected node %s pod CIDRs %v, annotations %v", n1Name, node1.Spec.PodCIDRs, node1.Annotations)
+	}
+	if node2.Spec.PodCIDR != "" {
+		t.Errorf("unexpected update of node %s of another shard: pod CIDRs %v", n2Name, node2.Spec.PodCIDRs)
+	}
+
+	if err := syncNodeLister(c); err != nil {
+		t.Fatalf("failed to sync node lister: %v", err)
+	}
+	if err := c.processIPPoolCreateOrUpdate(shard1); err != nil {
+		t.Fatalf("failed to processIPPoolCreateOrUpdate: %v", err)
+	}
+	node2, _ = cs.CoreV1().Nodes().Get(context.Background(), n2Name, metav1.GetOptions{})
+	if node2.Spec.PodCIDR != "10.0.1.0/24" {
+		t.Errorf("unexpected node %s pod CIDRs %v", n2Name, node2.Spec.PodCIDRs)
+	}
+
+	// nodes with the allocated pod CIDRs are not patched again
+	if err := syncNodeLister(c); err != nil {
+		t.Fatalf("failed to sync node lister: %v", err)
+	}
+	cs.ClearActions()
+	if err := c.processIPPoolCreateOrUpdate(shard0); err != nil {
+		t.Fatalf("failed to processIPPoolCreateOrUpdate: %v", err)
+	}
+	for _, action := range cs.Actions() {
+		if action.Matches("patch", "nodes") {
+			t.Errorf("unexpected node patch %v", action)
+		}
+	}
+}
+
+func TestProcessIPPoolConditions(t *testing.T) {
+	c, cs := newController()
+	for _, n := range []corev1.Node{n1, n2} {
+		node := n
+		if _, err := cs.CoreV1().Nodes().Create(context.Background(), &node, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create test node %s: %v", n.Name, err)
+		}
+	}
+
+	ippool := createIPPool(nil)
+	ippool.Spec.Subnets = []ippoolv1alpha1.SubnetRequest{{Name: n1Name}}
+	ippool.Status.Conditions = []ippoolv1alpha1.IPPoolCondition{{
+		Type:    ippoolv1alpha1.IPPoolConditionTypeReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  "SubnetAllocationFailed",
+		Message: "ip block exhausted",
+	}}
+	if err := syncNodeLister(c); err != nil {
+		t.Fatalf("failed to sync node lister: %v", err)
+	}
+	if err := c.processIPPoolCreateOrUpdate(ippool); err != nil {
+		t.Fatalf("failed to processIPPoolCreateOrUpdate: %v", err)
+	}
+
+	node1, _ := cs.CoreV1().Nodes().Get(context.Background(), n1Name, metav1.GetOptions{})
+	condition := helper.GetNodeCondition(node1, helper.NodeConditionIPPoolReady)
+	if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != "SubnetAllocationFailed" || condition.Message != "ip block exhausted" {
+		t.Errorf("unexpected node %s condition %v", n1Name, condition)
+	}
+	node2, _ := cs.CoreV1().Nodes().Get(context.Background(), n2Name, metav1.GetOptions{})
+	if condition := helper.GetNodeCondition(node2, helper.NodeConditionIPPoolReady); condition != nil {
+		t.Errorf("unexpected condition %v on node %s without subnet request", condition, n2Name)
+	}
+	select {
+	case event := <-c.recorder.(*record.FakeRecorder).Events:
+		if !strings.Contains(event, reasonIPPoolNotReady) || !strings.Contains(event, "ip block exhausted") {
+			t.Errorf("unexpected event %q", event)
+		}
+	default:
+		t.Errorf("expected %s event", reasonIPPoolNotReady)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "routablepod_node_controller"
+
+var (
+	// leakedSubnetRequests counts the subnet requests of deleted nodes removed by the reconcile
+	leakedSubnetRequests = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "leaked_subnet_requests_total",
+			Help:           "Number of IPPool subnet requests of deleted nodes removed by the periodic reconcile",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// repairedSubnetRequests counts the missing subnet requests of existing nodes added by the reconcile
+	repairedSubnetRequests = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "repaired_subnet_requests_total",
+			Help:           "Number of missing IPPool subnet requests of existing nodes added by the periodic reconcile",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	registerOnce sync.Once
+)
+
+// registerMetrics registers the node controller metrics
+func registerMetrics() {
+	registerOnce.Do(func() {
+		legacyregistry.MustRegister(leakedSubnetRequests)
+		legacyregistry.MustRegister(repairedSubnetRequests)
+	})
+}
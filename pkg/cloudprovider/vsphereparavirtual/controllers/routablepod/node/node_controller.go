@@ -43,7 +43,9 @@ const (
 
 // Controller adds or removes node's CIDR allocation request from ippool spec
 // whenever a node is added/updated/removed.
-// Create a ippool if there isn't one for current cluster.
+// Create a ippool if there isn't one for current cluster. The requests are spread over
+// ippool shards with a limited number of nodes per shard.
+// A periodic reconcile removes the requests of nodes deleted while the controller was down.
 type Controller struct {
 	ippoolclientset ippoolclientset.Interface
 
@@ -56,6 +58,11 @@ type Controller struct {
 	clusterName string
 	clusterNS   string
 
+	// subnetFamilies are the pod CIDRs requested for each node
+	subnetFamilies []helper.SubnetFamily
+	// nodesPerIPPool is the maximum number of nodes per ippool shard, 0 keeps all nodes in one ippool
+	nodesPerIPPool int
+
 	ownerRef *metav1.OwnerReference
 }
 
@@ -66,8 +73,12 @@ func NewController(
 	informerManager *k8s.InformerManager,
 	clusterName string,
 	clusterNS string,
+	subnetFamilies []helper.SubnetFamily,
+	nodesPerIPPool int,
 	ownerRef *metav1.OwnerReference) *Controller {
 
+	registerMetrics()
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events(clusterNS)})
@@ -84,6 +95,9 @@ func NewController(
 		clusterName: clusterName,
 		clusterNS:   clusterNS,
 
+		subnetFamilies: subnetFamilies,
+		nodesPerIPPool: nodesPerIPPool,
+
 		ownerRef: ownerRef,
 	}
 
@@ -133,6 +147,13 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	klog.V(4).Info("Starting node workers.")
 	go wait.Until(c.runWorker, time.Second, stopCh)
 
+	klog.V(4).Info("Starting ippool reconcile.")
+	go wait.Until(func() {
+		if err := c.reconcile(); err != nil {
+			utilruntime.HandleError(fmt.Errorf("error reconciling ippool subnet requests: %v", err))
+		}
+	}, reconcilePeriod, stopCh)
+
 	<-stopCh
 }
 
@@ -214,88 +235,207 @@ func (c *Controller) syncNode(key string) error {
 	return err
 }
 
-// remove the node subnet allocation request from ippool's spec
-// if ippool is not found, skip the removing
+// families returns the configured subnet families or the IPv4 default
+func (c *Controller) families() []helper.SubnetFamily {
+	if len(c.subnetFamilies) == 0 {
+		return helper.DefaultSubnetFamilies()
+	}
+	return c.subnetFamilies
+}
+
+// remove the node subnet allocation requests of all IP families from the ippool shards' spec
+// if no ippool is found, skip the removing
 func (c *Controller) processNodeDelete(name string) error {
 	ctx := context.Background()
 
-	ippool, err := c.ippoolclientset.NsxV1alpha1().IPPools(c.clusterNS).Get(ctx, helper.IppoolNameFromClusterName(c.clusterName), metav1.GetOptions{})
+	shards, err := c.listIPPoolShards(ctx)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			klog.V(4).Info("ippool is gone, no need to remove the node request")
-			return nil
-		}
 		return err
 	}
+	if len(shards) == 0 {
+		klog.V(4).Info("ippool is gone, no need to remove the node request")
+		return nil
+	}
 
-	newSubnets := []ippoolv1alpha1.SubnetRequest{}
-	for _, sub := range ippool.Spec.Subnets {
-		if sub.Name == name {
+	for _, shard := range shards {
+		if !ippoolHasNode(shard, name) {
 			continue
 		}
-		newSubnets = append(newSubnets, sub)
+		err := helper.PatchIPPool(ctx, c.ippoolclientset, c.clusterNS, shard.Name, func(ippool *ippoolv1alpha1.IPPool) bool {
+			newSubnets := []ippoolv1alpha1.SubnetRequest{}
+			for _, sub := range ippool.Spec.Subnets {
+				if helper.NodeNameOfSubnetRequest(sub) != name {
+					newSubnets = append(newSubnets, sub)
+				}
+			}
+			changed := len(newSubnets) != len(ippool.Spec.Subnets)
+			ippool.Spec.Subnets = newSubnets
+			return changed
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
 	}
-	ippool.Spec.Subnets = newSubnets
-
-	_, err = c.ippoolclientset.NsxV1alpha1().IPPools(c.clusterNS).Update(context.TODO(), ippool, metav1.UpdateOptions{})
-
-	return err
+	return nil
 }
 
 // when a node is created or updated, check if the node has podCIDR field set.
-// if node's podCIDR is empty, add the node CIDR allocation request to ippool spec.
+// if node's podCIDR is empty, add the node CIDR allocation request of each IP family to the spec
+// of the ippool shard of the node.
 func (c *Controller) processNodeCreateOrUpdate(node *corev1.Node) error {
 	ctx := context.Background()
-	ippool, err := c.ippoolclientset.NsxV1alpha1().IPPools(c.clusterNS).Get(ctx, helper.IppoolNameFromClusterName(c.clusterName), metav1.GetOptions{})
+	shards, err := c.listIPPoolShards(ctx)
 	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			return err
+		return err
+	}
+	shardName, err := c.ippoolShardForNode(ctx, node.Name, shards)
+	if err != nil {
+		return err
+	}
+
+	var missing []ippoolv1alpha1.SubnetRequest
+	// add node cidr allocation req to the ippool spec only when node doesn't contain pod cidr
+	if node.Spec.PodCIDR == "" || len(node.Spec.PodCIDRs) == 0 {
+		missing = c.missingSubnetRequests(node, requestedSubnets(shards))
+	}
+	// skip if the requests already added
+	if len(missing) == 0 {
+		klog.V(4).Infof("node %s already requested the ip", node.Name)
+		return nil
+	}
+
+	klog.V(4).Infof("updating CIDR request in IPPool %s/%s for node %s", c.clusterNS, shardName, node.Name)
+	if err := c.addSubnetRequests(ctx, shardName, missing); err != nil {
+		return err
+	}
+
+	klog.V(4).Infof("updated CIDR in IPPool %s/%s for node %s", c.clusterNS, shardName, node.Name)
+	return nil
+}
+
+// addSubnetRequests adds the subnet requests which are not requested yet to the spec of an ippool shard
+func (c *Controller) addSubnetRequests(ctx context.Context, shardName string, requests []ippoolv1alpha1.SubnetRequest) error {
+	return helper.PatchIPPool(ctx, c.ippoolclientset, c.clusterNS, shardName, func(ippool *ippoolv1alpha1.IPPool) bool {
+		changed := false
+		requested := requestedSubnets([]*ippoolv1alpha1.IPPool{ippool})
+		for _, request := range requests {
+			if !requested[request.Name] {
+				ippool.Spec.Subnets = append(ippool.Spec.Subnets, request)
+				changed = true
+			}
 		}
-		// if ippool does not exist, create one
-		klog.V(4).Info("creating ippool")
-		if ippool, err = c.createIPPool(); err != nil {
-			klog.Error("error creating ippool")
-			return err
+		if ippool.OwnerReferences == nil {
+			ippool.OwnerReferences = []metav1.OwnerReference{*c.ownerRef}
+			changed = true
 		}
+		return changed
+	})
+}
+
+// listIPPoolShards returns the ippool shards of the cluster sorted by shard index
+func (c *Controller) listIPPoolShards(ctx context.Context) ([]*ippoolv1alpha1.IPPool, error) {
+	list, err := c.ippoolclientset.NsxV1alpha1().IPPools(c.clusterNS).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	ippools := make([]*ippoolv1alpha1.IPPool, 0, len(list.Items))
+	for i := range list.Items {
+		ippools = append(ippools, &list.Items[i])
+	}
+	return helper.IppoolShards(c.clusterName, ippools), nil
+}
 
-	// skip if the request already added
-	for _, sub := range ippool.Spec.Subnets {
-		if sub.Name == node.Name {
-			klog.V(4).Info("node %s already requested the ip", node.Name)
-			return nil
+// ippoolShardForNode returns the name of the ippool shard holding the requests of a node.
+// New nodes are assigned to the first shard with less than nodesPerIPPool nodes, a new
+// shard is created if all shards are full.
+func (c *Controller) ippoolShardForNode(ctx context.Context, nodeName string, shards []*ippoolv1alpha1.IPPool) (string, error) {
+	for _, shard := range shards {
+		if ippoolHasNode(shard, nodeName) {
+			return shard.Name, nil
 		}
 	}
 
-	klog.V(4).Info("updating CIDR request in IPPool %s/%s for node %s", ippool.Namespace, ippool.Name, node.Name)
+	used := make(map[int]bool)
+	for _, shard := range shards {
+		index, _ := helper.IppoolShardIndex(c.clusterName, shard.Name)
+		used[index] = true
+		if c.nodesPerIPPool <= 0 {
+			// no sharding, all nodes share the first ippool
+			if index == 0 {
+				return shard.Name, nil
+			}
+			continue
+		}
+		if len(ippoolNodes(shard)) < c.nodesPerIPPool {
+			return shard.Name, nil
+		}
+	}
 
-	newIPPool := ippool.DeepCopy()
-	// add node cidr allocation req to the ippool spec only when node doesn't contain pod cidr
-	if node.Spec.PodCIDR == "" || len(node.Spec.PodCIDRs) == 0 {
-		newIPPool.Spec.Subnets = append(newIPPool.Spec.Subnets, ippoolv1alpha1.SubnetRequest{
-			Name:         node.Name,
-			IPFamily:     helper.IPFamilyDefault,
-			PrefixLength: helper.PrefixLengthDefault,
-		})
+	index := 0
+	for used[index] {
+		index++
 	}
+	// if ippool does not exist, create one
+	name := helper.IppoolShardName(c.clusterName, index)
+	klog.V(4).Infof("creating ippool %s/%s", c.clusterNS, name)
+	if _, err := c.createIPPool(name); err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("error creating ippool %s/%s", c.clusterNS, name)
+		return "", err
+	}
+	return name, nil
+}
 
-	if newIPPool.OwnerReferences == nil {
-		newIPPool.OwnerReferences = []metav1.OwnerReference{*c.ownerRef}
+// ippoolNodes returns the names of the nodes with requests in an ippool
+func ippoolNodes(ippool *ippoolv1alpha1.IPPool) map[string]bool {
+	nodes := make(map[string]bool)
+	for _, sub := range ippool.Spec.Subnets {
+		nodes[helper.NodeNameOfSubnetRequest(sub)] = true
 	}
+	return nodes
+}
 
-	_, err = c.ippoolclientset.NsxV1alpha1().IPPools(c.clusterNS).Update(context.Background(), newIPPool, metav1.UpdateOptions{})
-	if err != nil {
-		return err
+// ippoolHasNode returns true if the ippool holds requests of the node
+func ippoolHasNode(ippool *ippoolv1alpha1.IPPool, nodeName string) bool {
+	return ippoolNodes(ippool)[nodeName]
+}
+
+// requestedSubnets returns the names of the subnet requests in the ippools
+func requestedSubnets(ippools []*ippoolv1alpha1.IPPool) map[string]bool {
+	requested := make(map[string]bool)
+	for _, ippool := range ippools {
+		for _, sub := range ippool.Spec.Subnets {
+			requested[sub.Name] = true
+		}
 	}
+	return requested
+}
 
-	klog.V(4).Info("updated CIDR in IPPool %s/%s for node %s", ippool.Namespace, ippool.Name, node.Name)
-	return nil
+// missingSubnetRequests returns the subnet requests of the IP families that are not requested for the node yet
+func (c *Controller) missingSubnetRequests(node *corev1.Node, requested map[string]bool) []ippoolv1alpha1.SubnetRequest {
+	var missing []ippoolv1alpha1.SubnetRequest
+	for _, family := range c.families() {
+		name := helper.SubnetName(node.Name, family.IPFamily)
+		if !requested[name] {
+			prefixLength, err := helper.PrefixLengthForNode(node, family)
+			if err != nil {
+				klog.Warningf("%v, using prefix length %d", err, family.PrefixLength)
+				c.recorder.Eventf(node, corev1.EventTypeWarning, "InvalidPrefixLength", "%v, using prefix length %d", err, family.PrefixLength)
+				prefixLength = family.PrefixLength
+			}
+			missing = append(missing, ippoolv1alpha1.SubnetRequest{
+				Name:         name,
+				IPFamily:     family.IPFamily,
+				PrefixLength: prefixLength,
+			})
+		}
+	}
+	return missing
 }
 
-func (c *Controller) createIPPool() (*ippoolv1alpha1.IPPool, error) {
+func (c *Controller) createIPPool(name string) (*ippoolv1alpha1.IPPool, error) {
 	ippool := &ippoolv1alpha1.IPPool{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      helper.IppoolNameFromClusterName(c.clusterName),
+			Name:      name,
 			Namespace: c.clusterNS,
 			OwnerReferences: []metav1.OwnerReference{
 				*c.ownerRef,
@@ -15,13 +15,18 @@ package node
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
@@ -144,7 +149,7 @@ func TestProcessNodeCreateOrUpdate(t *testing.T) {
 			actions := ippcs.Actions()
 			numPatches := 0
 			for _, a := range actions {
-				if a.Matches("update", "ippools") {
+				if a.Matches("patch", "ippools") {
 					numPatches++
 				}
 			}
@@ -246,7 +251,7 @@ func TestProcessNodeDelete(t *testing.T) {
 			actions := ippcs.Actions()
 			numPatches := 0
 			for _, a := range actions {
-				if a.Matches("update", "ippools") {
+				if a.Matches("patch", "ippools") {
 					numPatches++
 				}
 			}
@@ -317,3 +322,236 @@ func createIPPool(ippcs *fakeippoolclientset.Clientset, nodes []corev1.Node) (*i
 
 	return ippcs.NsxV1alpha1().IPPools(testClusterNS).Create(context.Background(), ippool, metav1.CreateOptions{})
 }
+
+func TestProcessNodeCreateOrUpdateDualStack(t *testing.T) {
+	s := scheme.Scheme
+	if err := ippoolscheme.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add route scheme: (%v)", err)
+	}
+
+	ippc, ippcs := newController()
+	ippc.subnetFamilies = []helper.SubnetFamily{
+		{IPFamily: helper.IPFamilyDefault, PrefixLength: 26},
+		{IPFamily: helper.IPFamilyIPv6, PrefixLength: 80},
+	}
+	if err := ippc.processNodeCreateOrUpdate(&n1); err != nil {
+		t.Fatalf("failed to process node %s: %v", n1.Name, err)
+	}
+
+	ipp, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	expected := []ippoolv1alpha1.SubnetRequest{
+		{Name: n1Name, IPFamily: helper.IPFamilyDefault, PrefixLength: 26},
+		{Name: n1Name + "_ipv6", IPFamily: helper.IPFamilyIPv6, PrefixLength: 80},
+	}
+	if !reflect.DeepEqual(expected, ipp.Spec.Subnets) {
+		t.Errorf("expected subnet requests %v, got %v", expected, ipp.Spec.Subnets)
+	}
+
+	if err := ippc.processNodeDelete(n1Name); err != nil {
+		t.Fatalf("failed to delete node %s: %v", n1Name, err)
+	}
+	ipp, err = ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	if len(ipp.Spec.Subnets) != 0 {
+		t.Errorf("expected subnet requests of both families to be removed, got %v", ipp.Spec.Subnets)
+	}
+}
+
+func TestProcessNodeCreateOrUpdatePrefixLengthOverride(t *testing.T) {
+	ippc, ippcs := newController()
+	node := createNode(n1Name)
+	node.Annotations = map[string]string{helper.PrefixLengthAnnotation: "26"}
+	invalid := createNode(n2Name)
+	invalid.Labels = map[string]string{helper.PrefixLengthAnnotation: "40"}
+	for _, n := range []corev1.Node{node, invalid} {
+		if err := ippc.processNodeCreateOrUpdate(&n); err != nil {
+			t.Fatalf("failed to process node %s: %v", n.Name, err)
+		}
+	}
+
+	ipp, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	expected := []ippoolv1alpha1.SubnetRequest{
+		{Name: n1Name, IPFamily: helper.IPFamilyDefault, PrefixLength: 26},
+		{Name: n2Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+	}
+	if !reflect.DeepEqual(expected, ipp.Spec.Subnets) {
+		t.Errorf("expected subnet requests %v, got %v", expected, ipp.Spec.Subnets)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	c, ippcs := newController()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c.nodesLister = corelisters.NewNodeLister(indexer)
+
+	allocated := createNode(n2Name)
+	allocated.Spec.PodCIDR = "10.0.1.0/24"
+	allocated.Spec.PodCIDRs = []string{"10.0.1.0/24"}
+	// the name looks like an additional subnet of the missing node web
+	lookalike := createNode("web-ipv4-extra-1")
+	lookalike.Spec.PodCIDR = "10.0.2.0/24"
+	lookalike.Spec.PodCIDRs = []string{"10.0.2.0/24"}
+	for _, n := range []corev1.Node{n1, allocated, lookalike} {
+		node := n
+		if err := indexer.Add(&node); err != nil {
+			t.Fatalf("failed to add node %s: %v", n.Name, err)
+		}
+	}
+
+	// no ippool yet
+	if err := c.reconcile(); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	ipp := &ippoolv1alpha1.IPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helper.IppoolNameFromClusterName(testClusterName),
+			Namespace: testClusterNS,
+		},
+		Spec: ippoolv1alpha1.IPPoolSpec{
+			Subnets: []ippoolv1alpha1.SubnetRequest{
+				{Name: n2Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+				{Name: helper.AdditionalSubnetName(n2Name, helper.IPFamilyDefault, 1), IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+				{Name: lookalike.Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+				{Name: "deleted", IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+				{Name: helper.AdditionalSubnetName("deleted", helper.IPFamilyDefault, 1), IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+			},
+		},
+	}
+	if _, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Create(context.Background(), ipp, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create ippool: %v", err)
+	}
+
+	if err := c.reconcile(); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	ipp, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	expected := []ippoolv1alpha1.SubnetRequest{
+		{Name: n2Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+		{Name: helper.AdditionalSubnetName(n2Name, helper.IPFamilyDefault, 1), IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+		{Name: lookalike.Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+		{Name: n1Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+	}
+	if !reflect.DeepEqual(expected, ipp.Spec.Subnets) {
+		t.Errorf("expected subnet requests %v, got %v", expected, ipp.Spec.Subnets)
+	}
+
+	// nothing to update once in sync
+	ippcs.ClearActions()
+	if err := c.reconcile(); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	for _, action := range ippcs.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("unexpected ippool patch after reconcile")
+		}
+	}
+}
+
+func TestReconcileKeepsNodeAddedConcurrently(t *testing.T) {
+	c, ippcs := newController()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	c.nodesLister = corelisters.NewNodeLister(indexer)
+
+	ipp := &ippoolv1alpha1.IPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      helper.IppoolNameFromClusterName(testClusterName),
+			Namespace: testClusterNS,
+		},
+		Spec: ippoolv1alpha1.IPPoolSpec{
+			Subnets: []ippoolv1alpha1.SubnetRequest{
+				{Name: n1Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+				{Name: "deleted", IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+			},
+		},
+	}
+	if _, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Create(context.Background(), ipp, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create ippool: %v", err)
+	}
+	// the node appears after the nodes have been listed, while its request is already in the ippool
+	ippcs.PrependReactor("get", "ippools", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		node := createNode(n1Name)
+		node.Spec.PodCIDR = "10.0.1.0/24"
+		node.Spec.PodCIDRs = []string{"10.0.1.0/24"}
+		return false, nil, indexer.Add(&node)
+	})
+
+	if err := c.reconcile(); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	ipp, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolNameFromClusterName(testClusterName), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	expected := []ippoolv1alpha1.SubnetRequest{
+		{Name: n1Name, IPFamily: helper.IPFamilyDefault, PrefixLength: helper.PrefixLengthDefault},
+	}
+	if !reflect.DeepEqual(expected, ipp.Spec.Subnets) {
+		t.Errorf("expected subnet requests %v, got %v", expected, ipp.Spec.Subnets)
+	}
+}
+
+func TestProcessNodeCreateOrUpdateSharded(t *testing.T) {
+	ippc, ippcs := newController()
+	ippc.nodesPerIPPool = 2
+	nodes := []corev1.Node{createNode("a"), createNode("b"), createNode("c"), createNode("d"), createNode("e")}
+	for _, n := range nodes {
+		node := n
+		if err := ippc.processNodeCreateOrUpdate(&node); err != nil {
+			t.Fatalf("failed to process node %s: %v", n.Name, err)
+		}
+	}
+	// processing a node again keeps it in its shard
+	if err := ippc.processNodeCreateOrUpdate(&nodes[2]); err != nil {
+		t.Fatalf("failed to process node %s: %v", nodes[2].Name, err)
+	}
+
+	expected := map[string][]string{
+		helper.IppoolShardName(testClusterName, 0): {"a", "b"},
+		helper.IppoolShardName(testClusterName, 1): {"c", "d"},
+		helper.IppoolShardName(testClusterName, 2): {"e"},
+	}
+	shards, err := ippc.listIPPoolShards(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list ippool shards: %v", err)
+	}
+	if len(shards) != len(expected) {
+		t.Fatalf("expected %d shards, got %d", len(expected), len(shards))
+	}
+	for _, shard := range shards {
+		var names []string
+		for _, sub := range shard.Spec.Subnets {
+			names = append(names, sub.Name)
+		}
+		if !reflect.DeepEqual(expected[shard.Name], names) {
+			t.Errorf("expected requests %v in %s, got %v", expected[shard.Name], shard.Name, names)
+		}
+	}
+
+	// deleting a node frees a slot in its shard
+	if err := ippc.processNodeDelete("c"); err != nil {
+		t.Fatalf("failed to delete node: %v", err)
+	}
+	f := createNode("f")
+	if err := ippc.processNodeCreateOrUpdate(&f); err != nil {
+		t.Fatalf("failed to process node %s: %v", f.Name, err)
+	}
+	shard, err := ippcs.NsxV1alpha1().IPPools(testClusterNS).Get(context.Background(), helper.IppoolShardName(testClusterName, 1), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ippool: %v", err)
+	}
+	if len(shard.Spec.Subnets) != 2 || shard.Spec.Subnets[0].Name != "d" || shard.Spec.Subnets[1].Name != "f" {
+		t.Errorf("unexpected requests %v", shard.Spec.Subnets)
+	}
+}
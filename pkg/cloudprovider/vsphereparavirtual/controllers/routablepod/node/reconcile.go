@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	ippoolv1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// reconcilePeriod is the interval of the full reconcile of the ippool subnet requests
+	reconcilePeriod = 5 * time.Minute
+)
+
+// reconcile compares the subnet requests in the ippool shards with the nodes in the lister.
+// Node delete events are missed while the controller is down, so the requests of nodes
+// that no longer exist are removed, which releases their subnets. Nodes without pod CIDRs
+// whose requests are missing get them added again.
+func (c *Controller) reconcile() error {
+	ctx := context.Background()
+	shards, err := c.listIPPoolShards(ctx)
+	if err != nil {
+		return err
+	}
+	if len(shards) == 0 {
+		// the ippool is created when the first node is processed
+		return nil
+	}
+	nodes, err := c.nodesLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for _, node := range nodes {
+		existing[node.Name] = true
+	}
+
+	inSync := true
+	for i, shard := range shards {
+		var leaked []string
+		err := helper.PatchIPPool(ctx, c.ippoolclientset, c.clusterNS, shard.Name, func(ippool *ippoolv1alpha1.IPPool) bool {
+			leaked = nil
+			newSubnets := []ippoolv1alpha1.SubnetRequest{}
+			for _, sub := range ippool.Spec.Subnets {
+				if nodeName := helper.NodeNameOfSubnetRequest(sub); !existing[nodeName] {
+					// the worker may have added the request of a node created after the
+					// nodes were listed, so the node is looked up again before removal
+					if _, err := c.nodesLister.Get(nodeName); !apierrors.IsNotFound(err) {
+						existing[nodeName] = true
+					} else {
+						leaked = append(leaked, sub.Name)
+						continue
+					}
+				}
+				newSubnets = append(newSubnets, sub)
+			}
+			ippool.Spec.Subnets = newSubnets
+			shards[i] = ippool
+			return len(leaked) != 0
+		})
+		if err != nil {
+			return err
+		}
+		if len(leaked) != 0 {
+			inSync = false
+			klog.Infof("removed %d subnet requests of deleted nodes from IPPool %s/%s: %v", len(leaked), shard.Namespace, shard.Name, leaked)
+			c.recorder.Eventf(shard, corev1.EventTypeWarning, "LeakedSubnetRequestsRemoved", "Removed subnet requests of deleted nodes: %v", leaked)
+			leakedSubnetRequests.Add(float64(len(leaked)))
+		}
+	}
+
+	requested := requestedSubnets(shards)
+	for _, node := range nodes {
+		// the requests of nodes with pod CIDRs are only kept, never added
+		if node.Spec.PodCIDR != "" && len(node.Spec.PodCIDRs) != 0 {
+			continue
+		}
+		missing := c.missingSubnetRequests(node, requested)
+		if len(missing) == 0 {
+			continue
+		}
+		inSync = false
+		shardName, err := c.ippoolShardForNode(ctx, node.Name, shards)
+		if err != nil {
+			return err
+		}
+		if err := c.addSubnetRequests(ctx, shardName, missing); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(missing))
+		for _, sub := range missing {
+			names = append(names, sub.Name)
+			requested[sub.Name] = true
+		}
+		klog.Infof("added missing subnet requests %v of node %s to IPPool %s/%s", names, node.Name, c.clusterNS, shardName)
+		c.recorder.Eventf(node, corev1.EventTypeNormal, "SubnetRequestsRepaired", "Added missing subnet requests %v to IPPool %s/%s", names, c.clusterNS, shardName)
+		repairedSubnetRequests.Add(float64(len(missing)))
+		// refresh the shards to assign the following nodes with the latest node counts
+		if shards, err = c.listIPPoolShards(ctx); err != nil {
+			return err
+		}
+	}
+
+	if inSync {
+		klog.V(4).Infof("subnet requests in the IPPools of cluster %s are in sync with the nodes", c.clusterName)
+	}
+	return nil
+}
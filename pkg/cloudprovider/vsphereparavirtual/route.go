@@ -32,12 +32,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 
 	rest "k8s.io/client-go/rest"
 	cloudprovider "k8s.io/cloud-provider"
 	v1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
 	client "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 	"k8s.io/cloud-provider-vsphere/pkg/util"
 	klog "k8s.io/klog/v2"
 )
@@ -55,6 +59,10 @@ type routesProvider struct {
 	nodeMap     map[string]*v1.Node
 	nodeMapLock sync.RWMutex
 	ownerRefs   []metav1.OwnerReference
+
+	// nodeClient and recorder reflect the RouteSet status on the nodes of the guest cluster
+	nodeClient kubernetes.Interface
+	recorder   record.EventRecorder
 }
 
 var _ RoutesProvider = &routesProvider{}
@@ -66,6 +74,13 @@ const (
 	RealizedStateTimeout = 10 * time.Second
 	// RealizedStateSleepTime is the interval between realized state check
 	RealizedStateSleepTime = 1 * time.Second
+
+	routesProviderName = "vsphere-paravirtual-routes"
+
+	// reasons used for the RouteSetReady node condition and node events
+	reasonRouteSetReady    = "RouteSetReady"
+	reasonRouteSetNotReady = "RouteSetNotReady"
+	reasonRouteSetPending  = "RouteSetPending"
 )
 
 // A list of possible RouteSet operation error messages
@@ -74,6 +89,7 @@ var (
 	ErrCreateRouteSet = errors.New("failed to create RouteSet")
 	ErrListRouteSet   = errors.New("failed to list RouteSet")
 	ErrDeleteRouteSet = errors.New("failed to delete RouteSet")
+	ErrUpdateRouteSet = errors.New("failed to update RouteSet")
 )
 
 // GetRouteSetClient returns a new RouteSet client that can be used to access SC
@@ -88,7 +104,8 @@ func GetRouteSetClient(config *rest.Config) (client.Interface, error) {
 }
 
 // NewRoutes returns an implementation of RoutesProvider
-func NewRoutes(clusterNS string, kcfg *rest.Config, ownerRef metav1.OwnerReference) (RoutesProvider, error) {
+// The RouteSet status is reflected on the nodes using the kubeClient of the guest cluster.
+func NewRoutes(clusterNS string, kcfg *rest.Config, ownerRef metav1.OwnerReference, kubeClient kubernetes.Interface) (RoutesProvider, error) {
 	routeClient, err := GetRouteSetClient(kcfg)
 	if err != nil {
 		return nil, err
@@ -97,17 +114,25 @@ func NewRoutes(clusterNS string, kcfg *rest.Config, ownerRef metav1.OwnerReferen
 	ownerRefs := []metav1.OwnerReference{
 		ownerRef,
 	}
-	return &routesProvider{
+	r := &routesProvider{
 		routeClient: routeClient,
 		namespace:   clusterNS,
 		nodeMap:     make(map[string]*v1.Node),
 		ownerRefs:   ownerRefs,
-	}, nil
+	}
+	if kubeClient != nil {
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+		r.nodeClient = kubeClient
+		r.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: routesProviderName})
+	}
+	return r, nil
 }
 
 // ListRoutes implements Routes.ListRoutes
 // Get RouteSet CR from SC namespace and then filters routes that belong to the specified clusterName
 // Only return cloudprovider.Route if RouteSet CR status 'Ready' is true
+// The 'Ready' condition of each RouteSet CR is reflected on its node
 func (r *routesProvider) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
 	klog.V(6).Infof("Attempting to list Routes for cluster %s", clusterName)
 
@@ -128,6 +153,9 @@ func (r *routesProvider) ListRoutes(ctx context.Context, clusterName string) ([]
 	if len(routeSets.Items) == 0 {
 		return []*cloudprovider.Route{}, nil
 	}
+	for i := range routeSets.Items {
+		r.updateNodeRouteSetCondition(ctx, &routeSets.Items[i])
+	}
 	return r.createCPRoutes(routeSets), nil
 }
 
@@ -140,7 +168,12 @@ func (r *routesProvider) createCPRoutes(routeSets *v1alpha1.RouteSetList) []*clo
 		if condition != nil && condition.Status == v1.ConditionTrue {
 			// one RouteSet per node, so we can use nodeName as the name of RouteSet CR
 			nodeName := routeSet.Name
+			additional := r.additionalPodCIDRs(nodeName)
 			for _, route := range routeSet.Spec.Routes {
+				// routes of additional pod CIDRs are not managed by the route controller
+				if additional[route.Destination] {
+					continue
+				}
 				cpRoute := &cloudprovider.Route{
 					Name:            route.Name,
 					TargetNode:      types.NodeName(nodeName),
@@ -175,6 +208,8 @@ func (r *routesProvider) CreateRoute(ctx context.Context, clusterName string, na
 }
 
 // createRouteSetCR creates RouteSet CR through RouteSet client
+// The RouteSet of a node holds a route per IP family, if the RouteSet already
+// exists the route is added to it or its next hop is updated.
 func (r *routesProvider) createRouteSetCR(ctx context.Context, clusterName string, nameHint string, nodeName string, cidr string, nodeIP string) (*v1alpha1.RouteSet, error) {
 	labels := map[string]string{
 		LabelKeyClusterName: clusterName,
@@ -211,7 +246,7 @@ func (r *routesProvider) createRouteSetCR(ctx context.Context, clusterName strin
 	_, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Create(ctx, routeSet, metav1.CreateOptions{})
 	if err != nil {
 		if apierrors.IsAlreadyExists(err) {
-			return routeSet, nil
+			return r.addRouteToRouteSetCR(ctx, nodeName, route)
 		}
 		klog.ErrorS(ErrCreateRouteSet, fmt.Sprintf("%v", err))
 		return nil, err
@@ -221,15 +256,49 @@ func (r *routesProvider) createRouteSetCR(ctx context.Context, clusterName strin
 	return routeSet, nil
 }
 
+// addRouteToRouteSetCR adds a route to the existing RouteSet CR of a node
+// A route with the same destination is replaced.
+func (r *routesProvider) addRouteToRouteSetCR(ctx context.Context, nodeName string, route v1alpha1.Route) (*v1alpha1.RouteSet, error) {
+	routeSet, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		klog.ErrorS(ErrGetRouteSet, fmt.Sprintf("%v", err))
+		return nil, err
+	}
+	routes := []v1alpha1.Route{}
+	for _, existing := range routeSet.Spec.Routes {
+		if existing.Destination == route.Destination {
+			if existing == route {
+				return routeSet, nil
+			}
+			continue
+		}
+		routes = append(routes, existing)
+	}
+	routeSet.Spec.Routes = append(routes, route)
+
+	updated, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Update(ctx, routeSet, metav1.UpdateOptions{})
+	if err != nil {
+		klog.ErrorS(ErrUpdateRouteSet, fmt.Sprintf("%v", err))
+		return nil, err
+	}
+	klog.V(6).Infof("Successfully added route %s to RouteSet CR for node %s", route.Name, nodeName)
+	return updated, nil
+}
+
 // checkStaticRouteRealizedState checks static route realized state
 // The check happens every 1 second and the default timeout is 10 seconds
 func (r *routesProvider) checkStaticRouteRealizedState(routeSetName string) error {
 	timeout := time.After(RealizedStateTimeout)
 	ticker := time.NewTicker(RealizedStateSleepTime)
 	defer ticker.Stop()
+	// message is the latest failure message of the RouteSet
+	message := ""
 	for {
 		select {
 		case <-timeout:
+			if message != "" {
+				return fmt.Errorf("timed out waiting for static route %s: %s", routeSetName, message)
+			}
 			return fmt.Errorf("timed out waiting for static route %s", routeSetName)
 		case <-ticker.C:
 			routeSet, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(context.Background(), routeSetName, metav1.GetOptions{})
@@ -241,14 +310,82 @@ func (r *routesProvider) checkStaticRouteRealizedState(routeSetName string) erro
 			if condition != nil && condition.Status == v1.ConditionTrue {
 				return nil
 			}
+			if condition != nil {
+				message = condition.Message
+			}
 		}
 	}
 }
 
+// updateNodeRouteSetCondition reflects the 'Ready' condition of a RouteSet CR on its node,
+// so that failures of the static routes can be debugged without supervisor access
+func (r *routesProvider) updateNodeRouteSetCondition(ctx context.Context, routeSet *v1alpha1.RouteSet) {
+	if r.nodeClient == nil {
+		return
+	}
+	// one RouteSet per node, so we can use nodeName as the name of RouteSet CR
+	node, err := r.getNode(routeSet.Name)
+	if err != nil {
+		return
+	}
+	condition := v1.NodeCondition{
+		Type:    helper.NodeConditionRouteSetReady,
+		Status:  v1.ConditionUnknown,
+		Reason:  reasonRouteSetPending,
+		Message: fmt.Sprintf("RouteSet %s/%s is not realized yet", routeSet.Namespace, routeSet.Name),
+	}
+	if ready := GetRouteSetCondition(&(routeSet.Status), v1alpha1.RouteSetConditionTypeReady); ready != nil {
+		condition.Status = ready.Status
+		condition.Reason = ready.Reason
+		condition.Message = ready.Message
+		if condition.Reason == "" {
+			condition.Reason = reasonRouteSetNotReady
+			if ready.Status == v1.ConditionTrue {
+				condition.Reason = reasonRouteSetReady
+			}
+		}
+	}
+
+	changed, err := helper.SetNodeCondition(ctx, r.nodeClient, node, condition)
+	if err != nil {
+		klog.Errorf("failed to update RouteSet condition of node %s: %v", node.Name, err)
+		return
+	}
+	if !changed {
+		return
+	}
+	switch condition.Status {
+	case v1.ConditionTrue:
+		r.recorder.Eventf(node, v1.EventTypeNormal, reasonRouteSetReady, "RouteSet %s/%s is ready", routeSet.Namespace, routeSet.Name)
+	case v1.ConditionFalse:
+		r.recorder.Eventf(node, v1.EventTypeWarning, reasonRouteSetNotReady, "RouteSet %s/%s is not ready: %s: %s",
+			routeSet.Namespace, routeSet.Name, condition.Reason, condition.Message)
+	}
+}
+
 // DeleteRoute implements Routes.DeleteRoute
-// Delete node's corresponding RouteSet CR
+// Remove the route from node's corresponding RouteSet CR, the RouteSet CR
+// is deleted with its last route
 func (r *routesProvider) DeleteRoute(ctx context.Context, clusterName string, route *cloudprovider.Route) error {
 	routeSetName := string(route.TargetNode)
+	routeSet, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(ctx, routeSetName, metav1.GetOptions{})
+	if err == nil {
+		routes := []v1alpha1.Route{}
+		for _, existing := range routeSet.Spec.Routes {
+			if existing.Destination != route.DestinationCIDR {
+				routes = append(routes, existing)
+			}
+		}
+		if len(routes) > 0 {
+			klog.V(6).Infof("Removing route %s from RouteSet CR %s in cluster %s", route.DestinationCIDR, routeSetName, clusterName)
+			routeSet.Spec.Routes = routes
+			if _, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Update(ctx, routeSet, metav1.UpdateOptions{}); err != nil {
+				klog.ErrorS(ErrUpdateRouteSet, fmt.Sprintf("%v", err))
+				return err
+			}
+			return nil
+		}
+	}
 	klog.V(6).Infof("Deleting RouteSet CR %s in cluster %s", routeSetName, clusterName)
 	return r.DeleteRouteSetCR(routeSetName)
 }
@@ -300,12 +437,68 @@ func (r *routesProvider) getNodeIPAddress(nodeName string, isIPv4 bool) (string,
 	return "", fmt.Errorf("node %s does not have the same IP family with podCIDR", nodeName)
 }
 
-// AddNode adds v1.Node in nodeMap
+// AddNode adds v1.Node in nodeMap and adds the routes of its additional pod CIDRs
 func (r *routesProvider) AddNode(node *v1.Node) {
 	r.nodeMapLock.Lock()
 	r.nodeMap[node.Name] = node
 	klog.V(6).Infof("Added node %s into nodeMap", node.Name)
 	r.nodeMapLock.Unlock()
+
+	if err := r.addAdditionalRoutes(context.Background(), node); err != nil {
+		klog.Errorf("failed to add routes of additional pod CIDRs for node %s: %v", node.Name, err)
+	}
+}
+
+// additionalPodCIDRs returns the additional pod CIDRs of a node
+func (r *routesProvider) additionalPodCIDRs(nodeName string) map[string]bool {
+	cidrs := make(map[string]bool)
+	node, err := r.getNode(nodeName)
+	if err != nil {
+		return cidrs
+	}
+	for _, cidr := range helper.AdditionalPodCIDRs(node) {
+		cidrs[cidr] = true
+	}
+	return cidrs
+}
+
+// addAdditionalRoutes adds the routes of the additional pod CIDRs of a node to its
+// RouteSet CR. The RouteSet CR is created by the route controller for the podCIDRs.
+func (r *routesProvider) addAdditionalRoutes(ctx context.Context, node *v1.Node) error {
+	cidrs := helper.AdditionalPodCIDRs(node)
+	if len(cidrs) == 0 {
+		return nil
+	}
+	routeSet, err := r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(ctx, node.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	existing := make(map[string]bool)
+	clusterName := routeSet.Labels[LabelKeyClusterName]
+	for _, route := range routeSet.Spec.Routes {
+		existing[route.Destination] = true
+	}
+	for _, cidr := range cidrs {
+		if existing[cidr] {
+			continue
+		}
+		nodeIP, err := r.getNodeIPAddress(node.Name, util.IsIPv4(cidr))
+		if err != nil {
+			return err
+		}
+		route := v1alpha1.Route{
+			Name:        r.GetRouteName(node.Name, cidr, clusterName),
+			Destination: cidr,
+			Target:      nodeIP,
+		}
+		if _, err := r.addRouteToRouteSetCR(ctx, node.Name, route); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DeleteNode deletes v1.Node from nodeMap and removes corresponding RouteSet CR
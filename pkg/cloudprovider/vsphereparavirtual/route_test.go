@@ -19,6 +19,7 @@ package vsphereparavirtual
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,10 +27,13 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
 	v1alpha1 "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/apis/nsxnetworking/v1alpha1"
 	fakeClient "k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/client/clientset/versioned/fake"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 	"k8s.io/cloud-provider-vsphere/pkg/util"
 )
 
@@ -173,6 +177,51 @@ func TestListRoutes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestListRoutesNodeCondition(t *testing.T) {
+	r, _ := initRouteTest()
+	fakeNode1 := buildFakeNode("fakeNode1")
+	fakeNode2 := buildFakeNode("fakeNode2")
+	nodeClient := k8sfake.NewSimpleClientset(fakeNode1, fakeNode2)
+	recorder := record.NewFakeRecorder(10)
+	r.nodeClient = nodeClient
+	r.recorder = recorder
+	r.AddNode(fakeNode1)
+	r.AddNode(fakeNode2)
+
+	routeSet1, err := r.createRouteSetCR(context.TODO(), testClustername, testNameHint, "fakeNode1", "100.96.0.0/24", testNodeIP)
+	assert.NoError(t, err)
+	routeSet1.Status.Conditions = []v1alpha1.RouteSetCondition{{
+		Type:    v1alpha1.RouteSetConditionTypeReady,
+		Status:  v1.ConditionFalse,
+		Reason:  "RealizationFailed",
+		Message: "static route 100.96.0.0/24 failed",
+	}}
+	_, err = r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Update(context.TODO(), routeSet1, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	_, err = createFakeRouteSetCR(r, testClustername, testNameHint, "fakeNode2", "100.96.1.0/24", testNodeIP)
+	assert.NoError(t, err)
+
+	routes, err := r.ListRoutes(context.TODO(), testClustername)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(routes))
+
+	node1, err := nodeClient.CoreV1().Nodes().Get(context.TODO(), "fakeNode1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	condition := helper.GetNodeCondition(node1, helper.NodeConditionRouteSetReady)
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, "RealizationFailed", condition.Reason)
+	assert.Equal(t, "static route 100.96.0.0/24 failed", condition.Message)
+	node2, err := nodeClient.CoreV1().Nodes().Get(context.TODO(), "fakeNode2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	condition = helper.GetNodeCondition(node2, helper.NodeConditionRouteSetReady)
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+
+	events := []string{<-recorder.Events, <-recorder.Events}
+	assert.Contains(t, strings.Join(events, "\n"), "Warning RouteSetNotReady RouteSet "+testClusterNameSpace+"/fakeNode1 is not ready: RealizationFailed: static route 100.96.0.0/24 failed")
+}
+
 func TestListRoutesFailed(t *testing.T) {
 	r, fcw := initRouteTest()
 	fcw.ListFunc = func(ctx context.Context, opts metav1.ListOptions) (result *v1alpha1.RouteSetList, err error) {
@@ -432,3 +481,76 @@ func TestCheckStaticRouteRealizedState(t *testing.T) {
 	err = r.DeleteRouteSetCR(fakeNode2.Name)
 	assert.NoError(t, err)
 }
+
+func TestCreateDualStackRouteSetCR(t *testing.T) {
+	r, _ := initRouteTest()
+	node := buildFakeNode(testNodeName)
+	r.nodeMap[testNodeName] = node
+	ipv6CIDR := "fd00:100:96::/64"
+	ipv6NodeIP := "fe80::20c:29ff:fe0b:b407"
+
+	_, err := r.createRouteSetCR(context.TODO(), testClustername, testNameHint, testNodeName, testCIDR, testNodeIP)
+	assert.NoError(t, err)
+	routeSetCR, err := r.createRouteSetCR(context.TODO(), testClustername, testNameHint, testNodeName, ipv6CIDR, ipv6NodeIP)
+	assert.NoError(t, err)
+
+	expectedRoutes := []v1alpha1.Route{
+		{
+			Name:        r.GetRouteName(testNodeName, testCIDR, testClustername),
+			Destination: testCIDR,
+			Target:      testNodeIP,
+		},
+		{
+			Name:        r.GetRouteName(testNodeName, ipv6CIDR, testClustername),
+			Destination: ipv6CIDR,
+			Target:      ipv6NodeIP,
+		},
+	}
+	assert.Equal(t, expectedRoutes, routeSetCR.Spec.Routes)
+
+	// next hop of an existing route is replaced
+	routeSetCR, err = r.createRouteSetCR(context.TODO(), testClustername, testNameHint, testNodeName, testCIDR, "172.50.0.14")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(routeSetCR.Spec.Routes))
+	assert.Equal(t, "172.50.0.14", routeSetCR.Spec.Routes[1].Target)
+
+	// deleting one route keeps the RouteSet with the other route
+	err = r.DeleteRoute(context.TODO(), testClustername, &cloudprovider.Route{TargetNode: testNodeName, DestinationCIDR: testCIDR})
+	assert.NoError(t, err)
+	routeSetCR, err = r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(context.TODO(), testNodeName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []v1alpha1.Route{expectedRoutes[1]}, routeSetCR.Spec.Routes)
+
+	err = r.DeleteRoute(context.TODO(), testClustername, &cloudprovider.Route{TargetNode: testNodeName, DestinationCIDR: ipv6CIDR})
+	assert.NoError(t, err)
+	_, err = r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(context.TODO(), testNodeName, metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestAddNodeAdditionalPodCIDRs(t *testing.T) {
+	r, _ := initRouteTest()
+	node := buildFakeNode(testNodeName)
+	r.AddNode(node)
+	routeSetCR, err := r.createRouteSetCR(context.TODO(), testClustername, testNameHint, testNodeName, testCIDR, testNodeIP)
+	assert.NoError(t, err)
+	routeSetCR.Status = createTestRouteSet().Status
+	_, err = r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Update(context.TODO(), routeSetCR, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	additionalCIDR := "100.96.5.0/24"
+	node = node.DeepCopy()
+	node.Annotations = map[string]string{helper.AdditionalPodCIDRsAnnotation: additionalCIDR}
+	r.AddNode(node)
+
+	routeSetCR, err = r.routeClient.NsxV1alpha1().RouteSets(r.namespace).Get(context.TODO(), testNodeName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(routeSetCR.Spec.Routes))
+	assert.Equal(t, additionalCIDR, routeSetCR.Spec.Routes[1].Destination)
+	assert.Equal(t, testNodeIP, routeSetCR.Spec.Routes[1].Target)
+
+	// the route controller only sees the routes of the pod CIDRs
+	routes, err := r.ListRoutes(context.TODO(), testClustername)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(routes))
+	assert.Equal(t, testCIDR, routes[0].DestinationCIDR)
+}
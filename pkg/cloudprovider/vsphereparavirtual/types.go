@@ -20,13 +20,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	cloudprovider "k8s.io/cloud-provider"
-	cpcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	"k8s.io/cloud-provider-vsphere/pkg/cloudprovider/vsphereparavirtual/controllers/routablepod/helper"
 	k8s "k8s.io/cloud-provider-vsphere/pkg/common/kubernetes"
 )
 
 // VSphereParavirtual is an implementation of cloud provider Interface for vsphere paravirtual.
 type VSphereParavirtual struct {
-	cfg            *cpcfg.Config
+	cfg            *ParavirtualConfig
+	subnetFamilies []helper.SubnetFamily
 	ownerReference *metav1.OwnerReference
 	client         clientset.Interface
 	informMgr      *k8s.InformerManager
@@ -22,6 +22,8 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 	klog "k8s.io/klog/v2"
+
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
 )
 
 /*
@@ -43,29 +45,48 @@ func (ccy *CommonConfigYAML) CreateConfig() *Config {
 	cfg.Global.VCenterPort = fmt.Sprint(ccy.Global.VCenterPort)
 	cfg.Global.InsecureFlag = ccy.Global.InsecureFlag
 	cfg.Global.Datacenters = strings.Join(ccy.Global.Datacenters, ",")
+	cfg.Global.IncludeDatacenters = ccy.Global.IncludeDatacenters
+	cfg.Global.ExcludeDatacenters = ccy.Global.ExcludeDatacenters
+	cfg.Global.IncludeFolders = ccy.Global.IncludeFolders
+	cfg.Global.ExcludeFolders = ccy.Global.ExcludeFolders
 	cfg.Global.RoundTripperCount = ccy.Global.RoundTripperCount
 	cfg.Global.CAFile = ccy.Global.CAFile
 	cfg.Global.Thumbprint = ccy.Global.Thumbprint
 	cfg.Global.SecretName = ccy.Global.SecretName
 	cfg.Global.SecretNamespace = ccy.Global.SecretNamespace
 	cfg.Global.SecretsDirectory = ccy.Global.SecretsDirectory
+	if ccy.Global.CredentialSource != nil {
+		cfg.Global.CredentialSource = ccy.Global.CredentialSource.CreateConfig()
+	}
+	cfg.Global.AuthMode = ccy.Global.AuthMode
+	cfg.Global.CertFile = ccy.Global.CertFile
+	cfg.Global.KeyFile = ccy.Global.KeyFile
+	cfg.Global.TokenFile = ccy.Global.TokenFile
 
 	for keyVcConfig, valVcConfig := range ccy.Vcenter {
 		cfg.VirtualCenter[keyVcConfig] = &VirtualCenterConfig{
-			User:              valVcConfig.User,
-			Password:          valVcConfig.Password,
-			TenantRef:         valVcConfig.TenantRef,
-			VCenterIP:         valVcConfig.VCenterIP,
-			VCenterPort:       fmt.Sprint(valVcConfig.VCenterPort),
-			InsecureFlag:      valVcConfig.InsecureFlag,
-			Datacenters:       strings.Join(valVcConfig.Datacenters, ","),
-			RoundTripperCount: valVcConfig.RoundTripperCount,
-			CAFile:            valVcConfig.CAFile,
-			Thumbprint:        valVcConfig.Thumbprint,
-			SecretRef:         valVcConfig.SecretRef,
-			SecretName:        valVcConfig.SecretName,
-			SecretNamespace:   valVcConfig.SecretNamespace,
-			IPFamilyPriority:  valVcConfig.IPFamilyPriority,
+			User:               valVcConfig.User,
+			Password:           valVcConfig.Password,
+			TenantRef:          valVcConfig.TenantRef,
+			VCenterIP:          valVcConfig.VCenterIP,
+			VCenterPort:        fmt.Sprint(valVcConfig.VCenterPort),
+			InsecureFlag:       valVcConfig.InsecureFlag,
+			Datacenters:        strings.Join(valVcConfig.Datacenters, ","),
+			IncludeDatacenters: valVcConfig.IncludeDatacenters,
+			ExcludeDatacenters: valVcConfig.ExcludeDatacenters,
+			IncludeFolders:     valVcConfig.IncludeFolders,
+			ExcludeFolders:     valVcConfig.ExcludeFolders,
+			RoundTripperCount:  valVcConfig.RoundTripperCount,
+			CAFile:             valVcConfig.CAFile,
+			Thumbprint:         valVcConfig.Thumbprint,
+			SecretRef:          valVcConfig.SecretRef,
+			SecretName:         valVcConfig.SecretName,
+			SecretNamespace:    valVcConfig.SecretNamespace,
+			IPFamilyPriority:   valVcConfig.IPFamilyPriority,
+			AuthMode:           valVcConfig.AuthMode,
+			CertFile:           valVcConfig.CertFile,
+			KeyFile:            valVcConfig.KeyFile,
+			TokenFile:          valVcConfig.TokenFile,
 		}
 	}
 
@@ -78,7 +99,8 @@ func (ccy *CommonConfigYAML) CreateConfig() *Config {
 // isSecretInfoProvided returns true if k8s secret is set or using generic CO secret method.
 // If both k8s secret and generic CO both are true, we don't know which to use, so return false.
 func (ccy *CommonConfigYAML) isSecretInfoProvided() bool {
-	return (ccy.Global.SecretName != "" && ccy.Global.SecretNamespace != "" && ccy.Global.SecretsDirectory == "") ||
+	return ccy.Global.CredentialSource != nil ||
+		(ccy.Global.SecretName != "" && ccy.Global.SecretNamespace != "" && ccy.Global.SecretsDirectory == "") ||
 		(ccy.Global.SecretName == "" && ccy.Global.SecretNamespace == "" && ccy.Global.SecretsDirectory != "")
 }
 
@@ -87,6 +109,31 @@ func (vccy *VirtualCenterConfigYAML) isSecretInfoProvided() bool {
 	return vccy.SecretName != "" && vccy.SecretNamespace != ""
 }
 
+// usesTokenAuth returns true if the VC is logged in to with a SAML token
+// instead of a username and password.
+func (vccy *VirtualCenterConfigYAML) usesTokenAuth() bool {
+	return vccy.AuthMode == vclib.AuthModeCertificate || vccy.AuthMode == vclib.AuthModeToken
+}
+
+// validateAuthMode checks that the files required by the auth mode of the VC
+// are set, unless its credentials come from a secret.
+func (vccy *VirtualCenterConfigYAML) validateAuthMode(secretProvided bool) error {
+	switch vccy.AuthMode {
+	case "", vclib.AuthModePassword:
+	case vclib.AuthModeCertificate:
+		if (vccy.CertFile == "") != (vccy.KeyFile == "") || (vccy.CertFile == "" && !secretProvided) {
+			return ErrCertificateMissing
+		}
+	case vclib.AuthModeToken:
+		if vccy.TokenFile == "" && !secretProvided {
+			return ErrTokenMissing
+		}
+	default:
+		return ErrInvalidAuthMode
+	}
+	return nil
+}
+
 func (ccy *CommonConfigYAML) validateConfig() error {
 	//Fix default global values
 	if ccy.Global.RoundTripperCount == 0 {
@@ -101,6 +148,12 @@ func (ccy *CommonConfigYAML) validateConfig() error {
 	if len(ccy.Global.IPFamilyPriority) == 0 {
 		ccy.Global.IPFamilyPriority = []string{DefaultIPFamily}
 	}
+	if ccy.Global.CredentialSource != nil {
+		if err := ccy.Global.CredentialSource.Validate(); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
 
 	// Create a single instance of VSphereInstance for the Global VCenterIP if the
 	// VirtualCenter does not already exist in the map
@@ -140,19 +193,37 @@ func (ccy *CommonConfigYAML) validateConfig() error {
 		// in the YAML-based config, the tenant ref is required in the config
 		vcConfig.TenantRef = tenantRef
 
+		if vcConfig.AuthMode == "" {
+			vcConfig.AuthMode = ccy.Global.AuthMode
+		}
+		if vcConfig.CertFile == "" && vcConfig.KeyFile == "" {
+			vcConfig.CertFile = ccy.Global.CertFile
+			vcConfig.KeyFile = ccy.Global.KeyFile
+		}
+		if vcConfig.TokenFile == "" {
+			vcConfig.TokenFile = ccy.Global.TokenFile
+		}
+		if err := vcConfig.validateAuthMode(ccy.isSecretInfoProvided() || vcConfig.isSecretInfoProvided()); err != nil {
+			klog.Errorf("Invalid auth mode %q for vc %s: %s", vcConfig.AuthMode, tenantRef, err)
+			return err
+		}
+
 		if !ccy.isSecretInfoProvided() && !vcConfig.isSecretInfoProvided() {
-			if vcConfig.User == "" {
-				vcConfig.User = ccy.Global.User
+			// certificate and token auth modes log in with the files validated above
+			if !vcConfig.usesTokenAuth() {
 				if vcConfig.User == "" {
-					klog.Errorf("vcConfig.User is empty for vc %s!", tenantRef)
-					return ErrUsernameMissing
+					vcConfig.User = ccy.Global.User
+					if vcConfig.User == "" {
+						klog.Errorf("vcConfig.User is empty for vc %s!", tenantRef)
+						return ErrUsernameMissing
+					}
 				}
-			}
-			if vcConfig.Password == "" {
-				vcConfig.Password = ccy.Global.Password
 				if vcConfig.Password == "" {
-					klog.Errorf("vcConfig.Password is empty for vc %s!", tenantRef)
-					return ErrPasswordMissing
+					vcConfig.Password = ccy.Global.Password
+					if vcConfig.Password == "" {
+						klog.Errorf("vcConfig.Password is empty for vc %s!", tenantRef)
+						return ErrPasswordMissing
+					}
 				}
 			}
 		} else if ccy.isSecretInfoProvided() && !vcConfig.isSecretInfoProvided() {
@@ -165,11 +236,30 @@ func (ccy *CommonConfigYAML) validateConfig() error {
 			vcConfig.VCenterPort = ccy.Global.VCenterPort
 		}
 
-		if len(vcConfig.Datacenters) == 0 {
+		if len(vcConfig.Datacenters) == 0 && len(vcConfig.IncludeDatacenters) == 0 && len(vcConfig.ExcludeDatacenters) == 0 {
 			if len(ccy.Global.Datacenters) != 0 {
 				vcConfig.Datacenters = ccy.Global.Datacenters
+			} else {
+				vcConfig.IncludeDatacenters = ccy.Global.IncludeDatacenters
+				vcConfig.ExcludeDatacenters = ccy.Global.ExcludeDatacenters
 			}
 		}
+		if len(vcConfig.Datacenters) != 0 && (len(vcConfig.IncludeDatacenters) != 0 || len(vcConfig.ExcludeDatacenters) != 0) {
+			klog.Errorf("Invalid datacenters for vc %s: %s", tenantRef, ErrDatacenterFilterConflict)
+			return ErrDatacenterFilterConflict
+		}
+		if _, err := NewNameFilter(vcConfig.IncludeDatacenters, vcConfig.ExcludeDatacenters); err != nil {
+			klog.Errorf("Invalid datacenter patterns for vc %s: %s", tenantRef, err)
+			return err
+		}
+		if len(vcConfig.IncludeFolders) == 0 && len(vcConfig.ExcludeFolders) == 0 {
+			vcConfig.IncludeFolders = ccy.Global.IncludeFolders
+			vcConfig.ExcludeFolders = ccy.Global.ExcludeFolders
+		}
+		if _, err := NewNameFilter(vcConfig.IncludeFolders, vcConfig.ExcludeFolders); err != nil {
+			klog.Errorf("Invalid folder patterns for vc %s: %s", tenantRef, err)
+			return err
+		}
 		if vcConfig.RoundTripperCount == 0 {
 			vcConfig.RoundTripperCount = ccy.Global.RoundTripperCount
 		}
@@ -193,6 +283,67 @@ func (ccy *CommonConfigYAML) validateConfig() error {
 	return nil
 }
 
+// CreateConfig generates a common CredentialSource object from its YAML
+// representation.
+func (csy *CredentialSourceYAML) CreateConfig() *CredentialSource {
+	source := &CredentialSource{
+		Server: csy.Server,
+	}
+	if csy.Vault != nil {
+		source.Vault = &VaultCredentialSource{
+			Address:      csy.Vault.Address,
+			Path:         csy.Vault.Path,
+			Engine:       csy.Vault.Engine,
+			Namespace:    csy.Vault.Namespace,
+			Token:        csy.Vault.Token,
+			TokenFile:    csy.Vault.TokenFile,
+			CAFile:       csy.Vault.CAFile,
+			InsecureFlag: csy.Vault.InsecureFlag,
+		}
+	}
+	if csy.Exec != nil {
+		source.Exec = &ExecCredentialSource{
+			Command: csy.Exec.Command,
+			Args:    csy.Exec.Args,
+			Env:     csy.Exec.Env,
+		}
+	}
+	return source
+}
+
+// Validate checks that exactly one provider is configured with its required
+// settings and fixes its default values.
+func (csy *CredentialSourceYAML) Validate() error {
+	if (csy.Vault == nil) == (csy.Exec == nil) {
+		return fmt.Errorf("%w: exactly one of vault and exec must be set", ErrInvalidCredentialSource)
+	}
+
+	if csy.Exec != nil {
+		if csy.Exec.Command == "" {
+			return fmt.Errorf("%w: exec command is missing", ErrInvalidCredentialSource)
+		}
+		return nil
+	}
+
+	if csy.Vault.Address == "" {
+		return fmt.Errorf("%w: vault address is missing", ErrInvalidCredentialSource)
+	}
+	if csy.Vault.Path == "" {
+		return fmt.Errorf("%w: vault path is missing", ErrInvalidCredentialSource)
+	}
+	if csy.Vault.Token == "" && csy.Vault.TokenFile == "" {
+		return fmt.Errorf("%w: vault token or tokenFile must be set", ErrInvalidCredentialSource)
+	}
+	switch csy.Vault.Engine {
+	case "":
+		csy.Vault.Engine = VaultEngineKV
+	case VaultEngineKV, VaultEngineDynamic:
+	default:
+		return fmt.Errorf("%w: unsupported vault engine %q", ErrInvalidCredentialSource, csy.Vault.Engine)
+	}
+	return nil
+}
+
 // ReadRawConfigYAML parses vSphere cloud config file and stores it into ConfigYAML
 func ReadRawConfigYAML(byConfig []byte) (*CommonConfigYAML, error) {
 	if len(byConfig) == 0 {
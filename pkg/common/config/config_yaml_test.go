@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -137,3 +138,247 @@ func TestTenantRefsYAML(t *testing.T) {
 		t.Errorf("vcConfig3 SecretRef should be kube-system/eu-secret but actual=%s", vcConfig3.SecretRef)
 	}
 }
+
+const credentialSourceConfigYAML = `
+global:
+  port: 443
+  insecureFlag: true
+  credentialSource:
+    server: 10.0.0.1
+    vault:
+      address: https://vault.example.com:8200
+      path: secret/data/vsphere
+      tokenFile: /var/run/secrets/vault/token
+
+vcenter:
+  tenant1:
+    server: 10.0.0.1
+    datacenters:
+      - vic0dc
+`
+
+func TestCredentialSourceYAML(t *testing.T) {
+	cfg, err := ReadConfigYAML([]byte(credentialSourceConfigYAML))
+	if err != nil {
+		t.Fatalf("Should succeed when a valid config is provided: %s", err)
+	}
+
+	source := cfg.Global.CredentialSource
+	if source == nil || source.Vault == nil {
+		t.Fatalf("Should return a vault credential source")
+	}
+	if source.Server != "10.0.0.1" {
+		t.Errorf("incorrect credential source server: %s", source.Server)
+	}
+	if source.Vault.Address != "https://vault.example.com:8200" || source.Vault.Path != "secret/data/vsphere" {
+		t.Errorf("incorrect vault address/path: %s/%s", source.Vault.Address, source.Vault.Path)
+	}
+	if source.Vault.Engine != VaultEngineKV {
+		t.Errorf("vault engine should default to %s but actual=%s", VaultEngineKV, source.Vault.Engine)
+	}
+	if vcConfig := cfg.VirtualCenter["tenant1"]; vcConfig.SecretRef != DefaultCredentialManager {
+		t.Errorf("vcConfig SecretRef should be %s but actual=%s", DefaultCredentialManager, vcConfig.SecretRef)
+	}
+}
+
+func TestCredentialSourceYAMLValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source CredentialSourceYAML
+		valid  bool
+	}{
+		{
+			name: "no provider",
+		},
+		{
+			name: "both providers",
+			source: CredentialSourceYAML{
+				Vault: &VaultCredentialSourceYAML{Address: "https://vault", Path: "secret/data/vsphere", Token: "token"},
+				Exec:  &ExecCredentialSourceYAML{Command: "/bin/plugin"},
+			},
+		},
+		{
+			name:   "exec without command",
+			source: CredentialSourceYAML{Exec: &ExecCredentialSourceYAML{}},
+		},
+		{
+			name:   "exec",
+			source: CredentialSourceYAML{Exec: &ExecCredentialSourceYAML{Command: "/bin/plugin"}},
+			valid:  true,
+		},
+		{
+			name:   "vault without token",
+			source: CredentialSourceYAML{Vault: &VaultCredentialSourceYAML{Address: "https://vault", Path: "secret/data/vsphere"}},
+		},
+		{
+			name:   "vault without path",
+			source: CredentialSourceYAML{Vault: &VaultCredentialSourceYAML{Address: "https://vault", Token: "token"}},
+		},
+		{
+			name:   "vault with unknown engine",
+			source: CredentialSourceYAML{Vault: &VaultCredentialSourceYAML{Address: "https://vault", Path: "ldap/creds/cpi", Token: "token", Engine: "ldap"}},
+		},
+		{
+			name:   "dynamic vault secret",
+			source: CredentialSourceYAML{Vault: &VaultCredentialSourceYAML{Address: "https://vault", Path: "vsphere/creds/cpi", Token: "token", Engine: VaultEngineDynamic}},
+			valid:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.source.Validate()
+		if testCase.valid && err != nil {
+			t.Errorf("%s: should succeed but failed: %s", testCase.name, err)
+		}
+		if !testCase.valid && !errors.Is(err, ErrInvalidCredentialSource) {
+			t.Errorf("%s: should fail with %s but got: %v", testCase.name, ErrInvalidCredentialSource, err)
+		}
+	}
+}
+
+const authModeConfigYAML = `
+global:
+  port: 443
+  insecureFlag: true
+  authMode: certificate
+  certFile: /etc/cloud/solution-user.crt
+  keyFile: /etc/cloud/solution-user.key
+
+vcenter:
+  tenant1:
+    server: 10.0.0.1
+    datacenters:
+      - vic0dc
+  tenant2:
+    server: 10.0.0.2
+    authMode: token
+    tokenFile: /etc/cloud/saml-token.xml
+    datacenters:
+      - vic1dc
+`
+
+func TestAuthModeYAML(t *testing.T) {
+	cfg, err := ReadConfigYAML([]byte(authModeConfigYAML))
+	if err != nil {
+		t.Fatalf("Should succeed when a valid config is provided: %s", err)
+	}
+
+	vcConfig1 := cfg.VirtualCenter["tenant1"]
+	if vcConfig1.AuthMode != "certificate" {
+		t.Errorf("tenant1 authMode should be inherited from global but actual=%s", vcConfig1.AuthMode)
+	}
+	if vcConfig1.CertFile != "/etc/cloud/solution-user.crt" || vcConfig1.KeyFile != "/etc/cloud/solution-user.key" {
+		t.Errorf("incorrect tenant1 certFile/keyFile: %s/%s", vcConfig1.CertFile, vcConfig1.KeyFile)
+	}
+	if vcConfig1.User != "" || vcConfig1.SecretRef != "" {
+		t.Errorf("tenant1 should not have a user or secret ref: %s/%s", vcConfig1.User, vcConfig1.SecretRef)
+	}
+
+	vcConfig2 := cfg.VirtualCenter["tenant2"]
+	if vcConfig2.AuthMode != "token" || vcConfig2.TokenFile != "/etc/cloud/saml-token.xml" {
+		t.Errorf("incorrect tenant2 authMode/tokenFile: %s/%s", vcConfig2.AuthMode, vcConfig2.TokenFile)
+	}
+}
+
+func TestAuthModeYAMLValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vcConfig VirtualCenterConfigYAML
+		secret   bool
+		err      error
+	}{
+		{
+			name:     "password",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "password"},
+		},
+		{
+			name:     "unknown",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "kerberos"},
+			err:      ErrInvalidAuthMode,
+		},
+		{
+			name:     "certificate without files",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "certificate"},
+			err:      ErrCertificateMissing,
+		},
+		{
+			name:     "certificate without key",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "certificate", CertFile: "/etc/cloud/solution-user.crt"},
+			secret:   true,
+			err:      ErrCertificateMissing,
+		},
+		{
+			name:     "certificate from secret",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "certificate"},
+			secret:   true,
+		},
+		{
+			name:     "token without file",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "token"},
+			err:      ErrTokenMissing,
+		},
+		{
+			name:     "token from secret",
+			vcConfig: VirtualCenterConfigYAML{AuthMode: "token"},
+			secret:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		if err := testCase.vcConfig.validateAuthMode(testCase.secret); err != testCase.err {
+			t.Errorf("%s: expected error %v but got: %v", testCase.name, testCase.err, err)
+		}
+	}
+}
+
+const datacenterPatternsConfigYAML = `
+global:
+  user: user
+  password: password
+  includeDatacenters:
+    - dc-*
+  excludeFolders:
+    - re:tmp-.*
+
+vcenter:
+  tenant1:
+    server: 10.0.0.1
+  tenant2:
+    server: 10.0.0.2
+    datacenters:
+      - vic1dc
+    includeFolders:
+      - k8s
+`
+
+func TestDatacenterPatternsYAML(t *testing.T) {
+	cfg, err := ReadConfigYAML([]byte(datacenterPatternsConfigYAML))
+	if err != nil {
+		t.Fatalf("Should succeed when a valid config is provided: %s", err)
+	}
+
+	vcConfig1 := cfg.VirtualCenter["tenant1"]
+	if len(vcConfig1.IncludeDatacenters) != 1 || vcConfig1.IncludeDatacenters[0] != "dc-*" {
+		t.Errorf("tenant1 includeDatacenters should be inherited from global but actual=%v", vcConfig1.IncludeDatacenters)
+	}
+	if len(vcConfig1.ExcludeFolders) != 1 || vcConfig1.ExcludeFolders[0] != "re:tmp-.*" {
+		t.Errorf("tenant1 excludeFolders should be inherited from global but actual=%v", vcConfig1.ExcludeFolders)
+	}
+
+	vcConfig2 := cfg.VirtualCenter["tenant2"]
+	if vcConfig2.Datacenters != "vic1dc" || len(vcConfig2.IncludeDatacenters) != 0 {
+		t.Errorf("tenant2 should only list datacenters but actual=%s/%v", vcConfig2.Datacenters, vcConfig2.IncludeDatacenters)
+	}
+	if len(vcConfig2.IncludeFolders) != 1 || len(vcConfig2.ExcludeFolders) != 0 {
+		t.Errorf("tenant2 folders should not be inherited but actual=%v/%v", vcConfig2.IncludeFolders, vcConfig2.ExcludeFolders)
+	}
+
+	conflict := strings.Replace(datacenterPatternsConfigYAML, "    includeFolders:", "    excludeDatacenters:", 1)
+	if _, err := ReadConfigYAML([]byte(conflict)); err != ErrDatacenterFilterConflict {
+		t.Errorf("Should fail when datacenters are listed and selected by patterns: %v", err)
+	}
+	invalid := strings.Replace(datacenterPatternsConfigYAML, "re:tmp-.*", "re:tmp-(", 1)
+	if _, err := ReadConfigYAML([]byte(invalid)); err == nil {
+		t.Error("Should fail with an invalid folder pattern")
+	}
+}
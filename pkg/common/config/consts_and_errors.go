@@ -47,6 +47,11 @@ const (
 
 	// DefaultCredentialManager used for the Global CredMgr/Lister
 	DefaultCredentialManager string = "Global"
+
+	// VaultEngineKV reads a KV version 2 secret from Vault.
+	VaultEngineKV = "kv"
+	// VaultEngineDynamic reads a leased, dynamic secret from Vault.
+	VaultEngineDynamic = "dynamic"
 )
 
 var (
@@ -66,4 +71,22 @@ var (
 
 	// ErrInvalidIPFamilyType is returned when an invalid IPFamily type is encountered
 	ErrInvalidIPFamilyType = errors.New("Invalid IP Family type")
+
+	// ErrInvalidCredentialSource is returned when a credential source does not
+	// configure exactly one provider or misses its required settings.
+	ErrInvalidCredentialSource = errors.New("Invalid credential source")
+
+	// ErrInvalidAuthMode is returned when an unsupported auth mode is configured.
+	ErrInvalidAuthMode = errors.New("Invalid auth mode, must be password, certificate or token")
+
+	// ErrCertificateMissing is returned when the certificate auth mode has no
+	// certificate and private key.
+	ErrCertificateMissing = errors.New("Certificate and private key are missing")
+
+	// ErrTokenMissing is returned when the token auth mode has no token.
+	ErrTokenMissing = errors.New("Token is missing")
+
+	// ErrDatacenterFilterConflict is returned when datacenters are listed and
+	// selected by patterns at the same time.
+	ErrDatacenterFilterConflict = errors.New("Datacenters cannot be combined with includeDatacenters or excludeDatacenters")
 )
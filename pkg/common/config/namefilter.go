@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RegexpPatternPrefix marks a pattern of a NameFilter as regular expression
+// instead of glob.
+const RegexpPatternPrefix = "re:"
+
+// NameFilter selects names by include and exclude patterns. A pattern is a
+// glob as in path.Match or, with the prefix "re:", a regular expression, both
+// matching the whole name.
+type NameFilter struct {
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+// NewNameFilter compiles the include and exclude patterns. It returns nil if
+// there are no patterns.
+func NewNameFilter(include, exclude []string) (*NameFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	filter := &NameFilter{}
+	var err error
+	if filter.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if filter.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func compilePatterns(patterns []string) ([]func(string) bool, error) {
+	matchers := make([]func(string) bool, 0, len(patterns))
+	for _, pattern := range patterns {
+		if expr := strings.TrimPrefix(pattern, RegexpPatternPrefix); expr != pattern {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+			}
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		glob := pattern
+		matchers = append(matchers, func(name string) bool {
+			matched, _ := path.Match(glob, name)
+			return matched
+		})
+	}
+	return matchers, nil
+}
+
+func matchAny(matchers []func(string) bool, name string) bool {
+	for _, match := range matchers {
+		if match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the name matches an include pattern, or there are
+// none, and no exclude pattern. A nil filter matches all names.
+func (f *NameFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	return (len(f.include) == 0 || matchAny(f.include, name)) && !matchAny(f.exclude, name)
+}
+
+// MatchPath matches a slash separated path by its parents: it returns true if
+// the path or a parent matches an include pattern, or there are none, and
+// neither the path nor a parent matches an exclude pattern. A nil filter
+// matches all paths.
+func (f *NameFilter) MatchPath(p string) bool {
+	if f == nil {
+		return true
+	}
+	included := len(f.include) == 0
+	var parent string
+	for _, element := range strings.Split(p, "/") {
+		if element == "" {
+			continue
+		}
+		parent = path.Join(parent, element)
+		if matchAny(f.exclude, parent) {
+			return false
+		}
+		included = included || matchAny(f.include, parent)
+	}
+	return included
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+)
+
+func TestNameFilter(t *testing.T) {
+	filter, err := NewNameFilter([]string{"dc-*", "re:lab-[0-9]+"}, []string{"dc-test*"})
+	if err != nil {
+		t.Fatalf("Should succeed with valid patterns: %s", err)
+	}
+	names := map[string]bool{
+		"dc-west":    true,
+		"dc-test-01": false,
+		"lab-42":     true,
+		"lab-42-old": false,
+		"other":      false,
+	}
+	for name, match := range names {
+		if filter.Match(name) != match {
+			t.Errorf("%s: expected match %t", name, match)
+		}
+	}
+
+	paths := map[string]bool{
+		"dc-west":         true,
+		"dc-west/k8s":     true,
+		"dc-test/k8s":     false,
+		"dc-west/dc-test": true,
+		"other/dc-west":   false,
+		"other":           false,
+		"":                false,
+	}
+	for path, match := range paths {
+		if filter.MatchPath(path) != match {
+			t.Errorf("%s: expected path match %t", path, match)
+		}
+	}
+
+	var none *NameFilter
+	if !none.Match("any") || !none.MatchPath("any/path") {
+		t.Error("A nil filter should match all names")
+	}
+	if filter, err := NewNameFilter(nil, nil); filter != nil || err != nil {
+		t.Errorf("Expected no filter without patterns but got %v, %v", filter, err)
+	}
+	for _, pattern := range []string{"dc-[", "re:lab-("} {
+		if _, err := NewNameFilter([]string{pattern}, nil); err == nil {
+			t.Errorf("%s: should fail with an invalid pattern", pattern)
+		}
+	}
+}
@@ -37,6 +37,13 @@ type Global struct {
 	InsecureFlag bool
 	// Datacenter in which VMs are located.
 	Datacenters string
+	// Name patterns of the datacenters in which VMs are searched if
+	// Datacenters is empty.
+	IncludeDatacenters []string
+	ExcludeDatacenters []string
+	// Path patterns of the VM folders outside of which found VMs are ignored.
+	IncludeFolders []string
+	ExcludeFolders []string
 	// Soap round tripper count (retries = RoundTripper - 1)
 	RoundTripperCount uint
 	// Specifies the path to a CA certificate in PEM format. Optional; if not
@@ -53,6 +60,19 @@ type Global struct {
 	// 2) we are not in a k8s env, namely DC/OS, since CSI is CO agnostic
 	// Default: /etc/cloud/credentials
 	SecretsDirectory string
+	// CredentialSource is an external provider of the vCenter credentials used
+	// in place of a Kubernetes secret or the secrets directory.
+	CredentialSource *CredentialSource
+	// AuthMode selects how to log in to vCenter: "password", "certificate"
+	// or "token". If empty, a PEM encoded user is used as solution user
+	// certificate with the password as its private key.
+	AuthMode string
+	// CertFile and KeyFile hold the PEM encoded solution user certificate
+	// and private key used with the "certificate" auth mode.
+	CertFile string
+	KeyFile  string
+	// TokenFile holds the SAML bearer token used with the "token" auth mode.
+	TokenFile string
 }
 
 // VirtualCenterConfig struct
@@ -75,6 +95,13 @@ type VirtualCenterConfig struct {
 	InsecureFlag bool
 	// Datacenter in which VMs are located.
 	Datacenters string
+	// Name patterns of the datacenters in which VMs are searched if
+	// Datacenters is empty.
+	IncludeDatacenters []string
+	ExcludeDatacenters []string
+	// Path patterns of the VM folders outside of which found VMs are ignored.
+	IncludeFolders []string
+	ExcludeFolders []string
 	// Soap round tripper count (retries = RoundTripper - 1)
 	RoundTripperCount uint
 	// Specifies the path to a CA certificate in PEM format. Optional; if not
@@ -94,6 +121,15 @@ type VirtualCenterConfig struct {
 	// ipv4 - IPv4 addresses only (Default)
 	// ipv6 - IPv6 addresses only
 	IPFamilyPriority []string
+	// AuthMode selects how to log in to vCenter: "password", "certificate"
+	// or "token".
+	AuthMode string
+	// CertFile and KeyFile hold the PEM encoded solution user certificate
+	// and private key used with the "certificate" auth mode.
+	CertFile string
+	KeyFile  string
+	// TokenFile holds the SAML bearer token used with the "token" auth mode.
+	TokenFile string
 }
 
 // Labels struct
@@ -115,3 +151,52 @@ type Config struct {
 	// Tag categories and tags which correspond to "built-in node labels: zones and region"
 	Labels Labels
 }
+
+// CredentialSource configures an external provider of credentials. Exactly one
+// of Vault and Exec is set.
+type CredentialSource struct {
+	// Vault reads the credentials from HashiCorp Vault.
+	Vault *VaultCredentialSource
+	// Exec runs a plugin that prints the credentials.
+	Exec *ExecCredentialSource
+	// Server is the vCenter that plain "username" and "password" keys in the
+	// provided credentials belong to. Not needed if the credentials already use
+	// the "<server>.username" or "server_<x>" key formats.
+	Server string
+}
+
+// VaultCredentialSource configures reading credentials from HashiCorp Vault.
+type VaultCredentialSource struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Path of the secret below /v1/, e.g. secret/data/vsphere for a KV version 2
+	// secrets engine mounted at secret/.
+	Path string
+	// Engine is the kind of secret read: "kv" for a KV version 2 secret
+	// (default) or "dynamic" for a leased secret that is renewed until it
+	// cannot be renewed anymore.
+	Engine string
+	// Namespace is the Vault Enterprise namespace of the secret.
+	Namespace string
+	// Token authenticates against Vault.
+	Token string
+	// TokenFile contains the token and is read on every request, so a token
+	// rotated by a Vault agent is picked up.
+	TokenFile string
+	// Specifies the path to a CA certificate in PEM format. Optional; if not
+	// configured, the system's CA certificates will be used.
+	CAFile string
+	// True if Vault uses self-signed cert.
+	InsecureFlag bool
+}
+
+// ExecCredentialSource configures a plugin that prints the credentials as an
+// ExecCredential to its standard output, like client-go credential plugins.
+type ExecCredentialSource struct {
+	// Command to run.
+	Command string
+	// Args passed to the command.
+	Args []string
+	// Env holds additional environment variables of the command.
+	Env map[string]string
+}
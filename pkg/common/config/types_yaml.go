@@ -42,6 +42,16 @@ type GlobalYAML struct {
 	InsecureFlag bool `yaml:"insecureFlag"`
 	// Datacenter in which VMs are located.
 	Datacenters []string `yaml:"datacenters"`
+	// IncludeDatacenters and ExcludeDatacenters select the datacenters in
+	// which VMs are searched by name patterns, instead of listing them in
+	// datacenters. The matching datacenters are discovered periodically.
+	IncludeDatacenters []string `yaml:"includeDatacenters"`
+	ExcludeDatacenters []string `yaml:"excludeDatacenters"`
+	// IncludeFolders and ExcludeFolders ignore the VMs found outside of the VM
+	// folders matching the path patterns, relative to the VM folder of the
+	// datacenter.
+	IncludeFolders []string `yaml:"includeFolders"`
+	ExcludeFolders []string `yaml:"excludeFolders"`
 	// Soap round tripper count (retries = RoundTripper - 1)
 	RoundTripperCount uint `yaml:"soapRoundtripCount"`
 	// Specifies the path to a CA certificate in PEM format. Optional; if not
@@ -69,6 +79,19 @@ type GlobalYAML struct {
 	// ipv4 - IPv4 addresses only (Default)
 	// ipv6 - IPv6 addresses only
 	IPFamilyPriority []string `yaml:"ipFamily"`
+	// CredentialSource is an external provider of the vCenter credentials used
+	// in place of a Kubernetes secret or the secrets directory.
+	CredentialSource *CredentialSourceYAML `yaml:"credentialSource"`
+	// AuthMode selects how to log in to vCenter: "password", "certificate"
+	// or "token". If empty, a PEM encoded user is used as solution user
+	// certificate with the password as its private key.
+	AuthMode string `yaml:"authMode"`
+	// CertFile and KeyFile hold the PEM encoded solution user certificate
+	// and private key used with the "certificate" auth mode.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// TokenFile holds the SAML bearer token used with the "token" auth mode.
+	TokenFile string `yaml:"tokenFile"`
 }
 
 // VirtualCenterConfigYAML contains information used to access a remote vCenter
@@ -92,6 +115,16 @@ type VirtualCenterConfigYAML struct {
 	InsecureFlag bool `yaml:"insecureFlag"`
 	// Datacenter in which VMs are located.
 	Datacenters []string `yaml:"datacenters"`
+	// IncludeDatacenters and ExcludeDatacenters select the datacenters in
+	// which VMs are searched by name patterns, instead of listing them in
+	// datacenters. The matching datacenters are discovered periodically.
+	IncludeDatacenters []string `yaml:"includeDatacenters"`
+	ExcludeDatacenters []string `yaml:"excludeDatacenters"`
+	// IncludeFolders and ExcludeFolders ignore the VMs found outside of the VM
+	// folders matching the path patterns, relative to the VM folder of the
+	// datacenter.
+	IncludeFolders []string `yaml:"includeFolders"`
+	ExcludeFolders []string `yaml:"excludeFolders"`
 	// Soap round tripper count (retries = RoundTripper - 1)
 	RoundTripperCount uint `yaml:"soapRoundtripCount"`
 	// Specifies the path to a CA certificate in PEM format. Optional; if not
@@ -111,6 +144,15 @@ type VirtualCenterConfigYAML struct {
 	// ipv4 - IPv4 addresses only (Default)
 	// ipv6 - IPv6 addresses only
 	IPFamilyPriority []string `yaml:"ipFamily"`
+	// AuthMode selects how to log in to vCenter: "password", "certificate"
+	// or "token".
+	AuthMode string `yaml:"authMode"`
+	// CertFile and KeyFile hold the PEM encoded solution user certificate
+	// and private key used with the "certificate" auth mode.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// TokenFile holds the SAML bearer token used with the "token" auth mode.
+	TokenFile string `yaml:"tokenFile"`
 }
 
 // LabelsYAML tags categories and tags which correspond to "built-in node labels: zones and region"
@@ -130,3 +172,45 @@ type CommonConfigYAML struct {
 	// Tag categories and tags which correspond to "built-in node labels: zones and region"
 	Labels LabelsYAML
 }
+
+// CredentialSourceYAML configures an external provider of credentials. Exactly
+// one of Vault and Exec is set.
+type CredentialSourceYAML struct {
+	// Vault reads the credentials from HashiCorp Vault.
+	Vault *VaultCredentialSourceYAML `yaml:"vault"`
+	// Exec runs a plugin that prints the credentials.
+	Exec *ExecCredentialSourceYAML `yaml:"exec"`
+	// Server is the vCenter that plain "username" and "password" keys in the
+	// provided credentials belong to.
+	Server string `yaml:"server"`
+}
+
+// VaultCredentialSourceYAML configures reading credentials from HashiCorp Vault.
+type VaultCredentialSourceYAML struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string `yaml:"address"`
+	// Path of the secret below /v1/, e.g. secret/data/vsphere.
+	Path string `yaml:"path"`
+	// Engine is the kind of secret read: "kv" (default) or "dynamic".
+	Engine string `yaml:"engine"`
+	// Namespace is the Vault Enterprise namespace of the secret.
+	Namespace string `yaml:"namespace"`
+	// Token authenticates against Vault.
+	Token string `yaml:"token"`
+	// TokenFile contains the token and is read on every request.
+	TokenFile string `yaml:"tokenFile"`
+	// Specifies the path to a CA certificate in PEM format.
+	CAFile string `yaml:"caFile"`
+	// True if Vault uses self-signed cert.
+	InsecureFlag bool `yaml:"insecureFlag"`
+}
+
+// ExecCredentialSourceYAML configures a plugin that prints the credentials.
+type ExecCredentialSourceYAML struct {
+	// Command to run.
+	Command string `yaml:"command"`
+	// Args passed to the command.
+	Args []string `yaml:"args"`
+	// Env holds additional environment variables of the command.
+	Env map[string]string `yaml:"env"`
+}
@@ -40,8 +40,21 @@ func NewConnectionManager(cfg *vcfg.Config, informMgr *k8s.InformerManager, clie
 		VsphereInstanceMap: generateInstanceMap(cfg),
 		credentialManagers: make(map[string]*cm.CredentialManager),
 		informerManagers:   make(map[string]*k8s.InformerManager),
+		tenantStops:        make(map[string]chan struct{}),
 	}
 
+	if cfg.Global.CredentialSource != nil {
+		source, err := cm.NewSource(cfg.Global.CredentialSource)
+		if err == nil {
+			klog.V(2).Info("Initializing with external credential source")
+			credMgr := cm.NewCredentialManager("", "", "", nil)
+			credMgr.Source = source
+			connMgr.credentialManagers[vcfg.DefaultCredentialManager] = credMgr
+
+			return connMgr
+		}
+		klog.Errorf("Failed to initialize credential source: %v", err)
+	}
 	if cfg.Global.SecretsDirectory != "" {
 		klog.V(2).Info("Initializing for generic CO with secrets")
 		credMgr, _ := connMgr.createManagersPerTenant("", "", cfg.Global.SecretsDirectory, nil)
@@ -71,31 +84,134 @@ func generateInstanceMap(cfg *vcfg.Config) map[string]*VSphereInstance {
 	vsphereInstanceMap := make(map[string]*VSphereInstance)
 
 	for _, vcConfig := range cfg.VirtualCenter {
-		vSphereConn := vclib.VSphereConnection{
-			Username:          vcConfig.User,
-			Password:          vcConfig.Password,
-			Hostname:          vcConfig.VCenterIP,
-			Insecure:          vcConfig.InsecureFlag,
-			RoundTripperCount: vcConfig.RoundTripperCount,
-			Port:              vcConfig.VCenterPort,
-			CACert:            vcConfig.CAFile,
-			Thumbprint:        vcConfig.Thumbprint,
+		vsphereInstanceMap[vcConfig.TenantRef] = newVSphereInstance(vcConfig)
+	}
+
+	return vsphereInstanceMap
+}
+
+// newVSphereInstance creates the connection object of a vCenter
+func newVSphereInstance(vcConfig *vcfg.VirtualCenterConfig) *VSphereInstance {
+	vSphereConn := vclib.VSphereConnection{
+		Username:          vcConfig.User,
+		Password:          vcConfig.Password,
+		Hostname:          vcConfig.VCenterIP,
+		Insecure:          vcConfig.InsecureFlag,
+		RoundTripperCount: vcConfig.RoundTripperCount,
+		Port:              vcConfig.VCenterPort,
+		CACert:            vcConfig.CAFile,
+		Thumbprint:        vcConfig.Thumbprint,
+		AuthMode:          vcConfig.AuthMode,
+		CertFile:          vcConfig.CertFile,
+		KeyFile:           vcConfig.KeyFile,
+		TokenFile:         vcConfig.TokenFile,
+	}
+	return &VSphereInstance{
+		Conn:      &vSphereConn,
+		Cfg:       vcConfig,
+		discovery: newDatacenterDiscovery(vcConfig),
+	}
+}
+
+// Instances returns the vCenters by tenant ref. The returned map must not be
+// modified.
+func (connMgr *ConnectionManager) Instances() map[string]*VSphereInstance {
+	connMgr.instancesLock.RLock()
+	defer connMgr.instancesLock.RUnlock()
+	return connMgr.VsphereInstanceMap
+}
+
+// SetVCenter adds a vCenter at runtime or replaces the one with the same
+// tenant ref, which is logged out. A vCenter with its own secret gets its own
+// credential manager.
+func (connMgr *ConnectionManager) SetVCenter(vcConfig *vcfg.VirtualCenterConfig) *VSphereInstance {
+	if !strings.EqualFold(vcConfig.SecretRef, vcfg.DefaultCredentialManager) {
+		connMgr.Lock()
+		if _, found := connMgr.credentialManagers[vcConfig.SecretRef]; !found {
+			klog.V(3).Infof("Adding credMgr/informMgr for vcServer=%s", vcConfig.VCenterIP)
+			credsMgr, informMgr := connMgr.createManagersPerTenant(vcConfig.SecretName,
+				vcConfig.SecretNamespace, "", connMgr.client)
+			connMgr.credentialManagers[vcConfig.SecretRef] = credsMgr
+			connMgr.informerManagers[vcConfig.SecretRef] = informMgr
+			if connMgr.credentialsStop != nil {
+				tenantStop := make(chan struct{})
+				connMgr.tenantStops[vcConfig.SecretRef] = tenantStop
+				connMgr.watchCredentials(vcConfig.SecretRef, credsMgr, mergeStop(connMgr.credentialsStop, tenantStop))
+			}
 		}
-		vsphereIns := VSphereInstance{
-			Conn: &vSphereConn,
-			Cfg:  vcConfig,
+		connMgr.Unlock()
+	}
+
+	vsi := newVSphereInstance(vcConfig)
+	old := connMgr.replaceInstance(vcConfig.TenantRef, vsi)
+	if old != nil {
+		connMgr.logout(old)
+		connMgr.releaseSecretRef(old.Cfg.SecretRef)
+	}
+	return vsi
+}
+
+// RemoveVCenter removes a vCenter at runtime and logs it out. The credential
+// manager of its secret is removed as well once no other vCenter uses it.
+func (connMgr *ConnectionManager) RemoveVCenter(tenantRef string) {
+	if old := connMgr.replaceInstance(tenantRef, nil); old != nil {
+		connMgr.logout(old)
+		connMgr.releaseSecretRef(old.Cfg.SecretRef)
+	}
+}
+
+// releaseSecretRef stops the credential watch and removes the managers of the
+// secret of a single vCenter once no vCenter uses it anymore. The secret
+// informer is shared by all managers and keeps running.
+func (connMgr *ConnectionManager) releaseSecretRef(secretRef string) {
+	if strings.EqualFold(secretRef, vcfg.DefaultCredentialManager) {
+		return
+	}
+	for _, vsi := range connMgr.Instances() {
+		if vsi.Cfg.SecretRef == secretRef {
+			return
 		}
-		vsphereInstanceMap[vcConfig.TenantRef] = &vsphereIns
 	}
 
-	return vsphereInstanceMap
+	connMgr.Lock()
+	defer connMgr.Unlock()
+	if _, found := connMgr.credentialManagers[secretRef]; !found {
+		return
+	}
+	klog.V(3).Infof("Removing credMgr/informMgr of credentialHolder=%s", secretRef)
+	if tenantStop, ok := connMgr.tenantStops[secretRef]; ok {
+		close(tenantStop)
+		delete(connMgr.tenantStops, secretRef)
+	}
+	delete(connMgr.credentialManagers, secretRef)
+	delete(connMgr.informerManagers, secretRef)
+}
+
+// replaceInstance replaces the vCenter of tenantRef in a copy of the instance
+// map, or removes it if vsi is nil, and returns the replaced one.
+func (connMgr *ConnectionManager) replaceInstance(tenantRef string, vsi *VSphereInstance) *VSphereInstance {
+	connMgr.instancesLock.Lock()
+	defer connMgr.instancesLock.Unlock()
+
+	instances := make(map[string]*VSphereInstance, len(connMgr.VsphereInstanceMap)+1)
+	for key, value := range connMgr.VsphereInstanceMap {
+		instances[key] = value
+	}
+	old := instances[tenantRef]
+	if vsi != nil {
+		instances[tenantRef] = vsi
+	} else {
+		delete(instances, tenantRef)
+	}
+	connMgr.VsphereInstanceMap = instances
+	return old
 }
 
 // InitializeSecretLister initializes the individual secret listers that are NOT
 // handled through the Default/Global lister tied to the default service account.
 func (connMgr *ConnectionManager) InitializeSecretLister() {
 	// For each vsi that has a Secret set createManagersPerTenant
-	for _, vInstance := range connMgr.VsphereInstanceMap {
+	for _, vInstance := range connMgr.Instances() {
 		klog.V(3).Infof("Checking vcServer=%s SecretRef=%s", vInstance.Cfg.VCenterIP, vInstance.Cfg.SecretRef)
 		if strings.EqualFold(vInstance.Cfg.SecretRef, vcfg.DefaultCredentialManager) {
 			klog.V(3).Infof("Skipping. vCenter %s is configured using global service account/secret.", vInstance.Cfg.VCenterIP)
@@ -143,7 +259,8 @@ func (connMgr *ConnectionManager) Connect(ctx context.Context, vcInstance *VSphe
 		return nil
 	}
 
-	if !vclib.IsInvalidCredentialsError(err) || connMgr.credentialManagers == nil {
+	// the certificate or token of the auth mode may only be stored in the secret
+	if !(vclib.IsInvalidCredentialsError(err) || err == vclib.ErrNoAuthCredentials) || connMgr.credentialManagers == nil {
 		klog.Errorf("Cannot connect to vCenter with err: %v", err)
 		return err
 	}
@@ -156,31 +273,134 @@ func (connMgr *ConnectionManager) Connect(ctx context.Context, vcInstance *VSphe
 		klog.Errorf("Unable to find credential manager for vcServer=%s credentialHolder=%s", vcInstance.Cfg.VCenterIP, vcInstance.Cfg.SecretRef)
 		return ErrUnableToFindCredentialManager
 	}
+	if vclib.IsInvalidCredentialsError(err) {
+		// the credentials of an external source may have been rotated
+		credMgr.ExpireSourceCredentials()
+	}
 	credentials, err := credMgr.GetCredential(vcInstance.Cfg.VCenterIP)
 	if err != nil {
 		klog.Error("Failed to get credentials from Secret Credential Manager with err:", err)
 		return err
 	}
-	vcInstance.Conn.UpdateCredentials(credentials.User, credentials.Password)
+	updateConnectionCredentials(vcInstance.Conn, credentials)
 	return vcInstance.Conn.Connect(ctx)
 }
 
+// WatchCredentials watches the mounted secrets directories, external
+// credential sources and Kubernetes secrets of the credential managers and
+// re-logs in to the affected vCenters as soon as their credentials are rotated,
+// instead of waiting for the current session to be rejected. The credential
+// managers of vCenters added later on are watched as well. The watches end once
+// stop is closed.
+func (connMgr *ConnectionManager) WatchCredentials(stop <-chan struct{}) {
+	connMgr.Lock()
+	defer connMgr.Unlock()
+
+	connMgr.credentialsStop = stop
+	for secretRef, credMgr := range connMgr.credentialManagers {
+		connMgr.watchCredentials(secretRef, credMgr, stop)
+	}
+}
+
+// watchCredentials watches the credentials of a credential manager. The
+// Kubernetes secrets of single vCenters are watched, the global secret is
+// only read once its credentials are rejected. The caller must hold the lock.
+func (connMgr *ConnectionManager) watchCredentials(secretRef string, credMgr *cm.CredentialManager, stop <-chan struct{}) {
+	onChange := func(servers []string) {
+		connMgr.rotateCredentials(context.Background(), secretRef, credMgr, servers)
+	}
+
+	switch {
+	case credMgr.Source != nil:
+		klog.V(2).Infof("Watching credential source for credential rotation. credentialHolder=%s", secretRef)
+		if err := credMgr.WatchSource(stop, onChange); err != nil {
+			klog.Errorf("Failed to watch credential source. err: %v", err)
+		}
+	case credMgr.SecretsDirectory != "":
+		klog.V(2).Infof("Watching secrets directory %s for credential rotation. credentialHolder=%s", credMgr.SecretsDirectory, secretRef)
+		if err := credMgr.WatchSecretsDirectory(stop, onChange); err != nil {
+			klog.Errorf("Failed to watch secrets directory %s. err: %v", credMgr.SecretsDirectory, err)
+		}
+	case !strings.EqualFold(secretRef, vcfg.DefaultCredentialManager) && connMgr.informerManagers[secretRef] != nil:
+		klog.V(2).Infof("Watching secret for credential rotation. credentialHolder=%s", secretRef)
+		informer := connMgr.informerManagers[secretRef].GetSecretInformer().Informer()
+		if err := credMgr.WatchSecret(informer, stop, onChange); err != nil {
+			klog.Errorf("Failed to watch secret %s. err: %v", secretRef, err)
+		}
+	}
+}
+
+// mergeStop returns a channel that is closed once one of the channels is closed
+func mergeStop(stop1, stop2 <-chan struct{}) <-chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-stop1:
+		case <-stop2:
+		}
+		close(stop)
+	}()
+	return stop
+}
+
+// rotateCredentials updates the credentials of the vCenters in servers that are
+// managed by credMgr and re-logs in to the ones already connected.
+func (connMgr *ConnectionManager) rotateCredentials(ctx context.Context, secretRef string, credMgr *cm.CredentialManager, servers []string) {
+	for _, server := range servers {
+		for _, vcInstance := range connMgr.Instances() {
+			if vcInstance.Cfg.SecretRef != secretRef || vcInstance.Cfg.VCenterIP != server {
+				continue
+			}
+
+			// the watch already refreshed the cache
+			credentials, found := credMgr.Cache.GetCredential(server)
+			if !found {
+				klog.Errorf("Rotated credentials not found for vcServer=%s", server)
+				continue
+			}
+
+			connMgr.Lock()
+			updateConnectionCredentials(vcInstance.Conn, &credentials)
+			if vcInstance.Conn.Client != nil {
+				klog.V(2).Infof("Credentials rotated. Logging in again. vcServer=%s credentialHolder=%s", server, secretRef)
+				if err := vcInstance.Conn.Relogin(ctx); err != nil {
+					klog.Errorf("Failed to log in to vCenter %s with rotated credentials. err: %v", server, err)
+				}
+			}
+			connMgr.Unlock()
+		}
+	}
+}
+
+// updateConnectionCredentials updates the username and password, solution user
+// certificate and SAML token of conn with the ones in credentials.
+func updateConnectionCredentials(conn *vclib.VSphereConnection, credentials *cm.Credential) {
+	conn.UpdateCredentials(credentials.User, credentials.Password)
+	conn.UpdateCertificate(credentials.Certificate, credentials.PrivateKey)
+	conn.UpdateToken(credentials.Token)
+}
+
 // Logout closes existing connections to remote vCenter endpoints.
 func (connMgr *ConnectionManager) Logout() {
-	for _, vsphereIns := range connMgr.VsphereInstanceMap {
-		connMgr.Lock()
-		c := vsphereIns.Conn.Client
-		connMgr.Unlock()
-		if c != nil {
-			vsphereIns.Conn.Logout(context.TODO())
-		}
+	for _, vsphereIns := range connMgr.Instances() {
+		connMgr.logout(vsphereIns)
+	}
+}
+
+// logout closes the connection of a vCenter if it is connected.
+func (connMgr *ConnectionManager) logout(vsphereIns *VSphereInstance) {
+	connMgr.Lock()
+	c := vsphereIns.Conn.Client
+	connMgr.Unlock()
+	if c != nil {
+		vsphereIns.Conn.Logout(context.TODO())
 	}
 }
 
 // Verify validates the configuration by attempting to connect to the
 // configured, remote vCenter endpoints.
 func (connMgr *ConnectionManager) Verify() error {
-	for _, vcInstance := range connMgr.VsphereInstanceMap {
+	for _, vcInstance := range connMgr.Instances() {
 		err := connMgr.Connect(context.Background(), vcInstance)
 		if err == nil {
 			klog.V(3).Infof("vCenter connect %s succeeded.", vcInstance.Cfg.VCenterIP)
@@ -195,7 +415,7 @@ func (connMgr *ConnectionManager) Verify() error {
 // VerifyWithContext is the same as Verify but allows a Go Context
 // to control the lifecycle of the connection event.
 func (connMgr *ConnectionManager) VerifyWithContext(ctx context.Context) error {
-	for _, vcInstance := range connMgr.VsphereInstanceMap {
+	for _, vcInstance := range connMgr.Instances() {
 		err := connMgr.Connect(ctx, vcInstance)
 		if err == nil {
 			klog.V(3).Infof("vCenter connect %s succeeded.", vcInstance.Cfg.VCenterIP)
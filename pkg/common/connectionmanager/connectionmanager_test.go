@@ -0,0 +1,275 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectionmanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib/fixtures"
+)
+
+func TestWatchCredentialsSecretsDirectory(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	dir := t.TempDir()
+	writeSecret := func(password string) {
+		t.Helper()
+		files := map[string]string{
+			config.Global.VCenterIP + ".username": config.Global.User,
+			config.Global.VCenterIP + ".password": password,
+		}
+		for name, value := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeSecret(config.Global.Password)
+
+	config.Global.SecretsDirectory = dir
+	vcInstance := config.VirtualCenter[config.Global.VCenterIP]
+	vcInstance.SecretRef = vcfg.DefaultCredentialManager
+
+	connMgr := NewConnectionManager(config, nil, nil)
+	defer connMgr.Logout()
+
+	ctx := context.Background()
+	vsi := connMgr.VsphereInstanceMap[config.Global.VCenterIP]
+	if err := connMgr.Connect(ctx, vsi); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	initialSession := sessionKey(t, connMgr, vsi)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	connMgr.WatchCredentials(stop)
+
+	// Rotated credentials replace the existing session right away.
+	writeSecret("rotated")
+	waitForRelogin(t, connMgr, vsi, initialSession, "rotated")
+}
+
+func TestWatchCredentialsSource(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	credential := `{"kind":"ExecCredential","status":{"data":{"username":"` + config.Global.User + `","password":"rotated"}}}`
+	config.Global.CredentialSource = &vcfg.CredentialSource{
+		Exec: &vcfg.ExecCredentialSource{
+			Command: "/bin/sh",
+			Args:    []string{"-c", "echo '" + credential + "'"},
+		},
+		Server: config.Global.VCenterIP,
+	}
+	vcInstance := config.VirtualCenter[config.Global.VCenterIP]
+	vcInstance.SecretRef = vcfg.DefaultCredentialManager
+
+	connMgr := NewConnectionManager(config, nil, nil)
+	defer connMgr.Logout()
+
+	ctx := context.Background()
+	vsi := connMgr.VsphereInstanceMap[config.Global.VCenterIP]
+	if err := connMgr.Connect(ctx, vsi); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	initialSession := sessionKey(t, connMgr, vsi)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	connMgr.WatchCredentials(stop)
+
+	// Credentials differing from the cloud config replace the session once fetched.
+	waitForRelogin(t, connMgr, vsi, initialSession, "rotated")
+}
+
+func TestWatchCredentialsSecretOfVCenterAddedAtRuntime(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "runtime-credentials", Namespace: "kube-system", ResourceVersion: "1"},
+		Data: map[string][]byte{
+			config.Global.VCenterIP + ".username": []byte(config.Global.User),
+			config.Global.VCenterIP + ".password": []byte(config.Global.Password),
+		},
+	}
+	client := fake.NewSimpleClientset(secret)
+	connMgr := NewConnectionManager(config, nil, client)
+	defer connMgr.Logout()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	connMgr.WatchCredentials(stop)
+
+	vcConfig := *config.VirtualCenter[config.Global.VCenterIP]
+	vcConfig.TenantRef = "runtime"
+	vcConfig.SecretName = secret.Name
+	vcConfig.SecretNamespace = secret.Namespace
+	vcConfig.SecretRef = secret.Namespace + "/" + secret.Name
+	vsi := connMgr.SetVCenter(&vcConfig)
+	if err := connMgr.Connect(context.Background(), vsi); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	initialSession := sessionKey(t, connMgr, vsi)
+
+	// Rotated credentials replace the existing session right away.
+	rotated := secret.DeepCopy()
+	rotated.ResourceVersion = "2"
+	rotated.Data[config.Global.VCenterIP+".password"] = []byte("rotated")
+	if _, err := client.CoreV1().Secrets(secret.Namespace).Update(context.Background(), rotated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRelogin(t, connMgr, vsi, initialSession, "rotated")
+
+	// Removing the vCenter stops the watch and removes its credential manager.
+	connMgr.RemoveVCenter("runtime")
+	connMgr.Lock()
+	defer connMgr.Unlock()
+	if connMgr.credentialManagers[vcConfig.SecretRef] != nil || connMgr.informerManagers[vcConfig.SecretRef] != nil {
+		t.Error("Expected the credential manager of the removed vCenter to be removed")
+	}
+	if _, ok := connMgr.tenantStops[vcConfig.SecretRef]; ok {
+		t.Error("Expected the credential watch of the removed vCenter to be stopped")
+	}
+}
+
+func TestConnectCertificateFromSecretsDirectory(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		config.Global.VCenterIP + ".certificate": fixtures.ServerCertPath,
+		config.Global.VCenterIP + ".privateKey":  fixtures.ServerKeyPath,
+	}
+	for name, src := range files {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config.Global.SecretsDirectory = dir
+	vcInstance := config.VirtualCenter[config.Global.VCenterIP]
+	vcInstance.SecretRef = vcfg.DefaultCredentialManager
+	vcInstance.AuthMode = vclib.AuthModeCertificate
+	vcInstance.User = ""
+	vcInstance.Password = ""
+
+	connMgr := NewConnectionManager(config, nil, nil)
+	defer connMgr.Logout()
+
+	// The solution user certificate is only stored in the secret.
+	vsi := connMgr.VsphereInstanceMap[config.Global.VCenterIP]
+	if err := connMgr.Connect(context.Background(), vsi); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if vsi.Conn.Certificate == "" || vsi.Conn.PrivateKey == "" {
+		t.Error("Expected the certificate and private key from the secret")
+	}
+}
+
+func TestSetVCenter(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	fileVC := config.VirtualCenter[config.Global.VCenterIP]
+	connMgr := NewConnectionManager(config, nil, nil)
+	defer connMgr.Logout()
+
+	// A vCenter added at runtime is found next to the ones of the config.
+	vcConfig := *fileVC
+	vcConfig.TenantRef = "runtime"
+	vsi := connMgr.SetVCenter(&vcConfig)
+	if err := connMgr.Connect(context.Background(), vsi); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if len(connMgr.Instances()) != 2 || connMgr.Instances()["runtime"] != vsi {
+		t.Fatalf("Expected the runtime vCenter next to the config one: %v", connMgr.Instances())
+	}
+	items, err := connMgr.ListAllVCandDCPairs(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllVCandDCPairs err=%v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected 2 vCenter/datacenter pairs but got %d", len(items))
+	}
+
+	// Replacing the vCenter logs out the replaced connection.
+	if replaced := connMgr.SetVCenter(&vcConfig); replaced == vsi {
+		t.Error("Expected a new vCenter connection")
+	}
+	if userSession, _ := session.NewManager(vsi.Conn.Client).UserSession(context.Background()); userSession != nil {
+		t.Error("Expected the replaced vCenter to be logged out")
+	}
+
+	connMgr.RemoveVCenter("runtime")
+	if len(connMgr.Instances()) != 1 || connMgr.Instances()[config.Global.VCenterIP] == nil {
+		t.Errorf("Expected only the config vCenter: %v", connMgr.Instances())
+	}
+}
+
+// waitForRelogin waits until vsi uses password in a session other than
+// initialSession. The client of vsi is kept, as the objects cached for the
+// nodes are bound to it.
+func waitForRelogin(t *testing.T, connMgr *ConnectionManager, vsi *VSphereInstance, initialSession string, password string) {
+	t.Helper()
+	client := vsi.Conn.Client
+	err := wait.PollImmediate(50*time.Millisecond, 10*time.Second, func() (bool, error) {
+		connMgr.Lock()
+		rotated := vsi.Conn.Password == password
+		connMgr.Unlock()
+		return rotated && sessionKey(t, connMgr, vsi) != initialSession, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vsi.Conn.Client != client {
+		t.Error("Expected the client to be kept for the new session")
+	}
+}
+
+// sessionKey returns the key of the current session of vsi
+func sessionKey(t *testing.T, connMgr *ConnectionManager, vsi *VSphereInstance) string {
+	t.Helper()
+	connMgr.Lock()
+	defer connMgr.Unlock()
+	userSession, err := session.NewManager(vsi.Conn.Client).UserSession(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userSession == nil {
+		return ""
+	}
+	return userSession.Key
+}
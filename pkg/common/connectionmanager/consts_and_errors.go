@@ -18,6 +18,7 @@ package connectionmanager
 
 import (
 	"errors"
+	"time"
 )
 
 // FindVM is the type that represents the types of searches used to
@@ -48,6 +49,10 @@ const (
 	RetryAttemptDelaySecs int = 1
 )
 
+// DatacenterDiscoveryInterval is the time after which the datacenters
+// matching the datacenter patterns of a vCenter are discovered again.
+var DatacenterDiscoveryInterval = 10 * time.Minute
+
 // Error Messages
 const (
 	ConnectionNotFoundErrMsg       = "vCenter not found"
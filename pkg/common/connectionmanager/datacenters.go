@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectionmanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	klog "k8s.io/klog/v2"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	vclib "k8s.io/cloud-provider-vsphere/pkg/common/vclib"
+)
+
+// datacenterDiscovery caches the datacenters of a vCenter selected by the
+// datacenter patterns of its config.
+type datacenterDiscovery struct {
+	sync.Mutex
+
+	datacenters *vcfg.NameFilter
+	folders     *vcfg.NameFilter
+
+	// the references are kept instead of the objects, which are bound to the
+	// client of the connection at the time of the discovery
+	refs       []types.ManagedObjectReference
+	paths      []string
+	discovered time.Time
+}
+
+func newDatacenterDiscovery(vcConfig *vcfg.VirtualCenterConfig) *datacenterDiscovery {
+	discovery := &datacenterDiscovery{}
+	var err error
+	// the patterns are validated with the config
+	if discovery.datacenters, err = vcfg.NewNameFilter(vcConfig.IncludeDatacenters, vcConfig.ExcludeDatacenters); err != nil {
+		klog.Errorf("Ignoring datacenter patterns of vc %s: %v", vcConfig.TenantRef, err)
+	}
+	if discovery.folders, err = vcfg.NewNameFilter(vcConfig.IncludeFolders, vcConfig.ExcludeFolders); err != nil {
+		klog.Errorf("Ignoring folder patterns of vc %s: %v", vcConfig.TenantRef, err)
+	}
+	return discovery
+}
+
+// datacenters returns the datacenters of a connected vCenter in which VMs are
+// searched: the ones listed in the config, the ones matching the datacenter
+// patterns, or all. Datacenters listed but not found are skipped and the
+// error is returned along with the others.
+func (cm *ConnectionManager) datacenters(ctx context.Context, vsi *VSphereInstance) ([]*vclib.Datacenter, error) {
+	if vsi.Cfg.Datacenters != "" {
+		var datacenterObjs []*vclib.Datacenter
+		var lastErr error
+		for _, dc := range strings.Split(vsi.Cfg.Datacenters, ",") {
+			dc = strings.TrimSpace(dc)
+			if dc == "" {
+				continue
+			}
+			datacenterObj, err := vclib.GetDatacenter(ctx, vsi.Conn, dc)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			datacenterObjs = append(datacenterObjs, datacenterObj)
+		}
+		return datacenterObjs, lastErr
+	}
+
+	discovery := vsi.discovery
+	if discovery == nil || discovery.datacenters == nil {
+		return vclib.GetAllDatacenter(ctx, vsi.Conn)
+	}
+
+	discovery.Lock()
+	defer discovery.Unlock()
+	if time.Since(discovery.discovered) > DatacenterDiscoveryInterval {
+		all, err := vclib.GetAllDatacenter(ctx, vsi.Conn)
+		if err != nil {
+			return nil, err
+		}
+		discovery.refs = nil
+		discovery.paths = nil
+		for _, datacenterObj := range all {
+			if discovery.datacenters.Match(datacenterObj.Name()) {
+				discovery.refs = append(discovery.refs, datacenterObj.Reference())
+				discovery.paths = append(discovery.paths, datacenterObj.InventoryPath)
+			}
+		}
+		discovery.discovered = time.Now()
+		klog.V(2).Infof("Discovered datacenters %v of %d in vc=%s", discovery.paths, len(all), vsi.Cfg.VCenterIP)
+	}
+
+	datacenterObjs := make([]*vclib.Datacenter, 0, len(discovery.refs))
+	for i, ref := range discovery.refs {
+		datacenter := object.NewDatacenter(vsi.Conn.Client, ref)
+		datacenter.InventoryPath = discovery.paths[i]
+		datacenterObjs = append(datacenterObjs, &vclib.Datacenter{Datacenter: datacenter})
+	}
+	return datacenterObjs, nil
+}
+
+// inFolders returns true if the VM is located in the VM folders matching the
+// folder patterns of the vCenter. A VM in a vApp is located in the folder of
+// the vApp. The patterns are applied to the VMs found in a datacenter rather
+// than limiting the search, since the search index of vCenter cannot be scoped
+// to folders.
+func inFolders(ctx context.Context, vsi *VSphereInstance, vm *vclib.VirtualMachine) (bool, error) {
+	if vsi.discovery == nil || vsi.discovery.folders == nil {
+		return true, nil
+	}
+	client := vm.Client()
+	folder, err := vmFolder(ctx, client, vm.Reference())
+	if err != nil {
+		return false, err
+	}
+	entities, err := mo.Ancestors(ctx, client, client.ServiceContent.PropertyCollector, folder)
+	if err != nil {
+		return false, err
+	}
+	// the ancestors are ordered from the root folder down to the folder of
+	// the VM, the folders of interest follow the VM folder of the datacenter
+	var folders []string
+	for i, entity := range entities {
+		if entity.Self.Type == "Datacenter" {
+			folders = nil
+			for _, folder := range entities[i+1:] {
+				if folder.Self.Type == "Folder" {
+					folders = append(folders, folder.Name)
+				}
+			}
+		}
+	}
+	if len(folders) > 0 {
+		folders = folders[1:]
+	}
+	return vsi.discovery.folders.MatchPath(strings.Join(folders, "/")), nil
+}
+
+// vmFolder returns the folder of a VM. The parent of a VM in a vApp is not
+// set, its folder is the one of the outermost vApp.
+func vmFolder(ctx context.Context, client *vim25.Client, ref types.ManagedObjectReference) (types.ManagedObjectReference, error) {
+	pc := property.DefaultCollector(client)
+	var vm mo.VirtualMachine
+	if err := pc.RetrieveOne(ctx, ref, []string{"parent", "parentVApp"}, &vm); err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	if vm.Parent != nil {
+		return *vm.Parent, nil
+	}
+	for vapp := vm.ParentVApp; vapp != nil; {
+		var app mo.VirtualApp
+		if err := pc.RetrieveOne(ctx, *vapp, []string{"parentFolder", "parentVApp"}, &app); err != nil {
+			return types.ManagedObjectReference{}, err
+		}
+		if app.ParentFolder != nil {
+			return *app.ParentFolder, nil
+		}
+		vapp = app.ParentVApp
+	}
+	return types.ManagedObjectReference{}, fmt.Errorf("no folder found for VM %s", ref.Value)
+}
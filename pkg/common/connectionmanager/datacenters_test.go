@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectionmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
+)
+
+func TestDatacenterDiscovery(t *testing.T) {
+	config, cleanup := configFromSim(true)
+	defer cleanup()
+
+	vcConfig := config.VirtualCenter[config.Global.VCenterIP]
+	vcConfig.Datacenters = ""
+	vcConfig.IncludeDatacenters = []string{"DC*"}
+	vcConfig.ExcludeDatacenters = []string{"re:DC[1-9]"}
+
+	connMgr := NewConnectionManager(config, nil, nil)
+	defer connMgr.Logout()
+
+	ctx := context.Background()
+	items, err := connMgr.ListAllVCandDCPairs(ctx)
+	if err != nil {
+		t.Fatalf("ListAllVCandDCPairs err=%v", err)
+	}
+	if len(items) != 1 || items[0].DataCenter.Name() != "DC0" {
+		t.Fatalf("Expected only datacenter DC0 but got %v", items)
+	}
+
+	// The discovered datacenters are reused until the next discovery.
+	vsi := connMgr.Instances()[config.Global.VCenterIP]
+	discovered := vsi.discovery.discovered
+	if _, err := connMgr.ListAllVCandDCPairs(ctx); err != nil {
+		t.Fatalf("ListAllVCandDCPairs err=%v", err)
+	}
+	if vsi.discovery.discovered != discovered {
+		t.Error("Expected the datacenters to be cached")
+	}
+}
+
+func TestWhichVCandDCByNodeIDInFolders(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	ctx := context.Background()
+	vcConfig := config.VirtualCenter[config.Global.VCenterIP]
+	setup := NewConnectionManager(config, nil, nil)
+	defer setup.Logout()
+	vsi := setup.Instances()[config.Global.VCenterIP]
+	if err := setup.Connect(ctx, vsi); err != nil {
+		t.Fatal(err)
+	}
+
+	// move a VM to the folder k8s/prod
+	datacenter, err := vclib.GetDatacenter(ctx, vsi.Conn, vclib.TestDefaultDatacenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	folders, err := datacenter.Folders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k8sFolder, err := folders.VmFolder.CreateFolder(ctx, "k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodFolder, err := k8sFolder.CreateFolder(ctx, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	simVM := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	task, err := prodFolder.MoveInto(ctx, []types.ManagedObjectReference{simVM.Reference()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := task.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		include []string
+		exclude []string
+		found   bool
+	}{
+		{name: "no folders", found: true},
+		{name: "included parent", include: []string{"k8s"}, found: true},
+		{name: "included folder", include: []string{"k8s/*"}, found: true},
+		{name: "other folder", include: []string{"other"}},
+		{name: "excluded folder", include: []string{"k8s"}, exclude: []string{"k8s/prod"}},
+	}
+	for _, testCase := range testCases {
+		vcConfig.IncludeFolders = testCase.include
+		vcConfig.ExcludeFolders = testCase.exclude
+		connMgr := NewConnectionManager(config, nil, nil)
+
+		info, err := connMgr.WhichVCandDCByNodeID(ctx, simVM.Config.Uuid, FindVMByUUID)
+		if testCase.found && (err != nil || info == nil) {
+			t.Errorf("%s: expected the VM to be found but got err=%v", testCase.name, err)
+		}
+		if !testCase.found && err != vclib.ErrNoVMFound {
+			t.Errorf("%s: expected the VM not to be found but got err=%v", testCase.name, err)
+		}
+		connMgr.Logout()
+	}
+}
+
+func TestWhichVCandDCByNodeIDInFoldersVApp(t *testing.T) {
+	config, cleanup := configFromSim(false)
+	defer cleanup()
+
+	ctx := context.Background()
+	vcConfig := config.VirtualCenter[config.Global.VCenterIP]
+	setup := NewConnectionManager(config, nil, nil)
+	defer setup.Logout()
+	vsi := setup.Instances()[config.Global.VCenterIP]
+	if err := setup.Connect(ctx, vsi); err != nil {
+		t.Fatal(err)
+	}
+
+	// place a VM in a vApp of the folder k8s/prod, another VM stays in the
+	// VM folder of the datacenter and has an empty folder path
+	datacenter, err := vclib.GetDatacenter(ctx, vsi.Conn, vclib.TestDefaultDatacenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	folders, err := datacenter.Folders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k8sFolder, err := folders.VmFolder.CreateFolder(ctx, "k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodFolder, err := k8sFolder.CreateFolder(ctx, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	simPool := simulator.Map.Any("ResourcePool").(*simulator.ResourcePool)
+	pool := object.NewResourcePool(vsi.Conn.Client, simPool.Reference())
+	vapp, err := pool.CreateVApp(ctx, "vapp", types.DefaultResourceConfigSpec(), types.VAppConfigSpec{}, prodFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vms := simulator.Map.All("VirtualMachine")
+	vappVM := vms[0].(*simulator.VirtualMachine)
+	rootVM := vms[1].(*simulator.VirtualMachine)
+	vappRef := vapp.Reference()
+	vappVM.Parent = nil
+	vappVM.ParentVApp = &vappRef
+
+	testCases := []struct {
+		name    string
+		vm      *simulator.VirtualMachine
+		include []string
+		exclude []string
+		found   bool
+	}{
+		{name: "vApp in included folder", vm: vappVM, include: []string{"k8s/*"}, found: true},
+		{name: "vApp in other folder", vm: vappVM, include: []string{"other"}},
+		{name: "vApp in excluded folder", vm: vappVM, exclude: []string{"k8s/prod"}},
+		{name: "empty path with included folders", vm: rootVM, include: []string{"k8s"}},
+		{name: "empty path with excluded folders", vm: rootVM, exclude: []string{"k8s"}, found: true},
+	}
+	for _, testCase := range testCases {
+		vcConfig.IncludeFolders = testCase.include
+		vcConfig.ExcludeFolders = testCase.exclude
+		connMgr := NewConnectionManager(config, nil, nil)
+
+		info, err := connMgr.WhichVCandDCByNodeID(ctx, testCase.vm.Config.Uuid, FindVMByUUID)
+		if testCase.found && (err != nil || info == nil) {
+			t.Errorf("%s: expected the VM to be found but got err=%v", testCase.name, err)
+		}
+		if !testCase.found && err != vclib.ErrNoVMFound {
+			t.Errorf("%s: expected the VM not to be found but got err=%v", testCase.name, err)
+		}
+		connMgr.Logout()
+	}
+}
@@ -53,6 +53,7 @@ func (cm *ConnectionManager) WhichVCandDCByNodeID(ctx context.Context, nodeID st
 		tenantRef  string
 		vc         string
 		datacenter *vclib.Datacenter
+		vsi        *VSphereInstance
 	}
 
 	var mutex = &sync.Mutex{}
@@ -98,7 +99,7 @@ func (cm *ConnectionManager) WhichVCandDCByNodeID(ctx context.Context, nodeID st
 	}
 
 	go func() {
-		for _, vsi := range cm.VsphereInstanceMap {
+		for _, vsi := range cm.Instances() {
 			var datacenterObjs []*vclib.Datacenter
 
 			if getVMFound() {
@@ -120,28 +121,10 @@ func (cm *ConnectionManager) WhichVCandDCByNodeID(ctx context.Context, nodeID st
 				continue
 			}
 
-			if vsi.Cfg.Datacenters == "" {
-				datacenterObjs, err = vclib.GetAllDatacenter(ctx, vsi.Conn)
-				if err != nil {
-					klog.Error("WhichVCandDCByNodeID error dc:", err)
-					setGlobalErr(err)
-					continue
-				}
-			} else {
-				datacenters := strings.Split(vsi.Cfg.Datacenters, ",")
-				for _, dc := range datacenters {
-					dc = strings.TrimSpace(dc)
-					if dc == "" {
-						continue
-					}
-					datacenterObj, err := vclib.GetDatacenter(ctx, vsi.Conn, dc)
-					if err != nil {
-						klog.Error("WhichVCandDCByNodeID error dc:", err)
-						setGlobalErr(err)
-						continue
-					}
-					datacenterObjs = append(datacenterObjs, datacenterObj)
-				}
+			datacenterObjs, err = cm.datacenters(ctx, vsi)
+			if err != nil {
+				klog.Error("WhichVCandDCByNodeID error dc:", err)
+				setGlobalErr(err)
 			}
 
 			for _, datacenterObj := range datacenterObjs {
@@ -154,6 +137,7 @@ func (cm *ConnectionManager) WhichVCandDCByNodeID(ctx context.Context, nodeID st
 					tenantRef:  vsi.Cfg.TenantRef,
 					vc:         vsi.Cfg.VCenterIP,
 					datacenter: datacenterObj,
+					vsi:        vsi,
 				}
 			}
 		}
@@ -189,6 +173,19 @@ func (cm *ConnectionManager) WhichVCandDCByNodeID(ctx context.Context, nodeID st
 					continue
 				}
 
+				found, err := inFolders(ctx, res.vsi, vm)
+				if err != nil {
+					klog.Errorf("Error looking up the folder of vm=%+v in vc=%s and datacenter=%s: %v",
+						vm, res.vc, res.datacenter.Name(), err)
+					setGlobalErr(err)
+					continue
+				}
+				if !found {
+					klog.V(2).Infof("Ignoring node %s in vc=%s and datacenter=%s outside of the configured folders",
+						myNodeID, res.vc, res.datacenter.Name())
+					continue
+				}
+
 				var oVM mo.VirtualMachine
 				err = vm.Properties(ctx, vm.Reference(), []string{"config", "summary", "guest"}, &oVM)
 				if err != nil {
@@ -274,7 +271,7 @@ func (cm *ConnectionManager) WhichVCandDCByFCDId(ctx context.Context, fcdID stri
 	}
 
 	go func() {
-		for _, vsi := range cm.VsphereInstanceMap {
+		for _, vsi := range cm.Instances() {
 			var datacenterObjs []*vclib.Datacenter
 
 			if getFCDFound() {
@@ -296,28 +293,10 @@ func (cm *ConnectionManager) WhichVCandDCByFCDId(ctx context.Context, fcdID stri
 				continue
 			}
 
-			if vsi.Cfg.Datacenters == "" {
-				datacenterObjs, err = vclib.GetAllDatacenter(ctx, vsi.Conn)
-				if err != nil {
-					klog.Error("WhichVCandDCByFCDId error dc:", err)
-					setGlobalErr(err)
-					continue
-				}
-			} else {
-				datacenters := strings.Split(vsi.Cfg.Datacenters, ",")
-				for _, dc := range datacenters {
-					dc = strings.TrimSpace(dc)
-					if dc == "" {
-						continue
-					}
-					datacenterObj, err := vclib.GetDatacenter(ctx, vsi.Conn, dc)
-					if err != nil {
-						klog.Error("WhichVCandDCByFCDId error dc:", err)
-						setGlobalErr(err)
-						continue
-					}
-					datacenterObjs = append(datacenterObjs, datacenterObj)
-				}
+			datacenterObjs, err = cm.datacenters(ctx, vsi)
+			if err != nil {
+				klog.Error("WhichVCandDCByFCDId error dc:", err)
+				setGlobalErr(err)
 			}
 
 			for _, datacenterObj := range datacenterObjs {
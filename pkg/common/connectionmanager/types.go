@@ -34,19 +34,30 @@ type ConnectionManager struct {
 	client clientset.Interface
 
 	// Maps the VC server to VSphereInstance
+	// The map is replaced instead of modified once vCenters are added at
+	// runtime, use Instances to read it
 	VsphereInstanceMap map[string]*VSphereInstance
+	instancesLock      sync.RWMutex
 	// CredentialManager per VC
 	// The global CredentialManager will have an entry in this map with the key of "Global"
 	credentialManagers map[string]*cm.CredentialManager
 	// InformerManagers per VC
 	// The global InformerManager will have an entry in this map with the key of "Global"
 	informerManagers map[string]*k8s.InformerManager
+	// credentialsStop ends the credential watches, nil until WatchCredentials
+	// is called
+	credentialsStop <-chan struct{}
+	// tenantStops end the credential watches of the credential managers added
+	// at runtime, by SecretRef
+	tenantStops map[string]chan struct{}
 }
 
 // VSphereInstance represents a vSphere instance where one or more kubernetes nodes are running.
 type VSphereInstance struct {
 	Conn *vclib.VSphereConnection
 	Cfg  *vcfg.VirtualCenterConfig
+
+	discovery *datacenterDiscovery
 }
 
 // VMDiscoveryInfo contains VM info about a discovered VM
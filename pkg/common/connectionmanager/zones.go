@@ -52,7 +52,7 @@ func (cm *ConnectionManager) WhichVCandDCByZone(ctx context.Context,
 	klog.V(4).Infof("WhichVCandDCByZone called with zone: %s and region: %s", zoneLooking, regionLooking)
 
 	// Need at least one VC
-	numOfVCs := len(cm.VsphereInstanceMap)
+	numOfVCs := len(cm.Instances())
 	if numOfVCs == 0 {
 		err := ErrMustHaveAtLeastOneVCDC
 		klog.Errorf("%v", err)
@@ -72,7 +72,7 @@ func (cm *ConnectionManager) getDIFromSingleVC(ctx context.Context,
 	zoneLabel string, regionLabel string, zoneLooking string, regionLooking string) (*ZoneDiscoveryInfo, error) {
 	klog.V(4).Infof("getDIFromSingleVC called with zone: %s and region: %s", zoneLooking, regionLooking)
 
-	if len(cm.VsphereInstanceMap) != 1 {
+	if len(cm.Instances()) != 1 {
 		err := ErrUnsupportedConfiguration
 		klog.Errorf("%v", err)
 		return nil, err
@@ -82,7 +82,7 @@ func (cm *ConnectionManager) getDIFromSingleVC(ctx context.Context,
 
 	// Get first vSphere Instance
 	var tmpVsi *VSphereInstance
-	for _, tmpVsi = range cm.VsphereInstanceMap {
+	for _, tmpVsi = range cm.Instances() {
 		break //Grab the first one because there is only one
 	}
 
@@ -95,27 +95,26 @@ func (cm *ConnectionManager) getDIFromSingleVC(ctx context.Context,
 		time.Sleep(time.Duration(RetryAttemptDelaySecs) * time.Second)
 	}
 
-	numOfDc, err := vclib.GetNumberOfDatacenters(ctx, tmpVsi.Conn)
-	if err != nil {
+	datacenterObjs, err := cm.datacenters(ctx, tmpVsi)
+	if err != nil && len(datacenterObjs) == 0 {
 		klog.Errorf("%v", err)
 		return nil, err
 	}
 
 	// More than 1 DC in this VC
-	if numOfDc > 1 {
+	if len(datacenterObjs) > 1 {
 		klog.Info("Multi Datacenter configuration detected")
 		return cm.getDIFromMultiVCorDC(ctx, zoneLabel, regionLabel, zoneLooking, regionLooking)
 	}
+	if len(datacenterObjs) == 0 {
+		err := ErrMustHaveAtLeastOneVCDC
+		klog.Errorf("%v", err)
+		return nil, err
+	}
 
 	// We are sure this is single VC and DC
 	klog.Info("Single vCenter/Datacenter configuration detected")
 
-	datacenterObjs, err := vclib.GetAllDatacenter(ctx, tmpVsi.Conn)
-	if err != nil {
-		klog.Error("GetAllDatacenter failed. Err:", err)
-		return nil, err
-	}
-
 	discoveryInfo := &ZoneDiscoveryInfo{
 		VcServer:   vc,
 		DataCenter: datacenterObjs[0],
@@ -172,7 +171,7 @@ func (cm *ConnectionManager) getDIFromMultiVCorDC(ctx context.Context,
 	}
 
 	go func() {
-		for _, vsi := range cm.VsphereInstanceMap {
+		for _, vsi := range cm.Instances() {
 			var datacenterObjs []*vclib.Datacenter
 
 			if getZoneFound() {
@@ -194,28 +193,10 @@ func (cm *ConnectionManager) getDIFromMultiVCorDC(ctx context.Context,
 				continue
 			}
 
-			if vsi.Cfg.Datacenters == "" {
-				datacenterObjs, err = vclib.GetAllDatacenter(ctx, vsi.Conn)
-				if err != nil {
-					klog.Error("getDIFromMultiVCorDC error dc:", err)
-					setGlobalErr(err)
-					continue
-				}
-			} else {
-				datacenters := strings.Split(vsi.Cfg.Datacenters, ",")
-				for _, dc := range datacenters {
-					dc = strings.TrimSpace(dc)
-					if dc == "" {
-						continue
-					}
-					datacenterObj, err := vclib.GetDatacenter(ctx, vsi.Conn, dc)
-					if err != nil {
-						klog.Error("getDIFromMultiVCorDC error dc:", err)
-						setGlobalErr(err)
-						continue
-					}
-					datacenterObjs = append(datacenterObjs, datacenterObj)
-				}
+			datacenterObjs, err = cm.datacenters(ctx, vsi)
+			if err != nil {
+				klog.Error("getDIFromMultiVCorDC error dc:", err)
+				setGlobalErr(err)
 			}
 
 			for _, datacenterObj := range datacenterObjs {
@@ -320,7 +301,7 @@ func (cm *ConnectionManager) LookupZoneByMoref(ctx context.Context, tenantRef st
 
 	result := make(map[string]string)
 
-	vsi := cm.VsphereInstanceMap[tenantRef]
+	vsi := cm.Instances()[tenantRef]
 	if vsi == nil {
 		err := ErrConnectionNotFound
 		klog.Errorf("Unable to find Connection for tenantRef=%s", tenantRef)
@@ -18,12 +18,37 @@ package credentialmanager
 
 import (
 	"errors"
+	"time"
 )
 
 const (
 	usernamePrefix = "username_"
 	passwordPrefix = "password_"
 	serverPrefix   = "server_"
+
+	certificateSuffix = ".certificate"
+	privateKeySuffix  = ".privateKey"
+	tokenSuffix       = ".token"
+
+	// secretsDirectoryDebounce is how long the secrets directory must be
+	// quiet before it is re-read, so that files written one after the other
+	// are picked up together.
+	secretsDirectoryDebounce = 500 * time.Millisecond
+
+	// sourceRefreshInterval is how often credentials that do not expire are
+	// fetched again from their source. Expiring credentials are fetched again
+	// halfway through their lifetime, but at least that often.
+	sourceRefreshInterval = 5 * time.Minute
+	// sourceMinRefreshInterval limits how often short-lived credentials are
+	// fetched again from their source.
+	sourceMinRefreshInterval = 10 * time.Second
+	// sourceRetryInterval is how long to wait after a failed fetch.
+	sourceRetryInterval = 30 * time.Second
+	// sourceFetchTimeout bounds a single fetch from a source.
+	sourceFetchTimeout = 30 * time.Second
+
+	// execCredentialKind is the kind of the object printed by exec plugins.
+	execCredentialKind = "ExecCredential"
 )
 
 // Errors
@@ -39,4 +64,13 @@ var (
 
 	// ErrIncompleteCredentialSet is returned when the credentials do not contain all required values
 	ErrIncompleteCredentialSet = errors.New("Credentials did not have all required values")
+
+	// ErrSecretsDirectoryNotSet is returned when watching a credential manager without a secrets directory.
+	ErrSecretsDirectoryNotSet = errors.New("Secrets directory is not set")
+
+	// ErrSecretNotSet is returned when watching a credential manager without a Kubernetes secret.
+	ErrSecretNotSet = errors.New("Secret is not set")
+
+	// ErrCredentialSourceNotSet is returned when watching a credential manager without a credential source.
+	ErrCredentialSourceNotSet = errors.New("Credential source is not set")
 )
@@ -19,6 +19,8 @@ package credentialmanager
 import (
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -61,6 +63,15 @@ func (credentialManager *CredentialManager) GetCredential(server string) (*Crede
 		}
 	}
 
+	//get the creds from the external credential source if it exists
+	if credentialManager.Source != nil {
+		klog.V(4).Info("Source is valid. Fetching credentials.")
+		err := credentialManager.updateCredentialsMapSource()
+		if err != nil {
+			klog.Warningf("Failed fetching credentials from source, using cached credentials: %q", err)
+		}
+	}
+
 	//get the creds using the Secrets File if it exists
 	if credentialManager.SecretsDirectory != "" {
 		klog.V(4).Infof("SecretsDirectory is not empty. SecretsDirectory=%s", credentialManager.SecretsDirectory)
@@ -106,23 +117,38 @@ func (credentialManager *CredentialManager) updateCredentialsMapFile() error {
 		return nil
 	}
 
-	//take the mounted secrets in the form of files and make it looks like we
-	//parsed it from a k8s secret so we can reuse the SecretCache.parseSecret() func
-	data := make(map[string][]byte)
-	entries, err := os.ReadDir(credentialManager.SecretsDirectory)
+	data, err := credentialManager.readSecretsDirectory()
+	credentialManager.secretsDirectoryParsed = true
 	if err != nil {
-		credentialManager.secretsDirectoryParsed = true
 		klog.Warningf("Failed to find secrets directory %s. error: %q", credentialManager.SecretsDirectory, err)
 		return err
 	}
 
+	credentialManager.Cache.UpdateSecretFile(data)
+	return credentialManager.Cache.parseSecret()
+}
+
+// readSecretsDirectory takes the mounted secrets in the form of files and makes
+// it look like we parsed it from a k8s secret so we can reuse parseConfig.
+func (credentialManager *CredentialManager) readSecretsDirectory() (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	entries, err := os.ReadDir(credentialManager.SecretsDirectory)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, f := range entries {
+		// Kubernetes projects secrets through hidden, timestamped directories
+		// and a "..data" symlink pointing at the current one.
+		if strings.HasPrefix(f.Name(), "..") {
+			continue
+		}
 		if f.IsDir() {
 			klog.Warningf("Skipping parse of directory: %s", f.Name())
 			continue
 		}
 
-		fullFilePath := credentialManager.SecretsDirectory + "/" + f.Name()
+		fullFilePath := filepath.Join(credentialManager.SecretsDirectory, f.Name())
 		contents, err := os.ReadFile(fullFilePath)
 		if err != nil {
 			klog.Warningf("Cannot read  file %s. error: %q", fullFilePath, err)
@@ -132,9 +158,7 @@ func (credentialManager *CredentialManager) updateCredentialsMapFile() error {
 		data[f.Name()] = contents
 	}
 
-	credentialManager.secretsDirectoryParsed = true
-	credentialManager.Cache.UpdateSecretFile(data)
-	return credentialManager.Cache.parseSecret()
+	return data, nil
 }
 
 // GetSecret returns a Kubernetes secret.
@@ -186,6 +210,40 @@ func (cache *SecretCache) parseSecret() error {
 	return parseConfig(data, cache.VirtualCenter)
 }
 
+// replaceSecretData parses the provided secret data and, if it is valid,
+// replaces the cached credentials with it. It returns the servers whose
+// credentials were added or changed. Invalid data, like a partially written
+// file, leaves the cache untouched.
+func (cache *SecretCache) replaceSecretData(data map[string][]byte) ([]string, error) {
+	credentials := make(map[string]*Credential)
+	if err := parseConfig(data, credentials); err != nil {
+		return nil, err
+	}
+
+	cache.cacheLock.Lock()
+	defer cache.cacheLock.Unlock()
+
+	var changed []string
+	for server, credential := range credentials {
+		if cached, ok := cache.VirtualCenter[server]; !ok || *cached != *credential {
+			changed = append(changed, server)
+		}
+	}
+	sort.Strings(changed)
+
+	cache.SecretFile = data
+	cache.VirtualCenter = credentials
+	return changed, nil
+}
+
+// isComplete returns true if the credential holds a username and password, a
+// certificate and private key, or a token.
+func (credential *Credential) isComplete() bool {
+	return (credential.User != "" && credential.Password != "") ||
+		(credential.Certificate != "" && credential.PrivateKey != "") ||
+		credential.Token != ""
+}
+
 // parseConfig returns vCenter ip/fdqn mapping to its credentials viz. Username and Password.
 func parseConfig(data map[string][]byte, config map[string]*Credential) error {
 	if len(data) == 0 {
@@ -205,6 +263,24 @@ func parseConfig(data map[string][]byte, config map[string]*Credential) error {
 				config[vcServer] = &Credential{}
 			}
 			config[vcServer].User = strings.TrimSuffix(string(credentialValue), "\n")
+		} else if strings.HasSuffix(credentialKey, certificateSuffix) {
+			vcServer := strings.TrimSuffix(credentialKey, certificateSuffix)
+			if _, ok := config[vcServer]; !ok {
+				config[vcServer] = &Credential{}
+			}
+			config[vcServer].Certificate = string(credentialValue)
+		} else if strings.HasSuffix(credentialKey, privateKeySuffix) {
+			vcServer := strings.TrimSuffix(credentialKey, privateKeySuffix)
+			if _, ok := config[vcServer]; !ok {
+				config[vcServer] = &Credential{}
+			}
+			config[vcServer].PrivateKey = string(credentialValue)
+		} else if strings.HasSuffix(credentialKey, tokenSuffix) {
+			vcServer := strings.TrimSuffix(credentialKey, tokenSuffix)
+			if _, ok := config[vcServer]; !ok {
+				config[vcServer] = &Credential{}
+			}
+			config[vcServer].Token = strings.TrimSpace(string(credentialValue))
 		} else {
 			unknownKeys[credentialKey] = credentialValue
 		}
@@ -287,8 +363,8 @@ func parseConfig(data map[string][]byte, config map[string]*Credential) error {
 	}
 
 	for vcServer, credential := range config {
-		if credential.User == "" || credential.Password == "" {
-			klog.Errorf("Username/Password is missing for server %s", vcServer)
+		if !credential.isComplete() {
+			klog.Errorf("Username/Password, Certificate/PrivateKey or Token is missing for server %s", vcServer)
 			return ErrCredentialMissing
 		}
 	}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klog "k8s.io/klog/v2"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+// execSource runs a plugin printing the credentials, like client-go
+// credential plugins.
+type execSource struct {
+	cfg *vcfg.ExecCredentialSource
+}
+
+// execCredential is printed by exec plugins to their standard output.
+type execCredential struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Status     *execCredentialStatus `json:"status"`
+}
+
+// execCredentialStatus holds the credentials printed by an exec plugin.
+type execCredentialStatus struct {
+	// Data uses the same keys as a Kubernetes secret.
+	Data map[string]string `json:"data"`
+	// ExpirationTimestamp is when the credentials expire. Optional.
+	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+}
+
+func newExecSource(cfg *vcfg.ExecCredentialSource) *execSource {
+	return &execSource{cfg: cfg}
+}
+
+// Fetch runs the plugin and returns the credentials it printed.
+func (e *execSource) Fetch(ctx context.Context) (map[string][]byte, time.Time, error) {
+	cmd := exec.CommandContext(ctx, e.cfg.Command, e.cfg.Args...)
+	cmd.Env = os.Environ()
+	names := make([]string, 0, len(e.cfg.Env))
+	for name := range e.cfg.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd.Env = append(cmd.Env, name+"="+e.cfg.Env[name])
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("exec plugin %s failed: %v: %s", e.cfg.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	if stderr.Len() > 0 {
+		klog.V(4).Infof("exec plugin %s: %s", e.cfg.Command, strings.TrimSpace(stderr.String()))
+	}
+
+	cred := execCredential{}
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "decoding output of exec plugin %s failed", e.cfg.Command)
+	}
+	if cred.Kind != execCredentialKind {
+		return nil, time.Time{}, fmt.Errorf("exec plugin %s printed kind %q, expected %q", e.cfg.Command, cred.Kind, execCredentialKind)
+	}
+	if cred.Status == nil || len(cred.Status.Data) == 0 {
+		return nil, time.Time{}, fmt.Errorf("exec plugin %s printed no credentials", e.cfg.Command)
+	}
+
+	data := make(map[string][]byte, len(cred.Status.Data))
+	for key, value := range cred.Status.Data {
+		data[key] = []byte(value)
+	}
+	var expiry time.Time
+	if cred.Status.ExpirationTimestamp != nil {
+		expiry = cred.Status.ExpirationTimestamp.Time
+	}
+	return data, expiry, nil
+}
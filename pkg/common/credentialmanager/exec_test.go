@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+func TestExecSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		script         string
+		expectedData   map[string]string
+		expectedExpiry time.Time
+		expectedErr    string
+	}{
+		{
+			name:         "credentials",
+			script:       `echo '{"apiVersion":"v1","kind":"ExecCredential","status":{"data":{"username":"user","password":"'$PASSWORD'"}}}'`,
+			expectedData: map[string]string{"username": "user", "password": "secret"},
+		},
+		{
+			name:           "expiring credentials",
+			script:         `echo '{"kind":"ExecCredential","status":{"data":{"username":"user"},"expirationTimestamp":"2030-01-02T03:04:05Z"}}'`,
+			expectedData:   map[string]string{"username": "user"},
+			expectedExpiry: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:        "failing plugin",
+			script:      `echo "vault is sealed" >&2; exit 1`,
+			expectedErr: "vault is sealed",
+		},
+		{
+			name:        "unexpected kind",
+			script:      `echo '{"kind":"Secret","status":{"data":{"username":"user"}}}'`,
+			expectedErr: `printed kind "Secret"`,
+		},
+		{
+			name:        "no credentials",
+			script:      `echo '{"kind":"ExecCredential"}'`,
+			expectedErr: "printed no credentials",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			source := newExecSource(&vcfg.ExecCredentialSource{
+				Command: "/bin/sh",
+				Args:    []string{"-c", testCase.script},
+				Env:     map[string]string{"PASSWORD": "secret"},
+			})
+
+			data, expiry, err := source.Fetch(context.Background())
+			if testCase.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if len(data) != len(testCase.expectedData) {
+				t.Errorf("expected %v, got %v", testCase.expectedData, data)
+			}
+			for key, value := range testCase.expectedData {
+				if string(data[key]) != value {
+					t.Errorf("expected %s=%s, got %s", key, value, data[key])
+				}
+			}
+			if !expiry.Equal(testCase.expectedExpiry) {
+				t.Errorf("expected expiry %v, got %v", testCase.expectedExpiry, expiry)
+			}
+		})
+	}
+}
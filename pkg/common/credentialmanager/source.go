@@ -0,0 +1,212 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"context"
+	"time"
+
+	klog "k8s.io/klog/v2"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+// NewSource returns the Source described by the provided configuration.
+func NewSource(cfg *vcfg.CredentialSource) (Source, error) {
+	var source Source
+	switch {
+	case cfg.Vault != nil:
+		vault, err := newVaultSource(cfg.Vault)
+		if err != nil {
+			return nil, err
+		}
+		source = vault
+	case cfg.Exec != nil:
+		source = newExecSource(cfg.Exec)
+	default:
+		return nil, vcfg.ErrInvalidCredentialSource
+	}
+
+	if cfg.Server != "" {
+		source = &serverSource{Source: source, server: cfg.Server}
+	}
+	return source, nil
+}
+
+// serverSource assigns the plain "username" and "password" keys provided by
+// a Source to a single server.
+type serverSource struct {
+	Source
+	server string
+}
+
+// Fetch returns the credentials of the wrapped Source using the
+// "<server>.username" and "<server>.password" keys.
+func (s *serverSource) Fetch(ctx context.Context) (map[string][]byte, time.Time, error) {
+	data, expiry, err := s.Source.Fetch(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	mapped := make(map[string][]byte, len(data))
+	for key, value := range data {
+		switch key {
+		case "username", "password":
+			mapped[s.server+"."+key] = value
+		default:
+			mapped[key] = value
+		}
+	}
+	return mapped, expiry, nil
+}
+
+// WatchSource fetches credentials from source until stop is closed and passes
+// every successfully fetched set to onFetch. Expiring credentials are fetched
+// again halfway through their remaining lifetime.
+func WatchSource(source Source, stop <-chan struct{}, onFetch func(data map[string][]byte)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	go func() {
+		for {
+			delay := refreshSource(ctx, source, onFetch)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}()
+}
+
+// refreshSource fetches credentials from source once and returns how long to
+// wait before fetching them again.
+func refreshSource(ctx context.Context, source Source, onFetch func(data map[string][]byte)) time.Duration {
+	ctx, cancel := context.WithTimeout(ctx, sourceFetchTimeout)
+	defer cancel()
+
+	data, expiry, err := source.Fetch(ctx)
+	if err != nil {
+		klog.Warningf("Failed fetching credentials from source: %v", err)
+		return sourceRetryInterval
+	}
+	onFetch(data)
+	return sourceRefreshDelay(expiry, time.Now())
+}
+
+// sourceRefreshDelay returns how long credentials expiring at expiry can be
+// used before they should be fetched again.
+func sourceRefreshDelay(expiry time.Time, now time.Time) time.Duration {
+	if expiry.IsZero() {
+		return sourceRefreshInterval
+	}
+
+	delay := expiry.Sub(now) / 2
+	if delay < sourceMinRefreshInterval {
+		return sourceMinRefreshInterval
+	}
+	if delay > sourceRefreshInterval {
+		return sourceRefreshInterval
+	}
+	return delay
+}
+
+// WatchSource keeps the cached credentials in sync with the Source until stop
+// is closed. onChange is called with the servers whose credentials changed.
+func (credentialManager *CredentialManager) WatchSource(stop <-chan struct{}, onChange func(servers []string)) error {
+	if credentialManager.Source == nil {
+		return ErrCredentialSourceNotSet
+	}
+
+	source := sourceFunc(func(ctx context.Context) (map[string][]byte, time.Time, error) {
+		credentialManager.sourceLock.Lock()
+		defer credentialManager.sourceLock.Unlock()
+		return credentialManager.fetchSource(ctx)
+	})
+	WatchSource(source, stop, func(data map[string][]byte) {
+		servers, err := credentialManager.Cache.replaceSecretData(data)
+		if err != nil {
+			klog.Warningf("Failed parsing credentials from source, keeping the previous credentials: %q", err)
+			return
+		}
+		if len(servers) > 0 && onChange != nil {
+			klog.V(2).Infof("Credentials rotated in source for servers %v", servers)
+			onChange(servers)
+		}
+	})
+	return nil
+}
+
+// updateCredentialsMapSource fetches the credentials from Source if they are
+// due to be refreshed, and keeps the cached ones otherwise.
+func (credentialManager *CredentialManager) updateCredentialsMapSource() error {
+	credentialManager.sourceLock.Lock()
+	defer credentialManager.sourceLock.Unlock()
+
+	if time.Now().Before(credentialManager.sourceNextRefresh) {
+		klog.V(4).Info("Using cached credentials of the source")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sourceFetchTimeout)
+	defer cancel()
+
+	data, _, err := credentialManager.fetchSource(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = credentialManager.Cache.replaceSecretData(data)
+	return err
+}
+
+// fetchSource fetches the credentials from Source and schedules the next
+// refresh. The caller must hold sourceLock.
+func (credentialManager *CredentialManager) fetchSource(ctx context.Context) (map[string][]byte, time.Time, error) {
+	now := time.Now()
+	credentialManager.sourceLastFetch = now
+	data, expiry, err := credentialManager.Source.Fetch(ctx)
+	if err != nil {
+		credentialManager.sourceNextRefresh = now.Add(sourceRetryInterval)
+		return nil, time.Time{}, err
+	}
+	credentialManager.sourceNextRefresh = now.Add(sourceRefreshDelay(expiry, now))
+	return data, expiry, nil
+}
+
+// ExpireSourceCredentials makes the next GetCredential fetch the credentials
+// from Source again, e.g. after they were rejected by the server. Fetches are
+// still limited to one per sourceMinRefreshInterval.
+func (credentialManager *CredentialManager) ExpireSourceCredentials() {
+	credentialManager.sourceLock.Lock()
+	defer credentialManager.sourceLock.Unlock()
+
+	next := credentialManager.sourceLastFetch.Add(sourceMinRefreshInterval)
+	if next.Before(credentialManager.sourceNextRefresh) {
+		credentialManager.sourceNextRefresh = next
+	}
+}
+
+// sourceFunc adapts a function to the Source interface.
+type sourceFunc func(ctx context.Context) (map[string][]byte, time.Time, error)
+
+// Fetch calls f.
+func (f sourceFunc) Fetch(ctx context.Context) (map[string][]byte, time.Time, error) {
+	return f(ctx)
+}
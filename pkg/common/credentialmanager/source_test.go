@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource returns preset credentials.
+type fakeSource struct {
+	sync.Mutex
+	data    map[string][]byte
+	expiry  time.Time
+	err     error
+	fetches int
+}
+
+func (f *fakeSource) Fetch(_ context.Context) (map[string][]byte, time.Time, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.fetches++
+	return f.data, f.expiry, f.err
+}
+
+func TestServerSource(t *testing.T) {
+	source := &serverSource{
+		Source: &fakeSource{data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("password"),
+			"server_a": []byte("fd01::1"),
+		}},
+		server: "10.0.0.1",
+	}
+
+	data, _, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	expected := map[string][]byte{
+		"10.0.0.1.username": []byte("user"),
+		"10.0.0.1.password": []byte("password"),
+		"server_a":          []byte("fd01::1"),
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+}
+
+func TestSourceRefreshDelay(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name     string
+		expiry   time.Time
+		expected time.Duration
+	}{
+		{"no expiry", time.Time{}, sourceRefreshInterval},
+		{"half of the lifetime", now.Add(4 * time.Minute), 2 * time.Minute},
+		{"long lifetime", now.Add(24 * time.Hour), sourceRefreshInterval},
+		{"short lifetime", now.Add(time.Second), sourceMinRefreshInterval},
+		{"expired", now.Add(-time.Minute), sourceMinRefreshInterval},
+	}
+
+	for _, testCase := range testCases {
+		if delay := sourceRefreshDelay(testCase.expiry, now); delay != testCase.expected {
+			t.Errorf("%s: expected %s, got %s", testCase.name, testCase.expected, delay)
+		}
+	}
+}
+
+func TestCredentialManagerSource(t *testing.T) {
+	source := &fakeSource{data: map[string][]byte{
+		"10.0.0.1.username": []byte("user"),
+		"10.0.0.1.password": []byte("password"),
+	}}
+	credMgr := NewCredentialManager("", "", "", nil)
+	credMgr.Source = source
+
+	rotated := make(chan []string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := credMgr.WatchSource(stop, func(servers []string) {
+		rotated <- servers
+	}); err != nil {
+		t.Fatalf("WatchSource failed: %v", err)
+	}
+
+	select {
+	case servers := <-rotated:
+		if !reflect.DeepEqual(servers, []string{"10.0.0.1"}) {
+			t.Errorf("expected 10.0.0.1 to be reported, got %v", servers)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the fetched credentials")
+	}
+
+	// GetCredential uses the cached credentials until they are due to be refreshed.
+	source.Lock()
+	source.data = map[string][]byte{
+		"10.0.0.1.username": []byte("user"),
+		"10.0.0.1.password": []byte("rotated"),
+	}
+	source.Unlock()
+	credential, err := credMgr.GetCredential("10.0.0.1")
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if credential.Password != "password" {
+		t.Errorf("expected the cached password, got %q", credential.Password)
+	}
+	source.Lock()
+	if source.fetches != 1 {
+		t.Errorf("expected a single fetch, got %d", source.fetches)
+	}
+	source.Unlock()
+
+	// Expired credentials are fetched again, but not more often than sourceMinRefreshInterval.
+	credMgr.ExpireSourceCredentials()
+	if credential, _ = credMgr.GetCredential("10.0.0.1"); credential.Password != "password" {
+		t.Errorf("expected the cached password right after the last fetch, got %q", credential.Password)
+	}
+	credMgr.sourceLock.Lock()
+	credMgr.sourceLastFetch = time.Now().Add(-sourceMinRefreshInterval)
+	credMgr.sourceLock.Unlock()
+	credMgr.ExpireSourceCredentials()
+	credential, err = credMgr.GetCredential("10.0.0.1")
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if credential.Password != "rotated" {
+		t.Errorf("expected the rotated password, got %q", credential.Password)
+	}
+
+	// The cached credentials are used while the source is unavailable.
+	source.Lock()
+	source.err = errors.New("unavailable")
+	source.Unlock()
+	credMgr.sourceLock.Lock()
+	credMgr.sourceNextRefresh = time.Time{}
+	credMgr.sourceLock.Unlock()
+	credential, err = credMgr.GetCredential("10.0.0.1")
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if credential.Password != "rotated" {
+		t.Errorf("expected the cached password, got %q", credential.Password)
+	}
+}
+
+func TestWatchSourceWithoutSource(t *testing.T) {
+	credMgr := NewCredentialManager("", "", "", nil)
+	if err := credMgr.WatchSource(nil, nil); err != ErrCredentialSourceNotSet {
+		t.Errorf("expected %v, got %v", ErrCredentialSourceNotSet, err)
+	}
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package credentialmanager
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	clientv1 "k8s.io/client-go/listers/core/v1"
@@ -36,6 +38,12 @@ type SecretCache struct {
 type Credential struct {
 	User     string `gcfg:"user"`
 	Password string `gcfg:"password"`
+	// Certificate and PrivateKey are the PEM encoded solution user
+	// certificate and private key.
+	Certificate string
+	PrivateKey  string
+	// Token is a SAML bearer token.
+	Token string
 }
 
 // CredentialManager is used to manage vCenter credentials stored as
@@ -46,5 +54,20 @@ type CredentialManager struct {
 	SecretLister           clientv1.SecretLister
 	SecretsDirectory       string
 	secretsDirectoryParsed bool // internal placeholder to identify we parsed the SecretsDirectory
+	Source                 Source
 	Cache                  *SecretCache
+
+	// sourceLock serializes fetches from Source and guards the fetch schedule
+	sourceLock        sync.Mutex
+	sourceLastFetch   time.Time
+	sourceNextRefresh time.Time
+}
+
+// Source provides credentials from outside of the cluster, like a secret store
+// or a credential plugin.
+type Source interface {
+	// Fetch returns the credentials as secret data, using the same keys as a
+	// Kubernetes secret, and the time they expire at. A zero time means the
+	// credentials do not expire.
+	Fetch(ctx context.Context) (map[string][]byte, time.Time, error)
 }
@@ -0,0 +1,263 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	klog "k8s.io/klog/v2"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+// vaultSource reads credentials from HashiCorp Vault, either from a KV
+// version 2 secret or from a dynamic secret whose lease is renewed for as long
+// as Vault allows it.
+type vaultSource struct {
+	cfg    *vcfg.VaultCredentialSource
+	client *http.Client
+
+	leaseLock sync.Mutex
+	lease     *vaultLease
+}
+
+// vaultLease is the dynamic secret currently in use.
+type vaultLease struct {
+	id        string
+	data      map[string][]byte
+	duration  time.Duration
+	renewable bool
+	expiry    time.Time
+}
+
+// vaultResponse is the envelope of Vault API responses.
+type vaultResponse struct {
+	LeaseID       string          `json:"lease_id"`
+	LeaseDuration int             `json:"lease_duration"`
+	Renewable     bool            `json:"renewable"`
+	Data          json.RawMessage `json:"data"`
+	Errors        []string        `json:"errors"`
+}
+
+// vaultKVData is the data of a KV version 2 secret.
+type vaultKVData struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+func newVaultSource(cfg *vcfg.VaultCredentialSource) (*vaultSource, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureFlag}
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading vault CA file %s failed", cfg.CAFile)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("vault CA file %s contains no certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	return &vaultSource{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}, nil
+}
+
+// Fetch returns the credentials stored in Vault.
+func (v *vaultSource) Fetch(ctx context.Context) (map[string][]byte, time.Time, error) {
+	if v.cfg.Engine == vcfg.VaultEngineDynamic {
+		return v.fetchDynamic(ctx)
+	}
+	return v.fetchKV(ctx)
+}
+
+func (v *vaultSource) fetchKV(ctx context.Context) (map[string][]byte, time.Time, error) {
+	resp, err := v.do(ctx, http.MethodGet, v.cfg.Path, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	kv := vaultKVData{}
+	if err := json.Unmarshal(resp.Data, &kv); err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "decoding vault secret %s failed", v.cfg.Path)
+	}
+	if kv.Data == nil {
+		return nil, time.Time{}, fmt.Errorf("vault secret %s is not a KV version 2 secret", v.cfg.Path)
+	}
+
+	data, err := vaultSecretData(kv.Data)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "vault secret %s", v.cfg.Path)
+	}
+	return data, time.Time{}, nil
+}
+
+// fetchDynamic renews the lease of the current dynamic secret and reads a new
+// one once the lease cannot be renewed anymore.
+func (v *vaultSource) fetchDynamic(ctx context.Context) (map[string][]byte, time.Time, error) {
+	v.leaseLock.Lock()
+	defer v.leaseLock.Unlock()
+
+	if lease := v.lease; lease != nil && lease.renewable && time.Now().Before(lease.expiry) {
+		err := v.renew(ctx, lease)
+		if err == nil {
+			return lease.data, lease.expiry, nil
+		}
+		klog.Warningf("Renewing vault lease %s failed, reading a new secret: %v", lease.id, err)
+	}
+
+	resp, err := v.do(ctx, http.MethodGet, v.cfg.Path, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(resp.Data, &raw); err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "decoding vault secret %s failed", v.cfg.Path)
+	}
+	data, err := vaultSecretData(raw)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "vault secret %s", v.cfg.Path)
+	}
+
+	lease := &vaultLease{
+		id:        resp.LeaseID,
+		data:      data,
+		duration:  time.Duration(resp.LeaseDuration) * time.Second,
+		renewable: resp.Renewable && resp.LeaseID != "",
+	}
+	if lease.duration > 0 {
+		lease.expiry = time.Now().Add(lease.duration)
+	}
+	v.lease = lease
+	klog.V(4).Infof("Read vault secret %s with lease %s valid for %s", v.cfg.Path, lease.id, lease.duration)
+
+	return lease.data, lease.expiry, nil
+}
+
+// renew extends the lease by its original duration. A lease that is granted
+// less than half of it is close to its maximum TTL and is not renewed again.
+func (v *vaultSource) renew(ctx context.Context, lease *vaultLease) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"lease_id":  lease.id,
+		"increment": int(lease.duration.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.do(ctx, http.MethodPut, "sys/leases/renew", body)
+	if err != nil {
+		return err
+	}
+
+	granted := time.Duration(resp.LeaseDuration) * time.Second
+	if granted < lease.duration/2 {
+		return fmt.Errorf("lease was only extended by %s", granted)
+	}
+	lease.expiry = time.Now().Add(granted)
+	lease.renewable = resp.Renewable
+	klog.V(4).Infof("Renewed vault lease %s for %s", lease.id, granted)
+	return nil
+}
+
+// do sends a request to the Vault API and decodes the response envelope.
+func (v *vaultSource) do(ctx context.Context, method string, path string, body []byte) (*vaultResponse, error) {
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(v.cfg.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	contents, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &vaultResponse{}
+	if len(contents) > 0 {
+		if err := json.Unmarshal(contents, resp); err != nil {
+			return nil, errors.Wrapf(err, "decoding vault response of %s failed", path)
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from vault for %s: %s", res.StatusCode, path, strings.Join(resp.Errors, "; "))
+	}
+	return resp, nil
+}
+
+// token returns the Vault token, reading TokenFile on every call so that a
+// token rotated by a Vault agent is picked up.
+func (v *vaultSource) token() (string, error) {
+	if v.cfg.TokenFile == "" {
+		return v.cfg.Token, nil
+	}
+	token, err := os.ReadFile(v.cfg.TokenFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading vault token file %s failed", v.cfg.TokenFile)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// vaultSecretData converts the fields of a Vault secret to secret data.
+func vaultSecretData(fields map[string]interface{}) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(fields))
+	for key, value := range fields {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %s is not a string", key)
+		}
+		data[key] = []byte(str)
+	}
+	return data, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
+const testVaultToken = "s.test-token"
+
+// fakeVault serves the subset of the Vault API used by vaultSource.
+type fakeVault struct {
+	sync.Mutex
+	namespace string
+	kv        map[string]interface{}
+	// secrets is the number of dynamic secrets read so far.
+	secrets int
+	// renewals is the number of lease renewals so far.
+	renewals int
+	// grant is the lease duration granted on renewal.
+	grant int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != testVaultToken || r.Header.Get("X-Vault-Namespace") != f.namespace {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	var resp interface{}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/vsphere":
+		resp = map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     f.kv,
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/vsphere/creds/cpi":
+		f.secrets++
+		resp = map[string]interface{}{
+			"lease_id":       fmt.Sprintf("vsphere/creds/cpi/%d", f.secrets),
+			"lease_duration": 60,
+			"renewable":      true,
+			"data": map[string]interface{}{
+				"username": fmt.Sprintf("user-%d", f.secrets),
+				"password": "password",
+			},
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/sys/leases/renew":
+		req := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["lease_id"] != fmt.Sprintf("vsphere/creds/cpi/%d", f.secrets) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid lease"]}`))
+			return
+		}
+		f.renewals++
+		resp = map[string]interface{}{
+			"lease_id":       req["lease_id"],
+			"lease_duration": f.grant,
+			"renewable":      true,
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestVaultSourceKV(t *testing.T) {
+	vault := &fakeVault{
+		namespace: "team",
+		kv: map[string]interface{}{
+			"10.0.0.1.username": "user",
+			"10.0.0.1.password": "password",
+		},
+	}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(testVaultToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := newVaultSource(&vcfg.VaultCredentialSource{
+		Address:   server.URL + "/",
+		Path:      "/secret/data/vsphere",
+		Engine:    vcfg.VaultEngineKV,
+		Namespace: "team",
+		TokenFile: tokenFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, expiry, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !expiry.IsZero() {
+		t.Errorf("expected KV secrets not to expire, got %v", expiry)
+	}
+	if string(data["10.0.0.1.username"]) != "user" || string(data["10.0.0.1.password"]) != "password" {
+		t.Errorf("unexpected data %v", data)
+	}
+
+	// A token rotated in the token file is used right away.
+	if err := os.WriteFile(tokenFile, []byte("s.revoked"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := source.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+
+	vault.kv["10.0.0.1.port"] = 443
+	source.cfg.TokenFile = ""
+	source.cfg.Token = testVaultToken
+	if _, _, err := source.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "not a string") {
+		t.Errorf("expected an error for a non-string field, got %v", err)
+	}
+}
+
+func TestVaultSourceDynamic(t *testing.T) {
+	vault := &fakeVault{grant: 60}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	source, err := newVaultSource(&vcfg.VaultCredentialSource{
+		Address: server.URL,
+		Path:    "vsphere/creds/cpi",
+		Engine:  vcfg.VaultEngineDynamic,
+		Token:   testVaultToken,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetch := func() map[string][]byte {
+		t.Helper()
+		data, expiry, err := source.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if expiry.IsZero() {
+			t.Error("expected dynamic secrets to expire")
+		}
+		return data
+	}
+
+	if data := fetch(); string(data["username"]) != "user-1" {
+		t.Fatalf("expected the first secret, got %v", data)
+	}
+
+	// The lease is renewed and the credentials are kept.
+	if data := fetch(); string(data["username"]) != "user-1" {
+		t.Errorf("expected the renewed secret, got %v", data)
+	}
+	if vault.renewals != 1 || vault.secrets != 1 {
+		t.Errorf("expected one renewal of one secret, got %d renewals of %d secrets", vault.renewals, vault.secrets)
+	}
+
+	// A lease close to its maximum TTL is replaced by a new secret.
+	vault.grant = 5
+	if data := fetch(); string(data["username"]) != "user-2" {
+		t.Errorf("expected a new secret, got %v", data)
+	}
+	if vault.renewals != 2 || vault.secrets != 2 {
+		t.Errorf("expected two renewals of two secrets, got %d renewals of %d secrets", vault.renewals, vault.secrets)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+// WatchSecretsDirectory watches the SecretsDirectory and reloads the cached
+// credentials whenever its content changes. Watching the directory rather than
+// the individual files also catches the atomic "..data" symlink swap Kubernetes
+// uses to update mounted secrets. onChange is called with the servers whose
+// credentials were rotated. The watch ends once stop is closed.
+func (credentialManager *CredentialManager) WatchSecretsDirectory(stop <-chan struct{}, onChange func(servers []string)) error {
+	if credentialManager.SecretsDirectory == "" {
+		return ErrSecretsDirectoryNotSet
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(credentialManager.SecretsDirectory); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	// Prime the cache so that the first change only reports actual rotations.
+	if err := credentialManager.updateCredentialsMapFile(); err != nil {
+		klog.Warningf("Failed parsing SecretsDirectory %q: %q", credentialManager.SecretsDirectory, err)
+	}
+
+	go func() {
+		defer func() {
+			_ = watcher.Close()
+		}()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				klog.Warningf("Secrets directory watcher for %s receives err: %v", credentialManager.SecretsDirectory, err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				klog.V(5).Infof("Secrets directory watcher receives %s", event)
+				debounce = time.After(secretsDirectoryDebounce)
+			case <-debounce:
+				debounce = nil
+				servers, err := credentialManager.reloadSecretsDirectory()
+				if err != nil {
+					klog.Warningf("Failed reloading SecretsDirectory %q, keeping the previous credentials: %q", credentialManager.SecretsDirectory, err)
+					continue
+				}
+				if len(servers) > 0 && onChange != nil {
+					klog.V(2).Infof("Credentials rotated in SecretsDirectory %q for servers %v", credentialManager.SecretsDirectory, servers)
+					onChange(servers)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reloadSecretsDirectory re-reads the SecretsDirectory and returns the servers
+// whose credentials were added or changed.
+func (credentialManager *CredentialManager) reloadSecretsDirectory() ([]string, error) {
+	data, err := credentialManager.readSecretsDirectory()
+	if err != nil {
+		return nil, err
+	}
+	return credentialManager.Cache.replaceSecretData(data)
+}
+
+// WatchSecret reloads the cached credentials whenever the Kubernetes secret
+// SecretName in SecretNamespace changes. informer is the secret informer
+// SecretLister is created from. onChange is called with the servers whose
+// credentials were rotated. The watch ends once stop is closed.
+func (credentialManager *CredentialManager) WatchSecret(informer cache.SharedIndexInformer, stop <-chan struct{}, onChange func(servers []string)) error {
+	if credentialManager.SecretName == "" || credentialManager.SecretLister == nil {
+		return ErrSecretNotSet
+	}
+
+	// Prime the cache so that the first event only reports actual rotations.
+	if err := credentialManager.updateCredentialsMapK8s(); err != nil {
+		klog.V(4).Infof("Failed reading secret %s/%s: %q", credentialManager.SecretNamespace, credentialManager.SecretName, err)
+	}
+
+	onSecret := func(obj interface{}) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok || secret.Namespace != credentialManager.SecretNamespace || secret.Name != credentialManager.SecretName {
+			return
+		}
+		servers, err := credentialManager.reloadSecret(secret)
+		if err != nil {
+			klog.Warningf("Failed parsing secret %s/%s, keeping the previous credentials: %q", secret.Namespace, secret.Name, err)
+			return
+		}
+		if len(servers) > 0 && onChange != nil {
+			klog.V(2).Infof("Credentials rotated in secret %s/%s for servers %v", secret.Namespace, secret.Name, servers)
+			onChange(servers)
+		}
+	}
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onSecret,
+		UpdateFunc: func(_, obj interface{}) { onSecret(obj) },
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-stop
+		if err := informer.RemoveEventHandler(registration); err != nil {
+			klog.Warningf("Failed removing the watch of secret %s/%s: %v", credentialManager.SecretNamespace, credentialManager.SecretName, err)
+		}
+	}()
+	return nil
+}
+
+// reloadSecret replaces the cached credentials with the ones of secret, unless
+// it is the cached version already, and returns the servers whose credentials
+// were added or changed.
+func (credentialManager *CredentialManager) reloadSecret(secret *corev1.Secret) ([]string, error) {
+	cached := credentialManager.Cache.GetSecret()
+	if cached != nil && cached.GetResourceVersion() == secret.GetResourceVersion() {
+		return nil, nil
+	}
+	servers, err := credentialManager.Cache.replaceSecretData(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	credentialManager.Cache.UpdateSecret(secret)
+	return servers, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialmanager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeProjectedSecret mimics the atomic writer Kubernetes uses for secret
+// volumes: the data is written to a new timestamped directory, which then
+// replaces the previous one by renaming a "..data" symlink over it.
+func writeProjectedSecret(t *testing.T, dir, version string, data map[string]string) {
+	t.Helper()
+
+	tsDir := filepath.Join(dir, "..ts_"+version)
+	if err := os.Mkdir(tsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range data {
+		if err := os.WriteFile(filepath.Join(tsDir, key), []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, _ := os.Readlink(filepath.Join(dir, "..data"))
+	if err := os.Symlink(filepath.Base(tsDir), filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	for key := range data {
+		link := filepath.Join(dir, key)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join("..data", key), link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if previous != "" {
+		if err := os.RemoveAll(filepath.Join(dir, previous)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWatchSecretsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectedSecret(t, dir, "1", map[string]string{
+		"10.0.0.1.username": "user",
+		"10.0.0.1.password": "password",
+		"10.0.0.2.username": "other-user",
+		"10.0.0.2.password": "other-password",
+	})
+
+	credMgr := NewCredentialManager("", "", dir, nil)
+	rotated := make(chan []string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := credMgr.WatchSecretsDirectory(stop, func(servers []string) {
+		rotated <- servers
+	}); err != nil {
+		t.Fatalf("WatchSecretsDirectory failed: %v", err)
+	}
+
+	credential, err := credMgr.GetCredential("10.0.0.1")
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if credential.Password != "password" {
+		t.Fatalf("expected initial password, got %q", credential.Password)
+	}
+
+	writeProjectedSecret(t, dir, "2", map[string]string{
+		"10.0.0.1.username": "user",
+		"10.0.0.1.password": "rotated-password",
+		"10.0.0.2.username": "other-user",
+		"10.0.0.2.password": "other-password",
+	})
+
+	select {
+	case servers := <-rotated:
+		if !reflect.DeepEqual(servers, []string{"10.0.0.1"}) {
+			t.Errorf("expected only 10.0.0.1 to be rotated, got %v", servers)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the rotated credentials")
+	}
+
+	credential, err = credMgr.GetCredential("10.0.0.1")
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if credential.User != "user" || credential.Password != "rotated-password" {
+		t.Errorf("expected rotated credentials, got %+v", credential)
+	}
+}
+
+func TestWatchSecretsDirectoryWithoutDirectory(t *testing.T) {
+	credMgr := NewCredentialManager("", "", "", nil)
+	if err := credMgr.WatchSecretsDirectory(nil, nil); err != ErrSecretsDirectoryNotSet {
+		t.Errorf("expected %v, got %v", ErrSecretsDirectoryNotSet, err)
+	}
+}
+
+func TestReplaceSecretFile(t *testing.T) {
+	cache := &SecretCache{
+		VirtualCenter: map[string]*Credential{
+			"10.0.0.1": {User: "user", Password: "password"},
+		},
+	}
+
+	// A partially written secret must not replace the cached credentials.
+	if _, err := cache.replaceSecretData(map[string][]byte{
+		"10.0.0.1.username": []byte("user"),
+	}); err != ErrCredentialMissing {
+		t.Fatalf("expected %v, got %v", ErrCredentialMissing, err)
+	}
+	if credential, _ := cache.GetCredential("10.0.0.1"); credential.Password != "password" {
+		t.Errorf("expected cached credentials to be kept, got %+v", credential)
+	}
+
+	changed, err := cache.replaceSecretData(map[string][]byte{
+		"10.0.0.1.username": []byte("user"),
+		"10.0.0.1.password": []byte("password"),
+		"10.0.0.2.username": []byte("user"),
+		"10.0.0.2.password": []byte("password"),
+	})
+	if err != nil {
+		t.Fatalf("replaceSecretData failed: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"10.0.0.2"}) {
+		t.Errorf("expected only 10.0.0.2 to be reported, got %v", changed)
+	}
+}
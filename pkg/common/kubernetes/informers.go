@@ -118,6 +118,29 @@ func (im *InformerManager) IsNodeInformerSynced() cache.InformerSynced {
 	return im.informerFactory.Core().V1().Nodes().Informer().HasSynced
 }
 
+// AddPodListener hooks up add, update, delete callbacks
+func (im *InformerManager) AddPodListener(add, remove func(obj interface{}), update func(oldObj, newObj interface{})) {
+	if im.podInformer == nil {
+		im.podInformer = im.informerFactory.Core().V1().Pods().Informer()
+	}
+
+	im.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    add,
+		UpdateFunc: update,
+		DeleteFunc: remove,
+	})
+}
+
+// GetPodLister creates a lister to use
+func (im *InformerManager) GetPodLister() listerv1.PodLister {
+	return im.informerFactory.Core().V1().Pods().Lister()
+}
+
+// IsPodInformerSynced returns whether pod informer is synced
+func (im *InformerManager) IsPodInformerSynced() cache.InformerSynced {
+	return im.informerFactory.Core().V1().Pods().Informer().HasSynced
+}
+
 // Listen starts the Informers. Based on client-go informer package, if the Lister has
 // already been initialized, it will not re-init them. Only new non-init Listers will be initialized.
 func (im *InformerManager) Listen() {
@@ -38,4 +38,7 @@ type InformerManager struct {
 
 	// node informer
 	nodeInformer cache.SharedInformer
+
+	// pod informer
+	podInformer cache.SharedInformer
 }
@@ -18,11 +18,18 @@ package vclib
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/pem"
+	"encoding/xml"
+	"fmt"
 	"net"
 	neturl "net/url"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/sts"
@@ -46,7 +53,27 @@ type VSphereConnection struct {
 	Thumbprint        string
 	Insecure          bool
 	RoundTripperCount uint
-	credentialsLock   sync.Mutex
+	// AuthMode selects how to log in: AuthModePassword, AuthModeCertificate
+	// or AuthModeToken. If empty, a PEM encoded Username is used as solution
+	// user certificate with Password as its private key.
+	AuthMode string
+	// Certificate and PrivateKey are the PEM encoded solution user certificate
+	// and private key used with AuthModeCertificate. They take precedence
+	// over CertFile and KeyFile, which are read on every login otherwise.
+	Certificate string
+	PrivateKey  string
+	CertFile    string
+	KeyFile     string
+	// Token is the SAML bearer token used with AuthModeToken. It takes
+	// precedence over TokenFile, which is read on every login otherwise.
+	Token           string
+	TokenFile       string
+	credentialsLock sync.Mutex
+	// tokenExpiry is when the SAML token of the current session expires.
+	tokenExpiry time.Time
+	// tokenDigest identifies the certificate or token the current session
+	// was established with.
+	tokenDigest string
 }
 
 var (
@@ -55,7 +82,9 @@ var (
 
 // Connect makes connection to vCenter and sets VSphereConnection.Client.
 // If connection.Client is already set, it obtains the existing user session.
-// if user session is not valid, connection.Client will be set to the new client.
+// If the user session is not valid, a new session is created for connection.Client.
+// The same happens if the session was established with a SAML token that is about
+// to expire, or with a certificate or token that changed since.
 func (connection *VSphereConnection) Connect(ctx context.Context) error {
 	var err error
 	clientLock.Lock()
@@ -75,31 +104,102 @@ func (connection *VSphereConnection) Connect(ctx context.Context) error {
 		klog.Errorf("Error while obtaining user session. err: %+v", err)
 		return err
 	}
-	if userSession != nil {
+	if userSession == nil {
+		klog.Warning("Creating new client session since the existing session is not valid or not authenticated")
+	} else if connection.tokenNeedsRenewal() {
+		klog.V(2).Info("Creating new client session since the SAML token is about to expire or its certificate changed")
+	} else {
 		return nil
 	}
-	klog.Warning("Creating new client session since the existing session is not valid or not authenticated")
 
-	connection.Client, err = connection.NewClient(ctx)
+	client, err := connection.NewClient(ctx)
 	if err != nil {
 		klog.Errorf("Failed to create govmomi client. err: %+v", err)
 		return err
 	}
+	connection.adoptSession(ctx, client, userSession != nil)
 	return nil
 }
 
+// Relogin creates a new session with the current credentials and replaces the
+// existing one with it. The existing session is logged out only once the new
+// login succeeded, so it keeps being used if the new credentials are rejected.
+// Like Connect, it holds clientLock while creating the new client, as NewClient
+// updates the connection.
+func (connection *VSphereConnection) Relogin(ctx context.Context) error {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	client, err := connection.NewClient(ctx)
+	if err != nil {
+		klog.Errorf("Failed to create govmomi client. err: %+v", err)
+		return err
+	}
+	if connection.Client == nil {
+		connection.Client = client
+		return nil
+	}
+	connection.adoptSession(ctx, client, true)
+	return nil
+}
+
+// adoptSession moves the session of client to connection.Client, so that the
+// objects bound to connection.Client, like the VMs and datacenters cached for
+// the nodes, use the new session as well. If logout is set, the previous
+// session of connection.Client is logged out with client afterwards.
+func (connection *VSphereConnection) adoptSession(ctx context.Context, client *vim25.Client, logout bool) {
+	u := connection.Client.URL()
+	previous := connection.Client.Jar.Cookies(u)
+	connection.Client.Jar.SetCookies(u, client.Jar.Cookies(client.URL()))
+	if !logout {
+		return
+	}
+	client.Jar.SetCookies(client.URL(), previous)
+	if err := session.NewManager(client).Logout(ctx); err != nil {
+		klog.Warningf("Logout of the previous session failed: %s", err)
+	}
+}
+
 // Signer returns an sts.Signer for use with SAML token auth if connection is configured for such.
 // Returns nil if username/password auth is configured for the connection.
 func (connection *VSphereConnection) Signer(ctx context.Context, client *vim25.Client) (*sts.Signer, error) {
-	// TODO: Add separate fields for certificate and private-key.
-	// For now we can leave the config structs and validation as-is and
-	// decide to use LoginByToken if the username value is PEM encoded.
-	b, _ := pem.Decode([]byte(connection.Username))
-	if b == nil {
+	switch connection.AuthMode {
+	case AuthModeCertificate:
+		cert, key, err := connection.certificateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		return issueToken(ctx, client, cert, key)
+	case AuthModeToken:
+		token, err := connection.bearerToken()
+		if err != nil {
+			return nil, err
+		}
+		signer := &sts.Signer{Token: token}
+		signer.Lifetime.Expires, err = tokenExpiry(token)
+		if err != nil {
+			klog.Warningf("Failed to read the lifetime of the SAML token. err: %+v", err)
+		}
+		return signer, nil
+	case "":
+		// Deprecated: decide to use LoginByToken if the username value is
+		// PEM encoded. Use AuthModeCertificate instead.
+		b, _ := pem.Decode([]byte(connection.Username))
+		if b == nil {
+			return nil, nil
+		}
+		return issueToken(ctx, client, []byte(connection.Username), []byte(connection.Password))
+	case AuthModePassword:
 		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth mode %q", connection.AuthMode)
 	}
+}
 
-	cert, err := tls.X509KeyPair([]byte(connection.Username), []byte(connection.Password))
+// issueToken issues a SAML token for the provided solution user certificate
+// and private key.
+func issueToken(ctx context.Context, client *vim25.Client, certPEM []byte, keyPEM []byte) (*sts.Signer, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
 		klog.Errorf("Failed to load X509 key pair. err: %+v", err)
 		return nil, err
@@ -114,6 +214,7 @@ func (connection *VSphereConnection) Signer(ctx context.Context, client *vim25.C
 	req := sts.TokenRequest{
 		Certificate: &cert,
 		Delegatable: true,
+		Lifetime:    TokenLifetime,
 	}
 
 	signer, err := tokens.Issue(ctx, req)
@@ -142,11 +243,121 @@ func (connection *VSphereConnection) login(ctx context.Context, client *vim25.Cl
 		return m.Login(ctx, neturl.UserPassword(connection.Username, connection.Password))
 	}
 
-	klog.V(3).Infof("SessionManager.LoginByToken with certificate %q", connection.Username)
+	klog.V(3).Infof("SessionManager.LoginByToken with auth mode %q", connection.AuthMode)
 
 	header := soap.Header{Security: signer}
 
-	return m.LoginByToken(client.WithHeader(ctx, header))
+	if err := m.LoginByToken(client.WithHeader(ctx, header)); err != nil {
+		return err
+	}
+
+	connection.tokenExpiry = signer.Lifetime.Expires
+	connection.tokenDigest, _ = connection.currentTokenDigest()
+	if !connection.tokenExpiry.IsZero() {
+		klog.V(3).Infof("SAML token of the session expires at %s", connection.tokenExpiry)
+	}
+	return nil
+}
+
+// tokenNeedsRenewal returns true if the session was established with a SAML
+// token for a solution user certificate that is about to expire, or with a
+// certificate or token that changed since.
+func (connection *VSphereConnection) tokenNeedsRenewal() bool {
+	connection.credentialsLock.Lock()
+	defer connection.credentialsLock.Unlock()
+
+	if connection.AuthMode != AuthModeCertificate && connection.AuthMode != AuthModeToken {
+		return false
+	}
+	// a new token can only be issued for a certificate
+	if connection.AuthMode == AuthModeCertificate && !connection.tokenExpiry.IsZero() &&
+		time.Until(connection.tokenExpiry) < TokenRenewalWindow {
+		return true
+	}
+
+	digest, err := connection.currentTokenDigest()
+	if err != nil {
+		klog.Warningf("Failed to read the credentials of auth mode %q. err: %+v", connection.AuthMode, err)
+		return false
+	}
+	return digest != connection.tokenDigest
+}
+
+// currentTokenDigest returns a digest of the current certificate and private
+// key, or token, of the connection.
+func (connection *VSphereConnection) currentTokenDigest() (string, error) {
+	h := sha256.New()
+	switch connection.AuthMode {
+	case AuthModeCertificate:
+		cert, key, err := connection.certificateKeyPair()
+		if err != nil {
+			return "", err
+		}
+		h.Write(cert)
+		h.Write(key)
+	case AuthModeToken:
+		token, err := connection.bearerToken()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(token))
+	default:
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// certificateKeyPair returns the PEM encoded solution user certificate and
+// private key of the connection.
+func (connection *VSphereConnection) certificateKeyPair() ([]byte, []byte, error) {
+	if connection.Certificate != "" || connection.PrivateKey != "" {
+		return []byte(connection.Certificate), []byte(connection.PrivateKey), nil
+	}
+	if connection.CertFile == "" || connection.KeyFile == "" {
+		return nil, nil, ErrNoAuthCredentials
+	}
+
+	cert, err := os.ReadFile(connection.CertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := os.ReadFile(connection.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// bearerToken returns the SAML bearer token of the connection.
+func (connection *VSphereConnection) bearerToken() (string, error) {
+	if connection.Token != "" {
+		return connection.Token, nil
+	}
+	if connection.TokenFile == "" {
+		return "", ErrNoAuthCredentials
+	}
+
+	token, err := os.ReadFile(connection.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// tokenExpiry returns when the provided SAML token expires.
+func tokenExpiry(token string) (time.Time, error) {
+	var assertion struct {
+		Conditions struct {
+			NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+		} `xml:"Conditions"`
+	}
+	if err := xml.Unmarshal([]byte(token), &assertion); err != nil {
+		return time.Time{}, err
+	}
+	if assertion.Conditions.NotOnOrAfter == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, assertion.Conditions.NotOnOrAfter)
 }
 
 // Logout calls SessionManager.Logout for the given connection.
@@ -202,3 +413,21 @@ func (connection *VSphereConnection) UpdateCredentials(username string, password
 	connection.Username = username
 	connection.Password = password
 }
+
+// UpdateCertificate updates the PEM encoded solution user certificate and
+// private key used with AuthModeCertificate.
+// Note: A session established with a different certificate is renewed on the next Connect
+func (connection *VSphereConnection) UpdateCertificate(certificate string, privateKey string) {
+	connection.credentialsLock.Lock()
+	defer connection.credentialsLock.Unlock()
+	connection.Certificate = certificate
+	connection.PrivateKey = privateKey
+}
+
+// UpdateToken updates the SAML bearer token used with AuthModeToken.
+// Note: A session established with a different token is renewed on the next Connect
+func (connection *VSphereConnection) UpdateToken(token string) {
+	connection.credentialsLock.Lock()
+	defer connection.credentialsLock.Unlock()
+	connection.Token = token
+}
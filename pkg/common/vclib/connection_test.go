@@ -26,10 +26,19 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
+	_ "github.com/vmware/govmomi/lookup/simulator"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/sts/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
 
 	"k8s.io/cloud-provider-vsphere/pkg/common/vclib"
 	"k8s.io/cloud-provider-vsphere/pkg/common/vclib/fixtures"
@@ -184,6 +193,176 @@ func TestInvalidCaCert(t *testing.T) {
 	}
 }
 
+func TestCertificateAuthMode(t *testing.T) {
+	ctx := context.Background()
+	connection, cleanup := newSimulatorConnection(t)
+	defer cleanup()
+
+	connection.AuthMode = vclib.AuthModeCertificate
+	if err := connection.Connect(ctx); !errors.Is(err, vclib.ErrNoAuthCredentials) {
+		t.Fatalf("Expected %s without a certificate, got: %v", vclib.ErrNoAuthCredentials, err)
+	}
+
+	dir := t.TempDir()
+	connection.CertFile = filepath.Join(dir, "solution-user.crt")
+	connection.KeyFile = filepath.Join(dir, "solution-user.key")
+	copyFile(t, fixtures.ServerCertPath, connection.CertFile)
+	copyFile(t, fixtures.ServerKeyPath, connection.KeyFile)
+	if err := connection.Connect(ctx); err != nil {
+		t.Fatalf("Failed to log in with a solution user certificate: %s", err)
+	}
+	client, key := connection.Client, sessionKey(t, connection)
+	datacenter, err := vclib.GetDatacenter(ctx, connection, vclib.TestDefaultDatacenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the simulator issues tokens valid for less than vclib.TokenRenewalWindow
+	if err := connection.Connect(ctx); err != nil {
+		t.Fatalf("Failed to renew the SAML token: %s", err)
+	}
+	if sessionKey(t, connection) == key {
+		t.Error("Expected a new session for a SAML token about to expire")
+	}
+	if connection.Client != client {
+		t.Error("Expected the client to be kept for the new session")
+	}
+	if _, err := datacenter.Folders(ctx); err != nil {
+		t.Errorf("Expected objects of the client to use the new session: %s", err)
+	}
+	var sm mo.SessionManager
+	if err := property.DefaultCollector(client).RetrieveOne(ctx, *client.ServiceContent.SessionManager, []string{"sessionList"}, &sm); err != nil {
+		t.Fatal(err)
+	}
+	for _, userSession := range sm.SessionList {
+		if userSession.Key == key {
+			t.Error("Expected the previous session to be logged out")
+		}
+	}
+}
+
+func TestTokenAuthMode(t *testing.T) {
+	ctx := context.Background()
+	connection, cleanup := newSimulatorConnection(t)
+	defer cleanup()
+
+	connection.AuthMode = vclib.AuthModeToken
+	connection.TokenFile = filepath.Join(t.TempDir(), "token.xml")
+	writeToken(t, connection.TokenFile, "cpi@vsphere.local")
+
+	if err := connection.Connect(ctx); err != nil {
+		t.Fatalf("Failed to log in with a SAML token: %s", err)
+	}
+	client, key := connection.Client, sessionKey(t, connection)
+
+	if err := connection.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sessionKey(t, connection) != key {
+		t.Error("Expected the session to be kept for an unchanged token")
+	}
+
+	writeToken(t, connection.TokenFile, "cpi-rotated@vsphere.local")
+	if err := connection.Connect(ctx); err != nil {
+		t.Fatalf("Failed to log in with the rotated SAML token: %s", err)
+	}
+	if sessionKey(t, connection) == key {
+		t.Error("Expected a new session for a rotated token")
+	}
+	if connection.Client != client {
+		t.Error("Expected the client to be kept for the new session")
+	}
+
+	connection.UpdateToken("")
+	connection.TokenFile = ""
+	if _, err := connection.NewClient(ctx); !errors.Is(err, vclib.ErrNoAuthCredentials) {
+		t.Errorf("Expected %s without a token, got: %v", vclib.ErrNoAuthCredentials, err)
+	}
+}
+
+func TestReloginWhileConnecting(t *testing.T) {
+	ctx := context.Background()
+	connection, cleanup := newSimulatorConnection(t)
+	defer cleanup()
+
+	connection.AuthMode = vclib.AuthModeToken
+	connection.TokenFile = filepath.Join(t.TempDir(), "token.xml")
+	writeToken(t, connection.TokenFile, "cpi@vsphere.local")
+
+	// credential rotation may re-login while the connection is established
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := connection.Relogin(ctx); err != nil {
+				t.Errorf("Relogin failed: %s", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := connection.Connect(ctx); err != nil {
+				t.Errorf("Connect failed: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// sessionKey returns the key of the current session of the connection
+func sessionKey(t *testing.T, connection *vclib.VSphereConnection) string {
+	userSession, err := session.NewManager(connection.Client).UserSession(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userSession == nil {
+		t.Fatal("Expected an authenticated session")
+	}
+	return userSession.Key
+}
+
+func newSimulatorConnection(t *testing.T) (*vclib.VSphereConnection, func()) {
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	model.Service.RegisterEndpoints = true
+	model.Service.TLS = new(tls.Config)
+	s := model.Service.NewServer()
+
+	connection := &vclib.VSphereConnection{
+		Hostname: s.URL.Hostname(),
+		Port:     s.URL.Port(),
+		Insecure: true,
+	}
+	return connection, func() {
+		s.Close()
+		model.Remove()
+	}
+}
+
+func copyFile(t *testing.T, src string, dst string) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeToken writes a minimal SAML bearer token for subject, which is all
+// the simulator checks.
+func writeToken(t *testing.T, path string, subject string) {
+	token := fmt.Sprintf(`<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion">`+
+		`<saml2:Subject><saml2:NameID>%s</saml2:NameID></saml2:Subject>`+
+		`<saml2:Conditions NotOnOrAfter="%s"/></saml2:Assertion>`,
+		subject, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(token), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func verifyWrappedX509UnkownAuthorityErr(t *testing.T, err error) {
 	urlErr, ok := err.(*url.Error)
 	if !ok {
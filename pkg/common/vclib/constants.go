@@ -16,6 +16,8 @@ limitations under the License.
 
 package vclib
 
+import "time"
+
 // FindFCD is the type that represents the types of searches used to
 // discover FCDs.
 type FindFCD int
@@ -113,6 +115,24 @@ const (
 	ActivePowerState = "poweredOn"
 )
 
+// Authentication Constants
+const (
+	// AuthModePassword logs in with a username and password.
+	AuthModePassword = "password"
+	// AuthModeCertificate logs in with a SAML token issued by the STS for a
+	// solution user certificate and private key.
+	AuthModeCertificate = "certificate"
+	// AuthModeToken logs in with a SAML bearer token issued beforehand.
+	AuthModeToken = "token"
+
+	// TokenLifetime is the lifetime requested for SAML tokens issued for a
+	// solution user certificate.
+	TokenLifetime = time.Hour
+	// TokenRenewalWindow is how long before its SAML token expires a session
+	// established with a solution user certificate is replaced.
+	TokenRenewalWindow = 5 * time.Minute
+)
+
 // Test Constants
 const (
 	// TestDefaultDatacenter is a good constant, yes it is!
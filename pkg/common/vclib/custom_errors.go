@@ -30,6 +30,7 @@ const (
 	NoDatastoreFoundErrMsg         = "Datastore not found"
 	NoDatacenterFoundErrMsg        = "Datacenter not found"
 	NoDataStoreClustersFoundErrMsg = "No DatastoreClusters Found"
+	NoAuthCredentialsErrMsg        = "No certificate or token found for the auth mode"
 )
 
 // Error constants
@@ -44,4 +45,5 @@ var (
 	ErrNoDatastoreFound         = errors.New(NoDatastoreFoundErrMsg)
 	ErrNoDatacenterFound        = errors.New(NoDatacenterFoundErrMsg)
 	ErrNoDataStoreClustersFound = errors.New(NoDataStoreClustersFoundErrMsg)
+	ErrNoAuthCredentials        = errors.New(NoAuthCredentialsErrMsg)
 )
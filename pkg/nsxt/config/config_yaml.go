@@ -45,6 +45,9 @@ func (ncy *NsxtConfigYAML) CreateConfig() *Config {
 	cfg.CAFile = ncy.NSXT.CAFile
 	cfg.SecretName = ncy.NSXT.SecretName
 	cfg.SecretNamespace = ncy.NSXT.SecretNamespace
+	if ncy.NSXT.CredentialSource != nil {
+		cfg.CredentialSource = ncy.NSXT.CredentialSource.CreateConfig()
+	}
 
 	return cfg
 }
@@ -75,6 +78,10 @@ func (cfg *NsxtYAML) validateConfig() error {
 		if cfg.SecretName == "" {
 			return errors.New("secret name is required if secret namespace is provided")
 		}
+	} else if cfg.CredentialSource != nil {
+		if err := cfg.CredentialSource.Validate(); err != nil {
+			return err
+		}
 	} else {
 		return errors.New("user or vmc access token or client cert file must be set")
 	}
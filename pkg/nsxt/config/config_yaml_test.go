@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
 )
 
 func TestYAMLValidateTokenConfig(t *testing.T) {
@@ -154,6 +156,22 @@ func TestYAMLValidateSecretConfig(t *testing.T) {
 	}
 }
 
+func TestYAMLValidateCredentialSourceConfig(t *testing.T) {
+	cfg := &NsxtYAML{
+		CredentialSource: &vcfg.CredentialSourceYAML{},
+	}
+	err := cfg.validateConfig()
+	assert.ErrorIs(t, err, vcfg.ErrInvalidCredentialSource)
+
+	cfg.CredentialSource.Exec = &vcfg.ExecCredentialSourceYAML{Command: "/bin/plugin"}
+	err = cfg.validateConfig()
+	assert.EqualError(t, err, "host is empty")
+
+	cfg.Host = "server"
+	err = cfg.validateConfig()
+	assert.Nil(t, err)
+}
+
 func TestReadRawConfigYAML(t *testing.T) {
 	contents := `
 nsxt:
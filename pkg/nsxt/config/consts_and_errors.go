@@ -21,4 +21,10 @@ const (
 	UsernameKeyInSecret = "username"
 	// PasswordKeyInSecret is the password key in secret
 	PasswordKeyInSecret = "password"
+	// ClientCertKeyInSecret is the PEM encoded client certificate key in secret
+	ClientCertKeyInSecret = "tls.crt"
+	// ClientKeyKeyInSecret is the PEM encoded client private key key in secret
+	ClientKeyKeyInSecret = "tls.key"
+	// VMCAccessTokenKeyInSecret is the VMC access token key in secret
+	VMCAccessTokenKeyInSecret = "vmcAccessToken"
 )
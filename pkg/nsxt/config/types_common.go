@@ -16,6 +16,10 @@
 
 package config
 
+import (
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
 // Config is used to read and store information from the cloud configuration file
 type Config struct {
 	// NSX-T username.
@@ -32,6 +36,8 @@ type Config struct {
 	SecretName string
 	// SecretNamespace is the secret namespace for NSX-T username and password
 	SecretNamespace string
+	// CredentialSource is an external provider of the NSX-T username and password
+	CredentialSource *vcfg.CredentialSource
 
 	VMCAccessToken     string
 	VMCAuthHost        string
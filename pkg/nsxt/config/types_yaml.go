@@ -16,6 +16,10 @@
 
 package config
 
+import (
+	vcfg "k8s.io/cloud-provider-vsphere/pkg/common/config"
+)
+
 /*
 	TODO:
 	When the INI based cloud-config is deprecated, this file should be renamed
@@ -45,6 +49,8 @@ type NsxtYAML struct {
 	SecretName string `yaml:"secretName"`
 	// SecretNamespace is the secret namespace for NSX-T username and password
 	SecretNamespace string `yaml:"secretNamespace"`
+	// CredentialSource is an external provider of the NSX-T username and password
+	CredentialSource *vcfg.CredentialSourceYAML `yaml:"credentialSource"`
 
 	VMCAccessToken     string `yaml:"vmcAccessToken"`
 	VMCAuthHost        string `yaml:"vmcAuthHost"`
@@ -17,32 +17,66 @@
 package nsxt
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/core"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/protocol/client"
 	"github.com/vmware/vsphere-automation-sdk-go/runtime/security"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/aaa"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/cloud-provider-vsphere/pkg/common/credentialmanager"
 	"k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
 	nsxtcfg "k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
 	klog "k8s.io/klog/v2"
 )
 
+const (
+	// credentialSourceTimeout bounds the initial fetch of the NSXT credentials
+	// from a credential source
+	credentialSourceTimeout = 30 * time.Second
+	// credentialValidationTimeout bounds the test call validating rotated
+	// NSXT credentials
+	credentialValidationTimeout = 30 * time.Second
+)
+
 // ConnectorManager manages NSXT connection
 type ConnectorManager struct {
 	config    *config.Config
 	connector client.Connector
+	transport *http.Transport
+	tlsConfig *tls.Config
+	source    credentialmanager.Source
+	recorder  record.EventRecorder
+
+	// lock guards the fields below
+	lock sync.Mutex
+	// current holds the credentials the connector uses, which are restored
+	// if rotated credentials are rejected
+	current *connectorCredentials
+}
+
+// connectorCredentials holds the security context, client certificate and
+// VMC token source used to authenticate to NSXT
+type connectorCredentials struct {
+	securityCtx core.SecurityContext
+	clientCert  *tls.Certificate
+	vmcTokens   *vmcTokenSource
 }
 
 type remoteBasicAuthHeaderProcessor struct {
@@ -66,8 +100,8 @@ func NewConnectorManager(nsxtConfig *config.Config) (*ConnectorManager, error) {
 		return cm, nil
 	}
 	cm.config = nsxtConfig
-	url := fmt.Sprintf("https://%s", nsxtConfig.Host)
 	var securityCtx *core.SecurityContextImpl
+	var vmcTokens *vmcTokenSource
 	securityContextNeeded := true
 	if len(nsxtConfig.ClientAuthCertFile) > 0 {
 		securityContextNeeded = false
@@ -80,13 +114,11 @@ func NewConnectorManager(nsxtConfig *config.Config) (*ConnectorManager, error) {
 				return nil, fmt.Errorf("vmc auth host must be provided if auth token is provided")
 			}
 
-			apiToken, err := getAPIToken(nsxtConfig.VMCAuthHost, nsxtConfig.VMCAccessToken)
-			if err != nil {
+			// the API token is set on every request by the connector
+			vmcTokens = newVMCTokenSource(nsxtConfig.VMCAuthHost, nsxtConfig.VMCAccessToken)
+			if _, err := vmcTokens.Token(context.Background()); err != nil {
 				return nil, err
 			}
-
-			securityCtx.SetProperty(security.AUTHENTICATION_SCHEME_ID, security.OAUTH_SCHEME_ID)
-			securityCtx.SetProperty(security.ACCESS_TOKEN, apiToken)
 		} else if nsxtConfig.User != "" && nsxtConfig.Password != "" {
 			securityCtx.SetProperty(security.AUTHENTICATION_SCHEME_ID, security.USER_PASSWORD_SCHEME_ID)
 			securityCtx.SetProperty(security.USER_KEY, nsxtConfig.User)
@@ -98,25 +130,82 @@ func NewConnectorManager(nsxtConfig *config.Config) (*ConnectorManager, error) {
 	if err != nil {
 		return nil, err
 	}
-	httpClient := http.Client{
-		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
-			TLSClientConfig: tlsConfig,
-		},
+	cm.current = &connectorCredentials{vmcTokens: vmcTokens}
+	if len(tlsConfig.Certificates) > 0 {
+		cm.current.clientCert = &tlsConfig.Certificates[0]
 	}
+	// the client certificate can be rotated without rebuilding the connector
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cm.lock.Lock()
+		defer cm.lock.Unlock()
+		return clientCertificate(cm.current), nil
+	}
+	cm.tlsConfig = tlsConfig
 
-	connector := client.NewRestConnector(url, httpClient)
+	connector, transport := cm.newConnector(tlsConfig, 0, func() *vmcTokenSource {
+		cm.lock.Lock()
+		defer cm.lock.Unlock()
+		return cm.current.vmcTokens
+	})
 	if securityCtx != nil {
 		connector.SetSecurityContext(securityCtx)
-	}
-	if nsxtConfig.RemoteAuth {
-		connector.AddRequestProcessor(newRemoteBasicAuthHeaderProcessor())
+		cm.current.securityCtx = securityCtx
 	}
 	cm.connector = connector
+	cm.transport = transport
+
+	if nsxtConfig.CredentialSource != nil {
+		source, err := credentialmanager.NewSource(nsxtConfig.CredentialSource)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), credentialSourceTimeout)
+		defer cancel()
+		data, _, err := source.Fetch(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching NSXT credentials from credential source failed")
+		}
+		credentials, err := cm.parseCredentials(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading NSXT credentials from credential source failed")
+		}
+		cm.source = source
+		cm.applyCredentials(credentials)
+	}
 
 	return cm, nil
 }
 
+// newConnector creates a REST connector to NSXT using the provided TLS
+// configuration and the VMC token source returned by vmcTokens, if any.
+// A timeout of 0 means no timeout.
+func (cm *ConnectorManager) newConnector(tlsConfig *tls.Config, timeout time.Duration, vmcTokens func() *vmcTokenSource) (*client.RestConnector, *http.Transport) {
+	url := fmt.Sprintf("https://%s", cm.config.Host)
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	httpClient := http.Client{
+		Transport: &vmcTokenTransport{base: transport, tokens: vmcTokens},
+		Timeout:   timeout,
+	}
+
+	connector := client.NewRestConnector(url, httpClient)
+	if cm.config.RemoteAuth {
+		connector.AddRequestProcessor(newRemoteBasicAuthHeaderProcessor())
+	}
+	return connector, transport
+}
+
+// clientCertificate returns the client certificate of the credentials, or an
+// empty certificate to not present any
+func clientCertificate(credentials *connectorCredentials) *tls.Certificate {
+	if credentials == nil || credentials.clientCert == nil {
+		return &tls.Certificate{}
+	}
+	return credentials.clientCert
+}
+
 // getConnectorTLSConfig loads certificates to build TLS configuration
 func getConnectorTLSConfig(insecure bool, clientCertFile string, clientKeyFile string, caFile string) (*tls.Config, error) {
 	tlsConfig := tls.Config{InsecureSkipVerify: insecure}
@@ -151,50 +240,15 @@ func getConnectorTLSConfig(insecure bool, clientCertFile string, clientKeyFile s
 	return &tlsConfig, nil
 }
 
-type jwtToken struct {
-	IDToken      string `json:"id_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    string `json:"expires_in"`
-	Scope        string `json:"scope"`
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-}
-
-// getAPIToken gets JWT access token
-func getAPIToken(vmcAuthHost string, vmcAccessToken string) (string, error) {
-
-	payload := strings.NewReader("refresh_token=" + vmcAccessToken)
-	req, _ := http.NewRequest("POST", "https://"+vmcAuthHost, payload)
-
-	req.Header.Add("content-type", "application/x-www-form-urlencoded")
-	res, err := http.DefaultClient.Do(req)
-
-	if err != nil {
-		return "", err
-	}
-
-	if res.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(res.Body)
-		return "", fmt.Errorf("Unexpected status code %d trying to get auth token. %s", res.StatusCode, string(b))
-	}
-
-	defer res.Body.Close()
-	token := jwtToken{}
-	err = json.NewDecoder(res.Body).Decode(&token)
-	if err != nil {
-		return "", errors.Wrapf(err, "Decoding token failed with")
-	}
-
-	return token.AccessToken, nil
-}
-
 // GetConnector gets NSXT connector
 func (cm *ConnectorManager) GetConnector() client.Connector {
 	return cm.connector
 }
 
-// AddSecretListener adds secret informer add, update, delete callbacks
-func (cm *ConnectorManager) AddSecretListener(secretInformer v1.SecretInformer) error {
+// AddSecretListener adds secret informer add, update, delete callbacks.
+// If client is set, the outcome of credential rotations is recorded as events
+// on the secret.
+func (cm *ConnectorManager) AddSecretListener(secretInformer v1.SecretInformer, client clientset.Interface) error {
 	if cm.config == nil {
 		return errors.New("config is not available for NSXT connector manager")
 	}
@@ -206,6 +260,14 @@ func (cm *ConnectorManager) AddSecretListener(secretInformer v1.SecretInformer)
 		return errors.New("failed to initialize NSXT secret manager as secret informer is nil")
 	}
 
+	registerMetrics()
+	if client != nil {
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartLogging(klog.Infof)
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+		cm.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "vsphere-nsxt-connector"})
+	}
+
 	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    cm.secretAdded,
 		UpdateFunc: cm.secretUpdated,
@@ -249,43 +311,151 @@ func (cm *ConnectorManager) secretUpdated(oldObj, newObj interface{}) {
 	}
 }
 
-// secretDeleted handles secret deleted event
+// secretDeleted handles secret deleted event. The connector keeps using the
+// last credentials, as the load balancer and route providers have no other
+// connector to fall back to.
 func (cm *ConnectorManager) secretDeleted(obj interface{}) {
 	secret, ok := obj.(*corev1.Secret)
 	if secret == nil || !ok {
 		return
 	}
 	if cm.isForNsxtSecret(secret) {
-		cm.resetConnectorContext()
+		klog.Warningf("NSXT secret %s/%s deleted, keeping the current credentials", secret.Namespace, secret.Name)
+		cm.recordEvent(secret, corev1.EventTypeWarning, "SecretDeleted", "NSXT secret deleted, keeping the current credentials")
 	}
 }
 
-// updateConnectorContext updates security context of connector
+// updateConnectorContext updates security context of connector with the
+// credentials of the secret
 func (cm *ConnectorManager) updateConnectorContext(secret *corev1.Secret) {
-	var username, password string
-	for key, value := range secret.Data {
-		if key == nsxtcfg.UsernameKeyInSecret {
-			username = string(value)
+	cm.rotateCredentials(secret.Data, secret)
+}
+
+// updateConnectorCredentials updates security context of connector with the
+// credentials found in the provided credential source data
+func (cm *ConnectorManager) updateConnectorCredentials(data map[string][]byte) {
+	cm.rotateCredentials(data, nil)
+}
+
+// rotateCredentials validates the credentials found in data with a test call
+// to NSXT before applying them to the connector. Rejected credentials are only
+// applied if the current credentials fail the test call as well. The outcome
+// is recorded as event on secret, if set.
+func (cm *ConnectorManager) rotateCredentials(data map[string][]byte, secret *corev1.Secret) {
+	credentials, err := cm.parseCredentials(data)
+	if err != nil {
+		klog.Warningf("Invalid NSXT credentials: %v", err)
+		credentialRotations.WithLabelValues(rotationInvalid).Inc()
+		cm.recordEvent(secret, corev1.EventTypeWarning, "InvalidCredentials", "Invalid NSXT credentials: %v", err)
+		return
+	}
+
+	if err := cm.validateCredentials(credentials); err != nil {
+		cm.lock.Lock()
+		current := cm.current
+		cm.lock.Unlock()
+		if verr := cm.validateCredentials(current); verr == nil {
+			klog.Errorf("Rotated NSXT credentials were rejected, keeping the current credentials: %v", err)
+			credentialRotations.WithLabelValues(rotationRejected).Inc()
+			cm.recordEvent(secret, corev1.EventTypeWarning, "CredentialsRejected",
+				"Rotated NSXT credentials were rejected, keeping the current credentials: %v", err)
+			return
 		}
-		if key == nsxtcfg.PasswordKeyInSecret {
-			password = string(value)
+		klog.Warningf("Rotated NSXT credentials could not be validated, applying them as the current credentials fail as well: %v", err)
+		credentialRotations.WithLabelValues(rotationUnverified).Inc()
+		cm.recordEvent(secret, corev1.EventTypeWarning, "CredentialsUnverified",
+			"Rotated NSXT credentials could not be validated, applying them as the current credentials fail as well: %v", err)
+		cm.applyCredentials(credentials)
+		return
+	}
+
+	klog.V(2).Infof("Rotated NSXT credentials validated, updating security context for NSXT connection")
+	credentialRotations.WithLabelValues(rotationApplied).Inc()
+	cm.recordEvent(secret, corev1.EventTypeNormal, "CredentialsRotated", "Rotated NSXT credentials validated and applied")
+	cm.applyCredentials(credentials)
+}
+
+// parseCredentials reads the client certificate, VMC access token or username
+// and password, in this order of precedence, from data
+func (cm *ConnectorManager) parseCredentials(data map[string][]byte) (*connectorCredentials, error) {
+	credentials := &connectorCredentials{securityCtx: core.NewSecurityContextImpl()}
+
+	cert, key := data[nsxtcfg.ClientCertKeyInSecret], data[nsxtcfg.ClientKeyKeyInSecret]
+	if len(cert) > 0 || len(key) > 0 {
+		clientCert, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client cert/key pair")
+		}
+		credentials.clientCert = &clientCert
+		return credentials, nil
+	}
+
+	if token := string(data[nsxtcfg.VMCAccessTokenKeyInSecret]); token != "" {
+		if cm.config.VMCAuthHost == "" {
+			return nil, fmt.Errorf("vmc auth host must be provided if auth token is provided")
+		}
+		credentials.vmcTokens = newVMCTokenSource(cm.config.VMCAuthHost, token)
+		if _, err := credentials.vmcTokens.Token(context.Background()); err != nil {
+			return nil, err
 		}
+		return credentials, nil
 	}
+
+	username, password := string(data[nsxtcfg.UsernameKeyInSecret]), string(data[nsxtcfg.PasswordKeyInSecret])
 	if username == "" || password == "" {
-		klog.Warningf("NSXT username and password should be both provided in secret")
+		return nil, fmt.Errorf("NSXT username and password should be both provided")
+	}
+	credentials.securityCtx.SetProperty(security.AUTHENTICATION_SCHEME_ID, security.USER_PASSWORD_SCHEME_ID)
+	credentials.securityCtx.SetProperty(security.USER_KEY, username)
+	credentials.securityCtx.SetProperty(security.PASSWORD_KEY, password)
+	return credentials, nil
+}
+
+// validateCredentials performs a test call to NSXT with the credentials on a
+// separate connector
+func (cm *ConnectorManager) validateCredentials(credentials *connectorCredentials) error {
+	tlsConfig := cm.tlsConfig.Clone()
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return clientCertificate(credentials), nil
+	}
+	connector, transport := cm.newConnector(tlsConfig, credentialValidationTimeout, func() *vmcTokenSource {
+		return credentials.vmcTokens
+	})
+	defer transport.CloseIdleConnections()
+	if credentials.securityCtx != nil {
+		connector.SetSecurityContext(credentials.securityCtx)
+	}
+
+	_, err := aaa.NewUserInfoClient(connector).Get(nil, nil)
+	return err
+}
+
+// applyCredentials updates the security context and client certificate of the
+// connector
+func (cm *ConnectorManager) applyCredentials(credentials *connectorCredentials) {
+	cm.lock.Lock()
+	cm.current = credentials
+	cm.lock.Unlock()
+	cm.connector.SetSecurityContext(credentials.securityCtx)
+	// connections authenticated with the previous client certificate
+	cm.transport.CloseIdleConnections()
+}
+
+// recordEvent records an event on secret, if set and events are recorded
+func (cm *ConnectorManager) recordEvent(secret *corev1.Secret, eventType, reason, messageFmt string, args ...interface{}) {
+	if secret == nil || cm.recorder == nil {
 		return
 	}
-	klog.V(6).Infof("Updating security context for NSXT connection")
-	securityCtx := core.NewSecurityContextImpl()
-	securityCtx.SetProperty(security.AUTHENTICATION_SCHEME_ID, security.USER_PASSWORD_SCHEME_ID)
-	securityCtx.SetProperty(security.USER_KEY, username)
-	securityCtx.SetProperty(security.PASSWORD_KEY, password)
-	cm.connector.SetSecurityContext(securityCtx)
+	cm.recorder.Eventf(secret, eventType, reason, messageFmt, args...)
 }
 
-// resetConnectorContext resets security context of connector
-func (cm *ConnectorManager) resetConnectorContext() {
-	klog.V(6).Infof("Resetting security context for NSXT connection")
-	securityCtx := core.NewSecurityContextImpl()
-	cm.connector.SetSecurityContext(securityCtx)
+// WatchCredentialSource keeps the NSXT credentials in sync with the credential
+// source, if one is configured, until stop is closed
+func (cm *ConnectorManager) WatchCredentialSource(stop <-chan struct{}) {
+	if cm.source == nil {
+		klog.V(6).Infof("No need to watch NSXT credential source as it is not provided")
+		return
+	}
+	registerMetrics()
+	credentialmanager.WatchSource(cm.source, stop, cm.updateConnectorCredentials)
 }
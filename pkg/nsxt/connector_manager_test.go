@@ -0,0 +1,224 @@
+/*
+ Copyright 2026 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nsxt
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/aaa"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/cloud-provider-vsphere/pkg/common/vclib/fixtures"
+	"k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
+)
+
+// fakeNSXT serves the user info API of NSXT for the accepted passwords of
+// the admin user, or for any client certificate
+type fakeNSXT struct {
+	*httptest.Server
+	lock     sync.Mutex
+	accepted map[string]bool
+	// lastAuth is the password or "cert" of the last accepted request
+	lastAuth string
+}
+
+func newFakeNSXT(t *testing.T, accepted ...string) *fakeNSXT {
+	f := &fakeNSXT{accepted: map[string]bool{}}
+	for _, password := range accepted {
+		f.accepted[password] = true
+	}
+	f.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if !strings.HasSuffix(r.URL.Path, "/aaa/user-info") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		auth := "cert"
+		if len(r.TLS.PeerCertificates) == 0 {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "admin" || !f.accepted[password] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			auth = password
+		}
+		f.lastAuth = auth
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user_name": "admin"}`))
+	}))
+	f.Server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	f.StartTLS()
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeNSXT) accept(password string, accepted bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.accepted[password] = accepted
+}
+
+// auth makes a request with the connector of cm and returns how it was
+// authenticated
+func (f *fakeNSXT) auth(t *testing.T, cm *ConnectorManager) string {
+	t.Helper()
+	f.lock.Lock()
+	f.lastAuth = ""
+	f.lock.Unlock()
+	if _, err := aaa.NewUserInfoClient(cm.GetConnector()).Get(nil, nil); err != nil {
+		t.Fatalf("request with the connector failed: %v", err)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.lastAuth
+}
+
+func newTestConnectorManager(t *testing.T, f *fakeNSXT) (*ConnectorManager, *record.FakeRecorder) {
+	cm, err := NewConnectorManager(&config.Config{
+		Host:            f.Listener.Addr().String(),
+		InsecureFlag:    true,
+		User:            "admin",
+		Password:        "initial",
+		SecretName:      "nsxt-credentials",
+		SecretNamespace: "kube-system",
+	})
+	if err != nil {
+		t.Fatalf("NewConnectorManager failed: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	cm.recorder = recorder
+	return cm, recorder
+}
+
+func newTestSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "nsxt-credentials", Namespace: "kube-system"},
+		Data:       map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+func expectEvent(t *testing.T, recorder *record.FakeRecorder, reason string) {
+	t.Helper()
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, reason) {
+			t.Errorf("expected event %s but got: %s", reason, event)
+		}
+	default:
+		t.Errorf("expected event %s but none was recorded", reason)
+	}
+}
+
+func TestRotateCredentials(t *testing.T) {
+	f := newFakeNSXT(t, "initial", "rotated")
+	cm, recorder := newTestConnectorManager(t, f)
+
+	if auth := f.auth(t, cm); auth != "initial" {
+		t.Fatalf("expected the initial password but got: %s", auth)
+	}
+
+	cm.secretUpdated(newTestSecret(nil), newTestSecret(map[string]string{
+		config.UsernameKeyInSecret: "admin",
+		config.PasswordKeyInSecret: "rotated",
+	}))
+	expectEvent(t, recorder, "CredentialsRotated")
+	if auth := f.auth(t, cm); auth != "rotated" {
+		t.Errorf("expected the rotated password but got: %s", auth)
+	}
+}
+
+func TestRotateCredentialsRejected(t *testing.T) {
+	f := newFakeNSXT(t, "initial")
+	cm, recorder := newTestConnectorManager(t, f)
+
+	cm.secretAdded(newTestSecret(map[string]string{
+		config.UsernameKeyInSecret: "admin",
+		config.PasswordKeyInSecret: "wrong",
+	}))
+	expectEvent(t, recorder, "CredentialsRejected")
+	if auth := f.auth(t, cm); auth != "initial" {
+		t.Errorf("expected to fall back to the initial password but got: %s", auth)
+	}
+
+	cm.secretAdded(newTestSecret(map[string]string{config.UsernameKeyInSecret: "admin"}))
+	expectEvent(t, recorder, "InvalidCredentials")
+
+	cm.secretDeleted(newTestSecret(nil))
+	expectEvent(t, recorder, "SecretDeleted")
+	if auth := f.auth(t, cm); auth != "initial" {
+		t.Errorf("expected to keep the initial password but got: %s", auth)
+	}
+}
+
+func TestRotateCredentialsUnverified(t *testing.T) {
+	f := newFakeNSXT(t)
+	cm, recorder := newTestConnectorManager(t, f)
+
+	// neither the current nor the rotated credentials are accepted yet
+	cm.secretAdded(newTestSecret(map[string]string{
+		config.UsernameKeyInSecret: "admin",
+		config.PasswordKeyInSecret: "rotated",
+	}))
+	expectEvent(t, recorder, "CredentialsUnverified")
+
+	f.accept("rotated", true)
+	if auth := f.auth(t, cm); auth != "rotated" {
+		t.Errorf("expected the rotated password but got: %s", auth)
+	}
+}
+
+func TestRotateClientCertificate(t *testing.T) {
+	f := newFakeNSXT(t, "initial")
+	cm, recorder := newTestConnectorManager(t, f)
+
+	cert, err := os.ReadFile(fixtures.ServerCertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := os.ReadFile(fixtures.ServerKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cm.secretAdded(newTestSecret(map[string]string{
+		config.ClientCertKeyInSecret: "invalid",
+		config.ClientKeyKeyInSecret:  string(key),
+	}))
+	expectEvent(t, recorder, "InvalidCredentials")
+
+	cm.secretAdded(newTestSecret(map[string]string{
+		config.ClientCertKeyInSecret: string(cert),
+		config.ClientKeyKeyInSecret:  string(key),
+	}))
+	expectEvent(t, recorder, "CredentialsRotated")
+	if auth := f.auth(t, cm); auth != "cert" {
+		t.Errorf("expected the client certificate but got: %s", auth)
+	}
+}
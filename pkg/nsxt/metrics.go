@@ -0,0 +1,62 @@
+/*
+ Copyright 2026 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nsxt
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "nsxt_connector"
+
+// Results of a credential rotation
+const (
+	// rotationApplied means the rotated credentials passed validation
+	rotationApplied = "applied"
+	// rotationRejected means the rotated credentials failed validation and
+	// the previous credentials are kept
+	rotationRejected = "rejected"
+	// rotationUnverified means the rotated credentials failed validation as
+	// well as the previous ones, so the rotated credentials are used anyway
+	rotationUnverified = "unverified"
+	// rotationInvalid means no credentials could be read from the rotated data
+	rotationInvalid = "invalid"
+)
+
+var (
+	// credentialRotations counts the NSXT credential rotations by result
+	credentialRotations = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "credential_rotations_total",
+			Help:           "Number of NSXT credential rotations by result",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	registerOnce sync.Once
+)
+
+// registerMetrics registers the NSXT connector metrics
+func registerMetrics() {
+	registerOnce.Do(func() {
+		legacyregistry.MustRegister(credentialRotations)
+	})
+}
@@ -0,0 +1,229 @@
+/*
+ Copyright 2026 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nsxt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/security"
+	klog "k8s.io/klog/v2"
+)
+
+// vmcTokenRefreshWindow is how long before its expiry a VMC API token is
+// exchanged for a new one
+const vmcTokenRefreshWindow = 5 * time.Minute
+
+// vmcAuthTimeout bounds a single exchange of a VMC access token
+const vmcAuthTimeout = 30 * time.Second
+
+// vmcAuthClient is the HTTP client used to exchange VMC access tokens
+var vmcAuthClient = &http.Client{Timeout: vmcAuthTimeout}
+
+type jwtToken struct {
+	IDToken      string      `json:"id_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    json.Number `json:"expires_in"`
+	Scope        string      `json:"scope"`
+	AccessToken  string      `json:"access_token"`
+	RefreshToken string      `json:"refresh_token"`
+}
+
+// vmcTokenSource provides the API token for a VMC access token. The API token
+// is shared by all users of the source and exchanged for a new one shortly
+// before it expires.
+type vmcTokenSource struct {
+	authHost    string
+	accessToken string
+
+	// refreshLock serializes token exchanges
+	refreshLock sync.Mutex
+
+	// lock guards the fields below. It is not held during token exchanges.
+	lock   sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// newVMCTokenSource creates a token source for the VMC access token, which is
+// exchanged at the CSP authorization endpoint vmcAuthHost
+func newVMCTokenSource(vmcAuthHost string, vmcAccessToken string) *vmcTokenSource {
+	return &vmcTokenSource{
+		authHost:    vmcAuthHost,
+		accessToken: vmcAccessToken,
+	}
+}
+
+// Token returns the current API token, exchanging the access token for a new
+// one if there is none or it is about to expire. While another caller is
+// exchanging it, a token that is about to expire is still returned.
+func (s *vmcTokenSource) Token(ctx context.Context) (string, error) {
+	token, fresh, usable := s.current()
+	if fresh {
+		return token, nil
+	}
+	if usable {
+		if !s.refreshLock.TryLock() {
+			return token, nil
+		}
+	} else {
+		s.refreshLock.Lock()
+	}
+	defer s.refreshLock.Unlock()
+
+	// another caller may have exchanged the token in the meantime
+	token, fresh, usable = s.current()
+	if fresh {
+		return token, nil
+	}
+
+	apiToken, err := getAPIToken(ctx, s.authHost, s.accessToken)
+	if err != nil {
+		if usable {
+			klog.Warningf("Failed to exchange the VMC access token, using the current API token until it expires: %v", err)
+			return token, nil
+		}
+		return "", err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.token = apiToken.AccessToken
+	s.expiry = time.Time{}
+	if expiresIn, err := apiToken.ExpiresIn.Int64(); err == nil && expiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+		klog.V(4).Infof("Exchanged VMC access token for an API token expiring at %s", s.expiry)
+	}
+	return s.token, nil
+}
+
+// current returns the current API token, whether it is not about to expire,
+// and whether it has not expired yet
+func (s *vmcTokenSource) current() (string, bool, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token == "" {
+		return "", false, false
+	}
+	if s.expiry.IsZero() {
+		return s.token, true, true
+	}
+	remaining := time.Until(s.expiry)
+	return s.token, remaining > vmcTokenRefreshWindow, remaining > 0
+}
+
+// Invalidate drops the API token if it is still the current one, so the next
+// call to Token exchanges the access token again
+func (s *vmcTokenSource) Invalidate(token string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// getAPIToken exchanges the VMC access token for a JWT API token
+func getAPIToken(ctx context.Context, vmcAuthHost string, vmcAccessToken string) (*jwtToken, error) {
+
+	payload := strings.NewReader("refresh_token=" + vmcAccessToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+vmcAuthHost, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	res, err := vmcAuthClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("Unexpected status code %d trying to get auth token. %s", res.StatusCode, string(b))
+	}
+
+	token := jwtToken{}
+	err = json.NewDecoder(res.Body).Decode(&token)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Decoding token failed with")
+	}
+
+	return &token, nil
+}
+
+// vmcTokenTransport authenticates NSXT requests with the API token of the
+// token source, if there is one. A request rejected as unauthorized is retried
+// once with a new API token.
+type vmcTokenTransport struct {
+	base   http.RoundTripper
+	tokens func() *vmcTokenSource
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *vmcTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tokens := t.tokens()
+	if tokens == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := tokens.Token(req.Context())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get VMC API token")
+	}
+	res, err := t.base.RoundTrip(withCSPAuthToken(req, token))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// the request cannot be sent again
+		return res, nil
+	}
+
+	klog.V(2).Infof("NSXT rejected the VMC API token, retrying with a new one")
+	tokens.Invalidate(token)
+	token, err = tokens.Token(req.Context())
+	if err != nil {
+		klog.Errorf("Failed to get a new VMC API token: %v", err)
+		return res, nil
+	}
+	retry := withCSPAuthToken(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return res, nil
+		}
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// withCSPAuthToken returns a copy of req authenticated with the API token
+func withCSPAuthToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set(security.CSP_AUTH_TOKEN_KEY, token)
+	return clone
+}
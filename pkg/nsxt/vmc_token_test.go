@@ -0,0 +1,222 @@
+/*
+ Copyright 2026 The Kubernetes Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nsxt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vmware/vsphere-automation-sdk-go/runtime/security"
+	"github.com/vmware/vsphere-automation-sdk-go/services/nsxt/aaa"
+
+	"k8s.io/cloud-provider-vsphere/pkg/nsxt/config"
+)
+
+const fakeCSPPath = "/csp/gateway/am/api/auth/api-tokens/authorize"
+
+// fakeCSP exchanges the refresh token "refresh" for API tokens "token-<n>"
+// expiring after expiresIn seconds
+type fakeCSP struct {
+	*httptest.Server
+	lock      sync.Mutex
+	expiresIn int
+	exchanges int
+}
+
+func newFakeCSP(t *testing.T, expiresIn int) *fakeCSP {
+	f := &fakeCSP{expiresIn: expiresIn}
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if r.Method != http.MethodPost || r.URL.Path != fakeCSPPath || r.FormValue("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.exchanges++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": %d}`, f.exchanges, f.expiresIn)
+	}))
+	t.Cleanup(f.Close)
+
+	client := vmcAuthClient
+	vmcAuthClient = f.Client()
+	t.Cleanup(func() { vmcAuthClient = client })
+	return f
+}
+
+func (f *fakeCSP) authHost() string {
+	return f.Listener.Addr().String() + fakeCSPPath
+}
+
+func (f *fakeCSP) exchanged() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.exchanges
+}
+
+func TestVMCTokenSource(t *testing.T) {
+	csp := newFakeCSP(t, 1800)
+	tokens := newVMCTokenSource(csp.authHost(), "refresh")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := tokens.Token(context.Background()); err != nil || token != "token-1" {
+				t.Errorf("expected token-1 but got: %s, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if exchanged := csp.exchanged(); exchanged != 1 {
+		t.Errorf("expected the token to be shared but it was exchanged %d times", exchanged)
+	}
+
+	tokens.Invalidate("token-0")
+	if token, _ := tokens.Token(context.Background()); token != "token-1" {
+		t.Errorf("invalidating a previous token should keep token-1 but got: %s", token)
+	}
+	tokens.Invalidate("token-1")
+	if token, _ := tokens.Token(context.Background()); token != "token-2" {
+		t.Errorf("expected token-2 after invalidation but got: %s", token)
+	}
+}
+
+func TestVMCTokenSourceRefresh(t *testing.T) {
+	// tokens expiring within the refresh window are exchanged on every use
+	csp := newFakeCSP(t, 60)
+	tokens := newVMCTokenSource(csp.authHost(), "refresh")
+
+	for i := 1; i <= 2; i++ {
+		if token, err := tokens.Token(context.Background()); err != nil || token != fmt.Sprintf("token-%d", i) {
+			t.Errorf("expected token-%d but got: %s, %v", i, token, err)
+		}
+	}
+
+	invalid := newVMCTokenSource(csp.authHost(), "invalid")
+	if _, err := invalid.Token(context.Background()); err == nil {
+		t.Error("expected an error for a rejected access token")
+	}
+}
+
+func TestVMCTokenSourceHungExchange(t *testing.T) {
+	// the CSP hangs on every exchange but the first
+	var exchanges int32
+	release := make(chan struct{})
+	csp := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&exchanges, 1)
+		if n > 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 60}`, n)
+	}))
+	defer csp.Close()
+	defer close(release)
+	client := vmcAuthClient
+	vmcAuthClient = csp.Client()
+	defer func() { vmcAuthClient = client }()
+	authHost := csp.Listener.Addr().String() + fakeCSPPath
+
+	tokens := newVMCTokenSource(authHost, "refresh")
+	if token, err := tokens.Token(context.Background()); err != nil || token != "token-1" {
+		t.Fatalf("expected token-1 but got: %s, %v", token, err)
+	}
+
+	// token-1 is about to expire, so it is exchanged again
+	go func() { _, _ = tokens.Token(context.Background()) }()
+	for atomic.LoadInt32(&exchanges) < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// other callers keep using token-1 instead of waiting for the exchange
+	result := make(chan string, 1)
+	go func() {
+		token, _ := tokens.Token(context.Background())
+		result <- token
+	}()
+	select {
+	case token := <-result:
+		if token != "token-1" {
+			t.Errorf("expected token-1 during the exchange but got: %s", token)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Token blocked during the exchange of another caller")
+	}
+
+	// an exchange is bounded by the context of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := newVMCTokenSource(authHost, "refresh").Token(ctx); err == nil {
+		t.Error("expected an error for an exchange exceeding the context deadline")
+	}
+}
+
+func TestVMCTokenRetryOnUnauthorized(t *testing.T) {
+	csp := newFakeCSP(t, 1800)
+
+	// NSXT only accepts the most recent API token
+	var seen []string
+	nsxt := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(security.CSP_AUTH_TOKEN_KEY)
+		seen = append(seen, token)
+		if !strings.HasSuffix(r.URL.Path, "/aaa/user-info") || token != fmt.Sprintf("token-%d", csp.exchanged()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user_name": "admin"}`))
+	}))
+	defer nsxt.Close()
+
+	cm, err := NewConnectorManager(&config.Config{
+		Host:           nsxt.Listener.Addr().String(),
+		InsecureFlag:   true,
+		VMCAccessToken: "refresh",
+		VMCAuthHost:    csp.authHost(),
+	})
+	if err != nil {
+		t.Fatalf("NewConnectorManager failed: %v", err)
+	}
+
+	if _, err := aaa.NewUserInfoClient(cm.GetConnector()).Get(nil, nil); err != nil {
+		t.Fatalf("request with the API token failed: %v", err)
+	}
+
+	// the API token is revoked, e.g. as it expired early
+	csp.lock.Lock()
+	csp.exchanges++
+	csp.lock.Unlock()
+	if _, err := aaa.NewUserInfoClient(cm.GetConnector()).Get(nil, nil); err != nil {
+		t.Fatalf("request should be retried with a new API token: %v", err)
+	}
+	expected := []string{"token-1", "token-1", "token-3"}
+	if strings.Join(seen, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected tokens %v but NSXT saw %v", expected, seen)
+	}
+}